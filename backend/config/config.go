@@ -4,14 +4,19 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"log"
+	"strconv"
+	"strings"
 	"waf-backend/utils"
 )
 
 type Config struct {
-	Server   ServerConfig
-	OAuth    OAuthConfig
-	Security SecurityConfig
-	Logging  LoggingConfig
+	Server    ServerConfig
+	OAuth     OAuthConfig
+	Security  SecurityConfig
+	Logging   LoggingConfig
+	Redaction RedactionConfig
+	Tracing   TracingConfig
+	Rules     RulesConfig
 }
 
 type ServerConfig struct {
@@ -26,13 +31,41 @@ type OAuthConfig struct {
 }
 
 type SecurityConfig struct {
-	JWTSecret string
+	JWTSecret         string
+	AdminEmails       []string
+	ErasureSigningKey string // 삭제 증명서 서명 키 (JWT 시크릿과 분리, 자동 생성하지 않음)
 }
 
 type LoggingConfig struct {
 	Level string
 }
 
+type RedactionConfig struct {
+	Enabled       bool
+	HeaderNames   []string
+	ParamPatterns []string
+	Detectors     []string
+}
+
+type TracingConfig struct {
+	Enabled      bool
+	ServiceName  string
+	OTLPEndpoint string // OTLP/HTTP collector URL
+	SampleRatio  float64
+}
+
+// RulesConfig 사용자 커스텀 룰에 허용/금지할 SecLang 액션
+type RulesConfig struct {
+	AllowedActions   []string // 비어 있으면 전체 허용
+	DeniedActions    []string // exec, ctl:ruleEngine 처럼 값 접두어까지 지정 가능
+	CRSRulesDir      string   // 룰 ID 충돌 검사에 사용할 CRS *.conf 디렉토리
+	CRSPluginsDir    string   // 설치된 CRS 플러그인 (*-config.conf) 디렉토리
+	CRSVersion       string   // 배포된 CRS 버전 (v3와 v4는 paranoia level 변수 이름이 다르고 플러그인은 v4부터)
+	RequireReview    bool     // true면 룰 변경은 승인된 변경 세트로만 배포
+	ReviewerEmails   []string // 변경 세트를 승인할 수 있는 사용자 (관리자는 항상 가능)
+	HitRetentionDays int      // 룰 매칭 기록 보관 일수
+}
+
 // Load loads configuration from environment variables
 func Load() *Config {
 	return &Config{
@@ -46,12 +79,76 @@ func Load() *Config {
 			RedirectURL:        utils.GetEnv("GOOGLE_REDIRECT_URL", "http://localhost:3000/auth/callback"),
 		},
 		Security: SecurityConfig{
-			JWTSecret: getJWTSecret(),
+			JWTSecret:         getJWTSecret(),
+			AdminEmails:       splitList(utils.GetEnv("ADMIN_EMAILS", "")),
+			ErasureSigningKey: utils.GetEnv("ERASURE_SIGNING_KEY", ""),
 		},
 		Logging: LoggingConfig{
 			Level: utils.GetEnv("LOG_LEVEL", "info"),
 		},
+		Redaction: RedactionConfig{
+			Enabled:       utils.GetEnv("REDACTION_ENABLED", "true") == "true",
+			HeaderNames:   splitList(utils.GetEnv("REDACT_HEADERS", "Authorization,Proxy-Authorization,Cookie,Set-Cookie,X-Api-Key,X-Auth-Token")),
+			ParamPatterns: splitLines(utils.GetEnv("REDACT_PARAM_PATTERNS", `(?i)^(pass(word|wd)?|pwd|secret|token|access_token|refresh_token|api[_-]?key|session(id)?|auth.*|credit_?card|card_?number|cvv|ssn)$`)),
+			Detectors:     splitList(utils.GetEnv("REDACT_DETECTORS", "credit_card,email,jwt")),
+		},
+		Tracing: TracingConfig{
+			Enabled:      utils.GetEnv("TRACING_ENABLED", "false") == "true",
+			ServiceName:  utils.GetEnv("OTEL_SERVICE_NAME", "waf-backend"),
+			OTLPEndpoint: utils.GetEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			SampleRatio:  getFloat("TRACING_SAMPLE_RATIO", 1.0),
+		},
+		Rules: RulesConfig{
+			AllowedActions:   splitList(utils.GetEnv("RULE_ALLOWED_ACTIONS", "")),
+			DeniedActions:    splitList(utils.GetEnv("RULE_DENIED_ACTIONS", "exec,ctl:ruleEngine,ctl:ruleRemove")),
+			CRSRulesDir:      utils.GetEnv("CRS_RULES_DIR", "/etc/nginx/owasp-modsecurity-crs/rules"),
+			CRSPluginsDir:    utils.GetEnv("CRS_PLUGINS_DIR", "/etc/nginx/owasp-modsecurity-crs/plugins"),
+			CRSVersion:       utils.GetEnv("CRS_VERSION", "3.3.4"),
+			RequireReview:    utils.GetEnv("RULES_REQUIRE_REVIEW", "false") == "true",
+			ReviewerEmails:   splitList(utils.GetEnv("RULE_REVIEWER_EMAILS", "")),
+			HitRetentionDays: getInt("RULE_HIT_RETENTION_DAYS", 30),
+		},
+	}
+}
+
+// splitList 쉼표로 구분된 환경변수 값을 목록으로 변환
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// splitLines 한 줄에 하나씩 적는 환경변수 값을 목록으로 변환 (정규식처럼 쉼표가 들어갈 수 있는 값)
+func splitLines(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, "\n") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getFloat 숫자 환경변수 (잘못된 값이면 기본값)
+func getFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(utils.GetEnv(key, ""), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getInt 정수 환경변수 (잘못된 값이면 기본값)
+func getInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(utils.GetEnv(key, ""))
+	if err != nil || value <= 0 {
+		return defaultValue
 	}
+	return value
 }
 
 // getJWTSecret generates a secure JWT secret if not provided via environment
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"os"
 	"path/filepath"
 	"waf-backend/models"
 	"waf-backend/utils"
@@ -26,6 +27,12 @@ func InitDB(log *logrus.Logger) error {
 	
 	log.WithField("db_path", absPath).Info("Initializing SQLite database")
 	
+	// 데이터 디렉토리가 없으면 생성
+	if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
+		log.WithError(err).Error("Failed to create database directory")
+		return err
+	}
+	
 	// GORM logger 설정
 	var gormLogger logger.Interface
 	if utils.GetEnv("LOG_LEVEL", "info") == "debug" {
@@ -48,7 +55,7 @@ func InitDB(log *logrus.Logger) error {
 	
 	// Auto Migration 실행
 	log.Info("Running database migrations")
-	if err := db.AutoMigrate(&models.User{}, &models.CustomRule{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.CustomRule{}, &models.CustomRuleRevision{}, &models.RuleChangeset{}, &models.RuleChange{}, &models.CRSSetting{}, &models.EngineMode{}, &models.EngineModeChange{}, &models.PathBypass{}, &models.RuleHit{}, &models.AlertRule{}, &models.NotificationChannel{}, &models.BanPolicy{}, &models.IPBan{}, &models.Tenant{}, &models.ErasureCertificate{}); err != nil {
 		log.WithError(err).Error("Failed to run database migrations")
 		return err
 	}
@@ -0,0 +1,97 @@
+package dto
+
+import "time"
+
+// AlertFilter 알림 룰이 평가할 이벤트 조건
+type AlertFilter struct {
+	RuleIDs     []string `json:"rule_ids"`   // "932xxx", "9421*" 같은 패턴 지원
+	Severities  []string `json:"severities"` // Critical, Warning ...
+	AttackTypes []string `json:"attack_types"`
+	ClientIPs   []string `json:"client_ips"`
+	BlockedOnly bool     `json:"blocked_only"`
+}
+
+type AlertRule struct {
+	ID              string      `json:"id"`
+	Name            string      `json:"name"`
+	Description     string      `json:"description"`
+	Type            string      `json:"type"` // threshold, match
+	Filter          AlertFilter `json:"filter"`
+	Threshold       int         `json:"threshold"`
+	WindowMinutes   int         `json:"window_minutes"`
+	GroupBy         string      `json:"group_by"` // client_ip, rule_id, "" (전체)
+	CooldownMinutes int         `json:"cooldown_minutes"`
+	ChannelIDs      []string    `json:"channel_ids"`
+	Enabled         bool        `json:"enabled"`
+	UserID          string      `json:"user_id"`
+	OwnerEmail      string      `json:"-"` // 테넌트 범위 평가용
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+type AlertRuleRequest struct {
+	Name            string      `json:"name" binding:"required"`
+	Description     string      `json:"description"`
+	Type            string      `json:"type" binding:"required,oneof=threshold match"`
+	Filter          AlertFilter `json:"filter"`
+	Threshold       int         `json:"threshold" binding:"min=0"`
+	WindowMinutes   int         `json:"window_minutes" binding:"min=0"`
+	GroupBy         string      `json:"group_by" binding:"omitempty,oneof=client_ip rule_id attack_type"`
+	CooldownMinutes int         `json:"cooldown_minutes" binding:"min=0"`
+	ChannelIDs      []string    `json:"channel_ids"`
+	Enabled         bool        `json:"enabled"`
+}
+
+type NotificationChannel struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Type      string            `json:"type"` // webhook, slack, smtp
+	URL       string            `json:"url,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	SMTP      *SMTPSettings     `json:"smtp,omitempty"`
+	Enabled   bool              `json:"enabled"`
+	UserID    string            `json:"user_id"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type SMTPSettings struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+type NotificationChannelRequest struct {
+	Name    string            `json:"name" binding:"required"`
+	Type    string            `json:"type" binding:"required,oneof=webhook slack smtp"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	SMTP    *SMTPSettings     `json:"smtp"`
+	Enabled bool              `json:"enabled"`
+}
+
+// Alert 발생한 알림 인스턴스 (룰 + 그룹 키 단위로 중복 제거)
+type Alert struct {
+	ID         string     `json:"id"`
+	RuleID     string     `json:"rule_id"`
+	RuleName   string     `json:"rule_name"`
+	GroupKey   string     `json:"group_key"`
+	Status     string     `json:"status"` // firing, resolved
+	EventCount int        `json:"event_count"`
+	Message    string     `json:"message"`
+	LastEvent  *WAFLog    `json:"last_event,omitempty"`
+	FiredAt    time.Time  `json:"fired_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	UserID     string     `json:"user_id"`
+}
+
+// AlertNotification 채널로 전송되는 알림 페이로드
+type AlertNotification struct {
+	Status    string    `json:"status"` // firing, resolved
+	Alert     Alert     `json:"alert"`
+	Timestamp time.Time `json:"timestamp"`
+}
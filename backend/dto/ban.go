@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// BanPolicy 자동 차단 정책 (window 내 매칭 이벤트가 threshold를 넘으면 IP 차단)
+type BanPolicy struct {
+	ID            string      `json:"id"`
+	Name          string      `json:"name"`
+	Filter        AlertFilter `json:"filter"`
+	Threshold     int         `json:"threshold"`
+	WindowMinutes int         `json:"window_minutes"`
+	BanMinutes    int         `json:"ban_minutes"`
+	ExemptIPs     []string    `json:"exempt_ips"` // IP 또는 CIDR
+	Enabled       bool        `json:"enabled"`
+	UserID        string      `json:"user_id"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+type BanPolicyRequest struct {
+	Name          string      `json:"name" binding:"required"`
+	Filter        AlertFilter `json:"filter"`
+	Threshold     int         `json:"threshold" binding:"required,min=1"`
+	WindowMinutes int         `json:"window_minutes" binding:"required,min=1"`
+	BanMinutes    int         `json:"ban_minutes" binding:"required,min=1"`
+	ExemptIPs     []string    `json:"exempt_ips"`
+	Enabled       bool        `json:"enabled"`
+}
+
+// IPBan 배포되는 관리형 차단 항목
+type IPBan struct {
+	ID        string    `json:"id"`
+	IP        string    `json:"ip"` // IP 또는 CIDR
+	Reason    string    `json:"reason"`
+	PolicyID  string    `json:"policy_id,omitempty"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type IPBanRequest struct {
+	IP              string `json:"ip" binding:"required"`
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=1"`
+}
+
+type IPBanExtendRequest struct {
+	Minutes int `json:"minutes" binding:"required,min=1"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// BypassRequest 경로 예외 생성/교체
+// disable_engine이면 엔진 전체를 끄고, 아니면 rule_ids/tags에 해당하는 룰만 끔
+type BypassRequest struct {
+	Name          string     `json:"name" binding:"required,max=100"`
+	Host          string     `json:"host"` // 비우면 모든 호스트
+	PathPrefix    string     `json:"path_prefix" binding:"required"`
+	Methods       []string   `json:"methods"` // 비우면 모든 메서드
+	DisableEngine bool       `json:"disable_engine"`
+	RuleIDs       []int      `json:"rule_ids"`
+	Tags          []string   `json:"tags"`
+	Reason        string     `json:"reason"`
+	Owner         string     `json:"owner"` // 비우면 요청한 사용자
+	Enabled       *bool      `json:"enabled"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+// Bypass 경로 예외와 배포 상태 (active, disabled, expired)
+type Bypass struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Host          string     `json:"host,omitempty"`
+	PathPrefix    string     `json:"path_prefix"`
+	ModsecID      int        `json:"modsec_id"` // 배포되는 룰 ID
+	Methods       []string   `json:"methods,omitempty"`
+	DisableEngine bool       `json:"disable_engine"`
+	RuleIDs       []int      `json:"rule_ids,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+	Owner         string     `json:"owner"`
+	Enabled       bool       `json:"enabled"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Status        string     `json:"status"`
+	RuleText      string     `json:"rule_text,omitempty"` // active일 때 배포되는 룰
+	CreatedBy     string     `json:"created_by"`
+	UpdatedBy     string     `json:"updated_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// CRSSettings 전역 또는 호스트별 OWASP CRS 설정
+// 0이거나 생략한 값은 상위 설정(호스트 → 전역 → CRS 기본값)을 따름
+type CRSSettings struct {
+	Scope                    string          `json:"scope"` // global 또는 호스트 (*.example.com 가능)
+	BlockingParanoiaLevel    int             `json:"blocking_paranoia_level,omitempty"`
+	DetectionParanoiaLevel   int             `json:"detection_paranoia_level,omitempty"`
+	InboundAnomalyThreshold  int             `json:"inbound_anomaly_score_threshold,omitempty"`
+	OutboundAnomalyThreshold int             `json:"outbound_anomaly_score_threshold,omitempty"`
+	Plugins                  map[string]bool `json:"plugins,omitempty"`           // 플러그인 이름 → 활성 여부 (생략한 플러그인은 기본값)
+	DisabledRuleIDs          []int           `json:"disabled_rule_ids,omitempty"` // 이 범위에서 끌 CRS 룰 ID
+	UpdatedBy                string          `json:"updated_by,omitempty"`
+	UpdatedAt                *time.Time      `json:"updated_at,omitempty"`
+}
+
+// CRSSettingsRequest 한 범위의 설정 전체를 교체
+type CRSSettingsRequest struct {
+	BlockingParanoiaLevel    int             `json:"blocking_paranoia_level" binding:"min=0,max=4"`
+	DetectionParanoiaLevel   int             `json:"detection_paranoia_level" binding:"min=0,max=4"`
+	InboundAnomalyThreshold  int             `json:"inbound_anomaly_score_threshold" binding:"min=0,max=10000"`
+	OutboundAnomalyThreshold int             `json:"outbound_anomaly_score_threshold" binding:"min=0,max=10000"`
+	Plugins                  map[string]bool `json:"plugins"`
+	DisabledRuleIDs          []int           `json:"disabled_rule_ids"`
+}
+
+// CRSOverview 모든 범위의 설정과 배포될 설정
+type CRSOverview struct {
+	CRSVersion       int           `json:"crs_version"` // 설정 변수 이름을 맞출 CRS 메이저 버전 (CRS_VERSION)
+	Settings         []CRSSettings `json:"settings"`
+	AvailablePlugins []string      `json:"available_plugins"` // 설치된 플러그인 (CRS_PLUGINS_DIR)
+	Setup            string        `json:"setup"`             // CRS보다 먼저 로드되는 crs-tuning.conf
+	Removals         string        `json:"removals"`          // CRS 이후 custom-rules.conf에 들어가는 SecRuleRemoveById
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+// EngineModeRequest 엔진 모드 변경. duration_minutes를 주면 그 시간이 지난 뒤 이전 모드로 자동 복귀
+type EngineModeRequest struct {
+	Mode            string `json:"mode" binding:"required,oneof=On DetectionOnly Off"`
+	DurationMinutes int    `json:"duration_minutes" binding:"min=0,max=10080"`
+	Reason          string `json:"reason"`
+}
+
+// EngineModeSetting 범위 하나의 현재 엔진 모드
+type EngineModeSetting struct {
+	Scope     string     `json:"scope"` // global 또는 호스트
+	Mode      string     `json:"mode"`
+	RevertTo  string     `json:"revert_to,omitempty"` // 임시 변경이면 만료 후 모드 (inherit: 전역 모드를 따름)
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// EngineModes 전역과 호스트별 엔진 모드
+type EngineModes struct {
+	Global EngineModeSetting   `json:"global"`
+	Hosts  []EngineModeSetting `json:"hosts"`
+}
+
+// EngineModeChange 엔진 모드 변경 감사 기록
+type EngineModeChange struct {
+	ID        uint       `json:"id"`
+	Scope     string     `json:"scope"`
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	ChangedBy string     `json:"changed_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -9,6 +9,7 @@ const (
 	ErrInvalidAuthFormat  ErrorCode = "ERR_INVALID_AUTH_FORMAT"
 	ErrInvalidToken       ErrorCode = "ERR_INVALID_TOKEN"
 	ErrAuthFailed         ErrorCode = "ERR_AUTH_FAILED"
+	ErrForbidden          ErrorCode = "ERR_FORBIDDEN"
 	
 	// Request errors
 	ErrInvalidRequest     ErrorCode = "ERR_INVALID_REQUEST"
@@ -0,0 +1,29 @@
+package dto
+
+// ExclusionRequest 저장된 WAF 이벤트로부터 예외 룰을 제안/생성하기 위한 요청
+// 비어있는 필드는 이벤트에서 추출한 값을 사용
+type ExclusionRequest struct {
+	Mode        string `json:"mode" binding:"omitempty,oneof=ctl update_target"` // ctl: ctl:ruleRemoveTargetById, update_target: SecRuleUpdateTargetById
+	Scope       string `json:"scope" binding:"omitempty,oneof=exact prefix"`     // URI 매칭 방식 (ctl 모드)
+	URI         string `json:"uri"`
+	RuleID      string `json:"rule_id"`
+	Variable    string `json:"variable"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ExclusionPreview 생성될 예외 룰 미리보기
+type ExclusionPreview struct {
+	EventID     string   `json:"event_id"`
+	Mode        string   `json:"mode"`
+	Scope       string   `json:"scope,omitempty"`
+	URI         string   `json:"uri,omitempty"`
+	Host        string   `json:"host,omitempty"` // ctl 모드 룰이 적용되는 호스트 (이벤트의 Host)
+	RuleID      string   `json:"rule_id"`
+	Variable    string   `json:"variable"`
+	ExclusionID int      `json:"exclusion_id,omitempty"` // ctl 모드에서 생성되는 SecRule의 ID
+	RuleText    string   `json:"rule_text"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// ErasureSubject 삭제 요청 대상 (IP 주소 또는 사용자 식별자 중 하나 이상)
+type ErasureSubject struct {
+	IP         string `json:"ip"`
+	Identifier string `json:"identifier"` // 이메일, 사용자명 등 이벤트 본문에 나타나는 값
+}
+
+type ErasureSearchResponse struct {
+	Matches map[string]int `json:"matches"` // 저장소별 매칭 개수
+	Events  []WAFLog       `json:"events"`  // 매칭된 이벤트 (최대 100개)
+}
+
+type ErasureRequest struct {
+	ErasureSubject
+	Mode   string `json:"mode" binding:"required,oneof=delete anonymize"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ErasureCertificate 삭제 처리 증명서 (감사 기록으로도 보관)
+// 원본 IP/식별자는 보관하지 않고 HMAC 해시만 남김
+type ErasureCertificate struct {
+	ID          string         `json:"id"`
+	SubjectHash string         `json:"subject_hash"`
+	SubjectType []string       `json:"subject_type"` // ip, identifier
+	Mode        string         `json:"mode"`
+	Reason      string         `json:"reason"`
+	RequestedBy string         `json:"requested_by"`
+	Erased      map[string]int `json:"erased"`   // 저장소별 삭제/익명화 개수
+	Retained    map[string]int `json:"retained"` // 보안 목적으로 유지된 항목 (예: 활성 IP 차단)
+	IssuedAt    time.Time      `json:"issued_at"`
+	Signature   string         `json:"signature"`
+}
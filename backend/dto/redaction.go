@@ -0,0 +1,10 @@
+package dto
+
+// RedactionConfig 저장/전송 전에 민감 정보를 마스킹하는 규칙
+type RedactionConfig struct {
+	Enabled         bool              `json:"enabled"`
+	HeaderNames     []string          `json:"header_names"`     // Authorization, Cookie ...
+	ParamPatterns   []string          `json:"param_patterns"`   // 파라미터 이름 정규식
+	Detectors       []string          `json:"detectors"`        // credit_card, email, jwt
+	CustomDetectors map[string]string `json:"custom_detectors"` // 이름 -> 값 정규식
+}
@@ -0,0 +1,38 @@
+package dto
+
+import "time"
+
+// ReplayRequest 저장된 이벤트(ID 목록 또는 시간 구간)를 대상 URL로 재전송
+type ReplayRequest struct {
+	EventIDs  []string   `json:"event_ids"`
+	From      *time.Time `json:"from"`
+	To        *time.Time `json:"to"`
+	TargetURL string     `json:"target_url"`
+	Limit     int        `json:"limit" binding:"min=0,max=500"`
+}
+
+type ReplayResult struct {
+	EventID         string `json:"event_id"`
+	Method          string `json:"method"`
+	URL             string `json:"url"`
+	Redacted        bool   `json:"redacted,omitempty"` // 마스킹된 값이 그대로 재전송되어 원래 요청과 다를 수 있음
+	Host            string `json:"host,omitempty"`
+	RuleID          string `json:"rule_id,omitempty"`
+	OriginalBlocked bool   `json:"original_blocked"`
+	Blocked         bool   `json:"blocked"`
+	StatusCode      int    `json:"status_code"`
+	Outcome         string `json:"outcome"` // still_blocked, now_allowed, now_blocked, still_allowed, error
+	Error           string `json:"error,omitempty"`
+	DurationMs      int64  `json:"duration_ms"`
+}
+
+type ReplayReport struct {
+	ID         string         `json:"id"`
+	TargetURL  string         `json:"target_url"`
+	UserID     string         `json:"user_id"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Total      int            `json:"total"`
+	Summary    map[string]int `json:"summary"` // outcome별 개수
+	Results    []ReplayResult `json:"results"`
+}
@@ -0,0 +1,88 @@
+package dto
+
+import (
+	"time"
+	"waf-backend/seclang"
+)
+
+type RuleChangesetRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+}
+
+// RuleChangeRequest 변경 세트에 추가할 룰 변경. update/delete는 rule_id 필요
+// template_id를 주면 rule_text 대신 템플릿을 template_params로 렌더링
+// (update에서는 빠진 파라미터에 기존 값을 쓰고, 템플릿 ID를 생략하면 룰에 연결된 템플릿 사용)
+type RuleChangeRequest struct {
+	Action         string                 `json:"action" binding:"required,oneof=create update delete"`
+	RuleID         string                 `json:"rule_id"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	RuleText       string                 `json:"rule_text" binding:"max=65536"`
+	Rules          []seclang.RuleSpec     `json:"rules,omitempty"` // rule_text 대신 구조화된 정의
+	TemplateID     string                 `json:"template_id"`
+	TemplateParams map[string]interface{} `json:"template_params"`
+	Enabled        bool                   `json:"enabled"`
+	Severity       string                 `json:"severity" binding:"omitempty,oneof=LOW MEDIUM HIGH CRITICAL"`
+	Group          *string                `json:"group"`   // update에서 생략하면 기존 값 유지
+	Targets        *[]RuleTarget          `json:"targets"` // update에서 생략하면 기존 값 유지, []는 모든 사이트
+	Reason         string                 `json:"reason"`
+}
+
+type RuleReviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+// RuleChangeset 검토/배포 대기 중인 룰 변경 묶음
+type RuleChangeset struct {
+	ID            string       `json:"id"`
+	Title         string       `json:"title"`
+	Description   string       `json:"description"`
+	Status        string       `json:"status"`
+	Author        string       `json:"author"`
+	ReviewedBy    string       `json:"reviewed_by,omitempty"`
+	ReviewComment string       `json:"review_comment,omitempty"`
+	PublishedBy   string       `json:"published_by,omitempty"`
+	Changes       []RuleChange `json:"changes"`
+	SubmittedAt   *time.Time   `json:"submitted_at,omitempty"`
+	ReviewedAt    *time.Time   `json:"reviewed_at,omitempty"`
+	PublishedAt   *time.Time   `json:"published_at,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// RuleChange 변경 세트의 룰 변경 하나와 현재 배포된 룰 대비 diff
+type RuleChange struct {
+	ID             uint                   `json:"id"`
+	Action         string                 `json:"action"`
+	RuleID         string                 `json:"rule_id"`
+	BaseRevision   int                    `json:"base_revision,omitempty"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	RuleText       string                 `json:"rule_text"`
+	Enabled        bool                   `json:"enabled"`
+	Severity       string                 `json:"severity"`
+	Reason         string                 `json:"reason,omitempty"`
+	TemplateID     string                 `json:"template_id,omitempty"`
+	TemplateParams map[string]interface{} `json:"template_params,omitempty"`
+	Group          string                 `json:"group,omitempty"`
+	Targets        []RuleTarget           `json:"targets,omitempty"`
+	Diff           string                 `json:"diff"`
+	Error          string                 `json:"error,omitempty"` // 현재 룰 기준으로 적용할 수 없으면 그 이유
+}
+
+// RuleChangeError 적용할 수 없는 변경과 오류 위치
+type RuleChangeError struct {
+	ChangeID uint              `json:"change_id"`
+	RuleID   string            `json:"rule_id"`
+	Error    string            `json:"error"`
+	Details  seclang.ErrorList `json:"details,omitempty"`
+}
+
+// RuleChangesetValidation 변경 세트를 현재 룰에 적용해 본 결과
+type RuleChangesetValidation struct {
+	ChangesetID string            `json:"changeset_id"`
+	Valid       bool              `json:"valid"`
+	Errors      []RuleChangeError `json:"errors"`
+	ConfigDiff  string            `json:"config_diff"` // 배포될 custom-rules.conf의 unified diff
+}
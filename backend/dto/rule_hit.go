@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+// RuleHitSummary 룰 목록에 함께 내려주는 매칭 요약 (보관 기간 안의 이벤트 기준)
+type RuleHitSummary struct {
+	Count     int64      `json:"count"`
+	LastHitAt *time.Time `json:"last_hit_at,omitempty"`
+}
+
+// RuleHitBucket 시간 구간별 매칭 수
+type RuleHitBucket struct {
+	Time    time.Time `json:"time"`
+	Hits    int64     `json:"hits"`
+	Blocked int64     `json:"blocked"`
+}
+
+// RuleHitTop 매칭이 많은 IP 또는 URI
+type RuleHitTop struct {
+	Value string `json:"value"`
+	Hits  int64  `json:"hits"`
+}
+
+// RuleHitAnalytics 룰 하나의 매칭 분석 (from~to 구간)
+type RuleHitAnalytics struct {
+	RuleID    string          `json:"rule_id"`
+	Name      string          `json:"name"`
+	Enabled   bool            `json:"enabled"`
+	ModsecIDs []int           `json:"modsec_rule_ids"`
+	Total     RuleHitSummary  `json:"total"` // 보관 기간 전체
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Bucket    string          `json:"bucket"`
+	Hits      int64           `json:"hits"`
+	Blocked   int64           `json:"blocked"`
+	Timeline  []RuleHitBucket `json:"timeline"`
+	TopIPs    []RuleHitTop    `json:"top_ips"`
+	TopURIs   []RuleHitTop    `json:"top_uris"`
+}
@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"time"
+	"waf-backend/seclang"
+)
+
+// RuleBundleVersion 내보내기 JSON 번들 형식 버전
+const RuleBundleVersion = 1
+
+// RuleBundle 룰 내보내기/가져오기용 JSON 번들
+type RuleBundle struct {
+	Version     int               `json:"version"`
+	ExportedAt  time.Time         `json:"exported_at"`
+	ExportedBy  string            `json:"exported_by,omitempty"`
+	RuleIDRange *RuleIDRange      `json:"rule_id_range,omitempty"` // 내보낸 사용자의 룰 ID 범위
+	Count       int               `json:"count"`
+	Rules       []RuleBundleEntry `json:"rules"`
+}
+
+// RuleBundleEntry 번들의 룰 하나. 가져올 때 enabled/severity를 생략하면 가져오기 옵션 값 사용
+type RuleBundleEntry struct {
+	ID             string                 `json:"id,omitempty"` // 내보낸 쪽의 룰 ID (가져올 때는 새로 발급)
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description,omitempty"`
+	RuleText       string                 `json:"rule_text"`
+	Enabled        *bool                  `json:"enabled,omitempty"`
+	Severity       string                 `json:"severity,omitempty"`
+	TemplateID     string                 `json:"template_id,omitempty"`
+	TemplateParams map[string]interface{} `json:"template_params,omitempty"`
+	Group          string                 `json:"group,omitempty"`
+	Targets        []RuleTarget           `json:"targets,omitempty"`
+	CreatedAt      *time.Time             `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time             `json:"updated_at,omitempty"`
+}
+
+// RuleImportOptions 가져오기 옵션 (쿼리 파라미터)
+type RuleImportOptions struct {
+	DryRun      bool   `form:"dry_run"`      // 검증 결과만 반환
+	ReassignIDs bool   `form:"reassign_ids"` // 충돌하거나 범위 밖인 ID를 내 범위의 빈 ID로 교체
+	Enabled     *bool  `form:"enabled"`      // 기본 true
+	Severity    string `form:"severity" binding:"omitempty,oneof=LOW MEDIUM HIGH CRITICAL"`
+	Reason      string `form:"reason"`
+
+	TargetHosts []string `form:"-"` // 룰 대상으로 허용되는 호스트 (nil이면 제한 없음, 대상 없는 룰은 이 호스트 전체로 제한)
+}
+
+// RuleImportResult 룰별 가져오기 결과
+type RuleImportResult struct {
+	DryRun      bool             `json:"dry_run"`
+	ChangesetID string           `json:"changeset_id,omitempty"` // 검토 모드면 룰 대신 변경 세트에 담김
+	Total       int              `json:"total"`
+	Imported    int              `json:"imported"`
+	Duplicates  int              `json:"duplicates"`
+	Failed      int              `json:"failed"`
+	Rules       []RuleImportItem `json:"rules"`
+}
+
+// RuleImportItem 룰 하나의 결과
+// status: imported, staged (변경 세트에 추가), valid (dry run), duplicate, error
+type RuleImportItem struct {
+	Index         int               `json:"index"`
+	Line          int               `json:"line,omitempty"` // .conf에서의 시작 줄
+	Name          string            `json:"name"`
+	Status        string            `json:"status"`
+	RuleID        string            `json:"rule_id,omitempty"`
+	DuplicateOf   string            `json:"duplicate_of,omitempty"` // 같은 내용의 기존 룰 ID 또는 "line N"
+	ReassignedIDs map[string]int    `json:"reassigned_ids,omitempty"`
+	RuleText      string            `json:"rule_text"`
+	Error         string            `json:"error,omitempty"`
+	Details       seclang.ErrorList `json:"details,omitempty"`
+}
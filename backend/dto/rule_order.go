@@ -0,0 +1,21 @@
+package dto
+
+// RuleOrderRequest 나열한 룰들을 지금 차지한 자리 안에서 이 순서로 재배치 (일부만 보내도 됨)
+type RuleOrderRequest struct {
+	RuleIDs []string `json:"rule_ids" binding:"required,min=1"`
+}
+
+// RuleMoveRequest 룰 하나 이동 (드래그 앤 드롭). before, after, position 중 하나만 지정
+// position은 내 룰 목록에서의 위치 (0부터)
+type RuleMoveRequest struct {
+	Before   string `json:"before"`
+	After    string `json:"after"`
+	Position *int   `json:"position" binding:"omitempty,min=0"`
+}
+
+// RulePhaseGroup phase별로 묶은 룰 (배포 순서 유지)
+type RulePhaseGroup struct {
+	Phase int                   `json:"phase"` // 0은 SecMarker만 있는 룰
+	Name  string                `json:"name"`
+	Rules []*CustomRuleResponse `json:"rules"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// RuleRevision 커스텀 룰의 특정 시점 스냅샷
+type RuleRevision struct {
+	RuleID         string                 `json:"rule_id"`
+	Revision       int                    `json:"revision"`
+	Action         string                 `json:"action"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	RuleText       string                 `json:"rule_text"`
+	Enabled        bool                   `json:"enabled"`
+	Severity       string                 `json:"severity"`
+	TemplateID     string                 `json:"template_id,omitempty"`
+	TemplateParams map[string]interface{} `json:"template_params,omitempty"`
+	Group          string                 `json:"group,omitempty"`
+	Targets        []RuleTarget           `json:"targets,omitempty"`
+	Author         string                 `json:"author"`
+	Reason         string                 `json:"reason,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// RuleFieldChange 두 리비전 사이에 바뀐 필드
+type RuleFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// RuleRevisionDiff 두 리비전 비교 결과
+type RuleRevisionDiff struct {
+	RuleID  string            `json:"rule_id"`
+	From    int               `json:"from"`
+	To      int               `json:"to"`
+	Changes []RuleFieldChange `json:"changes"`
+	Diff    string            `json:"diff"` // rule_text의 unified diff
+}
+
+type RuleRestoreRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+// RuleTarget 룰을 적용할 대상. 지정한 필드를 모두 만족하는 요청에만 적용 (대상이 없으면 모든 사이트)
+type RuleTarget struct {
+	Ingress    string `json:"ingress,omitempty"`     // "namespace/name" 또는 "name" (백엔드 네임스페이스)
+	Host       string `json:"host,omitempty"`        // example.com 또는 *.example.com
+	PathPrefix string `json:"path_prefix,omitempty"` // /api/
+}
+
+// RuleTargetsRequest 룰 하나의 그룹과 대상 지정 (targets를 비우면 모든 사이트에 적용)
+type RuleTargetsRequest struct {
+	Group   string       `json:"group"`
+	Targets []RuleTarget `json:"targets"`
+	Reason  string       `json:"reason"`
+}
+
+// RuleGroupTargetsRequest 그룹에 속한 내 룰 전체의 대상 지정
+type RuleGroupTargetsRequest struct {
+	Targets []RuleTarget `json:"targets"`
+	Reason  string       `json:"reason"`
+}
+
+// HostCoverage 호스트 하나에 적용되는 내 룰
+type HostCoverage struct {
+	Host      string              `json:"host"`
+	Ingresses []string            `json:"ingresses,omitempty"` // 이 호스트를 서비스하는 Ingress
+	Rules     []RuleCoverageEntry `json:"rules"`
+}
+
+// RuleCoverageEntry scope: global (모든 사이트) 또는 targeted
+type RuleCoverageEntry struct {
+	RuleID string   `json:"rule_id"`
+	Name   string   `json:"name"`
+	Group  string   `json:"group,omitempty"`
+	Scope  string   `json:"scope"`
+	Paths  []string `json:"paths,omitempty"` // 경로 제한이 있으면 적용되는 경로 접두사
+}
@@ -0,0 +1,51 @@
+package dto
+
+// RuleTemplate 파라미터를 채우면 SecRule 텍스트를 만들어 주는 룰 템플릿
+type RuleTemplate struct {
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Severity    string                  `json:"severity"` // 생성되는 룰의 기본 심각도
+	Parameters  []RuleTemplateParameter `json:"parameters"`
+}
+
+// RuleTemplateParameter 템플릿 파라미터
+// type: string, int, enum, regex, path, header_name, cidr_list, string_list
+type RuleTemplateParameter struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+	Options     []string    `json:"options,omitempty"` // enum 값
+	Min         *int        `json:"min,omitempty"`     // int 범위
+	Max         *int        `json:"max,omitempty"`
+}
+
+// RuleTemplateRenderRequest 저장하지 않고 렌더링 결과만 확인
+type RuleTemplateRenderRequest struct {
+	Params map[string]interface{} `json:"params"`
+}
+
+// RuleFromTemplateRequest 템플릿으로 룰 생성. name/severity를 비우면 템플릿 기본값
+type RuleFromTemplateRequest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Params      map[string]interface{} `json:"params"`
+	Enabled     bool                   `json:"enabled"`
+	Severity    string                 `json:"severity" binding:"omitempty,oneof=LOW MEDIUM HIGH CRITICAL"`
+	Reason      string                 `json:"reason"`
+}
+
+// RuleTemplateRerenderRequest 템플릿으로 만든 룰을 바뀐 파라미터로 다시 렌더링 (빠진 파라미터는 기존 값 유지)
+type RuleTemplateRerenderRequest struct {
+	Params map[string]interface{} `json:"params"`
+	Reason string                 `json:"reason"`
+}
+
+// RenderedRuleTemplate 렌더링된 룰 텍스트 (id는 저장할 때 배정)
+type RenderedRuleTemplate struct {
+	TemplateID string                 `json:"template_id"`
+	Params     map[string]interface{} `json:"params"` // 기본값까지 채운 파라미터
+	RuleText   string                 `json:"rule_text"`
+}
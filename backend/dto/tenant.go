@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// Tenant 보호 대상 호스트들을 소유하는 조직
+type Tenant struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Hosts       []string    `json:"hosts"`         // example.com, *.example.com
+	Members     []string    `json:"members"`       // 이벤트를 조회할 수 있는 사용자 이메일
+	RuleIDRange RuleIDRange `json:"rule_id_range"` // 멤버들의 커스텀 룰 ID 범위 (생성 시 배정)
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type TenantRequest struct {
+	Name    string   `json:"name" binding:"required"`
+	Hosts   []string `json:"hosts" binding:"required,min=1"`
+	Members []string `json:"members"`
+}
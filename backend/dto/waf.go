@@ -1,12 +1,16 @@
 package dto
 
-import "time"
+import (
+	"time"
+	"waf-backend/seclang"
+)
 
 type WAFLog struct {
 	ID          string    `json:"id"`
 	Timestamp   time.Time `json:"timestamp"`
 	ClientIP    string    `json:"client_ip"`
 	Method      string    `json:"method"`
+	Host        string    `json:"host,omitempty"`
 	URL         string    `json:"url"`
 	UserAgent   string    `json:"user_agent"`
 	AttackType  string    `json:"attack_type"`
@@ -14,7 +18,9 @@ type WAFLog struct {
 	Message     string    `json:"message"`
 	Blocked     bool      `json:"blocked"`
 	Severity    string    `json:"severity"`
+	MatchedVar  string    `json:"matched_var,omitempty"`
 	RawLog      string    `json:"raw_log"`
+	Redactions  []string  `json:"redactions,omitempty"` // 마스킹된 필드 (예: url:param:password)
 }
 
 type WAFStats struct {
@@ -32,35 +38,65 @@ type IPStat struct {
 	Blocked  int64  `json:"blocked"`
 }
 
-type CustomRule struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name" binding:"required"`
-	Description string    `json:"description"`
-	RuleText    string    `json:"rule_text" binding:"required"`
-	Enabled     bool      `json:"enabled"`
-	Severity    string    `json:"severity" binding:"required"`
-	UserID      string    `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+// CustomRuleRequest rule_text 대신 구조화된 rules를 보내면 SecRule 텍스트로 변환해서 저장
+type CustomRuleRequest struct {
+	Name        string             `json:"name" binding:"required"`
+	Description string             `json:"description"`
+	RuleText    string             `json:"rule_text" binding:"required_without=Rules,max=65536"`
+	Rules       []seclang.RuleSpec `json:"rules,omitempty"`
+	Enabled     bool               `json:"enabled"`
+	Severity    string             `json:"severity" binding:"required,oneof=LOW MEDIUM HIGH CRITICAL"`
+	Reason      string             `json:"reason"` // 리비전에 남길 변경 사유
 }
 
-type CustomRuleRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	RuleText    string `json:"rule_text" binding:"required"`
-	Enabled     bool   `json:"enabled"`
-	Severity    string `json:"severity" binding:"required,oneof=LOW MEDIUM HIGH CRITICAL"`
+// RuleIDRange 커스텀 룰에 쓸 수 있는 ModSecurity 룰 ID 범위 (양 끝 포함)
+type RuleIDRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// RuleValidationRequest 저장하지 않고 SecLang 문법과 정책만 검사
+type RuleValidationRequest struct {
+	RuleText string `json:"rule_text" binding:"required,max=65536"`
+}
+
+// RuleBuildRequest 구조화된 룰 정의를 SecRule 텍스트로 변환 (저장하지 않음)
+type RuleBuildRequest struct {
+	Rules []seclang.RuleSpec `json:"rules" binding:"required,min=1"`
+}
+
+// RuleTestRequest 룰을 샘플 요청에 대해 평가 (저장/배포하지 않음)
+type RuleTestRequest struct {
+	RuleText string            `json:"rule_text" binding:"required,max=65536"`
+	Request  RuleSampleRequest `json:"request" binding:"required"`
+}
+
+type RuleSampleRequest struct {
+	Method     string            `json:"method"` // 기본 GET
+	URI        string            `json:"uri" binding:"required"`
+	Protocol   string            `json:"protocol"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	RemoteAddr string            `json:"remote_addr"`
 }
 
 type CustomRuleResponse struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	RuleText    string    `json:"rule_text"`
-	Enabled     bool      `json:"enabled"`
-	Severity    string    `json:"severity"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	RuleText       string                 `json:"rule_text"`
+	Enabled        bool                   `json:"enabled"`
+	Severity       string                 `json:"severity"`
+	Source         string                 `json:"source,omitempty"`
+	TemplateID     string                 `json:"template_id,omitempty"`
+	TemplateParams map[string]interface{} `json:"template_params,omitempty"`
+	Group          string                 `json:"group,omitempty"`
+	Targets        []RuleTarget           `json:"targets,omitempty"` // 비어 있으면 모든 사이트
+	Priority       int                    `json:"priority"`          // 배포 순서 (작을수록 먼저)
+	Phase          int                    `json:"phase,omitempty"`   // 첫 룰의 phase (SecMarker만 있으면 0)
+	Hits           *RuleHitSummary        `json:"hits,omitempty"`    // 보관 기간 안의 매칭 수와 마지막 매칭
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
 }
 
 type SecurityTest struct {
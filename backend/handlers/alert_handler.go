@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AlertHandler struct {
+	alertService *services.AlertService
+	log          *logrus.Logger
+}
+
+func NewAlertHandler(alertService *services.AlertService, log *logrus.Logger) *AlertHandler {
+	return &AlertHandler{
+		alertService: alertService,
+		log:          log,
+	}
+}
+
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && status != services.AlertStatusFiring && status != services.AlertStatusResolved {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "status must be 'firing' or 'resolved'",
+			"code":  "ERR_INVALID_REQUEST",
+		})
+		return
+	}
+
+	alerts := h.alertService.GetAlerts(userID, status)
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": alerts,
+		"count":  len(alerts),
+	})
+}
+
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	var req dto.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.WithError(err).Error("Invalid alert rule creation request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.alertService.CreateRule(userID, c.GetString("email"), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to create alert rule")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_ALERT_RULE_CREATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"rule":    rule,
+		"message": "Alert rule created successfully",
+	})
+}
+
+func (h *AlertHandler) GetRules(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	rules := h.alertService.GetRules(userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+func (h *AlertHandler) GetRule(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	rule, err := h.alertService.GetRule(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_ALERT_RULE_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule": rule,
+	})
+}
+
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	var req dto.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.WithError(err).Error("Invalid alert rule update request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.alertService.UpdateRule(userID, c.GetString("email"), c.Param("id"), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to update alert rule")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_ALERT_RULE_UPDATE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule":    rule,
+		"message": "Alert rule updated successfully",
+	})
+}
+
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	if err := h.alertService.DeleteRule(userID, c.Param("id")); err != nil {
+		h.log.WithError(err).Error("Failed to delete alert rule")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_ALERT_RULE_DELETE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert rule deleted successfully",
+	})
+}
+
+func (h *AlertHandler) CreateChannel(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	var req dto.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.WithError(err).Error("Invalid notification channel request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	channel, err := h.alertService.CreateChannel(userID, &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to create notification channel")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANNEL_CREATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"channel": channel,
+		"message": "Notification channel created successfully",
+	})
+}
+
+func (h *AlertHandler) GetChannels(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	channels := h.alertService.GetChannels(userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"channels": channels,
+		"count":    len(channels),
+	})
+}
+
+func (h *AlertHandler) UpdateChannel(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	var req dto.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.WithError(err).Error("Invalid notification channel request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	channel, err := h.alertService.UpdateChannel(userID, c.Param("id"), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to update notification channel")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANNEL_UPDATE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channel": channel,
+		"message": "Notification channel updated successfully",
+	})
+}
+
+func (h *AlertHandler) DeleteChannel(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	if err := h.alertService.DeleteChannel(userID, c.Param("id")); err != nil {
+		h.log.WithError(err).Error("Failed to delete notification channel")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANNEL_DELETE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification channel deleted successfully",
+	})
+}
+
+func (h *AlertHandler) TestChannel(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	channelID := c.Param("id")
+	h.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"channel_id": channelID,
+	}).Info("Sending test notification")
+
+	if err := h.alertService.TestChannel(userID, channelID); err != nil {
+		h.log.WithError(err).Error("Test notification failed")
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to send test notification",
+			"code":    "ERR_CHANNEL_TEST_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Test notification sent successfully",
+	})
+}
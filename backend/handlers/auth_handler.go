@@ -156,6 +156,50 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// AdminMiddleware 관리자 권한 확인 미들웨어 (AuthMiddleware 이후에 사용)
+func (h *AuthHandler) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email := c.GetString("email")
+		if !h.authService.IsAdmin(email) {
+			h.log.WithFields(logrus.Fields{
+				"user_id": c.GetString("user_id"),
+				"email":   email,
+				"path":    c.Request.URL.Path,
+			}).Warn("Admin access denied")
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse(
+				"Admin privileges required",
+				dto.ErrForbidden,
+			))
+			c.Abort()
+			return
+		}
+		
+		c.Next()
+	}
+}
+
+// RuleReviewerMiddleware 룰 변경 세트 검토 권한 확인 미들웨어 (AuthMiddleware 이후에 사용)
+func (h *AuthHandler) RuleReviewerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email := c.GetString("email")
+		if !h.authService.IsRuleReviewer(email) {
+			h.log.WithFields(logrus.Fields{
+				"user_id": c.GetString("user_id"),
+				"email":   email,
+				"path":    c.Request.URL.Path,
+			}).Warn("Rule reviewer access denied")
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse(
+				"Rule reviewer privileges required",
+				dto.ErrForbidden,
+			))
+			c.Abort()
+			return
+		}
+		
+		c.Next()
+	}
+}
+
 // OptionalAuthMiddleware 선택적 인증 미들웨어 (토큰이 있으면 검증, 없어도 통과)
 func (h *AuthHandler) OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type BanHandler struct {
+	banService *services.BanService
+	log        *logrus.Logger
+}
+
+func NewBanHandler(banService *services.BanService, log *logrus.Logger) *BanHandler {
+	return &BanHandler{
+		banService: banService,
+		log:        log,
+	}
+}
+
+func (h *BanHandler) GetBans(c *gin.Context) {
+	bans := h.banService.GetBans()
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans":  bans,
+		"count": len(bans),
+	})
+}
+
+func (h *BanHandler) CreateBan(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	var req dto.IPBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.WithError(err).Error("Invalid ban request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"ip":       req.IP,
+		"duration": req.DurationMinutes,
+	}).Info("Creating manual IP ban")
+
+	ban, err := h.banService.CreateBan(userID, c.ClientIP(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_BAN_CREATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"ban":     ban,
+		"message": "IP banned successfully",
+	})
+}
+
+func (h *BanHandler) ExtendBan(c *gin.Context) {
+	var req dto.IPBanExtendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"user_id": c.GetString("user_id"),
+		"ban_id":  c.Param("id"),
+		"minutes": req.Minutes,
+	}).Info("Extending IP ban")
+
+	ban, err := h.banService.ExtendBan(c.Param("id"), req.Minutes)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_BAN_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ban":     ban,
+		"message": "IP ban extended successfully",
+	})
+}
+
+func (h *BanHandler) LiftBan(c *gin.Context) {
+	h.log.WithFields(logrus.Fields{
+		"user_id": c.GetString("user_id"),
+		"ban_id":  c.Param("id"),
+	}).Info("Lifting IP ban")
+
+	if err := h.banService.LiftBan(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_BAN_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "IP ban lifted successfully",
+	})
+}
+
+func (h *BanHandler) GetPolicies(c *gin.Context) {
+	policies := h.banService.GetPolicies()
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"count":    len(policies),
+	})
+}
+
+func (h *BanHandler) CreatePolicy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	var req dto.BanPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.WithError(err).Error("Invalid ban policy request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	policy, err := h.banService.CreatePolicy(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_BAN_POLICY_CREATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"policy":  policy,
+		"message": "Ban policy created successfully",
+	})
+}
+
+func (h *BanHandler) UpdatePolicy(c *gin.Context) {
+	var req dto.BanPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.WithError(err).Error("Invalid ban policy request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	policy, err := h.banService.UpdatePolicy(c.Param("id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_BAN_POLICY_UPDATE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy":  policy,
+		"message": "Ban policy updated successfully",
+	})
+}
+
+func (h *BanHandler) DeletePolicy(c *gin.Context) {
+	if err := h.banService.DeletePolicy(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_BAN_POLICY_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ban policy deleted successfully",
+	})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// BypassHandler 경로 예외 (엔진 끄기 또는 룰 ID/태그만 끄기)
+type BypassHandler struct {
+	bypassService *services.BypassService
+	log           *logrus.Logger
+}
+
+func NewBypassHandler(bypassService *services.BypassService, log *logrus.Logger) *BypassHandler {
+	return &BypassHandler{
+		bypassService: bypassService,
+		log:           log,
+	}
+}
+
+// GetBypasses 모든 예외와 상태 (active, disabled, expired), 활성 예외는 배포되는 룰 포함
+func (h *BypassHandler) GetBypasses(c *gin.Context) {
+	bypasses := h.bypassService.GetBypasses()
+	c.JSON(http.StatusOK, gin.H{
+		"bypasses": bypasses,
+		"count":    len(bypasses),
+	})
+}
+
+func (h *BypassHandler) GetBypass(c *gin.Context) {
+	bypass, err := h.bypassService.GetBypass(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_BYPASS_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bypass": bypass,
+	})
+}
+
+func (h *BypassHandler) CreateBypass(c *gin.Context) {
+	var req dto.BypassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	bypass, err := h.bypassService.CreateBypass(c.Request.Context(), c.GetString("user_id"), c.GetString("email"), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to create bypass")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_VALIDATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"bypass":  bypass,
+		"message": "Bypass created successfully",
+	})
+}
+
+// UpdateBypass 예외 전체를 교체 (enabled=false로 끄기 가능)
+func (h *BypassHandler) UpdateBypass(c *gin.Context) {
+	var req dto.BypassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	bypass, err := h.bypassService.UpdateBypass(c.Request.Context(), c.GetString("user_id"), c.GetString("email"), c.Param("id"), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to update bypass")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_VALIDATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bypass":  bypass,
+		"message": "Bypass updated successfully",
+	})
+}
+
+func (h *BypassHandler) DeleteBypass(c *gin.Context) {
+	if err := h.bypassService.DeleteBypass(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_BYPASS_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bypass deleted successfully",
+	})
+}
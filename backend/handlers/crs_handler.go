@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CRSHandler OWASP CRS 튜닝 (paranoia level, anomaly 임계값, 플러그인, 룰 끄기)
+type CRSHandler struct {
+	crsService *services.CRSService
+	log        *logrus.Logger
+}
+
+func NewCRSHandler(crsService *services.CRSService, log *logrus.Logger) *CRSHandler {
+	return &CRSHandler{
+		crsService: crsService,
+		log:        log,
+	}
+}
+
+// GetOverview 모든 범위의 설정, 설치된 플러그인, 배포될 설정
+func (h *CRSHandler) GetOverview(c *gin.Context) {
+	c.JSON(http.StatusOK, h.crsService.GetOverview())
+}
+
+func (h *CRSHandler) GetSettings(c *gin.Context) {
+	settings, err := h.crsService.GetSettings(c.Param("scope"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CRS_SETTINGS_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": settings,
+	})
+}
+
+// UpdateSettings 전역(scope=global) 또는 호스트의 설정을 교체하고 배포
+func (h *CRSHandler) UpdateSettings(c *gin.Context) {
+	var req dto.CRSSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	settings, err := h.crsService.UpdateSettings(c.Request.Context(), c.GetString("user_id"), c.Param("scope"), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to update CRS settings")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_VALIDATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": settings,
+		"message":  "CRS settings updated successfully",
+	})
+}
+
+// DeleteSettings 호스트 설정 삭제 (global이면 CRS 기본값으로 초기화)
+func (h *CRSHandler) DeleteSettings(c *gin.Context) {
+	if err := h.crsService.DeleteSettings(c.Request.Context(), c.GetString("user_id"), c.Param("scope")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CRS_SETTINGS_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "CRS settings deleted successfully",
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// EngineHandler 전역/호스트별 SecRuleEngine 모드 (On, DetectionOnly, Off)
+type EngineHandler struct {
+	engineService *services.EngineService
+	log           *logrus.Logger
+}
+
+func NewEngineHandler(engineService *services.EngineService, log *logrus.Logger) *EngineHandler {
+	return &EngineHandler{
+		engineService: engineService,
+		log:           log,
+	}
+}
+
+func (h *EngineHandler) GetModes(c *gin.Context) {
+	c.JSON(http.StatusOK, h.engineService.GetModes())
+}
+
+// GetAudit 모드 변경 기록 (?scope=로 한 범위만)
+func (h *EngineHandler) GetAudit(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	changes, err := h.engineService.GetAudit(c.Request.Context(), c.Query("scope"), limit)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to get engine mode audit")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_VALIDATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changes": changes,
+		"count":   len(changes),
+	})
+}
+
+// SetMode 전역(scope=global) 또는 호스트의 모드 변경. duration_minutes를 주면 만료 후 자동 복귀
+func (h *EngineHandler) SetMode(c *gin.Context) {
+	var req dto.EngineModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	setting, err := h.engineService.SetMode(c.Request.Context(), c.GetString("user_id"), c.Param("scope"), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to set engine mode")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_VALIDATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mode":    setting,
+		"message": "Engine mode updated successfully",
+	})
+}
+
+// ClearMode 호스트 설정 삭제 (global이면 On으로 초기화)
+func (h *EngineHandler) ClearMode(c *gin.Context) {
+	if err := h.engineService.ClearMode(c.Request.Context(), c.GetString("user_id"), c.Param("scope"), c.Query("reason")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_ENGINE_MODE_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Engine mode reset successfully",
+	})
+}
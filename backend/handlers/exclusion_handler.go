@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ExclusionHandler struct {
+	exclusionService *services.ExclusionService
+	tenantService    *services.TenantService
+	authService      *services.AuthService
+	log              *logrus.Logger
+}
+
+func NewExclusionHandler(exclusionService *services.ExclusionService, tenantService *services.TenantService, authService *services.AuthService, log *logrus.Logger) *ExclusionHandler {
+	return &ExclusionHandler{
+		exclusionService: exclusionService,
+		tenantService:    tenantService,
+		authService:      authService,
+		log:              log,
+	}
+}
+
+// PreviewExclusion 이벤트로부터 생성될 예외 룰 미리보기
+func (h *ExclusionHandler) PreviewExclusion(c *gin.Context) {
+	var req dto.ExclusionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	email := c.GetString("email")
+	preview, err := h.exclusionService.PreviewExclusion(c.Param("id"),
+		h.tenantService.ScopeFor(email), h.authService.IsAdmin(email), &req)
+	if err != nil {
+		h.log.WithError(err).Warn("Failed to build exclusion preview")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_EXCLUSION_PREVIEW_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preview": preview,
+	})
+}
+
+// CreateExclusion 예외 룰을 관리형 룰로 저장
+func (h *ExclusionHandler) CreateExclusion(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	var req dto.ExclusionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"event_id": c.Param("id"),
+		"mode":     req.Mode,
+	}).Info("Creating false-positive exclusion")
+
+	email := c.GetString("email")
+	rule, preview, err := h.exclusionService.CreateExclusion(c.Request.Context(), userID, c.Param("id"),
+		h.tenantService.ScopeFor(email), h.authService.IsAdmin(email), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to create exclusion")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_EXCLUSION_CREATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"rule":    rule,
+		"preview": preview,
+		"message": "Exclusion rule created successfully",
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type PrivacyHandler struct {
+	privacyService *services.PrivacyService
+	log            *logrus.Logger
+}
+
+func NewPrivacyHandler(privacyService *services.PrivacyService, log *logrus.Logger) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+		log:            log,
+	}
+}
+
+// Search 삭제 전에 정보주체와 관련된 데이터 조회
+func (h *PrivacyHandler) Search(c *gin.Context) {
+	var req dto.ErasureSubject
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.privacyService.Search(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_VALIDATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *PrivacyHandler) Erase(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	var req dto.ErasureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// 감사 기록에는 요청자 이메일을 남김
+	requestedBy := c.GetString("email")
+	if requestedBy == "" {
+		requestedBy = userID
+	}
+
+	cert, err := h.privacyService.Erase(requestedBy, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_VALIDATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"certificate": cert,
+		"message":     "Erasure completed successfully",
+	})
+}
+
+func (h *PrivacyHandler) GetCertificates(c *gin.Context) {
+	certs, err := h.privacyService.GetCertificates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch erasure certificates",
+			"code":  "ERR_FETCH_CERTIFICATES_FAILED",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"certificates": certs,
+		"total":        len(certs),
+	})
+}
+
+func (h *PrivacyHandler) GetCertificate(c *gin.Context) {
+	cert, err := h.privacyService.GetCertificate(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Erasure certificate not found",
+			"code":  "ERR_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"certificate": cert,
+		"valid":       h.privacyService.VerifyCertificate(*cert),
+	})
+}
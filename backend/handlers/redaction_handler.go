@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type RedactionHandler struct {
+	redactionService *services.RedactionService
+	log              *logrus.Logger
+}
+
+func NewRedactionHandler(redactionService *services.RedactionService, log *logrus.Logger) *RedactionHandler {
+	return &RedactionHandler{
+		redactionService: redactionService,
+		log:              log,
+	}
+}
+
+func (h *RedactionHandler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"config": h.redactionService.GetConfig(),
+	})
+}
+
+func (h *RedactionHandler) UpdateConfig(c *gin.Context) {
+	var req dto.RedactionConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.redactionService.UpdateConfig(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_VALIDATION_FAILED",
+		})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"user_id": c.GetString("user_id"),
+		"enabled": req.Enabled,
+	}).Info("Redaction configuration updated")
+
+	c.JSON(http.StatusOK, gin.H{
+		"config":  h.redactionService.GetConfig(),
+		"message": "Redaction configuration updated successfully",
+	})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ReplayHandler struct {
+	replayService *services.ReplayService
+	tenantService *services.TenantService
+	log           *logrus.Logger
+}
+
+func NewReplayHandler(replayService *services.ReplayService, tenantService *services.TenantService, log *logrus.Logger) *ReplayHandler {
+	return &ReplayHandler{
+		replayService: replayService,
+		tenantService: tenantService,
+		log:           log,
+	}
+}
+
+func (h *ReplayHandler) RunReplay(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	var req dto.ReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.WithError(err).Error("Invalid replay request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"event_ids":  len(req.EventIDs),
+		"target_url": req.TargetURL,
+	}).Info("Replay requested")
+
+	report, err := h.replayService.Replay(c.Request.Context(), userID,
+		h.tenantService.ScopeFor(c.GetString("email")), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Replay failed")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_REPLAY_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report":  report,
+		"message": "Replay completed successfully",
+	})
+}
+
+func (h *ReplayHandler) GetReports(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	reports := h.replayService.GetReports(userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"count":   len(reports),
+	})
+}
+
+func (h *ReplayHandler) GetReport(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+
+	report, err := h.replayService.GetReport(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_REPLAY_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report": report,
+	})
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RuleChangesetHandler 룰 변경 세트 (draft → 검토 → 승인 → 배포)
+type RuleChangesetHandler struct {
+	ruleService   *services.RuleService
+	tenantService *services.TenantService
+	authService   *services.AuthService
+	log           *logrus.Logger
+}
+
+func NewRuleChangesetHandler(ruleService *services.RuleService, tenantService *services.TenantService, authService *services.AuthService, log *logrus.Logger) *RuleChangesetHandler {
+	return &RuleChangesetHandler{
+		ruleService:   ruleService,
+		tenantService: tenantService,
+		authService:   authService,
+		log:           log,
+	}
+}
+
+func (h *RuleChangesetHandler) CreateChangeset(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RuleChangesetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	idRange := h.tenantService.RuleIDRangeFor(c.GetString("email"))
+	changeset, err := h.ruleService.CreateChangeset(c.Request.Context(), userID, idRange, &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to create changeset")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANGESET_CREATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"changeset": changeset,
+		"message":   "Changeset created successfully",
+	})
+}
+
+// ListChangesets ?status=in_review 처럼 상태로 필터링 가능
+func (h *RuleChangesetHandler) ListChangesets(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	changesets, err := h.ruleService.ListChangesets(c.Request.Context(), userID, h.isReviewer(c), c.Query("status"))
+	if err != nil {
+		h.log.WithError(err).Error("Failed to fetch changesets")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch changesets",
+			"code":  "ERR_FETCH_CHANGESETS_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changesets": changesets,
+		"count":      len(changesets),
+	})
+}
+
+func (h *RuleChangesetHandler) GetChangeset(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	changeset, err := h.ruleService.GetChangeset(c.Request.Context(), userID, h.isReviewer(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANGESET_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changeset": changeset,
+	})
+}
+
+func (h *RuleChangesetHandler) AddChange(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RuleChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	changeset, err := h.ruleService.AddChange(c.Request.Context(), userID, c.Param("id"), h.tenantService.RuleTargetHostsFor(c.GetString("email")), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to add change to changeset")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"code":    "ERR_CHANGE_INVALID",
+			"details": ruleErrorDetails(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changeset": changeset,
+		"message":   "Change added to changeset",
+	})
+}
+
+func (h *RuleChangesetHandler) RemoveChange(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	changeID, err := strconv.ParseUint(c.Param("changeId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid change ID",
+			"code":  "ERR_INVALID_REQUEST",
+		})
+		return
+	}
+
+	changeset, err := h.ruleService.RemoveChange(c.Request.Context(), userID, c.Param("id"), uint(changeID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANGE_REMOVE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changeset": changeset,
+		"message":   "Change removed from changeset",
+	})
+}
+
+// ValidateChangeset 현재 룰 기준으로 적용 가능 여부와 배포될 설정 diff (저장하지 않음)
+func (h *RuleChangesetHandler) ValidateChangeset(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	validation, err := h.ruleService.ValidateChangeset(c.Request.Context(), userID, h.isReviewer(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANGESET_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, validation)
+}
+
+func (h *RuleChangesetHandler) SubmitChangeset(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	changeset, err := h.ruleService.SubmitChangeset(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANGESET_SUBMIT_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changeset": changeset,
+		"message":   "Changeset submitted for review",
+	})
+}
+
+// ApproveChangeset 검토자 전용 (RuleReviewerMiddleware)
+func (h *RuleChangesetHandler) ApproveChangeset(c *gin.Context) {
+	h.review(c, true)
+}
+
+// RejectChangeset 검토자 전용 (RuleReviewerMiddleware)
+func (h *RuleChangesetHandler) RejectChangeset(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *RuleChangesetHandler) review(c *gin.Context, approve bool) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	// 본문(comment)은 선택
+	var req dto.RuleReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	review, message := h.ruleService.RejectChangeset, "Changeset rejected"
+	if approve {
+		review, message = h.ruleService.ApproveChangeset, "Changeset approved"
+	}
+
+	changeset, err := review(c.Request.Context(), userID, c.Param("id"), req.Comment)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANGESET_REVIEW_FAILED",
+		})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"changeset_id": changeset.ID,
+		"reviewer":     c.GetString("email"),
+		"status":       changeset.Status,
+	}).Info("Changeset reviewed")
+
+	c.JSON(http.StatusOK, gin.H{
+		"changeset": changeset,
+		"message":   message,
+	})
+}
+
+// PublishChangeset 승인된 변경 세트를 한 번에 배포 (작성자 또는 검토자)
+func (h *RuleChangesetHandler) PublishChangeset(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	changeset, err := h.ruleService.PublishChangeset(c.Request.Context(), userID, h.isReviewer(c), c.Param("id"))
+	if err != nil {
+		h.log.WithError(err).Error("Failed to publish changeset")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANGESET_PUBLISH_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changeset": changeset,
+		"message":   "Changeset published successfully",
+	})
+}
+
+func (h *RuleChangesetHandler) DiscardChangeset(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleService.DiscardChangeset(c.Request.Context(), userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_CHANGESET_DISCARD_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Changeset discarded",
+	})
+}
+
+func (h *RuleChangesetHandler) isReviewer(c *gin.Context) bool {
+	return h.authService.IsRuleReviewer(c.GetString("email"))
+}
+
+// requireUserID 인증된 사용자 ID (없으면 401 응답 후 false)
+func requireUserID(c *gin.Context) (string, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return "", false
+	}
+	return userID, true
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"waf-backend/dto"
+	"waf-backend/seclang"
 	"waf-backend/services"
 
 	"github.com/gin-gonic/gin"
@@ -10,14 +14,16 @@ import (
 )
 
 type RuleHandler struct {
-	ruleService *services.RuleService
-	log         *logrus.Logger
+	ruleService   *services.RuleService
+	tenantService *services.TenantService
+	log           *logrus.Logger
 }
 
-func NewRuleHandler(ruleService *services.RuleService, log *logrus.Logger) *RuleHandler {
+func NewRuleHandler(ruleService *services.RuleService, tenantService *services.TenantService, log *logrus.Logger) *RuleHandler {
 	return &RuleHandler{
-		ruleService: ruleService,
-		log:         log,
+		ruleService:   ruleService,
+		tenantService: tenantService,
+		log:           log,
 	}
 }
 
@@ -48,12 +54,18 @@ func (h *RuleHandler) CreateRule(c *gin.Context) {
 		"severity":  req.Severity,
 	}).Info("Creating custom rule")
 	
-	rule, err := h.ruleService.CreateRule(userID, &req)
+	idRange := h.tenantService.RuleIDRangeFor(c.GetString("email"))
+	allowedHosts := h.tenantService.RuleTargetHostsFor(c.GetString("email"))
+	rule, err := h.ruleService.CreateRule(c.Request.Context(), userID, idRange, allowedHosts, &req)
 	if err != nil {
 		h.log.WithError(err).Error("Failed to create rule")
+		if reviewRequired(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-			"code":  "ERR_RULE_CREATION_FAILED",
+			"error":   err.Error(),
+			"code":    "ERR_RULE_CREATION_FAILED",
+			"details": ruleErrorDetails(err),
 		})
 		return
 	}
@@ -82,7 +94,7 @@ func (h *RuleHandler) GetRules(c *gin.Context) {
 	
 	h.log.WithField("user_id", userID).Debug("Fetching user rules")
 	
-	rules, err := h.ruleService.GetRules(userID)
+	rules, err := h.ruleService.GetRules(c.Request.Context(), userID)
 	if err != nil {
 		h.log.WithError(err).Error("Failed to fetch rules")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -175,12 +187,18 @@ func (h *RuleHandler) UpdateRule(c *gin.Context) {
 		"rule_name": req.Name,
 	}).Info("Updating custom rule")
 	
-	rule, err := h.ruleService.UpdateRule(userID, ruleID, &req)
+	idRange := h.tenantService.RuleIDRangeFor(c.GetString("email"))
+	allowedHosts := h.tenantService.RuleTargetHostsFor(c.GetString("email"))
+	rule, err := h.ruleService.UpdateRule(c.Request.Context(), userID, ruleID, idRange, allowedHosts, &req)
 	if err != nil {
 		h.log.WithError(err).Error("Failed to update rule")
+		if reviewRequired(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-			"code":  "ERR_RULE_UPDATE_FAILED",
+			"error":   err.Error(),
+			"code":    "ERR_RULE_UPDATE_FAILED",
+			"details": ruleErrorDetails(err),
 		})
 		return
 	}
@@ -222,9 +240,12 @@ func (h *RuleHandler) DeleteRule(c *gin.Context) {
 		"rule_id": ruleID,
 	}).Info("Deleting custom rule")
 	
-	err := h.ruleService.DeleteRule(userID, ruleID)
+	err := h.ruleService.DeleteRule(c.Request.Context(), userID, ruleID, c.Query("reason"))
 	if err != nil {
 		h.log.WithError(err).Error("Failed to delete rule")
+		if reviewRequired(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 			"code":  "ERR_RULE_DELETE_FAILED",
@@ -240,4 +261,262 @@ func (h *RuleHandler) DeleteRule(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Rule deleted successfully",
 	})
-}
\ No newline at end of file
+}
+
+// GetRevisions 룰의 변경 이력 (삭제된 룰 포함)
+func (h *RuleHandler) GetRevisions(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+	
+	revisions, err := h.ruleService.GetRevisions(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_RULE_NOT_FOUND",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+		"count":     len(revisions),
+	})
+}
+
+// DiffRevisions ?from=N&to=M 두 리비전 비교
+func (h *RuleHandler) DiffRevisions(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+	
+	from, fromErr := strconv.Atoi(c.Query("from"))
+	to, toErr := strconv.Atoi(c.Query("to"))
+	if fromErr != nil || toErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from and to revision numbers are required",
+			"code":  "ERR_INVALID_REQUEST",
+		})
+		return
+	}
+	
+	diff, err := h.ruleService.DiffRevisions(c.Request.Context(), userID, c.Param("id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_REVISION_NOT_FOUND",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"diff": diff,
+	})
+}
+
+// RestoreRevision 리비전 내용으로 룰을 되돌리고 다시 배포
+func (h *RuleHandler) RestoreRevision(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User ID not found",
+			"code":  "ERR_NO_USER_ID",
+		})
+		return
+	}
+	
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid revision number",
+			"code":  "ERR_INVALID_REQUEST",
+		})
+		return
+	}
+	
+	// 본문(reason)은 선택
+	var req dto.RuleRestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+	
+	idRange := h.tenantService.RuleIDRangeFor(c.GetString("email"))
+	allowedHosts := h.tenantService.RuleTargetHostsFor(c.GetString("email"))
+	rule, err := h.ruleService.RestoreRevision(c.Request.Context(), userID, c.Param("id"), revision, idRange, allowedHosts, req.Reason)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to restore rule revision")
+		if reviewRequired(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"code":    "ERR_RULE_RESTORE_FAILED",
+			"details": ruleErrorDetails(err),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"rule":    rule,
+		"message": "Rule restored successfully",
+	})
+}
+
+// GetRuleIDRange 사용자가 커스텀 룰에 쓸 수 있는 ID 범위 (id를 생략하면 이 범위에서 자동 배정)
+func (h *RuleHandler) GetRuleIDRange(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"rule_id_range": h.tenantService.RuleIDRangeFor(c.GetString("email")),
+	})
+}
+
+// ValidateRule 룰을 저장하지 않고 파싱 결과와 위치별 오류 반환
+func (h *RuleHandler) ValidateRule(c *gin.Context) {
+	var req dto.RuleValidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+	
+	directives, err := h.ruleService.ValidateRule(req.RuleText)
+	if err != nil {
+		errs := ruleErrorDetails(err)
+		if errs == nil {
+			errs = seclang.ErrorList{{Position: seclang.Position{Line: 1, Column: 1}, Message: err.Error()}}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"valid":      false,
+			"errors":     errs,
+			"directives": directives,
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"valid":      true,
+		"errors":     seclang.ErrorList{},
+		"directives": directives,
+	})
+}
+
+// TestRule 룰을 저장하지 않고 샘플 요청에 대해 평가 (일치 여부, 일치한 변수, 실행된 액션)
+func (h *RuleHandler) TestRule(c *gin.Context) {
+	var req dto.RuleTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+	
+	result, err := h.ruleService.TestRule(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"code":    "ERR_RULE_VALIDATION",
+			"details": ruleErrorDetails(err),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"result": result,
+	})
+}
+
+// BuildRule 구조화된 룰 정의(변수, 연산자, 변환, phase, 액션, chain)를 SecRule 텍스트로 변환
+func (h *RuleHandler) BuildRule(c *gin.Context) {
+	var req dto.RuleBuildRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+	
+	ruleText, err := h.ruleService.BuildRule(req.Rules)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     err.Error(),
+			"code":      "ERR_RULE_BUILD_FAILED",
+			"details":   ruleErrorDetails(err),
+			"rule_text": ruleText,
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"rule_text": ruleText,
+	})
+}
+
+// ParseRule SecRule 텍스트를 폼 편집기용 구조로 변환 (BuildRule의 역방향)
+func (h *RuleHandler) ParseRule(c *gin.Context) {
+	var req dto.RuleValidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+	
+	specs, err := h.ruleService.DescribeRule(req.RuleText)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"code":    "ERR_RULE_VALIDATION",
+			"details": ruleErrorDetails(err),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"rules": specs,
+	})
+}
+
+// reviewRequired 검토 필수 모드에서 직접 수정하려 하면 409와 함께 true
+func reviewRequired(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrReviewRequired) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   err.Error(),
+		"code":    "ERR_REVIEW_REQUIRED",
+		"details": "Add the change to a changeset via /api/v1/rules/changesets and have it approved",
+	})
+	return true
+}
+
+// ruleErrorDetails SecLang 파싱/정책 오류면 위치가 포함된 오류 목록, 아니면 nil
+func ruleErrorDetails(err error) seclang.ErrorList {
+	var errs seclang.ErrorList
+	if errors.As(err, &errs) {
+		return errs
+	}
+	return nil
+}
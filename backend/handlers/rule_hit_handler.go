@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRuleAnalytics 룰 하나의 매칭 분석 (?window=24h&bucket=1h, Go duration 형식)
+// 구간이 너무 많으면 bucket을 늘려서 계산, 테넌트 사용자는 자기 호스트의 매칭만
+func (h *RuleHandler) GetRuleAnalytics(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var window, bucket time.Duration
+	for _, param := range []struct {
+		name  string
+		value *time.Duration
+	}{{"window", &window}, {"bucket", &bucket}} {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(raw)
+		if err != nil || duration < time.Minute {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid " + param.name + ", use a duration of at least 1m such as 24h or 15m",
+				"code":  "ERR_INVALID_REQUEST",
+			})
+			return
+		}
+		*param.value = duration
+	}
+
+	analytics, err := h.ruleService.GetRuleHitAnalytics(c.Request.Context(), userID, c.Param("id"),
+		h.tenantService.ScopeFor(c.GetString("email")), window, bucket)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to get rule analytics")
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_RULE_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"analytics": analytics,
+	})
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRuleImportSize 가져오기 파일 최대 크기
+const maxRuleImportSize = 5 << 20
+
+// ImportRules .conf 파일 또는 JSON 번들에서 룰을 한 번에 가져옴
+// 본문: multipart의 file 필드, JSON 번들(application/json), 또는 .conf 텍스트 그대로. 옵션은 쿼리 파라미터
+func (h *RuleHandler) ImportRules(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var opts dto.RuleImportOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	content, filename, err := readImportBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read import file",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	idRange := h.tenantService.RuleIDRangeFor(c.GetString("email"))
+	opts.TargetHosts = h.tenantService.RuleTargetHostsFor(c.GetString("email"))
+	var result *dto.RuleImportResult
+	if isRuleBundle(c, filename, content) {
+		var bundle dto.RuleBundle
+		if err := json.Unmarshal(content, &bundle); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid rule bundle",
+				"code":    "ERR_INVALID_REQUEST",
+				"details": err.Error(),
+			})
+			return
+		}
+		result, err = h.ruleService.ImportBundle(c.Request.Context(), userID, idRange, &bundle, &opts)
+	} else {
+		result, err = h.ruleService.ImportConf(c.Request.Context(), userID, idRange, string(content), &opts)
+	}
+	if err != nil {
+		h.log.WithError(err).Error("Failed to import rules")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"code":    "ERR_RULE_IMPORT_FAILED",
+			"details": ruleErrorDetails(err),
+		})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"file":       filename,
+		"dry_run":    opts.DryRun,
+		"imported":   result.Imported,
+		"duplicates": result.Duplicates,
+		"failed":     result.Failed,
+	}).Info("Rule import processed")
+
+	status := http.StatusOK
+	if !result.DryRun && result.Imported > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, result)
+}
+
+// ExportRules 내 룰을 .conf(format=conf, 기본) 또는 JSON 번들(format=json)로 다운로드
+// ids=rule_1,rule_2로 일부만 내보낼 수 있음
+func (h *RuleHandler) ExportRules(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "conf")
+	if format != "conf" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be conf or json",
+			"code":  "ERR_INVALID_REQUEST",
+		})
+		return
+	}
+	var ruleIDs []string
+	if ids := c.Query("ids"); ids != "" {
+		ruleIDs = strings.Split(ids, ",")
+	}
+
+	email := c.GetString("email")
+	bundle, err := h.ruleService.ExportRules(userID, email, h.tenantService.RuleIDRangeFor(email), ruleIDs)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_RULE_NOT_FOUND",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("custom-rules-%s.%s", bundle.ExportedAt.Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "json" {
+		c.JSON(http.StatusOK, bundle)
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(services.BundleConf(bundle)))
+}
+
+// readImportBody 업로드 파일 또는 요청 본문 (크기 제한 적용)
+func readImportBody(c *gin.Context) ([]byte, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRuleImportSize)
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		return content, header.Filename, err
+	}
+
+	content, err := io.ReadAll(c.Request.Body)
+	if err == nil && len(strings.TrimSpace(string(content))) == 0 {
+		err = fmt.Errorf("request body is empty")
+	}
+	return content, "", err
+}
+
+// isRuleBundle JSON 번들인지 (Content-Type, 확장자, 내용 순으로 판단)
+func isRuleBundle(c *gin.Context, filename string, content []byte) bool {
+	if c.ContentType() == "application/json" || strings.EqualFold(filepath.Ext(filename), ".json") {
+		return true
+	}
+	return strings.HasPrefix(strings.TrimSpace(string(content)), "{")
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRulesByPhase 내 룰을 phase별로 묶어서 배포 순서대로 반환
+func (h *RuleHandler) GetRulesByPhase(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"phases": h.ruleService.GetRulesByPhase(userID),
+	})
+}
+
+// ReorderRules 나열한 룰들의 배포 순서를 한 번에 변경
+func (h *RuleHandler) ReorderRules(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RuleOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rules, err := h.ruleService.ReorderRules(c.Request.Context(), userID, req.RuleIDs)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to reorder rules")
+		if reviewRequired(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_RULE_REORDER_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules":   rules,
+		"message": "Rules reordered successfully",
+	})
+}
+
+// MoveRule 룰 하나를 다른 룰 앞/뒤 또는 지정한 위치로 이동 (드래그 앤 드롭)
+func (h *RuleHandler) MoveRule(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RuleMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rules, err := h.ruleService.MoveRule(c.Request.Context(), userID, c.Param("id"), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to move rule")
+		if reviewRequired(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_RULE_REORDER_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules":   rules,
+		"message": "Rule moved successfully",
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetRuleTargets 룰 하나를 특정 Ingress/호스트/경로에만 적용 (targets를 비우면 모든 사이트)
+func (h *RuleHandler) SetRuleTargets(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RuleTargetsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	allowedHosts := h.tenantService.RuleTargetHostsFor(c.GetString("email"))
+	rule, err := h.ruleService.SetRuleTargets(c.Request.Context(), userID, c.Param("id"), allowedHosts, &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to set rule targets")
+		if reviewRequired(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_RULE_TARGETS_INVALID",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule":    rule,
+		"message": "Rule targets updated successfully",
+	})
+}
+
+// SetGroupTargets 그룹에 속한 내 룰 전체의 대상 변경
+func (h *RuleHandler) SetGroupTargets(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RuleGroupTargetsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	allowedHosts := h.tenantService.RuleTargetHostsFor(c.GetString("email"))
+	rules, err := h.ruleService.SetGroupTargets(c.Request.Context(), userID, c.Param("group"), allowedHosts, &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to set rule group targets")
+		if reviewRequired(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_RULE_TARGETS_INVALID",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules":   rules,
+		"message": "Rule group targets updated successfully",
+	})
+}
+
+// GetRuleCoverage 호스트별로 어떤 내 룰이 적용되는지 (?host=로 한 호스트만)
+func (h *RuleHandler) GetRuleCoverage(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	coverage, err := h.ruleService.RuleCoverage(c.Request.Context(), userID, c.Query("host"))
+	if err != nil {
+		h.log.WithError(err).Error("Failed to get rule coverage")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch rule coverage",
+			"code":    "ERR_FETCH_RULES_FAILED",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"hosts": coverage,
+	})
+}
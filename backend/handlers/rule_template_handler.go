@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"waf-backend/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ListTemplates 룰 템플릿 카탈로그와 파라미터 정의
+func (h *RuleHandler) ListTemplates(c *gin.Context) {
+	templates := h.ruleService.ListRuleTemplates()
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+// RenderTemplate 저장하지 않고 템플릿 렌더링 결과 확인 (id는 저장할 때 배정)
+func (h *RuleHandler) RenderTemplate(c *gin.Context) {
+	// 파라미터가 모두 기본값이면 본문 생략 가능
+	var req dto.RuleTemplateRenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rendered, err := h.ruleService.RenderRuleTemplate(c.Param("templateId"), req.Params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"code":    "ERR_TEMPLATE_RENDER_FAILED",
+			"details": ruleErrorDetails(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
+
+// CreateRuleFromTemplate 템플릿으로 룰 생성 (ID 자동 배정, 템플릿 연결 유지)
+func (h *RuleHandler) CreateRuleFromTemplate(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RuleFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	templateID := c.Param("templateId")
+	idRange := h.tenantService.RuleIDRangeFor(c.GetString("email"))
+	allowedHosts := h.tenantService.RuleTargetHostsFor(c.GetString("email"))
+	rule, err := h.ruleService.CreateRuleFromTemplate(c.Request.Context(), userID, templateID, idRange, allowedHosts, &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to create rule from template")
+		if reviewRequired(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"code":    "ERR_RULE_CREATION_FAILED",
+			"details": ruleErrorDetails(err),
+		})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"rule_id":     rule.ID,
+		"user_id":     userID,
+		"template_id": templateID,
+	}).Info("Custom rule created from template")
+
+	c.JSON(http.StatusCreated, gin.H{
+		"rule":    rule,
+		"message": "Rule created successfully",
+	})
+}
+
+// RerenderRule 템플릿으로 만든 룰을 바뀐 파라미터로 다시 렌더링 (기존 ID 유지)
+func (h *RuleHandler) RerenderRule(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RuleTemplateRerenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	idRange := h.tenantService.RuleIDRangeFor(c.GetString("email"))
+	allowedHosts := h.tenantService.RuleTargetHostsFor(c.GetString("email"))
+	rule, err := h.ruleService.RerenderRule(c.Request.Context(), userID, c.Param("id"), idRange, allowedHosts, &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to re-render rule")
+		if reviewRequired(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"code":    "ERR_RULE_UPDATE_FAILED",
+			"details": ruleErrorDetails(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule":    rule,
+		"message": "Rule re-rendered successfully",
+	})
+}
@@ -49,7 +49,7 @@ func (h *SecurityTestHandler) RunSecurityTest(c *gin.Context) {
 		"payloads":  len(req.Payloads),
 	}).Info("Running security test")
 	
-	test, err := h.securityTestService.RunSecurityTest(req.TestType, req.Payloads)
+	test, err := h.securityTestService.RunSecurityTest(c.Request.Context(), req.TestType, req.Payloads)
 	if err != nil {
 		h.log.WithError(err).Error("Failed to run security test")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -158,7 +158,7 @@ func (h *SecurityTestHandler) GetQuickTests(c *gin.Context) {
 	results := make([]gin.H, 0)
 	
 	for _, quickTest := range quickTests {
-		test, err := h.securityTestService.RunSecurityTest(quickTest.TestType, quickTest.Payloads)
+		test, err := h.securityTestService.RunSecurityTest(c.Request.Context(), quickTest.TestType, quickTest.Payloads)
 		if err != nil {
 			h.log.WithError(err).Error("Quick test failed")
 			continue
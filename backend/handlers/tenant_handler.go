@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"waf-backend/dto"
+	"waf-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type TenantHandler struct {
+	tenantService *services.TenantService
+	log           *logrus.Logger
+}
+
+func NewTenantHandler(tenantService *services.TenantService, log *logrus.Logger) *TenantHandler {
+	return &TenantHandler{
+		tenantService: tenantService,
+		log:           log,
+	}
+}
+
+// GetMyTenants 로그인한 사용자가 속한 테넌트 목록
+func (h *TenantHandler) GetMyTenants(c *gin.Context) {
+	tenants := h.tenantService.GetUserTenants(c.GetString("email"))
+	c.JSON(http.StatusOK, gin.H{
+		"tenants": tenants,
+		"total":   len(tenants),
+	})
+}
+
+func (h *TenantHandler) GetTenants(c *gin.Context) {
+	tenants := h.tenantService.GetTenants()
+	c.JSON(http.StatusOK, gin.H{
+		"tenants": tenants,
+		"total":   len(tenants),
+	})
+}
+
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	var req dto.TenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tenant, err := h.tenantService.CreateTenant(&req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to create tenant")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_TENANT_CREATION_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"tenant":  tenant,
+		"message": "Tenant created successfully",
+	})
+}
+
+func (h *TenantHandler) UpdateTenant(c *gin.Context) {
+	var req dto.TenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"code":    "ERR_INVALID_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tenant, err := h.tenantService.UpdateTenant(c.Param("id"), &req)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to update tenant")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "ERR_TENANT_UPDATE_FAILED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tenant":  tenant,
+		"message": "Tenant updated successfully",
+	})
+}
+
+func (h *TenantHandler) DeleteTenant(c *gin.Context) {
+	if err := h.tenantService.DeleteTenant(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tenant not found",
+			"code":  "ERR_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tenant deleted successfully",
+	})
+}
@@ -12,13 +12,15 @@ import (
 type WAFHandler struct {
 	wafService       *services.WAFService
 	websocketService *services.WebSocketService
+	tenantService    *services.TenantService
 	log              *logrus.Logger
 }
 
-func NewWAFHandler(wafService *services.WAFService, websocketService *services.WebSocketService, log *logrus.Logger) *WAFHandler {
+func NewWAFHandler(wafService *services.WAFService, websocketService *services.WebSocketService, tenantService *services.TenantService, log *logrus.Logger) *WAFHandler {
 	return &WAFHandler{
 		wafService:       wafService,
 		websocketService: websocketService,
+		tenantService:    tenantService,
 		log:              log,
 	}
 }
@@ -40,7 +42,8 @@ func (h *WAFHandler) GetLogs(c *gin.Context) {
 		"limit":   limit,
 	}).Debug("WAF logs requested")
 	
-	logs := h.wafService.GetLogs(limit)
+	// 사용자가 속한 테넌트의 이벤트만 조회
+	logs := h.wafService.GetLogs(limit, h.tenantService.ScopeFor(c.GetString("email")))
 	
 	c.JSON(http.StatusOK, gin.H{
 		"logs":  logs,
@@ -53,11 +56,9 @@ func (h *WAFHandler) GetStats(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	h.log.WithField("user_id", userID).Debug("WAF stats requested")
 	
-	stats := h.wafService.GetStats()
+	stats := h.wafService.GetStats(h.tenantService.ScopeFor(c.GetString("email")))
 	
 	// WebSocket 연결 수 추가
-	stats.Timestamp = stats.Timestamp
-	
 	c.JSON(http.StatusOK, gin.H{
 		"stats":             stats,
 		"websocket_clients": h.websocketService.GetConnectedClients(),
@@ -73,11 +74,12 @@ func (h *WAFHandler) GetDashboard(c *gin.Context) {
 		"email":   email,
 	}).Debug("Dashboard data requested")
 	
-	// 통계 정보 가져오기
-	stats := h.wafService.GetStats()
+	// 통계 정보 가져오기 (사용자 테넌트 범위)
+	scope := h.tenantService.ScopeFor(c.GetString("email"))
+	stats := h.wafService.GetStats(scope)
 	
 	// 최근 로그 10개
-	recentLogs := h.wafService.GetLogs(10)
+	recentLogs := h.wafService.GetLogs(10, scope)
 	
 	// 대시보드 응답 구성
 	dashboard := gin.H{
@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	"waf-backend/config"
+	"waf-backend/database"
 	"waf-backend/dto"
 	"waf-backend/handlers"
+	"waf-backend/metrics"
 	"waf-backend/services"
+	"waf-backend/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
@@ -28,22 +37,84 @@ func main() {
 	
 	log.Info("Starting WAF SaaS Backend Server v2.0")
 	
+	// OpenTelemetry tracing (OTLP/HTTP export, W3C trace context 전파)
+	shutdownTracing, err := tracing.Init(cfg, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize tracing")
+	}
+	go func() {
+		// 종료 시그널을 받으면 남은 span을 flush 후 종료
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.WithError(err).Warn("Failed to flush traces")
+		}
+		if err := database.CloseDB(); err != nil {
+			log.WithError(err).Warn("Failed to close database")
+		}
+		os.Exit(0)
+	}()
+	
+	// 알림 룰/채널 저장소 (SQLite)
+	if err := database.InitDB(log); err != nil {
+		log.WithError(err).Fatal("Failed to initialize database")
+	}
+	
 	// Initialize services
 	log.Info("Initializing services...")
 	authService := services.NewAuthService(cfg, log)
-	wafService := services.NewWAFService(log)
-	ruleService := services.NewRuleService(log)
+	redactionService := services.NewRedactionService(cfg, log)
+	wafService := services.NewWAFService(log, redactionService)
+	ruleService := services.NewRuleService(cfg, log, database.GetDB())
+	tenantService := services.NewTenantService(log, database.GetDB(), authService, ruleService)
 	securityTestService := services.NewSecurityTestService(log)
-	websocketService := services.NewWebSocketService(log, wafService)
+	websocketService := services.NewWebSocketService(log, wafService, tenantService)
+	alertService := services.NewAlertService(log, database.GetDB(), wafService, tenantService)
+	banService := services.NewBanService(log, database.GetDB(), wafService, ruleService)
+	exclusionService := services.NewExclusionService(log, wafService, ruleService)
+	crsService := services.NewCRSService(cfg, log, database.GetDB(), ruleService)
+	engineService := services.NewEngineService(log, database.GetDB(), ruleService)
+	bypassService := services.NewBypassService(log, database.GetDB(), ruleService)
+	replayService := services.NewReplayService(log, wafService)
+	privacyService := services.NewPrivacyService(cfg, log, database.GetDB(), wafService, alertService, banService, replayService, ruleService)
+	
+	// 관리형 snippet 등록이 끝난 뒤 배포된 설정을 DB 기준으로 맞춤
+	if err := ruleService.Reconcile(context.Background()); err != nil {
+		log.WithError(err).Warn("Failed to reconcile deployed rules with database")
+	}
+	
+	// Prometheus 메트릭 수집
+	wafService.AddLogListener(metrics.RecordEvent)
+	
+	// 커스텀 룰 매칭 기록 (룰별 분석)
+	wafService.AddLogListener(ruleService.RecordHit)
+	metrics.RegisterWebSocketClients(websocketService.GetConnectedClients)
 	
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService, log)
-	wafHandler := handlers.NewWAFHandler(wafService, websocketService, log)
-	ruleHandler := handlers.NewRuleHandler(ruleService, log)
+	wafHandler := handlers.NewWAFHandler(wafService, websocketService, tenantService, log)
+	ruleHandler := handlers.NewRuleHandler(ruleService, tenantService, log)
+	ruleChangesetHandler := handlers.NewRuleChangesetHandler(ruleService, tenantService, authService, log)
 	securityTestHandler := handlers.NewSecurityTestHandler(securityTestService, log)
+	alertHandler := handlers.NewAlertHandler(alertService, log)
+	banHandler := handlers.NewBanHandler(banService, log)
+	exclusionHandler := handlers.NewExclusionHandler(exclusionService, tenantService, authService, log)
+	crsHandler := handlers.NewCRSHandler(crsService, log)
+	engineHandler := handlers.NewEngineHandler(engineService, log)
+	bypassHandler := handlers.NewBypassHandler(bypassService, log)
+	replayHandler := handlers.NewReplayHandler(replayService, tenantService, log)
+	redactionHandler := handlers.NewRedactionHandler(redactionService, log)
+	privacyHandler := handlers.NewPrivacyHandler(privacyService, log)
+	tenantHandler := handlers.NewTenantHandler(tenantService, log)
 	
 	r := gin.Default()
 	
+	// 요청마다 server span 생성 (들어온 traceparent 헤더를 이어받음)
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	
 	// Add structured logging middleware
 	r.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		log.WithFields(logrus.Fields{
@@ -62,6 +133,12 @@ func main() {
 	
 	// CORS middleware with configurable origin
 	r.Use(corsMiddleware(cfg.Server.CORSOrigin))
+	
+	// HTTP handler latency metrics
+	r.Use(metrics.Middleware())
+	
+	// Prometheus scrape endpoint
+	r.GET("/metrics", metrics.Handler())
 
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
@@ -76,6 +153,16 @@ func main() {
 				"Custom Rules Management",
 				"Security Testing",
 				"Real-time WebSocket",
+				"Threshold Alerting",
+				"Automatic IP Banning",
+				"Sensitive Data Redaction",
+				"Data Subject Erasure",
+				"Prometheus Metrics",
+				"OpenTelemetry Tracing",
+				"Multi-tenant Event Isolation",
+				"OWASP CRS Tuning",
+				"Engine Mode Control",
+				"Path Bypasses",
 			},
 		})
 	})
@@ -98,6 +185,9 @@ func main() {
 			auth.POST("/logout", authHandler.Logout)
 		}
 		
+		// 로그인한 사용자의 테넌트 (이벤트 조회 범위)
+		protected.GET("/tenants", tenantHandler.GetMyTenants)
+		
 		// WAF monitoring routes
 		waf := protected.Group("/waf")
 		{
@@ -105,16 +195,96 @@ func main() {
 			waf.GET("/stats", wafHandler.GetStats)
 			waf.GET("/dashboard", wafHandler.GetDashboard)
 			waf.POST("/test-logs", wafHandler.GenerateTestLogs) // For testing purposes
+			
+			// False-positive triage: 이벤트로부터 CRS 예외 룰 생성
+			waf.POST("/logs/:id/exclusion/preview", exclusionHandler.PreviewExclusion)
+			waf.POST("/logs/:id/exclusion", exclusionHandler.CreateExclusion)
+			
+			// 저장된 요청 재전송으로 룰 변경 효과 검증
+			waf.POST("/replay", authHandler.AdminMiddleware(), replayHandler.RunReplay)
+			waf.GET("/replay", replayHandler.GetReports)
+			waf.GET("/replay/:id", replayHandler.GetReport)
+			
+			// 민감 정보 마스킹 설정
+			waf.GET("/redaction", redactionHandler.GetConfig)
+			waf.PUT("/redaction", authHandler.AdminMiddleware(), redactionHandler.UpdateConfig)
 		}
 		
 		// Custom rules management
 		rules := protected.Group("/rules")
 		{
 			rules.POST("/", ruleHandler.CreateRule)
+			rules.POST("/validate", ruleHandler.ValidateRule)
+			rules.POST("/test", ruleHandler.TestRule)
+			rules.POST("/build", ruleHandler.BuildRule)
+			rules.POST("/parse", ruleHandler.ParseRule)
+			rules.GET("/id-range", ruleHandler.GetRuleIDRange)
+			rules.POST("/import", ruleHandler.ImportRules)
+			rules.GET("/export", ruleHandler.ExportRules)
+			rules.GET("/phases", ruleHandler.GetRulesByPhase)
+			rules.PUT("/order", ruleHandler.ReorderRules)
+			rules.GET("/coverage", ruleHandler.GetRuleCoverage)
+			rules.PUT("/groups/:group/targets", ruleHandler.SetGroupTargets)
 			rules.GET("/", ruleHandler.GetRules)
 			rules.GET("/:id", ruleHandler.GetRule)
 			rules.PUT("/:id", ruleHandler.UpdateRule)
 			rules.DELETE("/:id", ruleHandler.DeleteRule)
+			rules.GET("/:id/revisions", ruleHandler.GetRevisions)
+			rules.GET("/:id/analytics", ruleHandler.GetRuleAnalytics)
+			rules.GET("/:id/revisions/diff", ruleHandler.DiffRevisions)
+			rules.POST("/:id/revisions/:revision/restore", ruleHandler.RestoreRevision)
+			rules.PUT("/:id/template", ruleHandler.RerenderRule)
+			rules.POST("/:id/move", ruleHandler.MoveRule)
+			rules.PUT("/:id/targets", ruleHandler.SetRuleTargets)
+			
+			// 룰 템플릿: 파라미터로 SecRule 생성
+			rules.GET("/templates", ruleHandler.ListTemplates)
+			rules.POST("/templates/:templateId", ruleHandler.CreateRuleFromTemplate)
+			rules.POST("/templates/:templateId/render", ruleHandler.RenderTemplate)
+			
+			// 변경 세트: draft에 변경을 모아 검증하고, 다른 검토자의 승인 후 한 번에 배포
+			changesets := rules.Group("/changesets")
+			{
+				changesets.POST("/", ruleChangesetHandler.CreateChangeset)
+				changesets.GET("/", ruleChangesetHandler.ListChangesets)
+				changesets.GET("/:id", ruleChangesetHandler.GetChangeset)
+				changesets.DELETE("/:id", ruleChangesetHandler.DiscardChangeset)
+				changesets.POST("/:id/changes", ruleChangesetHandler.AddChange)
+				changesets.DELETE("/:id/changes/:changeId", ruleChangesetHandler.RemoveChange)
+				changesets.POST("/:id/validate", ruleChangesetHandler.ValidateChangeset)
+				changesets.POST("/:id/submit", ruleChangesetHandler.SubmitChangeset)
+				changesets.POST("/:id/approve", authHandler.RuleReviewerMiddleware(), ruleChangesetHandler.ApproveChangeset)
+				changesets.POST("/:id/reject", authHandler.RuleReviewerMiddleware(), ruleChangesetHandler.RejectChangeset)
+				changesets.POST("/:id/publish", ruleChangesetHandler.PublishChangeset)
+			}
+		}
+		
+		// OWASP CRS 튜닝: 전역(scope=global) 또는 호스트별, 변경은 관리자 전용
+		crs := protected.Group("/crs")
+		{
+			crs.GET("/", crsHandler.GetOverview)
+			crs.GET("/:scope", crsHandler.GetSettings)
+			crs.PUT("/:scope", authHandler.AdminMiddleware(), crsHandler.UpdateSettings)
+			crs.DELETE("/:scope", authHandler.AdminMiddleware(), crsHandler.DeleteSettings)
+		}
+			
+		// 엔진 모드 (On/DetectionOnly/Off): 전역 또는 호스트별, 임시 변경은 자동 복귀
+		engine := protected.Group("/engine")
+		{
+			engine.GET("/", engineHandler.GetModes)
+			engine.GET("/audit", engineHandler.GetAudit)
+			engine.PUT("/:scope", authHandler.AdminMiddleware(), engineHandler.SetMode)
+			engine.DELETE("/:scope", authHandler.AdminMiddleware(), engineHandler.ClearMode)
+		}
+			
+		// 경로 예외: 엔진 끄기 또는 룰 ID/태그만 끄기, 변경은 관리자 전용
+		bypasses := protected.Group("/bypasses")
+		{
+			bypasses.GET("/", bypassHandler.GetBypasses)
+			bypasses.GET("/:id", bypassHandler.GetBypass)
+			bypasses.POST("/", authHandler.AdminMiddleware(), bypassHandler.CreateBypass)
+			bypasses.PUT("/:id", authHandler.AdminMiddleware(), bypassHandler.UpdateBypass)
+			bypasses.DELETE("/:id", authHandler.AdminMiddleware(), bypassHandler.DeleteBypass)
 		}
 		
 		// Security testing
@@ -124,6 +294,50 @@ func main() {
 			security.GET("/test-types", securityTestHandler.GetTestTypes)
 			security.GET("/quick-test", securityTestHandler.GetQuickTests)
 		}
+		
+		// Alerting (rules, notification channels, fired alerts)
+		alerts := protected.Group("/alerts")
+		{
+			alerts.GET("/", alertHandler.GetAlerts)
+			alerts.POST("/rules", alertHandler.CreateRule)
+			alerts.GET("/rules", alertHandler.GetRules)
+			alerts.GET("/rules/:id", alertHandler.GetRule)
+			alerts.PUT("/rules/:id", alertHandler.UpdateRule)
+			alerts.DELETE("/rules/:id", alertHandler.DeleteRule)
+			alerts.POST("/channels", alertHandler.CreateChannel)
+			alerts.GET("/channels", alertHandler.GetChannels)
+			alerts.PUT("/channels/:id", alertHandler.UpdateChannel)
+			alerts.DELETE("/channels/:id", alertHandler.DeleteChannel)
+			alerts.POST("/channels/:id/test", alertHandler.TestChannel)
+		}
+		
+		// Temporary IP bans (auto-ban policies + managed deny list)
+		bans := protected.Group("/bans")
+		bans.Use(authHandler.AdminMiddleware())
+		{
+			bans.GET("/", banHandler.GetBans)
+			bans.POST("/", banHandler.CreateBan)
+			bans.PUT("/:id/extend", banHandler.ExtendBan)
+			bans.DELETE("/:id", banHandler.LiftBan)
+			bans.GET("/policies", banHandler.GetPolicies)
+			bans.POST("/policies", banHandler.CreatePolicy)
+			bans.PUT("/policies/:id", banHandler.UpdatePolicy)
+			bans.DELETE("/policies/:id", banHandler.DeletePolicy)
+		}
+		
+		// Admin-only endpoints
+		admin := protected.Group("/admin")
+		admin.Use(authHandler.AdminMiddleware())
+		{
+			admin.POST("/privacy/search", privacyHandler.Search)
+			admin.POST("/privacy/erasure", privacyHandler.Erase)
+			admin.GET("/privacy/erasures", privacyHandler.GetCertificates)
+			admin.GET("/privacy/erasures/:id", privacyHandler.GetCertificate)
+			admin.GET("/tenants", tenantHandler.GetTenants)
+			admin.POST("/tenants", tenantHandler.CreateTenant)
+			admin.PUT("/tenants/:id", tenantHandler.UpdateTenant)
+			admin.DELETE("/tenants/:id", tenantHandler.DeleteTenant)
+		}
 	}
 
 	// WebSocket endpoint with custom authentication
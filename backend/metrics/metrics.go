@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+	"waf-backend/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "waf"
+
+var (
+	// WAF 이벤트
+	eventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_total",
+		Help:      "WAF events ingested, by attack type, severity, rule and disposition.",
+	}, []string{"attack_type", "severity", "rule_id", "disposition"})
+
+	// 로그 수집
+	ingestedLines = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingest_events_total",
+		Help:      "New events stored per log source.",
+	}, []string{"source"})
+	ingestLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ingest_lag_seconds",
+		Help:      "Delay between the event timestamp and ingestion for the most recent event per log source.",
+	}, []string{"source"})
+	parseErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingest_parse_errors_total",
+		Help:      "ModSecurity log lines that could not be parsed, per log source.",
+	}, []string{"source"})
+	fetchErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingest_fetch_errors_total",
+		Help:      "Failures reading from a log source.",
+	}, []string{"source"})
+
+	// 룰 배포
+	ruleDeploys = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rule_deploys_total",
+		Help:      "Rule deployments by target and result.",
+	}, []string{"target", "result"})
+	ruleDeployDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rule_deploy_duration_seconds",
+		Help:      "Rule deployment duration by target.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"target"})
+
+	// WebSocket
+	websocketDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_dropped_messages_total",
+		Help:      "WebSocket messages dropped because a buffer was full.",
+	}, []string{"reason"})
+
+	// HTTP
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP handler latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// RecordEvent WAFService 로그 리스너로 등록해서 저장된 이벤트를 집계
+func RecordEvent(wafLog dto.WAFLog) {
+	disposition := "detected"
+	if wafLog.Blocked {
+		disposition = "blocked"
+	}
+	eventsTotal.WithLabelValues(labelOrUnknown(wafLog.AttackType), labelOrUnknown(wafLog.Severity),
+		labelOrUnknown(wafLog.RuleID), disposition).Inc()
+}
+
+// RecordIngest 로그 소스에서 새 이벤트가 저장될 때 호출
+func RecordIngest(source string, eventTime time.Time) {
+	ingestedLines.WithLabelValues(source).Inc()
+	if !eventTime.IsZero() {
+		lag := time.Since(eventTime).Seconds()
+		if lag < 0 {
+			lag = 0
+		}
+		ingestLag.WithLabelValues(source).Set(lag)
+	}
+}
+
+func RecordParseError(source string) {
+	parseErrors.WithLabelValues(source).Inc()
+}
+
+func RecordFetchError(source string) {
+	fetchErrors.WithLabelValues(source).Inc()
+}
+
+// RecordRuleDeploy 배포 대상(configmap, ingress 등)별 결과와 소요 시간 기록
+func RecordRuleDeploy(target string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	ruleDeploys.WithLabelValues(target, result).Inc()
+	ruleDeployDuration.WithLabelValues(target).Observe(duration.Seconds())
+}
+
+func RecordWebSocketDrop(reason string) {
+	websocketDropped.WithLabelValues(reason).Inc()
+}
+
+// RegisterWebSocketClients 현재 연결된 WebSocket 클라이언트 수를 scrape 시점에 조회
+func RegisterWebSocketClients(count func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_clients",
+		Help:      "Connected WebSocket clients.",
+	}, func() float64 {
+		return float64(count())
+	})
+}
+
+// Middleware HTTP 핸들러 지연 시간 기록 (라우트 템플릿 기준으로 집계해서 카디널리티 제한)
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler Prometheus scrape 엔드포인트
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+func labelOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"waf-backend/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		log    dto.WAFLog
+		labels []string
+	}{
+		{"blocked", dto.WAFLog{AttackType: "SQL Injection", Severity: "CRITICAL", RuleID: "942100", Blocked: true}, []string{"SQL Injection", "CRITICAL", "942100", "blocked"}},
+		{"detected", dto.WAFLog{AttackType: "XSS", Severity: "WARNING", RuleID: "941100"}, []string{"XSS", "WARNING", "941100", "detected"}},
+		{"missing labels", dto.WAFLog{}, []string{"unknown", "unknown", "unknown", "detected"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := eventsTotal.WithLabelValues(tt.labels...)
+			before := testutil.ToFloat64(counter)
+			RecordEvent(tt.log)
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("events_total%v increased by %v, want 1", tt.labels, got)
+			}
+		})
+	}
+}
+
+func TestRecordRuleDeploy(t *testing.T) {
+	tests := []struct {
+		err    error
+		result string
+	}{
+		{nil, "success"},
+		{errors.New("configmap update failed"), "failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.result, func(t *testing.T) {
+			counter := ruleDeploys.WithLabelValues("test", tt.result)
+			before := testutil.ToFloat64(counter)
+			RecordRuleDeploy("test", 100*time.Millisecond, tt.err)
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("rule_deploys_total{result=%s} increased by %v, want 1", tt.result, got)
+			}
+		})
+	}
+}
+
+func TestRecordIngest(t *testing.T) {
+	RecordIngest("test", time.Now().Add(-2*time.Second))
+	if lag := testutil.ToFloat64(ingestLag.WithLabelValues("test")); lag < 2 || lag > 10 {
+		t.Errorf("ingest_lag_seconds = %v, want about 2", lag)
+	}
+
+	// 이벤트 시각이 미래면 0으로 기록
+	RecordIngest("test", time.Now().Add(time.Minute))
+	if lag := testutil.ToFloat64(ingestLag.WithLabelValues("test")); lag != 0 {
+		t.Errorf("ingest_lag_seconds = %v, want 0", lag)
+	}
+}
+
+// 라우트 템플릿 기준으로 집계해서 경로 파라미터마다 시계열이 생기지 않음
+func TestMiddlewareUsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/rules/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/rules/a", "/rules/b", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(httpDuration)
+	families, err := registry.Gather()
+	if err != nil || len(families) != 1 {
+		t.Fatalf("Gather() = %v, %v", families, err)
+	}
+
+	got := make(map[string]uint64)
+	for _, metric := range families[0].GetMetric() {
+		labels := make(map[string]string)
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		got[labels["route"]+" "+labels["status"]] = metric.GetHistogram().GetSampleCount()
+	}
+
+	want := map[string]uint64{"/rules/:id 200": 2, "unmatched 404": 1}
+	if len(got) != len(want) {
+		t.Fatalf("http_request_duration_seconds series = %v, want %v", got, want)
+	}
+	for series, count := range want {
+		if got[series] != count {
+			t.Errorf("http_request_duration_seconds{%s} count = %d, want %d", series, got[series], count)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// AlertRule 알림 룰 (threshold 또는 match)
+type AlertRule struct {
+	ID              string    `gorm:"primaryKey" json:"id"`
+	Name            string    `gorm:"not null" json:"name"`
+	Description     string    `json:"description"`
+	Type            string    `gorm:"not null" json:"type"`
+	Filter          string    `gorm:"type:text" json:"filter"` // dto.AlertFilter (JSON)
+	Threshold       int       `json:"threshold"`
+	WindowMinutes   int       `json:"window_minutes"`
+	GroupBy         string    `json:"group_by"`
+	CooldownMinutes int       `json:"cooldown_minutes"`
+	ChannelIDs      string    `gorm:"type:text" json:"channel_ids"` // JSON
+	Enabled         bool      `gorm:"not null" json:"enabled"`
+	UserID          string    `gorm:"not null;index" json:"user_id"`
+	OwnerEmail      string    `json:"owner_email"` // 테넌트 범위 평가용
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// NotificationChannel 알림 발송 채널 (webhook, slack, smtp)
+type NotificationChannel struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Type      string    `gorm:"not null" json:"type"`
+	URL       string    `gorm:"type:text" json:"url"`
+	Headers   string    `gorm:"type:text" json:"headers"` // JSON
+	SMTP      string    `gorm:"type:text" json:"smtp"`    // dto.SMTPSettings (JSON, 비밀번호 포함)
+	Enabled   bool      `gorm:"not null" json:"enabled"`
+	UserID    string    `gorm:"not null;index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// CRSSetting 전역(scope "global") 또는 호스트별 OWASP CRS 설정. 0/빈 값은 상위 설정을 따름
+type CRSSetting struct {
+	Scope                    string    `gorm:"primaryKey" json:"scope"`
+	BlockingParanoiaLevel    int       `json:"blocking_paranoia_level"`
+	DetectionParanoiaLevel   int       `json:"detection_paranoia_level"`
+	InboundAnomalyThreshold  int       `json:"inbound_anomaly_score_threshold"`
+	OutboundAnomalyThreshold int       `json:"outbound_anomaly_score_threshold"`
+	Plugins                  string    `gorm:"type:text" json:"plugins"`           // 플러그인 이름 → 활성 여부 (JSON)
+	DisabledRuleIDs          string    `gorm:"type:text" json:"disabled_rule_ids"` // 끌 CRS 룰 ID (JSON)
+	UpdatedBy                string    `json:"updated_by"`
+	UpdatedAt                time.Time `json:"updated_at"`
+}
@@ -3,15 +3,21 @@ package models
 import "time"
 
 type CustomRule struct {
-	ID          string    `gorm:"primaryKey" json:"id"`
-	Name        string    `gorm:"not null" json:"name"`
-	Description string    `json:"description"`
-	RuleText    string    `gorm:"type:text;not null" json:"rule_text"`
-	Enabled     bool      `gorm:"default:true" json:"enabled"`
-	Severity    string    `gorm:"default:MEDIUM" json:"severity"`
-	UserID      string    `gorm:"not null;index" json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             string    `gorm:"primaryKey" json:"id"`
+	Name           string    `gorm:"not null" json:"name"`
+	Description    string    `json:"description"`
+	RuleText       string    `gorm:"type:text;not null" json:"rule_text"`
+	Enabled        bool      `gorm:"not null" json:"enabled"` // default 태그가 있으면 false가 저장되지 않음
+	Severity       string    `gorm:"default:MEDIUM" json:"severity"`
+	Source         string    `gorm:"index" json:"source"`                      // "" (사용자 작성), fp-triage 등 관리형 룰 출처
+	TemplateID     string    `gorm:"index" json:"template_id"`                 // 템플릿으로 만든 룰이면 템플릿 ID
+	TemplateParams string    `gorm:"type:text" json:"template_params"`         // 렌더링에 쓴 파라미터 (JSON)
+	RuleGroup      string    `gorm:"index" json:"rule_group"`                  // 대상을 함께 지정할 룰 묶음 이름
+	Targets        string    `gorm:"type:text" json:"targets"`                 // 적용 대상 (JSON, 비어 있으면 모든 사이트)
+	Priority       int       `gorm:"not null;default:0;index" json:"priority"` // 배포 순서 (작을수록 먼저, 같으면 생성 순서)
+	UserID         string    `gorm:"not null;index" json:"user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type User struct {
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// EngineMode 전역(scope "global") 또는 호스트의 SecRuleEngine 모드
+type EngineMode struct {
+	Scope      string     `gorm:"primaryKey" json:"scope"`
+	Mode       string     `gorm:"not null" json:"mode"` // On, DetectionOnly, Off
+	RevertMode string     `json:"revert_mode"`          // 임시 변경이면 만료 후 돌아갈 모드 (호스트 설정이 없었으면 inherit)
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at"`
+	Reason     string     `json:"reason"`
+	UpdatedBy  string     `json:"updated_by"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// EngineModeChange 엔진 모드 변경 감사 기록 (만료로 되돌린 변경은 system)
+type EngineModeChange struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Scope     string     `gorm:"not null;index" json:"scope"`
+	FromMode  string     `json:"from_mode"`
+	ToMode    string     `json:"to_mode"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Reason    string     `json:"reason"`
+	ChangedBy string     `gorm:"not null" json:"changed_by"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ErasureCertificate 정보주체 삭제 증명서 (대상은 HMAC 해시로만 보관)
+type ErasureCertificate struct {
+	ID          string    `gorm:"primaryKey" json:"id"`
+	SubjectHash string    `gorm:"not null;index" json:"subject_hash"`
+	SubjectType string    `gorm:"type:text" json:"subject_type"` // JSON
+	Mode        string    `gorm:"not null" json:"mode"`
+	Reason      string    `gorm:"type:text" json:"reason"`
+	RequestedBy string    `json:"requested_by"`
+	Erased      string    `gorm:"type:text" json:"erased"`   // JSON
+	Retained    string    `gorm:"type:text" json:"retained"` // JSON
+	IssuedAt    time.Time `gorm:"not null;index" json:"issued_at"`
+	Signature   string    `gorm:"not null" json:"signature"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// BanPolicy 자동 차단 정책 (window 내 매칭 이벤트가 threshold를 넘으면 IP 차단)
+type BanPolicy struct {
+	ID            string    `gorm:"primaryKey" json:"id"`
+	Name          string    `gorm:"not null" json:"name"`
+	Filter        string    `gorm:"type:text" json:"filter"` // dto.AlertFilter (JSON)
+	Threshold     int       `gorm:"not null" json:"threshold"`
+	WindowMinutes int       `gorm:"not null" json:"window_minutes"`
+	BanMinutes    int       `gorm:"not null" json:"ban_minutes"`
+	ExemptIPs     string    `gorm:"type:text" json:"exempt_ips"` // IP 또는 CIDR (JSON)
+	Enabled       bool      `gorm:"not null" json:"enabled"`
+	UserID        string    `json:"user_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// IPBan 배포되는 관리형 차단 항목 (만료되면 삭제)
+type IPBan struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	IP        string    `gorm:"not null;uniqueIndex" json:"ip"` // IP 또는 CIDR
+	Reason    string    `json:"reason"`
+	PolicyID  string    `gorm:"index" json:"policy_id"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PathBypass 경로(호스트/메서드로 좁힐 수 있음)에 대한 WAF 예외
+// 룰 ID나 태그만 끄거나 (RuleIDs/Tags) 엔진 전체를 끔 (DisableEngine). 삭제해도 기록은 남음 (soft delete)
+type PathBypass struct {
+	ID            string         `gorm:"primaryKey" json:"id"`
+	Name          string         `gorm:"not null" json:"name"`
+	Host          string         `json:"host"` // 비우면 모든 호스트, *.example.com 가능
+	PathPrefix    string         `gorm:"not null" json:"path_prefix"`
+	ModsecID      int            `gorm:"index" json:"modsec_id"`   // 배포되는 룰 ID (96100~96999, 예외가 있는 동안 유지)
+	Methods       string         `gorm:"type:text" json:"methods"` // JSON, 비우면 모든 메서드
+	DisableEngine bool           `json:"disable_engine"`
+	RuleIDs       string         `gorm:"type:text" json:"rule_ids"` // 끌 룰 ID (JSON)
+	Tags          string         `gorm:"type:text" json:"tags"`     // 끌 룰 태그 (JSON)
+	Reason        string         `json:"reason"`
+	Owner         string         `gorm:"not null;index" json:"owner"`
+	Enabled       bool           `gorm:"not null" json:"enabled"`
+	ExpiresAt     *time.Time     `gorm:"index" json:"expires_at"`
+	CreatedBy     string         `json:"created_by"`
+	UpdatedBy     string         `json:"updated_by"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
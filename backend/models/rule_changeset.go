@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// RuleChangeset 검토 후 한 번에 배포되는 커스텀 룰 변경 묶음
+type RuleChangeset struct {
+	ID            string       `gorm:"primaryKey" json:"id"`
+	Title         string       `gorm:"not null" json:"title"`
+	Description   string       `json:"description"`
+	Status        string       `gorm:"not null;index" json:"status"`  // draft, in_review, approved, rejected, published, discarded
+	UserID        string       `gorm:"not null;index" json:"user_id"` // 작성자
+	RuleIDStart   int          `json:"rule_id_start"`                 // 작성자의 룰 ID 범위 (자동 배정/범위 검사에 사용)
+	RuleIDEnd     int          `json:"rule_id_end"`
+	ReviewedBy    string       `json:"reviewed_by"`
+	ReviewComment string       `json:"review_comment"`
+	PublishedBy   string       `json:"published_by"`
+	SubmittedAt   *time.Time   `json:"submitted_at"`
+	ReviewedAt    *time.Time   `json:"reviewed_at"`
+	PublishedAt   *time.Time   `json:"published_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	Changes       []RuleChange `gorm:"foreignKey:ChangesetID;constraint:OnDelete:CASCADE" json:"changes"`
+}
+
+// RuleChange 변경 세트에 담긴 룰 하나의 생성/수정/삭제
+type RuleChange struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	ChangesetID    string    `gorm:"not null;index" json:"changeset_id"`
+	Action         string    `gorm:"not null" json:"action"` // create, update, delete
+	RuleID         string    `gorm:"not null;index" json:"rule_id"`
+	BaseRevision   int       `json:"base_revision"` // 작성 시점의 룰 리비전 (배포 전에 바뀌었으면 충돌)
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	RuleText       string    `gorm:"type:text" json:"rule_text"`
+	Enabled        bool      `gorm:"not null" json:"enabled"`
+	Severity       string    `json:"severity"`
+	Reason         string    `json:"reason"`
+	TemplateID     string    `json:"template_id"` // 템플릿으로 렌더링한 변경이면 템플릿 ID
+	TemplateParams string    `gorm:"type:text" json:"template_params"`
+	RuleGroup      string    `json:"rule_group"` // 적용 후 룰의 그룹과 대상
+	Targets        string    `gorm:"type:text" json:"targets"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RuleHit 수집된 WAF 이벤트 중 커스텀 룰이 매칭된 것 (보관 기간이 지나면 삭제)
+type RuleHit struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	RuleID       string    `gorm:"not null;index:idx_rule_hit_time" json:"rule_id"` // CustomRule.ID
+	ModsecRuleID int       `gorm:"not null" json:"modsec_rule_id"`                  // 이벤트의 ModSecurity 룰 ID
+	EventID      string    `gorm:"index" json:"event_id"`
+	ClientIP     string    `gorm:"index" json:"client_ip"`
+	Method       string    `json:"method"`
+	Host         string    `json:"host"`
+	URI          string    `gorm:"type:text" json:"uri"` // 마스킹된 값
+	Blocked      bool      `json:"blocked"`
+	Timestamp    time.Time `gorm:"not null;index:idx_rule_hit_time" json:"timestamp"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// CustomRuleRevision 커스텀 룰 변경 이력 (추가만 하고 수정/삭제하지 않음)
+type CustomRuleRevision struct {
+	ID             uint      `gorm:"primaryKey" json:"-"`
+	RuleID         string    `gorm:"not null;uniqueIndex:idx_rule_revision" json:"rule_id"`
+	Revision       int       `gorm:"not null;uniqueIndex:idx_rule_revision" json:"revision"`
+	Action         string    `gorm:"not null" json:"action"` // create, update, enable, disable, delete, restore
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	RuleText       string    `gorm:"type:text" json:"rule_text"`
+	Enabled        bool      `json:"enabled"`
+	Severity       string    `json:"severity"`
+	Source         string    `json:"source"`
+	TemplateID     string    `json:"template_id"`
+	TemplateParams string    `gorm:"type:text" json:"template_params"`
+	RuleGroup      string    `json:"rule_group"`
+	Targets        string    `gorm:"type:text" json:"targets"`
+	UserID         string    `gorm:"not null;index" json:"user_id"` // 룰 소유자
+	Author         string    `gorm:"not null" json:"author"`        // 변경한 사용자
+	Reason         string    `json:"reason"`
+	CreatedAt      time.Time `json:"created_at"`
+}
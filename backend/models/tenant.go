@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Tenant 보호 대상 호스트를 소유하는 조직과 멤버들의 커스텀 룰 ID 범위
+type Tenant struct {
+	ID          string    `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Hosts       string    `gorm:"type:text" json:"hosts"`   // JSON, example.com, *.example.com
+	Members     string    `gorm:"type:text" json:"members"` // JSON, 멤버 이메일 (소문자)
+	RuleIDStart int       `gorm:"not null;uniqueIndex" json:"rule_id_start"`
+	RuleIDEnd   int       `gorm:"not null" json:"rule_id_end"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
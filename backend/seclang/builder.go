@@ -0,0 +1,411 @@
+package seclang
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RuleSpec 폼 편집기용으로 구조화한 SecRule/SecAction/SecMarker
+// SpecsOf가 채운 Source는 구조 필드를 바꾸지 않는 한 Build가 그대로 쓰므로 텍스트 → 구조 → 텍스트가 원본과 같음.
+// 필드를 바꾼 룰은 정규화된 형태로 다시 쓰임 (액션 순서, 따옴표, phase 이름, @rx 생략 등은 유지되지 않음)
+type RuleSpec struct {
+	Directive       string         `json:"directive,omitempty"` // SecRule(기본), SecAction, SecMarker
+	ID              int            `json:"id,omitempty"`        // 0이면 저장할 때 자동 배정
+	Variables       []VariableSpec `json:"variables,omitempty"`
+	Operator        string         `json:"operator,omitempty"` // @ 없는 이름 (비우면 rx)
+	Argument        string         `json:"argument,omitempty"`
+	Negated         bool           `json:"negated,omitempty"`
+	Transformations []string       `json:"transformations,omitempty"` // t: 값 (적용 순서대로, none 포함)
+	Phase           int            `json:"phase,omitempty"`           // 1-5 (request=2, response=4, logging=5)
+	Action          string         `json:"action,omitempty"`          // disruptive 액션 (deny, block, pass, allow:request, redirect:URL 등)
+	Status          int            `json:"status,omitempty"`
+	Msg             string         `json:"msg,omitempty"`
+	Logdata         string         `json:"logdata,omitempty"`
+	Severity        string         `json:"severity,omitempty"`
+	Tags            []string       `json:"tags,omitempty"`
+	Actions         []ActionSpec   `json:"actions,omitempty"` // 위 필드에 없는 나머지 액션 (setvar, capture, ctl 등, 순서 유지)
+	Chain           []RuleSpec     `json:"chain,omitempty"`   // chain으로 이어지는 SecRule들 (시작 룰에만)
+	Marker          string         `json:"marker,omitempty"`  // SecMarker 이름
+	Comment         string         `json:"comment,omitempty"` // 룰 앞의 주석 줄 (여러 줄이면 \n으로 구분)
+	Source          string         `json:"source,omitempty"`  // SpecsOf가 채우는 원본 텍스트 (chain 포함)
+}
+
+// VariableSpec SecRule 대상 변수 (예: {"name": "ARGS", "key": "id"}, {"name": "REQUEST_HEADERS", "count": true})
+type VariableSpec struct {
+	Name     string `json:"name"`
+	Key      string `json:"key,omitempty"`
+	KeyRegex bool   `json:"key_regex,omitempty"`
+	Count    bool   `json:"count,omitempty"`
+	Exclude  bool   `json:"exclude,omitempty"`
+}
+
+// ActionSpec RuleSpec 필드로 표현하지 않는 액션
+type ActionSpec struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// specFields RuleSpec 필드로 표현하는 액션 (Actions에 쓰면 오류)
+var specFields = map[string]string{
+	"id":       "id",
+	"phase":    "phase",
+	"chain":    "chain",
+	"t":        "transformations",
+	"status":   "status",
+	"msg":      "msg",
+	"logdata":  "logdata",
+	"severity": "severity",
+	"tag":      "tags",
+}
+
+var phaseNumbers = map[string]int{"request": 2, "response": 4, "logging": 5}
+
+// alwaysQuoted 공백이 들어가기 쉬운 액션 값은 관례대로 작은따옴표로 씀
+var alwaysQuoted = set("msg", "logdata", "tag", "severity", "ver", "rev")
+
+// Build 구조화된 룰들을 SecLang 텍스트로 변환 (한 줄에 지시어 하나, chain은 들여쓴 다음 줄)
+// 구조로 표현할 수 없는 정의만 여기서 거부하며, 문법/정책 검증은 결과 텍스트를 Parse해서 확인
+func Build(specs []RuleSpec) (string, error) {
+	if len(specs) == 0 {
+		return "", fmt.Errorf("no rules to build")
+	}
+
+	var lines []string
+	for i := range specs {
+		lines = append(lines, commentText(specs[i].Comment)...)
+		if specs[i].Source != "" && sourceMatches(&specs[i]) {
+			lines = append(lines, specs[i].Source)
+			continue
+		}
+
+		built, err := buildRule(&specs[i], fmt.Sprintf("rules[%d]", i), false, false)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, built)
+
+		for j := range specs[i].Chain {
+			link := &specs[i].Chain[j]
+			path := fmt.Sprintf("rules[%d].chain[%d]", i, j)
+			if len(link.Chain) > 0 {
+				return "", fmt.Errorf("%s: chained rules cannot have their own chain (list every link in the starter's chain)", path)
+			}
+			built, err := buildRule(link, path, true, j < len(specs[i].Chain)-1)
+			if err != nil {
+				return "", err
+			}
+			lines = append(lines, "    "+built)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// sourceMatches Source를 다시 파싱한 구조가 spec의 구조 필드와 같은지 (같으면 원본 텍스트를 그대로 씀)
+func sourceMatches(spec *RuleSpec) bool {
+	directives, err := ParseDraft(spec.Source)
+	if err != nil || len(directives) != 1 {
+		return false
+	}
+	parsed, err := SpecsOf(directives)
+	if err != nil {
+		return false
+	}
+	return structureOf(parsed[0]) == structureOf(*spec)
+}
+
+// structureOf 주석과 원본을 뺀 구조 필드 (빈 목록과 nil을 같게 보도록 JSON으로 비교)
+func structureOf(spec RuleSpec) string {
+	spec.Comment, spec.Source = "", ""
+	encoded, _ := json.Marshal(spec)
+	return string(encoded)
+}
+
+// commentText 주석 줄 목록 (#이 없는 줄은 붙여 줌)
+func commentText(comment string) []string {
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			line = "# " + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// commentLines 지시어 사이 텍스트에서 주석 줄만 모음
+func commentLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func buildRule(spec *RuleSpec, path string, chained, chainNext bool) (string, error) {
+	name := "SecRule"
+	if spec.Directive != "" {
+		name = directiveNames[strings.ToLower(spec.Directive)]
+	}
+	switch name {
+	case "SecRule", "SecAction", "SecMarker":
+	default:
+		return "", fmt.Errorf("%s: directive %q cannot be built (use SecRule, SecAction or SecMarker)", path, spec.Directive)
+	}
+	if chained && name != "SecRule" {
+		return "", fmt.Errorf("%s: only SecRule can be chained", path)
+	}
+
+	if name == "SecMarker" {
+		if spec.Marker == "" {
+			return "", fmt.Errorf("%s: marker is required for SecMarker", path)
+		}
+		return "SecMarker " + bareArg(spec.Marker), nil
+	}
+
+	hasMatch := len(spec.Variables) > 0 || spec.Operator != "" || spec.Argument != "" || spec.Negated
+	if name == "SecAction" && (hasMatch || len(spec.Chain) > 0) {
+		return "", fmt.Errorf("%s: SecAction takes no variables, operator or chain", path)
+	}
+	if chained && (spec.ID != 0 || spec.Phase != 0 || spec.Action != "") {
+		return "", fmt.Errorf("%s: id, phase and action can only be set on the chain starter", path)
+	}
+
+	actions, err := buildActions(spec, path, chainNext || (!chained && len(spec.Chain) > 0))
+	if err != nil {
+		return "", err
+	}
+
+	if name == "SecAction" {
+		return "SecAction " + quoteArg(strings.Join(actions, ",")), nil
+	}
+
+	if len(spec.Variables) == 0 {
+		return "", fmt.Errorf("%s: at least one variable is required", path)
+	}
+	variables := make([]string, 0, len(spec.Variables))
+	for i, variable := range spec.Variables {
+		text, err := variable.text()
+		if err != nil {
+			return "", fmt.Errorf("%s.variables[%d]: %v", path, i, err)
+		}
+		variables = append(variables, text)
+	}
+
+	operator := spec.Operator
+	if operator == "" {
+		operator = "rx"
+	}
+	operator = "@" + strings.TrimPrefix(operator, "@")
+	if spec.Argument != "" {
+		operator += " " + spec.Argument
+	}
+	if spec.Negated {
+		operator = "!" + operator
+	}
+
+	rule := "SecRule " + bareArg(strings.Join(variables, "|")) + " " + quoteArg(operator)
+	if len(actions) > 0 {
+		rule += " " + quoteArg(strings.Join(actions, ","))
+	}
+	return rule, nil
+}
+
+// buildActions id, phase, disruptive, status, chain, t:, msg, logdata, severity, tag, 나머지 순서
+func buildActions(spec *RuleSpec, path string, chain bool) ([]string, error) {
+	var actions []string
+	if spec.ID != 0 {
+		actions = append(actions, "id:"+strconv.Itoa(spec.ID))
+	}
+	if spec.Phase != 0 {
+		actions = append(actions, "phase:"+strconv.Itoa(spec.Phase))
+	}
+	if spec.Action != "" {
+		actionName, value, hasValue := strings.Cut(spec.Action, ":")
+		if actionSpecs[strings.ToLower(actionName)].kind != kindDisruptive {
+			return nil, fmt.Errorf("%s: action %q is not a disruptive action", path, actionName)
+		}
+		if hasValue {
+			actions = append(actions, formatAction(actionName, value))
+		} else {
+			actions = append(actions, actionName)
+		}
+	}
+	if spec.Status != 0 {
+		actions = append(actions, "status:"+strconv.Itoa(spec.Status))
+	}
+	if chain {
+		actions = append(actions, "chain")
+	}
+	for _, transformation := range spec.Transformations {
+		actions = append(actions, "t:"+transformation)
+	}
+	if spec.Msg != "" {
+		actions = append(actions, formatAction("msg", spec.Msg))
+	}
+	if spec.Logdata != "" {
+		actions = append(actions, formatAction("logdata", spec.Logdata))
+	}
+	if spec.Severity != "" {
+		actions = append(actions, formatAction("severity", spec.Severity))
+	}
+	for _, tag := range spec.Tags {
+		actions = append(actions, formatAction("tag", tag))
+	}
+
+	for i, action := range spec.Actions {
+		lower := strings.ToLower(action.Name)
+		if field, ok := specFields[lower]; ok {
+			return nil, fmt.Errorf("%s.actions[%d]: use the %s field instead of %s", path, i, field, action.Name)
+		}
+		if actionSpecs[lower].kind == kindDisruptive {
+			return nil, fmt.Errorf("%s.actions[%d]: use the action field for disruptive action %s", path, i, action.Name)
+		}
+		if action.Value == "" {
+			actions = append(actions, action.Name)
+		} else {
+			actions = append(actions, formatAction(action.Name, action.Value))
+		}
+	}
+	return actions, nil
+}
+
+func (v VariableSpec) text() (string, error) {
+	if v.Name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	var b strings.Builder
+	if v.Exclude {
+		b.WriteByte('!')
+	}
+	if v.Count {
+		b.WriteByte('&')
+	}
+	b.WriteString(strings.ToUpper(v.Name))
+
+	switch {
+	case v.Key == "":
+		if v.KeyRegex {
+			return "", fmt.Errorf("key_regex requires a key")
+		}
+	case strings.Contains(v.Key, "|"):
+		// 변수 목록은 |로 나뉘므로 키에 넣을 수 없음
+		return "", fmt.Errorf("key %q cannot contain |", v.Key)
+	case strings.EqualFold(v.Name, "XML"):
+		if v.KeyRegex {
+			return "", fmt.Errorf("XML keys are XPath expressions, not regular expressions")
+		}
+		b.WriteString(":" + v.Key)
+	case v.KeyRegex:
+		b.WriteString(":/" + v.Key + "/")
+	case strings.HasPrefix(v.Key, "/") || strings.HasPrefix(v.Key, "'"):
+		b.WriteString(":'" + v.Key + "'")
+	default:
+		b.WriteString(":" + v.Key)
+	}
+	return b.String(), nil
+}
+
+// formatAction 값에 쉼표, 따옴표, 앞뒤 공백이 있거나 관례상 따옴표를 쓰는 액션이면 작은따옴표로 감쌈
+func formatAction(name, value string) string {
+	if alwaysQuoted[strings.ToLower(name)] || strings.ContainsAny(value, ",'") || strings.TrimSpace(value) != value {
+		return name + ":'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+	}
+	return name + ":" + value
+}
+
+// quoteArg 지시어 인자를 큰따옴표로 감쌈 (안의 큰따옴표는 \")
+func quoteArg(arg string) string {
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
+
+// bareArg 공백이나 따옴표가 없으면 따옴표 없이 씀 (변수 목록, 마커 이름)
+func bareArg(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\"\\") {
+		return quoteArg(arg)
+	}
+	return arg
+}
+
+// SpecsOf 파싱된 지시어들을 구조화된 룰로 변환 (SecRule, SecAction, SecMarker만 가능)
+// 같은 필드 액션이 여러 번 나오면 ModSecurity처럼 마지막 값을 씀 (tag, t:는 모두 유지)
+// 원본 텍스트와 앞의 주석은 Source, Comment에 보관 (마지막 룰 뒤의 주석은 유지되지 않음)
+func SpecsOf(directives []*Directive) ([]RuleSpec, error) {
+	specs := make([]RuleSpec, 0, len(directives))
+	for _, directive := range directives {
+		switch directive.Name {
+		case "SecMarker":
+			specs = append(specs, RuleSpec{Directive: directive.Name, Marker: directive.Args[0], Comment: directive.comment, Source: directive.source})
+		case "SecRule", "SecAction":
+			spec := specOf(directive)
+			for _, link := range directive.Chain {
+				spec.Chain = append(spec.Chain, specOf(link))
+			}
+			spec.Comment, spec.Source = directive.comment, directive.source
+			specs = append(specs, spec)
+		default:
+			return nil, ErrorList{{Position: directive.Position, Message: fmt.Sprintf("%s cannot be represented as a structured rule", directive.Name)}}
+		}
+	}
+	return specs, nil
+}
+
+func specOf(directive *Directive) RuleSpec {
+	spec := RuleSpec{Directive: directive.Name}
+	for _, variable := range directive.Variables {
+		spec.Variables = append(spec.Variables, VariableSpec{
+			Name:     variable.Name,
+			Key:      variable.Key,
+			KeyRegex: variable.KeyRegex,
+			Count:    variable.Count,
+			Exclude:  variable.Exclude,
+		})
+	}
+	if operator := directive.Operator; operator != nil {
+		spec.Operator = operator.Name
+		spec.Argument = operator.Argument
+		spec.Negated = operator.Negated
+	}
+
+	for _, action := range directive.Actions {
+		switch strings.ToLower(action.Name) {
+		case "id":
+			spec.ID, _ = strconv.Atoi(action.Value)
+		case "phase":
+			if phase, ok := phaseNumbers[strings.ToLower(action.Value)]; ok {
+				spec.Phase = phase
+			} else {
+				spec.Phase, _ = strconv.Atoi(action.Value)
+			}
+		case "chain":
+		case "t":
+			spec.Transformations = append(spec.Transformations, action.Value)
+		case "status":
+			spec.Status, _ = strconv.Atoi(action.Value)
+		case "msg":
+			spec.Msg = action.Value
+		case "logdata":
+			spec.Logdata = action.Value
+		case "severity":
+			spec.Severity = action.Value
+		case "tag":
+			spec.Tags = append(spec.Tags, action.Value)
+		default:
+			if actionSpecs[strings.ToLower(action.Name)].kind != kindDisruptive {
+				spec.Actions = append(spec.Actions, ActionSpec{Name: action.Name, Value: action.Value})
+			} else if action.Value != "" {
+				spec.Action = action.Name + ":" + action.Value
+			} else {
+				spec.Action = action.Name
+			}
+		}
+	}
+	return spec
+}
@@ -0,0 +1,133 @@
+package seclang
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildRoundTripPreservesSource(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"action order", `SecRule ARGS "@rx a" "id:1001,phase:2,log,t:none,tag:'a',block"`},
+		{"quoted setvar", `SecRule ARGS "@rx a" "id:1002,phase:2,pass,setvar:'tx.score=+5'"`},
+		{"bare operator", `SecRule ARGS "test" "id:1003,phase:2,deny"`},
+		{"phase name", `SecRule ARGS "@rx a" "id:1004,phase:request,deny"`},
+		{"comments", "# block admin probes\n# owner: ops\nSecRule REQUEST_URI \"@beginsWith /admin\" \"id:1005,phase:1,deny\""},
+		{"chain with continuation", "SecRule REQUEST_URI \"@beginsWith /admin\" \\\n    \"id:1006,phase:1,deny,chain\"\n  SecRule REMOTE_ADDR \"!@ipMatch 10.0.0.0/8\""},
+		{"several directives", "SecMarker BEGIN\n\n# allow health checks\nSecAction \"id:1007,phase:1,pass,nolog,ctl:ruleEngine=Off\"\nSecRule XML:/* \"@rx a\" \"id:1008,deny\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directives, err := ParseDraft(tt.text)
+			if err != nil {
+				t.Fatalf("ParseDraft() error = %v", err)
+			}
+			specs, err := SpecsOf(directives)
+			if err != nil {
+				t.Fatalf("SpecsOf() error = %v", err)
+			}
+			built, err := Build(specs)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if built != stripBlankLines(tt.text) {
+				t.Errorf("Build() =\n%s\nwant\n%s", built, stripBlankLines(tt.text))
+			}
+		})
+	}
+}
+
+func TestBuildEditedSpecIsNormalized(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		edit func(*RuleSpec)
+		want string
+	}{
+		{
+			name: "changed status",
+			text: "# keep me\nSecRule ARGS \"test\" \"id:1001,phase:request,log,deny,setvar:'tx.score=+5'\"",
+			edit: func(spec *RuleSpec) { spec.Status = 406 },
+			want: "# keep me\nSecRule ARGS \"@rx test\" \"id:1001,phase:2,deny,status:406,log,setvar:tx.score=+5\"",
+		},
+		{
+			name: "added chain link",
+			text: `SecRule REQUEST_URI "@beginsWith /admin" "id:1002,phase:1,deny"`,
+			edit: func(spec *RuleSpec) {
+				spec.Chain = append(spec.Chain, RuleSpec{Directive: "SecRule", Variables: []VariableSpec{{Name: "REMOTE_ADDR"}}, Operator: "ipMatch", Argument: "10.0.0.0/8", Negated: true})
+			},
+			want: "SecRule REQUEST_URI \"@beginsWith /admin\" \"id:1002,phase:1,deny,chain\"\n    SecRule REMOTE_ADDR \"!@ipMatch 10.0.0.0/8\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directives, err := ParseDraft(tt.text)
+			if err != nil {
+				t.Fatalf("ParseDraft() error = %v", err)
+			}
+			specs, err := SpecsOf(directives)
+			if err != nil {
+				t.Fatalf("SpecsOf() error = %v", err)
+			}
+			tt.edit(&specs[0])
+			built, err := Build(specs)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if built != tt.want {
+				t.Fatalf("Build() =\n%s\nwant\n%s", built, tt.want)
+			}
+
+			// 정규화된 텍스트는 다시 파싱해도 같은 구조
+			reparsed, err := ParseDraft(built)
+			if err != nil {
+				t.Fatalf("ParseDraft(built) error = %v", err)
+			}
+			again, err := SpecsOf(reparsed)
+			if err != nil {
+				t.Fatalf("SpecsOf(built) error = %v", err)
+			}
+			if structureOf(again[0]) != structureOf(specs[0]) {
+				t.Errorf("structure changed after rebuild:\n%s\nwant\n%s", structureOf(again[0]), structureOf(specs[0]))
+			}
+		})
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec RuleSpec
+		want string
+	}{
+		{"no variables", RuleSpec{Operator: "rx", Argument: "a"}, "rules[0]: at least one variable is required"},
+		{"non-disruptive action field", RuleSpec{Variables: []VariableSpec{{Name: "ARGS"}}, Action: "log"}, `rules[0]: action "log" is not a disruptive action`},
+		{"field in actions", RuleSpec{Variables: []VariableSpec{{Name: "ARGS"}}, Actions: []ActionSpec{{Name: "id", Value: "1"}}}, "rules[0].actions[0]: use the id field instead of id"},
+		{"xml regex key", RuleSpec{Variables: []VariableSpec{{Name: "XML", Key: "/*", KeyRegex: true}}}, "rules[0].variables[0]: XML keys are XPath expressions, not regular expressions"},
+		{"marker without name", RuleSpec{Directive: "SecMarker"}, "rules[0]: marker is required for SecMarker"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build([]RuleSpec{tt.spec})
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Build() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// stripBlankLines Build는 지시어 사이의 빈 줄을 유지하지 않음
+func stripBlankLines(text string) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
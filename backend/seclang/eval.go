@@ -0,0 +1,762 @@
+package seclang
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Request 평가에 사용할 샘플 HTTP 요청
+type Request struct {
+	Method     string            `json:"method"`
+	URI        string            `json:"uri"`      // 경로와 쿼리 문자열 (예: /search?q=1)
+	Protocol   string            `json:"protocol"` // 기본 HTTP/1.1
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	RemoteAddr string            `json:"remote_addr"`
+}
+
+// Result 샘플 요청 하나를 룰 전체에 통과시킨 결과
+type Result struct {
+	Matched      bool              `json:"matched"` // 하나 이상의 룰이 일치
+	Interruption *Interruption     `json:"interruption,omitempty"`
+	Rules        []RuleResult      `json:"rules"`           // 평가된 룰 (평가 순서)
+	TX           map[string]string `json:"tx,omitempty"`    // 평가가 끝난 뒤의 TX 변수 (setvar, capture)
+	Notes        []string          `json:"notes,omitempty"` // 평가기가 흉내내지 못한 부분
+}
+
+// Interruption 요청을 중단시킨 disruptive 액션
+// id가 없는 룰(ParseDraft)이면 RuleID는 0이고 Position으로 룰을 구분
+type Interruption struct {
+	RuleID   int      `json:"rule_id,omitempty"`
+	Position Position `json:"position"`
+	Action   string   `json:"action"`
+	Status   int      `json:"status"`
+	URL      string   `json:"url,omitempty"`
+}
+
+// RuleResult 시작 룰 하나 (chain 포함)의 평가 결과
+type RuleResult struct {
+	ID          int          `json:"id"`
+	Phase       int          `json:"phase"`
+	Position    Position     `json:"position"`
+	Matched     bool         `json:"matched"`
+	MatchedVars []MatchedVar `json:"matched_vars,omitempty"`
+	Actions     []Action     `json:"actions,omitempty"` // 일치했을 때 실행된 액션
+	Message     string       `json:"message,omitempty"` // msg (매크로 확장 후)
+	LogData     string       `json:"logdata,omitempty"`
+}
+
+// MatchedVar 연산자와 일치한 변수
+type MatchedVar struct {
+	Chain       int    `json:"chain"` // 0은 시작 룰, 1부터 chain으로 연결된 룰
+	Name        string `json:"name"`  // 예: ARGS:q
+	Value       string `json:"value"`
+	Transformed string `json:"transformed"` // 변환(t:)을 거쳐 연산자에 전달된 값
+}
+
+// Evaluate 파싱된 지시어를 샘플 요청에 대해 phase 순서대로 평가 (외부 상태를 바꾸지 않음)
+// 요청 본문은 phase 2부터 보이며 urlencoded와 JSON만 ARGS로 파싱. 응답 변수와 영속 컬렉션(IP, SESSION 등)은 비어 있음
+func Evaluate(directives []*Directive, req *Request) *Result {
+	t := newTransaction(req)
+	result := &Result{Rules: make([]RuleResult, 0)}
+
+	for _, directive := range directives {
+		switch directive.Name {
+		case "SecRule", "SecAction", "SecMarker":
+		case "SecRuleRemoveById":
+			for _, arg := range directive.Args {
+				t.removeRuleIDs(arg)
+			}
+		default:
+			t.note("directive %s is not evaluated", directive.Name)
+		}
+	}
+
+	for phase := 1; phase <= 5 && !t.done(); phase++ {
+		if phase == 2 {
+			t.processBody()
+		}
+		// allow:request는 남은 요청 phase만 건너뜀
+		if t.allow == "request" {
+			if phase <= 2 {
+				continue
+			}
+			t.allow = ""
+		}
+		t.runPhase(directives, phase, result)
+	}
+
+	result.Interruption = t.interruption
+	if len(t.tx) > 0 {
+		result.TX = t.tx
+	}
+	result.Notes = t.notes
+	return result
+}
+
+type field struct {
+	key, value string
+}
+
+type target struct {
+	name, value string
+}
+
+// transaction 평가 중인 요청의 변수와 상태
+type transaction struct {
+	collections  map[string][]field
+	scalars      map[string]string
+	tx           map[string]string
+	body         string
+	removed      [][2]int
+	engine       string // On, DetectionOnly, Off
+	allow        string // allow 액션으로 건너뛰는 범위 (all, phase, request)
+	interruption *Interruption
+	current      *Directive
+	notes        []string
+	noted        map[string]bool
+}
+
+func newTransaction(req *Request) *transaction {
+	t := &transaction{
+		collections: make(map[string][]field),
+		scalars:     make(map[string]string),
+		tx:          make(map[string]string),
+		engine:      "On",
+		noted:       make(map[string]bool),
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	protocol := req.Protocol
+	if protocol == "" {
+		protocol = "HTTP/1.1"
+	}
+
+	// 절대 URL이면 경로와 쿼리만 사용
+	uri := req.URI
+	if parsed, err := url.Parse(uri); err == nil && parsed.Host != "" {
+		uri = parsed.RequestURI()
+	}
+	filename, query, _ := strings.Cut(uri, "?")
+
+	var headers []field
+	for name, value := range req.Headers {
+		headers = append(headers, field{name, value})
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i].key < headers[j].key })
+	t.collections["REQUEST_HEADERS"] = headers
+	t.collections["REQUEST_COOKIES"] = parseCookies(t.header("Cookie"))
+
+	t.collections["ARGS_GET"] = parseForm(query)
+	t.collections["ARGS_POST"] = nil
+	t.collections["ARGS"] = t.collections["ARGS_GET"]
+	for _, name := range []string{"FILES", "FILES_SIZES", "FILES_TMPNAMES", "MULTIPART_PART_HEADERS", "GEO", "IP", "GLOBAL", "SESSION", "USER", "RESOURCE", "ENV"} {
+		t.collections[name] = nil
+	}
+
+	host := t.header("Host")
+	if h, _, found := strings.Cut(host, ":"); found {
+		host = h
+	}
+
+	for name, value := range map[string]string{
+		"REQUEST_METHOD":      method,
+		"REQUEST_PROTOCOL":    protocol,
+		"REQUEST_URI":         uri,
+		"REQUEST_URI_RAW":     req.URI,
+		"REQUEST_FILENAME":    filename,
+		"REQUEST_BASENAME":    path.Base(filename),
+		"QUERY_STRING":        query,
+		"REQUEST_LINE":        method + " " + uri + " " + protocol,
+		"REQUEST_BODY":        "",
+		"REQUEST_BODY_LENGTH": "0",
+		"REMOTE_ADDR":         req.RemoteAddr,
+		"SERVER_NAME":         host,
+		"UNIQUE_ID":           "sandbox",
+		"MATCHED_VAR":         "",
+		"MATCHED_VAR_NAME":    "",
+		"DURATION":            "0",
+	} {
+		t.scalars[name] = value
+	}
+	for name, value := range map[string]string{"REQBODY_ERROR": "0", "REQBODY_ERROR_MSG": "", "REQBODY_PROCESSOR": ""} {
+		t.scalars[name] = value
+	}
+	t.body = req.Body
+	t.updateCombinedSize()
+	return t
+}
+
+// processBody ModSecurity처럼 본문 변수는 phase 2부터 사용할 수 있음
+func (t *transaction) processBody() {
+	t.scalars["REQUEST_BODY"] = t.body
+	t.scalars["REQUEST_BODY_LENGTH"] = strconv.Itoa(len(t.body))
+	t.collections["ARGS_POST"] = t.parseBody(t.body)
+	t.collections["ARGS"] = append(append([]field{}, t.collections["ARGS_GET"]...), t.collections["ARGS_POST"]...)
+	t.updateCombinedSize()
+}
+
+func (t *transaction) updateCombinedSize() {
+	combined := 0
+	for _, arg := range t.collections["ARGS"] {
+		combined += len(arg.key) + len(arg.value)
+	}
+	t.scalars["ARGS_COMBINED_SIZE"] = strconv.Itoa(combined)
+}
+
+// parseBody Content-Type에 따라 본문을 ARGS_POST로 파싱
+func (t *transaction) parseBody(body string) []field {
+	contentType := strings.ToLower(t.header("Content-Type"))
+	switch {
+	case body == "":
+		return nil
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		t.scalars["REQBODY_PROCESSOR"] = "URLENCODED"
+		return parseForm(body)
+	case strings.HasPrefix(contentType, "application/json"):
+		t.scalars["REQBODY_PROCESSOR"] = "JSON"
+		var value interface{}
+		if err := json.Unmarshal([]byte(body), &value); err != nil {
+			t.scalars["REQBODY_ERROR"] = "1"
+			t.scalars["REQBODY_ERROR_MSG"] = err.Error()
+			return nil
+		}
+		var fields []field
+		flattenJSON("json", value, &fields)
+		return fields
+	case strings.HasPrefix(contentType, "multipart/"):
+		t.note("multipart request bodies are not parsed into ARGS/FILES")
+	}
+	return nil
+}
+
+func (t *transaction) header(name string) string {
+	for _, header := range t.collections["REQUEST_HEADERS"] {
+		if strings.EqualFold(header.key, name) {
+			return header.value
+		}
+	}
+	return ""
+}
+
+func (t *transaction) note(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if !t.noted[message] {
+		t.noted[message] = true
+		t.notes = append(t.notes, message)
+	}
+}
+
+// done 더 이상 평가할 룰이 없음 (차단, ruleEngine=Off, allow)
+func (t *transaction) done() bool {
+	return t.interruption != nil || t.engine == "Off" || t.allow == "all"
+}
+
+func (t *transaction) runPhase(directives []*Directive, phase int, result *Result) {
+	skipAfter := ""
+	skip := 0
+	for _, directive := range directives {
+		if directive.Name == "SecMarker" {
+			if skipAfter != "" && len(directive.Args) > 0 && directive.Args[0] == skipAfter {
+				skipAfter = ""
+			}
+			continue
+		}
+		if (directive.Name != "SecRule" && directive.Name != "SecAction") || phaseOf(directive) != phase {
+			continue
+		}
+		if skipAfter != "" || t.isRemoved(directive.ID()) {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		rule := t.evalRule(directive, phase)
+		result.Rules = append(result.Rules, rule)
+		if !rule.Matched {
+			continue
+		}
+		result.Matched = true
+
+		if action := directive.Action("skip"); action != nil {
+			skip, _ = strconv.Atoi(action.Value)
+		}
+		if action := directive.Action("skipAfter"); action != nil {
+			skipAfter = action.Value
+		}
+		t.disrupt(directive, phase)
+
+		if t.done() || t.allow != "" {
+			if t.allow == "phase" {
+				t.allow = ""
+			}
+			return
+		}
+	}
+	if skipAfter != "" {
+		t.note("skipAfter marker %q was not found", skipAfter)
+	}
+}
+
+// evalRule 시작 룰과 chain을 차례로 평가. chain의 모든 룰이 일치해야 일치
+func (t *transaction) evalRule(directive *Directive, phase int) RuleResult {
+	t.current = directive
+	result := RuleResult{ID: directive.ID(), Phase: phase, Position: directive.Position}
+	if result.ID == 0 {
+		t.note("%s has no id: ModSecurity will refuse to load it until an id is assigned", ruleLabel(directive))
+	}
+
+	for i, link := range directive.Rules() {
+		matched, ok := t.matchLink(link, i)
+		if !ok {
+			return result
+		}
+		result.MatchedVars = append(result.MatchedVars, matched...)
+		t.runActions(link)
+		result.Actions = append(result.Actions, link.Actions...)
+	}
+
+	result.Matched = true
+	if msg := directive.Action("msg"); msg != nil {
+		result.Message = t.expand(msg.Value)
+	}
+	if logdata := directive.Action("logdata"); logdata != nil {
+		result.LogData = t.expand(logdata.Value)
+	}
+	return result
+}
+
+// matchLink 룰 하나의 변수에 변환과 연산자를 적용. 일치한 변수가 하나라도 있으면 ok
+func (t *transaction) matchLink(link *Directive, chain int) ([]MatchedVar, bool) {
+	if link.Name == "SecAction" || link.Operator == nil {
+		return nil, true
+	}
+
+	operator, supported := operators[strings.ToLower(link.Operator.Name)]
+	if !supported {
+		t.note("operator @%s is not supported by the evaluator and never matches", link.Operator.Name)
+		return nil, false
+	}
+	switch strings.ToLower(link.Operator.Name) {
+	case "detectsqli", "detectxss":
+		t.note("@%s uses a simplified pattern instead of libinjection", link.Operator.Name)
+	}
+
+	transforms := t.transformationsOf(link)
+	argument := t.expand(link.Operator.Argument)
+	if strings.EqualFold(link.Operator.Name, "rx") {
+		// 대상 변수가 없어도 지원하지 않는 정규식은 알려줌
+		if _, err := compileRegex(argument); err != nil {
+			t.note("rule at line %d: %v", link.Position.Line, err)
+			return nil, false
+		}
+	}
+	capture := link.Action("capture") != nil
+
+	var matched []MatchedVar
+	for _, target := range t.targets(link.Variables) {
+		value := target.value
+		for _, transform := range transforms {
+			value = transform(value)
+		}
+
+		ok, captures, err := operator(argument, value)
+		if err != nil {
+			t.note("rule at line %d: %v", link.Position.Line, err)
+			continue
+		}
+		if link.Operator.Negated {
+			ok, captures = !ok, nil
+		}
+		if !ok {
+			continue
+		}
+
+		matched = append(matched, MatchedVar{Chain: chain, Name: target.name, Value: target.value, Transformed: value})
+		t.scalars["MATCHED_VAR"] = target.value
+		t.scalars["MATCHED_VAR_NAME"] = target.name
+		if capture {
+			for i := 0; i < 10 && i < len(captures); i++ {
+				t.tx[strconv.Itoa(i)] = captures[i]
+			}
+		}
+	}
+	return matched, len(matched) > 0
+}
+
+func (t *transaction) transformationsOf(link *Directive) []func(string) string {
+	var transforms []func(string) string
+	for _, action := range link.Actions {
+		if action.Name != "t" {
+			continue
+		}
+		name := strings.ToLower(action.Value)
+		if name == "none" {
+			transforms = nil
+			continue
+		}
+		if transform, ok := transformations[name]; ok {
+			transforms = append(transforms, transform)
+		} else {
+			t.note("transformation t:%s is not supported by the evaluator and is ignored", action.Value)
+		}
+	}
+	return transforms
+}
+
+// targets 변수 목록이 가리키는 값들. !로 제외한 항목을 빼고, &는 개수로 바꿈
+func (t *transaction) targets(variables []Variable) []target {
+	var excluded []Variable
+	for _, v := range variables {
+		if v.Exclude {
+			excluded = append(excluded, v)
+		}
+	}
+
+	var result []target
+	for _, v := range variables {
+		if v.Exclude {
+			continue
+		}
+		values, ok := t.lookup(v.Name, v)
+		if !ok {
+			t.note("variable %s is not available in the evaluator", v.Name)
+			continue
+		}
+
+		var kept []target
+		for _, value := range values {
+			if !isExcluded(excluded, v.Name, value.key) {
+				kept = append(kept, target{name: displayName(v.Name, value.key), value: value.value})
+			}
+		}
+		if v.Count {
+			name := "&" + v.Name
+			if v.Key != "" {
+				name += ":" + v.Key
+			}
+			kept = []target{{name: name, value: strconv.Itoa(len(kept))}}
+		}
+		result = append(result, kept...)
+	}
+	return result
+}
+
+// lookup 이름이 name인 변수에서 v의 키 조건에 맞는 값 (스칼라 변수는 키가 빈 값 하나)
+func (t *transaction) lookup(name string, v Variable) ([]field, bool) {
+	var fields []field
+	switch {
+	case name == "TX":
+		for key, value := range t.tx {
+			fields = append(fields, field{key, value})
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+	case t.hasCollection(name):
+		fields = t.collections[name]
+	case strings.HasSuffix(name, "_NAMES") && t.hasCollection(strings.TrimSuffix(name, "_NAMES")):
+		for _, f := range t.collections[strings.TrimSuffix(name, "_NAMES")] {
+			fields = append(fields, field{f.key, f.key})
+		}
+	default:
+		value, ok := t.scalars[name]
+		if !ok {
+			return nil, false
+		}
+		return []field{{"", value}}, true
+	}
+
+	if v.Key == "" {
+		return fields, true
+	}
+	var matched []field
+	for _, f := range fields {
+		if keyMatches(v, f.key) {
+			matched = append(matched, f)
+		}
+	}
+	return matched, true
+}
+
+func (t *transaction) hasCollection(name string) bool {
+	_, ok := t.collections[name]
+	return ok
+}
+
+func keyMatches(v Variable, key string) bool {
+	if !v.KeyRegex {
+		return strings.EqualFold(v.Key, key)
+	}
+	re, err := compileRegex("(?i)" + v.Key)
+	return err == nil && re.MatchString(key)
+}
+
+func isExcluded(excluded []Variable, name, key string) bool {
+	for _, v := range excluded {
+		if v.Name == name && keyMatches(v, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func displayName(name, key string) string {
+	if key == "" {
+		return name
+	}
+	return name + ":" + key
+}
+
+// runActions 일치한 룰의 non-disruptive 액션 중 상태를 바꾸는 것 (setvar, ctl) 실행
+func (t *transaction) runActions(link *Directive) {
+	for _, action := range link.Actions {
+		switch strings.ToLower(action.Name) {
+		case "setvar":
+			t.setvar(action.Value)
+		case "ctl":
+			t.ctl(action.Value)
+		case "setenv", "setuid", "setsid", "setrsc", "initcol", "expirevar", "deprecatevar", "exec", "append", "prepend":
+			t.note("action %s is not simulated", action.Name)
+		}
+	}
+}
+
+// setvar tx.name=value, tx.name=+n, tx.name=-n, !tx.name (TX만 지원)
+func (t *transaction) setvar(expr string) {
+	remove := strings.HasPrefix(expr, "!")
+	expr = strings.TrimPrefix(expr, "!")
+	name, value, hasValue := strings.Cut(expr, "=")
+	collection, key, _ := strings.Cut(t.expand(name), ".")
+	if !strings.EqualFold(collection, "tx") {
+		t.note("setvar on %s collection is not simulated", strings.ToUpper(collection))
+		return
+	}
+	key = strings.ToLower(key)
+
+	switch {
+	case remove:
+		delete(t.tx, key)
+	case !hasValue:
+		t.tx[key] = "1"
+	case strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-"):
+		current, _ := strconv.Atoi(t.tx[key])
+		delta, _ := strconv.Atoi(t.expand(value[1:]))
+		if value[0] == '-' {
+			delta = -delta
+		}
+		t.tx[key] = strconv.Itoa(current + delta)
+	default:
+		t.tx[key] = t.expand(value)
+	}
+}
+
+// ctl ruleEngine과 ruleRemoveById만 평가에 반영
+func (t *transaction) ctl(expr string) {
+	option, value, _ := strings.Cut(expr, "=")
+	switch strings.ToLower(option) {
+	case "ruleengine":
+		switch strings.ToLower(value) {
+		case "on":
+			t.engine = "On"
+		case "off":
+			t.engine = "Off"
+		case "detectiononly":
+			t.engine = "DetectionOnly"
+		}
+	case "ruleremovebyid":
+		t.removeRuleIDs(value)
+	default:
+		t.note("ctl:%s is not simulated", option)
+	}
+}
+
+func (t *transaction) removeRuleIDs(value string) {
+	for _, part := range strings.Fields(strings.ReplaceAll(value, ",", " ")) {
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(from)
+		end := start
+		if err == nil && isRange {
+			end, err = strconv.Atoi(to)
+		}
+		if err == nil {
+			t.removed = append(t.removed, [2]int{start, end})
+		}
+	}
+}
+
+func (t *transaction) isRemoved(id int) bool {
+	for _, r := range t.removed {
+		if id >= r[0] && id <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// disrupt 일치한 시작 룰의 disruptive 액션 적용 (DetectionOnly면 노트만 남김)
+func (t *transaction) disrupt(directive *Directive, phase int) {
+	for _, action := range directive.Actions {
+		spec, ok := actionSpecs[strings.ToLower(action.Name)]
+		if !ok || spec.kind != kindDisruptive {
+			continue
+		}
+
+		switch action.Name {
+		case "pass":
+			return
+		case "allow":
+			switch strings.ToLower(action.Value) {
+			case "phase":
+				t.allow = "phase"
+			case "request":
+				t.allow = "request"
+			default:
+				t.allow = "all"
+			}
+			return
+		case "block":
+			t.note("block uses SecDefaultAction, evaluated as deny")
+		case "pause", "proxy":
+			t.note("action %s is evaluated as deny", action.Name)
+		}
+		if phase == 5 {
+			t.note("disruptive actions in phase 5 (logging) do not interrupt the request")
+			return
+		}
+
+		interruption := &Interruption{RuleID: directive.ID(), Position: directive.Position, Action: action.Name, Status: 403}
+		if action.Name == "redirect" {
+			interruption.Status = 302
+			interruption.URL = t.expand(action.Value)
+		}
+		if status := directive.Action("status"); status != nil {
+			interruption.Status, _ = strconv.Atoi(status.Value)
+		}
+
+		if t.engine == "DetectionOnly" {
+			t.note("%s would have interrupted the request with %s, but the rule engine is DetectionOnly", ruleLabel(directive), action.Name)
+			return
+		}
+		t.interruption = interruption
+		return
+	}
+}
+
+// ruleLabel 알림에 쓸 룰 이름 (id가 없으면 위치)
+func ruleLabel(directive *Directive) string {
+	if id := directive.ID(); id > 0 {
+		return fmt.Sprintf("rule %d", id)
+	}
+	return fmt.Sprintf("rule at line %d", directive.Position.Line)
+}
+
+var macroPattern = regexp.MustCompile(`%\{([^}]+)\}`)
+
+// expand %{TX.score}, %{MATCHED_VAR}, %{REQUEST_HEADERS.host}, %{RULE.id} 같은 매크로 확장
+func (t *transaction) expand(text string) string {
+	if !strings.Contains(text, "%{") {
+		return text
+	}
+	return macroPattern.ReplaceAllStringFunc(text, func(macro string) string {
+		name, key, _ := strings.Cut(macro[2:len(macro)-1], ".")
+		name = strings.ToUpper(name)
+
+		if name == "RULE" && t.current != nil {
+			if strings.EqualFold(key, "id") {
+				return strconv.Itoa(t.current.ID())
+			}
+			if action := t.current.Action(key); action != nil {
+				return action.Value
+			}
+			return ""
+		}
+
+		values, ok := t.lookup(name, Variable{Name: name, Key: key})
+		if !ok || len(values) == 0 {
+			return ""
+		}
+		return values[0].value
+	})
+}
+
+// phaseOf 시작 룰의 phase (기본 2)
+func phaseOf(directive *Directive) int {
+	action := directive.Action("phase")
+	if action == nil {
+		return 2
+	}
+	switch strings.ToLower(action.Value) {
+	case "request":
+		return 2
+	case "response":
+		return 4
+	case "logging":
+		return 5
+	}
+	phase, err := strconv.Atoi(action.Value)
+	if err != nil {
+		return 2
+	}
+	return phase
+}
+
+// parseForm a=1&b=2 형태를 순서대로 디코딩 (잘못된 인코딩은 그대로 둠)
+func parseForm(text string) []field {
+	var fields []field
+	for _, pair := range strings.Split(text, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		fields = append(fields, field{urlDecode(key), urlDecode(value)})
+	}
+	return fields
+}
+
+func parseCookies(header string) []field {
+	var fields []field
+	for _, pair := range strings.Split(header, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		fields = append(fields, field{key, value})
+	}
+	return fields
+}
+
+// flattenJSON {"a":{"b":1}} → json.a.b=1 (배열 원소는 부모 키를 그대로 사용)
+func flattenJSON(prefix string, value interface{}, fields *[]field) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			flattenJSON(prefix+"."+key, v[key], fields)
+		}
+	case []interface{}:
+		for _, item := range v {
+			flattenJSON(prefix, item, fields)
+		}
+	case nil:
+		*fields = append(*fields, field{prefix, ""})
+	case string:
+		*fields = append(*fields, field{prefix, v})
+	default:
+		*fields = append(*fields, field{prefix, fmt.Sprint(v)})
+	}
+}
@@ -0,0 +1,150 @@
+package seclang
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     string
+		req       Request
+		matched   bool
+		interrupt *Interruption // nil이면 중단되지 않아야 함
+		note      string        // 비어 있지 않으면 Notes에 포함되어야 함
+	}{
+		{
+			name:      "args match denies",
+			rules:     `SecRule ARGS:q "@contains attack" "id:1001,phase:1,deny,status:403"`,
+			req:       Request{URI: "/search?q=an+attack"},
+			matched:   true,
+			interrupt: &Interruption{RuleID: 1001, Position: Position{1, 1}, Action: "deny", Status: 403},
+		},
+		{
+			name:  "no match",
+			rules: `SecRule ARGS:q "@contains attack" "id:1001,phase:1,deny"`,
+			req:   Request{URI: "/search?q=hello"},
+		},
+		{
+			name:      "negated operator",
+			rules:     `SecRule REQUEST_METHOD "!@within GET HEAD" "id:1002,phase:1,deny,status:405"`,
+			req:       Request{Method: "POST", URI: "/"},
+			matched:   true,
+			interrupt: &Interruption{RuleID: 1002, Position: Position{1, 1}, Action: "deny", Status: 405},
+		},
+		{
+			name:  "negated operator does not match",
+			rules: `SecRule REQUEST_METHOD "!@within GET HEAD" "id:1002,phase:1,deny,status:405"`,
+			req:   Request{Method: "GET", URI: "/"},
+		},
+		{
+			name:      "transformations apply before operator",
+			rules:     `SecRule ARGS:cmd "@streq select" "id:1003,phase:1,t:none,t:lowercase,deny"`,
+			req:       Request{URI: "/?cmd=SeLeCt"},
+			matched:   true,
+			interrupt: &Interruption{RuleID: 1003, Position: Position{1, 1}, Action: "deny", Status: 403},
+		},
+		{
+			name:      "chain requires every link",
+			rules:     "SecRule REQUEST_URI \"@beginsWith /admin\" \"id:1004,phase:1,deny,chain\"\nSecRule REMOTE_ADDR \"!@ipMatch 10.0.0.0/8\"",
+			req:       Request{URI: "/admin/users", RemoteAddr: "203.0.113.7"},
+			matched:   true,
+			interrupt: &Interruption{RuleID: 1004, Position: Position{1, 1}, Action: "deny", Status: 403},
+		},
+		{
+			name:  "chain stops on first mismatch",
+			rules: "SecRule REQUEST_URI \"@beginsWith /admin\" \"id:1004,phase:1,deny,chain\"\nSecRule REMOTE_ADDR \"!@ipMatch 10.0.0.0/8\"",
+			req:   Request{URI: "/admin/users", RemoteAddr: "10.1.2.3"},
+		},
+		{
+			name:      "body is visible from phase 2",
+			rules:     `SecRule ARGS_POST:user "@rx ^admin$" "id:1005,phase:2,deny"`,
+			req:       Request{Method: "POST", URI: "/login", Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, Body: "user=admin"},
+			matched:   true,
+			interrupt: &Interruption{RuleID: 1005, Position: Position{1, 1}, Action: "deny", Status: 403},
+		},
+		{
+			name:      "redirect",
+			rules:     `SecRule REQUEST_URI "@streq /old" "id:1006,phase:1,redirect:/new"`,
+			req:       Request{URI: "/old"},
+			matched:   true,
+			interrupt: &Interruption{RuleID: 1006, Position: Position{1, 1}, Action: "redirect", Status: 302, URL: "/new"},
+		},
+		{
+			name:    "detection only does not interrupt",
+			rules:   "SecAction \"id:1007,phase:1,pass,nolog,ctl:ruleEngine=DetectionOnly\"\nSecRule ARGS \"@rx x\" \"id:1008,phase:1,deny\"",
+			req:     Request{URI: "/?a=x"},
+			matched: true,
+			note:    "rule 1008 would have interrupted the request with deny, but the rule engine is DetectionOnly",
+		},
+		{
+			name:    "removed rule is skipped",
+			rules:   "SecRule ARGS \"@rx x\" \"id:1009,phase:1,deny\"\nSecRuleRemoveById 1009",
+			req:     Request{URI: "/?a=x"},
+			matched: false,
+		},
+		{
+			name:      "rule without id is reported",
+			rules:     "SecRule ARGS \"@rx x\" \"id:1010,phase:1,pass\"\nSecRule ARGS \"@rx x\" \"phase:1,deny\"",
+			req:       Request{URI: "/?a=x"},
+			matched:   true,
+			interrupt: &Interruption{Position: Position{2, 1}, Action: "deny", Status: 403},
+			note:      "rule at line 2 has no id: ModSecurity will refuse to load it until an id is assigned",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directives, err := ParseDraft(tt.rules)
+			if err != nil {
+				t.Fatalf("ParseDraft() error = %v", err)
+			}
+			result := Evaluate(directives, &tt.req)
+
+			if result.Matched != tt.matched {
+				t.Errorf("Matched = %v, want %v", result.Matched, tt.matched)
+			}
+			switch {
+			case tt.interrupt == nil && result.Interruption != nil:
+				t.Errorf("Interruption = %+v, want nil", *result.Interruption)
+			case tt.interrupt != nil && result.Interruption == nil:
+				t.Errorf("Interruption = nil, want %+v", *tt.interrupt)
+			case tt.interrupt != nil && *result.Interruption != *tt.interrupt:
+				t.Errorf("Interruption = %+v, want %+v", *result.Interruption, *tt.interrupt)
+			}
+			if tt.note != "" && !containsNote(result.Notes, tt.note) {
+				t.Errorf("Notes = %q, want to contain %q", result.Notes, tt.note)
+			}
+		})
+	}
+}
+
+func TestEvaluateSetvarAndCapture(t *testing.T) {
+	rules := "SecRule ARGS:id \"@rx ^(\\d+)$\" \"id:2001,phase:1,pass,capture,setvar:tx.user_id=%{TX.1},setvar:tx.score=+5\"\n" +
+		"SecRule TX:score \"@ge 5\" \"id:2002,phase:1,pass,setvar:tx.flagged=1\""
+	directives, err := Parse(rules)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	result := Evaluate(directives, &Request{URI: "/?id=42"})
+
+	want := map[string]string{"user_id": "42", "score": "5", "flagged": "1"}
+	for name, value := range want {
+		if got := result.TX[name]; got != value {
+			t.Errorf("TX[%s] = %q, want %q", name, got, value)
+		}
+	}
+	if len(result.Rules) != 2 || !result.Rules[0].Matched || !result.Rules[1].Matched {
+		t.Errorf("Rules = %+v, want both matched", result.Rules)
+	}
+}
+
+func containsNote(notes []string, want string) bool {
+	for _, note := range notes {
+		if strings.Contains(note, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package seclang
+
+import "strings"
+
+// directiveNames 지원하는 지시어 (소문자 → 정식 이름)
+var directiveNames = canonical(
+	"SecRule",
+	"SecAction",
+	"SecMarker",
+	"SecRuleRemoveById",
+	"SecRuleRemoveByTag",
+	"SecRuleRemoveByMsg",
+	"SecRuleUpdateTargetById",
+	"SecRuleUpdateTargetByTag",
+	"SecRuleUpdateTargetByMsg",
+	"SecRuleUpdateActionById",
+)
+
+type actionKind int
+
+const (
+	kindNonDisruptive actionKind = iota
+	kindDisruptive
+	kindFlow
+	kindMeta
+	kindData
+)
+
+type valueMode int
+
+const (
+	valueRequired valueMode = iota
+	valueNone
+	valueOptional
+)
+
+type actionSpec struct {
+	name  string
+	kind  actionKind
+	value valueMode
+}
+
+var actionSpecs = func() map[string]actionSpec {
+	specs := []actionSpec{
+		// disruptive
+		{"allow", kindDisruptive, valueOptional},
+		{"block", kindDisruptive, valueNone},
+		{"deny", kindDisruptive, valueNone},
+		{"drop", kindDisruptive, valueNone},
+		{"pass", kindDisruptive, valueNone},
+		{"pause", kindDisruptive, valueRequired},
+		{"proxy", kindDisruptive, valueRequired},
+		{"redirect", kindDisruptive, valueRequired},
+		// flow
+		{"chain", kindFlow, valueNone},
+		{"skip", kindFlow, valueRequired},
+		{"skipAfter", kindFlow, valueRequired},
+		// meta-data
+		{"id", kindMeta, valueRequired},
+		{"phase", kindMeta, valueRequired},
+		{"msg", kindMeta, valueRequired},
+		{"tag", kindMeta, valueRequired},
+		{"severity", kindMeta, valueRequired},
+		{"rev", kindMeta, valueRequired},
+		{"ver", kindMeta, valueRequired},
+		{"maturity", kindMeta, valueRequired},
+		{"accuracy", kindMeta, valueRequired},
+		{"logdata", kindMeta, valueRequired},
+		// data
+		{"status", kindData, valueRequired},
+		{"xmlns", kindData, valueRequired},
+		// non-disruptive
+		{"t", kindNonDisruptive, valueRequired},
+		{"ctl", kindNonDisruptive, valueRequired},
+		{"setvar", kindNonDisruptive, valueRequired},
+		{"setenv", kindNonDisruptive, valueRequired},
+		{"setuid", kindNonDisruptive, valueRequired},
+		{"setsid", kindNonDisruptive, valueRequired},
+		{"setrsc", kindNonDisruptive, valueRequired},
+		{"initcol", kindNonDisruptive, valueRequired},
+		{"expirevar", kindNonDisruptive, valueRequired},
+		{"deprecatevar", kindNonDisruptive, valueRequired},
+		{"exec", kindNonDisruptive, valueRequired},
+		{"append", kindNonDisruptive, valueRequired},
+		{"prepend", kindNonDisruptive, valueRequired},
+		{"capture", kindNonDisruptive, valueNone},
+		{"log", kindNonDisruptive, valueNone},
+		{"nolog", kindNonDisruptive, valueNone},
+		{"auditlog", kindNonDisruptive, valueNone},
+		{"noauditlog", kindNonDisruptive, valueNone},
+		{"multiMatch", kindNonDisruptive, valueNone},
+		{"sanitiseArg", kindNonDisruptive, valueRequired},
+		{"sanitiseMatched", kindNonDisruptive, valueNone},
+		{"sanitiseMatchedBytes", kindNonDisruptive, valueOptional},
+		{"sanitiseRequestHeader", kindNonDisruptive, valueRequired},
+		{"sanitiseResponseHeader", kindNonDisruptive, valueRequired},
+	}
+
+	result := make(map[string]actionSpec, len(specs))
+	for _, spec := range specs {
+		result[strings.ToLower(spec.name)] = spec
+	}
+	return result
+}()
+
+type operatorSpec struct {
+	name     string
+	needsArg bool
+	numeric  bool
+}
+
+var operatorSpecs = func() map[string]operatorSpec {
+	specs := []operatorSpec{
+		{"rx", true, false},
+		{"pm", true, false},
+		{"pmf", true, false},
+		{"pmFromFile", true, false},
+		{"streq", true, false},
+		{"strmatch", true, false},
+		{"contains", true, false},
+		{"containsWord", true, false},
+		{"beginsWith", true, false},
+		{"endsWith", true, false},
+		{"within", true, false},
+		{"eq", true, true},
+		{"ge", true, true},
+		{"gt", true, true},
+		{"le", true, true},
+		{"lt", true, true},
+		{"ipMatch", true, false},
+		{"ipMatchF", true, false},
+		{"ipMatchFromFile", true, false},
+		{"rbl", true, false},
+		{"rsub", true, false},
+		{"validateByteRange", true, false},
+		{"validateDTD", true, false},
+		{"validateSchema", true, false},
+		{"validateHash", true, false},
+		{"verifyCC", true, false},
+		{"verifyCPF", true, false},
+		{"verifySSN", true, false},
+		{"inspectFile", true, false},
+		{"fuzzyHash", true, false},
+		{"gsbLookup", true, false},
+		{"detectSQLi", false, false},
+		{"detectXSS", false, false},
+		{"geoLookup", false, false},
+		{"unconditionalMatch", false, false},
+		{"noMatch", false, false},
+		{"validateUrlEncoding", false, false},
+		{"validateUtf8Encoding", false, false},
+	}
+
+	result := make(map[string]operatorSpec, len(specs))
+	for _, spec := range specs {
+		result[strings.ToLower(spec.name)] = spec
+	}
+	return result
+}()
+
+var knownVariables = set(
+	"ARGS", "ARGS_COMBINED_SIZE", "ARGS_GET", "ARGS_GET_NAMES", "ARGS_NAMES", "ARGS_POST", "ARGS_POST_NAMES",
+	"AUTH_TYPE", "DURATION", "ENV", "FILES", "FILES_COMBINED_SIZE", "FILES_NAMES", "FILES_SIZES",
+	"FILES_TMPNAMES", "FILES_TMP_CONTENT", "FULL_REQUEST", "FULL_REQUEST_LENGTH", "GEO", "GLOBAL",
+	"HIGHEST_SEVERITY", "INBOUND_DATA_ERROR", "IP", "MATCHED_VAR", "MATCHED_VARS", "MATCHED_VAR_NAME",
+	"MATCHED_VARS_NAMES", "MODSEC_BUILD", "MULTIPART_BOUNDARY_QUOTED", "MULTIPART_BOUNDARY_WHITESPACE",
+	"MULTIPART_CRLF_LF_LINES", "MULTIPART_DATA_AFTER", "MULTIPART_DATA_BEFORE",
+	"MULTIPART_FILE_LIMIT_EXCEEDED", "MULTIPART_FILENAME", "MULTIPART_HEADER_FOLDING",
+	"MULTIPART_INVALID_HEADER_FOLDING", "MULTIPART_INVALID_PART", "MULTIPART_INVALID_QUOTING",
+	"MULTIPART_LF_LINE", "MULTIPART_MISSING_SEMICOLON", "MULTIPART_NAME", "MULTIPART_PART_HEADERS",
+	"MULTIPART_STRICT_ERROR", "MULTIPART_UNMATCHED_BOUNDARY", "OUTBOUND_DATA_ERROR", "PATH_INFO",
+	"QUERY_STRING", "REMOTE_ADDR", "REMOTE_HOST", "REMOTE_PORT", "REMOTE_USER", "REQBODY_ERROR",
+	"REQBODY_ERROR_MSG", "REQBODY_PROCESSOR", "REQBODY_PROCESSOR_ERROR", "REQUEST_BASENAME",
+	"REQUEST_BODY", "REQUEST_BODY_LENGTH", "REQUEST_COOKIES", "REQUEST_COOKIES_NAMES", "REQUEST_FILENAME",
+	"REQUEST_HEADERS", "REQUEST_HEADERS_NAMES", "REQUEST_LINE", "REQUEST_METHOD", "REQUEST_PROTOCOL",
+	"REQUEST_URI", "REQUEST_URI_RAW", "RESOURCE", "RESPONSE_BODY", "RESPONSE_CONTENT_LENGTH",
+	"RESPONSE_CONTENT_TYPE", "RESPONSE_HEADERS", "RESPONSE_HEADERS_NAMES", "RESPONSE_PROTOCOL",
+	"RESPONSE_STATUS", "RULE", "SERVER_ADDR", "SERVER_NAME", "SERVER_PORT", "SESSION", "SESSIONID",
+	"STATUS_LINE", "TIME", "TIME_DAY", "TIME_EPOCH", "TIME_HOUR", "TIME_MIN", "TIME_MON", "TIME_SEC",
+	"TIME_WDAY", "TIME_YEAR", "TX", "UNIQUE_ID", "URLENCODED_ERROR", "USER", "USERID",
+	"WEBSERVER_ERROR_LOG", "XML",
+)
+
+var knownTransformations = lowerSet(
+	"none", "base64Decode", "base64DecodeExt", "base64Encode", "cmdLine", "compressWhitespace",
+	"cssDecode", "escapeSeqDecode", "hexDecode", "hexEncode", "htmlEntityDecode", "jsDecode", "length",
+	"lowercase", "md5", "normalisePath", "normalizePath", "normalisePathWin", "normalizePathWin",
+	"parityEven7bit", "parityOdd7bit", "parityZero7bit", "removeComments", "removeCommentsChar",
+	"removeNulls", "removeWhitespace", "replaceComments", "replaceNulls", "sha1", "sqlHexDecode",
+	"trim", "trimLeft", "trimRight", "uppercase", "urlDecode", "urlDecodeUni", "urlEncode",
+	"utf8toUnicode",
+)
+
+var knownCtlOptions = lowerSet(
+	"auditEngine", "auditLogParts", "debugLogLevel", "forceRequestBodyVariable", "hashEnforcement",
+	"hashEngine", "requestBodyAccess", "requestBodyLimit", "requestBodyProcessor", "responseBodyAccess",
+	"responseBodyLimit", "ruleEngine", "ruleRemoveById", "ruleRemoveByMsg", "ruleRemoveByTag",
+	"ruleRemoveTargetById", "ruleRemoveTargetByMsg", "ruleRemoveTargetByTag",
+)
+
+var validPhases = set("1", "2", "3", "4", "5", "request", "response", "logging")
+
+var validSeverities = set(
+	"0", "1", "2", "3", "4", "5", "6", "7",
+	"EMERGENCY", "ALERT", "CRITICAL", "ERROR", "WARNING", "NOTICE", "INFO", "DEBUG",
+)
+
+func set(values ...string) map[string]bool {
+	result := make(map[string]bool, len(values))
+	for _, value := range values {
+		result[value] = true
+	}
+	return result
+}
+
+func lowerSet(values ...string) map[string]bool {
+	result := make(map[string]bool, len(values))
+	for _, value := range values {
+		result[strings.ToLower(value)] = true
+	}
+	return result
+}
+
+func canonical(values ...string) map[string]string {
+	result := make(map[string]string, len(values))
+	for _, value := range values {
+		result[strings.ToLower(value)] = value
+	}
+	return result
+}
@@ -0,0 +1,214 @@
+package seclang
+
+import (
+	"container/list"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// operatorFunc 변환이 끝난 값 하나에 연산자 적용. captures는 capture 액션이 TX:0~9에 넣을 값
+type operatorFunc func(arg, value string) (matched bool, captures []string, err error)
+
+// operators 평가기가 지원하는 연산자 (소문자 이름). 나머지는 일치하지 않은 것으로 보고 노트에 기록
+var operators = map[string]operatorFunc{
+	"rx":                   opRx,
+	"pm":                   opPm,
+	"streq":                func(arg, value string) (bool, []string, error) { return value == arg, nil, nil },
+	"strmatch":             func(arg, value string) (bool, []string, error) { return strings.Contains(value, arg), nil, nil },
+	"contains":             func(arg, value string) (bool, []string, error) { return strings.Contains(value, arg), nil, nil },
+	"containsword":         opContainsWord,
+	"beginswith":           func(arg, value string) (bool, []string, error) { return strings.HasPrefix(value, arg), nil, nil },
+	"endswith":             func(arg, value string) (bool, []string, error) { return strings.HasSuffix(value, arg), nil, nil },
+	"within":               func(arg, value string) (bool, []string, error) { return strings.Contains(arg, value), nil, nil },
+	"eq":                   numeric(func(a, b int) bool { return a == b }),
+	"ge":                   numeric(func(a, b int) bool { return a >= b }),
+	"gt":                   numeric(func(a, b int) bool { return a > b }),
+	"le":                   numeric(func(a, b int) bool { return a <= b }),
+	"lt":                   numeric(func(a, b int) bool { return a < b }),
+	"ipmatch":              opIPMatch,
+	"detectsqli":           heuristic(sqliPattern),
+	"detectxss":            heuristic(xssPattern),
+	"unconditionalmatch":   func(arg, value string) (bool, []string, error) { return true, nil, nil },
+	"nomatch":              func(arg, value string) (bool, []string, error) { return false, nil, nil },
+	"validatebyterange":    opValidateByteRange,
+	"validateurlencoding":  opValidateURLEncoding,
+	"validateutf8encoding": func(arg, value string) (bool, []string, error) { return !utf8.ValidString(value), nil, nil },
+}
+
+// sqliPattern, xssPattern libinjection 대신 쓰는 단순 패턴 (결과에 근사치임을 노트로 남김)
+var (
+	sqliPattern = regexp.MustCompile(`(?i)(\bunion\b[\s(]+(all\s+)?select\b|\bselect\b.+\bfrom\b|\binsert\s+into\b|\bdelete\s+from\b|\bdrop\s+(table|database)\b|\bupdate\b.+\bset\b|['"\d)]\s*\b(or|and)\b\s*['"(]?\s*[\w'"]+\s*(=|<|>|like\b)|'\s*(--|#|/\*)|;\s*(select|drop|delete|update|insert|shutdown)\b|\b(sleep|benchmark|pg_sleep|waitfor\s+delay)\b\s*[('])`)
+	xssPattern  = regexp.MustCompile(`(?i)(<\s*/?\s*script\b|javascript\s*:|vbscript\s*:|<[^>]*\bon[a-z]+\s*=|<\s*(iframe|object|embed|svg|img|body|meta|link|style)\b|\bexpression\s*\(|document\s*\.\s*(cookie|location|write)|\balert\s*\()`)
+)
+
+// maxCachedRegexes 컴파일된 정규식 캐시 크기. 패턴은 사용자 입력이라 오래 안 쓴 것부터 버림
+const maxCachedRegexes = 256
+
+type cachedRegex struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+var (
+	regexCache = make(map[string]*list.Element) // 값은 regexLRU의 *cachedRegex 항목
+	regexLRU   = list.New()                     // 앞쪽이 최근 사용
+	regexMutex sync.Mutex
+)
+
+// compileRegex ModSecurity 정규식은 PCRE라서 RE2가 지원하지 않는 문법(역참조, lookaround)은 오류
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	regexMutex.Lock()
+	if elem, ok := regexCache[pattern]; ok {
+		regexLRU.MoveToFront(elem)
+		regexMutex.Unlock()
+		return elem.Value.(*cachedRegex).re, nil
+	}
+	regexMutex.Unlock()
+
+	// 컴파일은 락 밖에서 (큰 패턴이 다른 평가를 막지 않도록)
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pattern %q is not supported by the evaluator: %v", pattern, err)
+	}
+
+	regexMutex.Lock()
+	defer regexMutex.Unlock()
+	if elem, ok := regexCache[pattern]; ok {
+		regexLRU.MoveToFront(elem)
+		return elem.Value.(*cachedRegex).re, nil
+	}
+	regexCache[pattern] = regexLRU.PushFront(&cachedRegex{pattern: pattern, re: re})
+	for regexLRU.Len() > maxCachedRegexes {
+		oldest := regexLRU.Back()
+		regexLRU.Remove(oldest)
+		delete(regexCache, oldest.Value.(*cachedRegex).pattern)
+	}
+	return re, nil
+}
+
+func opRx(arg, value string) (bool, []string, error) {
+	re, err := compileRegex(arg)
+	if err != nil {
+		return false, nil, err
+	}
+	captures := re.FindStringSubmatch(value)
+	if captures == nil {
+		return false, nil, nil
+	}
+	return true, captures, nil
+}
+
+// opPm 공백으로 구분된 구문 중 하나라도 포함되면 일치 (대소문자 무시)
+func opPm(arg, value string) (bool, []string, error) {
+	lower := strings.ToLower(value)
+	for _, phrase := range strings.Fields(arg) {
+		if strings.Contains(lower, strings.ToLower(phrase)) {
+			return true, []string{phrase}, nil
+		}
+	}
+	return false, nil, nil
+}
+
+func opContainsWord(arg, value string) (bool, []string, error) {
+	for offset := 0; ; {
+		i := strings.Index(value[offset:], arg)
+		if i < 0 || arg == "" {
+			return false, nil, nil
+		}
+		start, end := offset+i, offset+i+len(arg)
+		if (start == 0 || !isWordByte(value[start-1])) && (end == len(value) || !isWordByte(value[end])) {
+			return true, nil, nil
+		}
+		offset = start + 1
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func numeric(compare func(a, b int) bool) operatorFunc {
+	return func(arg, value string) (bool, []string, error) {
+		a, _ := strconv.Atoi(strings.TrimSpace(value))
+		b, _ := strconv.Atoi(strings.TrimSpace(arg))
+		return compare(a, b), nil, nil
+	}
+}
+
+// opIPMatch 쉼표로 구분된 IP 또는 CIDR 중 하나에 포함되면 일치
+func opIPMatch(arg, value string) (bool, []string, error) {
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		return false, nil, nil
+	}
+	for _, entry := range strings.Split(arg, ",") {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			if other := net.ParseIP(entry); other != nil && other.Equal(ip) {
+				return true, nil, nil
+			}
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return false, nil, fmt.Errorf("invalid @ipMatch entry %q", entry)
+		}
+		if network.Contains(ip) {
+			return true, nil, nil
+		}
+	}
+	return false, nil, nil
+}
+
+func heuristic(pattern *regexp.Regexp) operatorFunc {
+	return func(arg, value string) (bool, []string, error) {
+		if match := pattern.FindString(value); match != "" {
+			return true, []string{match}, nil
+		}
+		return false, nil, nil
+	}
+}
+
+// opValidateByteRange 허용 범위("10,13,32-126") 밖의 바이트가 있으면 일치
+func opValidateByteRange(arg, value string) (bool, []string, error) {
+	var allowed [256]bool
+	for _, part := range strings.Split(arg, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		end := start
+		if err == nil && isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(to))
+		}
+		if err != nil || start < 0 || end > 255 || start > end {
+			return false, nil, fmt.Errorf("invalid @validateByteRange range %q", part)
+		}
+		for b := start; b <= end; b++ {
+			allowed[b] = true
+		}
+	}
+	for i := 0; i < len(value); i++ {
+		if !allowed[value[i]] {
+			return true, nil, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// opValidateURLEncoding 잘못된 % 인코딩이 있으면 일치
+func opValidateURLEncoding(arg, value string) (bool, []string, error) {
+	for i := 0; i < len(value); i++ {
+		if value[i] != '%' {
+			continue
+		}
+		if i+2 >= len(value) || !isHex(value[i+1:i+3]) {
+			return true, nil, nil
+		}
+		i += 2
+	}
+	return false, nil, nil
+}
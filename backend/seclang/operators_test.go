@@ -0,0 +1,44 @@
+package seclang
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCompileRegexCacheIsBounded(t *testing.T) {
+	first, err := compileRegex("^keep-me$")
+	if err != nil {
+		t.Fatalf("compileRegex() error = %v", err)
+	}
+
+	for i := 0; i < maxCachedRegexes*2; i++ {
+		if _, err := compileRegex(fmt.Sprintf("^pattern-%d$", i)); err != nil {
+			t.Fatalf("compileRegex() error = %v", err)
+		}
+		// 자주 쓰는 패턴은 밀려나지 않음
+		if again, _ := compileRegex("^keep-me$"); again != first {
+			t.Fatalf("recently used pattern was evicted after %d patterns", i+1)
+		}
+	}
+
+	regexMutex.Lock()
+	size, listSize := len(regexCache), regexLRU.Len()
+	_, oldestCached := regexCache["^pattern-0$"]
+	regexMutex.Unlock()
+
+	if size > maxCachedRegexes || listSize != size {
+		t.Errorf("cache holds %d entries (list %d), want at most %d", size, listSize, maxCachedRegexes)
+	}
+	if oldestCached {
+		t.Errorf("least recently used pattern is still cached")
+	}
+}
+
+func TestCompileRegexUnsupported(t *testing.T) {
+	tests := []string{`(a)\1`, `(?<=a)b`, `a(?!b)`}
+	for _, pattern := range tests {
+		if _, err := compileRegex(pattern); err == nil {
+			t.Errorf("compileRegex(%q) error = nil, want unsupported syntax error", pattern)
+		}
+	}
+}
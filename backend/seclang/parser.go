@@ -0,0 +1,597 @@
+// Package seclang ModSecurity SecLang 설정을 파싱하고 검증
+package seclang
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Position 원본 텍스트에서의 위치 (1부터 시작)
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error 위치 정보가 포함된 파싱/검증 오류
+type Error struct {
+	Position
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ErrorList 한 번의 파싱에서 발견된 모든 오류 (위치 순)
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+func (l *ErrorList) add(pos Position, format string, args ...interface{}) {
+	*l = append(*l, &Error{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l ErrorList) err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		if l[i].Line != l[j].Line {
+			return l[i].Line < l[j].Line
+		}
+		return l[i].Column < l[j].Column
+	})
+	return l
+}
+
+// Directive 하나의 SecLang 지시어. chain으로 연결된 SecRule은 시작 룰의 Chain에 들어감
+type Directive struct {
+	Name      string       `json:"name"`
+	Position  Position     `json:"position"`
+	Variables []Variable   `json:"variables,omitempty"`
+	Operator  *Operator    `json:"operator,omitempty"`
+	Actions   []Action     `json:"actions,omitempty"`
+	Args      []string     `json:"args,omitempty"` // SecMarker, SecRuleRemoveById 등의 인자
+	Chain     []*Directive `json:"chain,omitempty"`
+
+	actionsOff int    // 액션 토큰 내용의 시작 오프셋 (액션 토큰이 없으면 -1)
+	startOff   int    // 지시어 이름이 시작하는 오프셋
+	endOff     int    // 마지막 인자가 끝나는 오프셋
+	source     string // chain을 포함한 원본 텍스트 (시작 룰에만)
+	comment    string // 바로 앞의 주석 줄들 (시작 룰에만)
+}
+
+// Variable SecRule 대상 변수 (예: !ARGS:foo, &REQUEST_HEADERS:Host, ARGS:/^id_/)
+type Variable struct {
+	Name     string   `json:"name"`
+	Key      string   `json:"key,omitempty"`
+	KeyRegex bool     `json:"key_regex,omitempty"`
+	Count    bool     `json:"count,omitempty"`
+	Exclude  bool     `json:"exclude,omitempty"`
+	Position Position `json:"position"`
+}
+
+// Operator SecRule 연산자 (@ 없이 쓰면 @rx)
+type Operator struct {
+	Name     string   `json:"name"`
+	Argument string   `json:"argument,omitempty"`
+	Negated  bool     `json:"negated,omitempty"`
+	Position Position `json:"position"`
+}
+
+// Action 액션 목록의 항목 (예: id:1001, t:lowercase, deny)
+type Action struct {
+	Name     string   `json:"name"`
+	Value    string   `json:"value,omitempty"`
+	Position Position `json:"position"`
+}
+
+// ID id 액션 값 (없으면 0)
+func (d *Directive) ID() int {
+	if action := d.Action("id"); action != nil {
+		id, _ := strconv.Atoi(action.Value)
+		return id
+	}
+	return 0
+}
+
+// Action 이름이 같은 첫 번째 액션
+func (d *Directive) Action(name string) *Action {
+	for i := range d.Actions {
+		if strings.EqualFold(d.Actions[i].Name, name) {
+			return &d.Actions[i]
+		}
+	}
+	return nil
+}
+
+// Phase 시작 룰이 실행되는 phase (1-5, 생략하면 2)
+func (d *Directive) Phase() int {
+	return phaseOf(d)
+}
+
+// Rules 시작 룰과 chain으로 연결된 룰들
+func (d *Directive) Rules() []*Directive {
+	return append([]*Directive{d}, d.Chain...)
+}
+
+// Parse SecLang 텍스트를 지시어 목록으로 파싱하고 의미 검증까지 수행
+// 오류가 있으면 ErrorList를 반환하며, 이때도 파싱된 지시어는 함께 반환
+func Parse(text string) ([]*Directive, error) {
+	return parse(text, true)
+}
+
+// ParseDraft id가 없는 룰도 허용하는 Parse (ID를 배정하기 전의 룰을 평가할 때 사용)
+func ParseDraft(text string) ([]*Directive, error) {
+	return parse(text, false)
+}
+
+// AssignIDs id가 없는 시작 룰(SecRule, SecAction)에 allocate가 돌려준 ID를 넣은 텍스트 반환
+// 텍스트에 이미 있는 ID는 건너뜀. allocate가 0을 돌려주면 그 룰은 id 없이 둠. 문법 오류가 있으면 ErrorList 반환
+func AssignIDs(text string, allocate func() (int, error)) (string, error) {
+	directives, err := parse(text, false)
+	if err != nil {
+		return text, err
+	}
+
+	inText := make(map[int]bool)
+	for _, directive := range directives {
+		if id := directive.ID(); id > 0 {
+			inText[id] = true
+		}
+	}
+
+	// 위에서부터 ID를 배정하고, 앞쪽 오프셋이 유지되도록 뒤에서부터 삽입
+	ids := make(map[*Directive]int)
+	for _, directive := range directives {
+		if (directive.Name != "SecRule" && directive.Name != "SecAction") || directive.Action("id") != nil {
+			continue
+		}
+		id, err := allocate()
+		for err == nil && inText[id] {
+			id, err = allocate()
+		}
+		if err != nil {
+			return text, err
+		}
+		ids[directive] = id
+	}
+
+	for i := len(directives) - 1; i >= 0; i-- {
+		directive := directives[i]
+		id := ids[directive]
+		if id == 0 {
+			continue
+		}
+		if directive.actionsOff < 0 {
+			text = text[:directive.endOff] + fmt.Sprintf(` "id:%d"`, id) + text[directive.endOff:]
+		} else {
+			text = text[:directive.actionsOff] + fmt.Sprintf("id:%d,", id) + text[directive.actionsOff:]
+		}
+	}
+
+	return text, nil
+}
+
+func parse(text string, requireID bool) ([]*Directive, error) {
+	p := &parser{ids: make(map[int]Position), requireID: requireID}
+	sc := &scanner{src: text, line: 1, col: 1, errs: &p.errs}
+
+	for {
+		tokens, done := sc.scanDirective()
+		if len(tokens) > 0 {
+			p.parseDirective(tokens)
+		}
+		if done {
+			break
+		}
+	}
+
+	if p.chainAction != nil {
+		p.errs.add(p.chainAction.Position, "chain action must be followed by a SecRule")
+	}
+
+	// 구조화된 룰로 바꿨다가 다시 만들 때 원본을 유지할 수 있도록 원본 텍스트와 앞의 주석 보관
+	prevEnd := 0
+	for _, d := range p.directives {
+		end := d.endOff
+		if n := len(d.Chain); n > 0 {
+			end = d.Chain[n-1].endOff
+		}
+		if d.startOff < prevEnd || end < d.startOff {
+			continue
+		}
+		d.source = text[d.startOff:end]
+		d.comment = commentLines(text[prevEnd:d.startOff])
+		prevEnd = end
+	}
+
+	return p.directives, p.errs.err()
+}
+
+type parser struct {
+	directives  []*Directive
+	errs        ErrorList
+	chainStart  *Directive // chain을 기다리는 시작 룰
+	chainAction *Action
+	ids         map[int]Position
+	requireID   bool
+}
+
+func (p *parser) parseDirective(tokens []token) {
+	head := tokens[0]
+	args := tokens[1:]
+
+	name, known := directiveNames[strings.ToLower(head.text)]
+	if !known {
+		p.errs.add(head.start, "unknown directive %q", head.text)
+		return
+	}
+
+	d := &Directive{Name: name, Position: head.start, actionsOff: -1, startOff: head.off, endOff: head.end}
+	if len(args) > 0 {
+		d.endOff = args[len(args)-1].end
+	}
+
+	// chain 다음에는 반드시 SecRule이 와야 함
+	chained := false
+	if p.chainStart != nil {
+		if name == "SecRule" {
+			chained = true
+		} else {
+			p.errs.add(p.chainAction.Position, "chain action must be followed by a SecRule")
+			p.chainStart, p.chainAction = nil, nil
+		}
+	}
+
+	switch name {
+	case "SecRule":
+		if !p.expectArgs(d, args, 2, 3) {
+			return
+		}
+		d.Variables = p.parseVariables(args[0])
+		d.Operator = p.parseOperator(args[1])
+		if len(args) == 3 {
+			d.Actions = p.parseActions(args[2])
+			d.actionsOff = args[2].off
+		}
+	case "SecAction":
+		if !p.expectArgs(d, args, 1, 1) {
+			return
+		}
+		d.Actions = p.parseActions(args[0])
+		d.actionsOff = args[0].off
+	case "SecMarker", "SecRuleRemoveByTag", "SecRuleRemoveByMsg":
+		if !p.expectArgs(d, args, 1, 1) {
+			return
+		}
+		d.Args = []string{args[0].text}
+	case "SecRuleRemoveById":
+		if !p.expectArgs(d, args, 1, -1) {
+			return
+		}
+		for _, arg := range args {
+			for _, field := range strings.Fields(arg.text) {
+				if !validIDRange(field) {
+					p.errs.add(arg.start, "invalid rule id or range %q", field)
+				}
+				d.Args = append(d.Args, field)
+			}
+		}
+	case "SecRuleUpdateTargetById", "SecRuleUpdateTargetByTag", "SecRuleUpdateTargetByMsg":
+		if !p.expectArgs(d, args, 2, 3) {
+			return
+		}
+		if name == "SecRuleUpdateTargetById" && !validIDRange(args[0].text) {
+			p.errs.add(args[0].start, "invalid rule id or range %q", args[0].text)
+		}
+		d.Args = []string{args[0].text}
+		d.Variables = p.parseVariables(args[1])
+		if len(args) == 3 {
+			d.Args = append(d.Args, args[2].text)
+		}
+	case "SecRuleUpdateActionById":
+		if !p.expectArgs(d, args, 2, 2) {
+			return
+		}
+		if !validIDRange(args[0].text) {
+			p.errs.add(args[0].start, "invalid rule id %q", args[0].text)
+		}
+		d.Args = []string{args[0].text}
+		d.Actions = p.parseActions(args[1])
+	}
+
+	switch name {
+	case "SecRule", "SecAction":
+		p.checkRule(d, chained)
+	}
+
+	if chained {
+		p.chainStart.Chain = append(p.chainStart.Chain, d)
+	} else {
+		p.directives = append(p.directives, d)
+	}
+
+	// 이 룰이 chain으로 끝나면 다음 SecRule을 기다림
+	if chainAction := d.Action("chain"); chainAction != nil && name == "SecRule" {
+		if !chained {
+			p.chainStart = d
+		}
+		p.chainAction = chainAction
+	} else if chained {
+		p.chainStart, p.chainAction = nil, nil
+	}
+}
+
+// expectArgs 인자 개수 확인 (max < 0이면 제한 없음)
+func (p *parser) expectArgs(d *Directive, args []token, min, max int) bool {
+	if len(args) < min {
+		p.errs.add(d.Position, "%s expects at least %d argument(s), got %d", d.Name, min, len(args))
+		return false
+	}
+	if max >= 0 && len(args) > max {
+		p.errs.add(args[max].start, "%s expects at most %d argument(s), got %d", d.Name, max, len(args))
+		return false
+	}
+	return true
+}
+
+// checkRule 룰 단위 의미 검증 (id, phase, chain 규칙, disruptive 액션 수)
+func (p *parser) checkRule(d *Directive, chained bool) {
+	var disruptive []Action
+	for _, action := range d.Actions {
+		spec := actionSpecs[strings.ToLower(action.Name)]
+		if spec.kind == kindDisruptive {
+			disruptive = append(disruptive, action)
+		}
+		if chained && (spec.kind == kindDisruptive || action.Name == "id" || action.Name == "phase") {
+			p.errs.add(action.Position, "%s can only be specified in the chain starter rule", action.Name)
+		}
+	}
+	if len(disruptive) > 1 {
+		p.errs.add(disruptive[1].Position, "multiple disruptive actions (%s, %s)", disruptive[0].Name, disruptive[1].Name)
+	}
+
+	if d.Name == "SecAction" {
+		if chain := d.Action("chain"); chain != nil {
+			p.errs.add(chain.Position, "chain is not allowed in SecAction")
+		}
+	}
+
+	if chained {
+		return
+	}
+
+	idAction := d.Action("id")
+	if idAction == nil {
+		if p.requireID {
+			p.errs.add(d.Position, "missing required action id")
+		}
+		return
+	}
+	id := d.ID()
+	if id <= 0 {
+		return // 값 오류는 parseActions에서 보고
+	}
+	if first, exists := p.ids[id]; exists {
+		p.errs.add(idAction.Position, "duplicate rule id %d (first defined at line %d)", id, first.Line)
+		return
+	}
+	p.ids[id] = idAction.Position
+}
+
+func (p *parser) parseVariables(tok token) []Variable {
+	var variables []Variable
+	for _, part := range tok.split('|', false) {
+		text := part.text
+		pos := part.start
+		if strings.TrimSpace(text) == "" {
+			p.errs.add(pos, "empty variable")
+			continue
+		}
+
+		v := Variable{Position: pos}
+		if strings.HasPrefix(text, "!") {
+			v.Exclude = true
+			text = text[1:]
+		}
+		if strings.HasPrefix(text, "&") {
+			v.Count = true
+			text = text[1:]
+		}
+		if v.Exclude && v.Count {
+			p.errs.add(pos, "variable cannot be both excluded and counted")
+		}
+
+		name, key, hasKey := strings.Cut(text, ":")
+		v.Name = strings.ToUpper(name)
+		if !knownVariables[v.Name] {
+			p.errs.add(pos, "unknown variable %q", name)
+		}
+
+		if hasKey {
+			switch {
+			case key == "":
+				p.errs.add(pos, "empty key for variable %s", v.Name)
+			case v.Name == "XML" && !strings.HasPrefix(key, "'"):
+				// XML 키는 XPath 식이라 /로 시작해도 정규식이 아님
+				v.Key = key
+			case strings.HasPrefix(key, "/"):
+				if len(key) < 2 || !strings.HasSuffix(key, "/") {
+					p.errs.add(pos, "unterminated regular expression key for variable %s", v.Name)
+				} else {
+					v.Key = key[1 : len(key)-1]
+					v.KeyRegex = true
+				}
+			case strings.HasPrefix(key, "'"):
+				if len(key) < 2 || !strings.HasSuffix(key, "'") {
+					p.errs.add(pos, "unterminated quoted key for variable %s", v.Name)
+				} else {
+					v.Key = key[1 : len(key)-1]
+				}
+			default:
+				v.Key = key
+			}
+		}
+		if v.Exclude && !hasKey {
+			p.errs.add(pos, "excluded variable %s must specify a key", v.Name)
+		}
+
+		variables = append(variables, v)
+	}
+	return variables
+}
+
+func (p *parser) parseOperator(tok token) *Operator {
+	text := tok.text
+	op := &Operator{Position: tok.start}
+
+	offset := 0
+	if strings.HasPrefix(text, "!") {
+		op.Negated = true
+		offset = 1
+	}
+	rest := text[offset:]
+
+	// @가 없으면 정규식
+	if !strings.HasPrefix(rest, "@") {
+		op.Name = "rx"
+		op.Argument = rest
+		return op
+	}
+
+	nameEnd := strings.IndexAny(rest, " \t")
+	name := rest
+	if nameEnd >= 0 {
+		name = rest[:nameEnd]
+		op.Argument = strings.TrimLeft(rest[nameEnd:], " \t")
+	}
+
+	spec, known := operatorSpecs[strings.ToLower(name[1:])]
+	if !known {
+		p.errs.add(tok.at(offset), "unknown operator %q", name)
+		op.Name = name[1:]
+		return op
+	}
+	op.Name = spec.name
+
+	if spec.needsArg && op.Argument == "" {
+		p.errs.add(tok.at(offset), "operator @%s requires an argument", op.Name)
+	}
+	if spec.numeric && op.Argument != "" && !strings.Contains(op.Argument, "%{") {
+		if _, err := strconv.Atoi(op.Argument); err != nil {
+			p.errs.add(tok.at(offset), "operator @%s requires a numeric argument, got %q", op.Name, op.Argument)
+		}
+	}
+	return op
+}
+
+func (p *parser) parseActions(tok token) []Action {
+	var actions []Action
+	for _, part := range tok.split(',', true) {
+		text := strings.TrimSpace(part.text)
+		lead := len(part.text) - len(strings.TrimLeft(part.text, " \t\r\n"))
+		pos := part.at(lead)
+		if text == "" {
+			p.errs.add(pos, "empty action")
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(text, ":")
+		name = strings.TrimSpace(name)
+		value = unquoteAction(strings.TrimSpace(value))
+
+		spec, known := actionSpecs[strings.ToLower(name)]
+		if !known {
+			p.errs.add(pos, "unknown action %q", name)
+			actions = append(actions, Action{Name: name, Value: value, Position: pos})
+			continue
+		}
+
+		action := Action{Name: spec.name, Value: value, Position: pos}
+		switch {
+		case spec.value == valueRequired && (!hasValue || value == ""):
+			p.errs.add(pos, "action %s requires a value", spec.name)
+		case spec.value == valueNone && hasValue:
+			p.errs.add(pos, "action %s does not take a value", spec.name)
+		case hasValue:
+			if msg := checkActionValue(action); msg != "" {
+				p.errs.add(pos, "%s", msg)
+			}
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// checkActionValue 값 형식이 정해진 액션 검증 (오류 메시지, 정상이면 빈 문자열)
+func checkActionValue(action Action) string {
+	value := action.Value
+	switch action.Name {
+	case "id":
+		if id, err := strconv.Atoi(value); err != nil || id <= 0 {
+			return fmt.Sprintf("id must be a positive integer, got %q", value)
+		}
+	case "phase":
+		if !validPhases[strings.ToLower(value)] {
+			return fmt.Sprintf("phase must be 1-5, request, response or logging, got %q", value)
+		}
+	case "severity":
+		if !validSeverities[strings.ToUpper(value)] {
+			return fmt.Sprintf("invalid severity %q", value)
+		}
+	case "t":
+		if !knownTransformations[strings.ToLower(value)] {
+			return fmt.Sprintf("unknown transformation %q", value)
+		}
+	case "ctl":
+		option, _, hasEq := strings.Cut(value, "=")
+		if !hasEq {
+			return fmt.Sprintf("ctl must be in the form option=value, got %q", value)
+		}
+		if !knownCtlOptions[strings.ToLower(option)] {
+			return fmt.Sprintf("unknown ctl option %q", option)
+		}
+	case "status":
+		if code, err := strconv.Atoi(value); err != nil || code < 100 || code > 599 {
+			return fmt.Sprintf("status must be an HTTP status code, got %q", value)
+		}
+	case "skip":
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+			return fmt.Sprintf("skip must be a positive integer, got %q", value)
+		}
+	}
+	return ""
+}
+
+// unquoteAction msg:'...' 형태의 작은따옴표 제거
+func unquoteAction(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return strings.ReplaceAll(value[1:len(value)-1], `\'`, `'`)
+	}
+	return value
+}
+
+// validIDRange 1234 또는 1000-1999 형태
+func validIDRange(text string) bool {
+	from, to, isRange := strings.Cut(text, "-")
+	start, err := strconv.Atoi(from)
+	if err != nil || start <= 0 {
+		return false
+	}
+	if !isRange {
+		return true
+	}
+	end, err := strconv.Atoi(to)
+	return err == nil && end >= start
+}
@@ -0,0 +1,163 @@
+package seclang
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Position
+		msg  string
+	}{
+		{"missing id", `SecRule ARGS "@rx a" "phase:2,deny"`, Position{1, 1}, "missing required action id"},
+		{"dangling chain", `SecRule ARGS "@rx a" "id:1,chain"`, Position{1, 28}, "chain action must be followed by a SecRule"},
+		{"unknown variable", `SecRule FOO "@rx a" "id:1"`, Position{1, 9}, `unknown variable "FOO"`},
+		{"unknown operator", `SecRule ARGS "@nope a" "id:1"`, Position{1, 15}, `unknown operator "@nope"`},
+		{"unknown action", `SecRule ARGS "@rx a" "id:1,bogus"`, Position{1, 28}, `unknown action "bogus"`},
+		{"unterminated quote", `SecRule ARGS "@rx a`, Position{1, 14}, "unterminated quoted string"},
+		{"unterminated regex key", `SecRule ARGS:/^id_ "@rx a" "id:1"`, Position{1, 9}, "unterminated regular expression key for variable ARGS"},
+		{"duplicate id", "SecRule ARGS \"@rx a\" \"id:1\"\nSecRule ARGS \"@rx b\" \"id:1\"", Position{2, 23}, "duplicate rule id 1 (first defined at line 1)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.text)
+			var errs ErrorList
+			if !errors.As(err, &errs) || len(errs) == 0 {
+				t.Fatalf("Parse() error = %v, want ErrorList", err)
+			}
+			if errs[0].Position != tt.want || errs[0].Message != tt.msg {
+				t.Errorf("first error = %v, want line %d, column %d: %s", errs[0], tt.want.Line, tt.want.Column, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseChainsAndContinuations(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		ids       []int
+		chainLens []int
+	}{
+		{
+			name:      "chain",
+			text:      "SecRule ARGS \"@rx a\" \"id:1,phase:2,deny,chain\"\nSecRule REQUEST_URI \"@beginsWith /x\" \"t:none\"",
+			ids:       []int{1},
+			chainLens: []int{1},
+		},
+		{
+			name:      "chain of three followed by rule",
+			text:      "SecRule ARGS \"@rx a\" \"id:1,deny,chain\"\nSecRule ARGS \"@rx b\" \"chain\"\nSecRule ARGS \"@rx c\"\nSecRule ARGS \"@rx d\" \"id:2,deny\"",
+			ids:       []int{1, 2},
+			chainLens: []int{2, 0},
+		},
+		{
+			name:      "line continuation",
+			text:      "SecRule ARGS \\\n  \"@rx a\" \\\n  \"id:2,deny\"",
+			ids:       []int{2},
+			chainLens: []int{0},
+		},
+		{
+			name:      "continuation inside chain",
+			text:      "SecRule ARGS \"@rx a\" \\\n  \"id:3,deny,chain\"\nSecRule REQUEST_METHOD \\\n  \"@streq POST\"",
+			ids:       []int{3},
+			chainLens: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directives, err := Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(directives) != len(tt.ids) {
+				t.Fatalf("got %d directives, want %d", len(directives), len(tt.ids))
+			}
+			for i, directive := range directives {
+				if directive.ID() != tt.ids[i] {
+					t.Errorf("directive %d id = %d, want %d", i, directive.ID(), tt.ids[i])
+				}
+				if len(directive.Chain) != tt.chainLens[i] {
+					t.Errorf("directive %d chain length = %d, want %d", i, len(directive.Chain), tt.chainLens[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseOperator(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator string
+		want     Operator
+	}{
+		{"explicit", `@rx ^a`, Operator{Name: "rx", Argument: "^a"}},
+		{"negated", `!@beginsWith /admin`, Operator{Name: "beginsWith", Argument: "/admin", Negated: true}},
+		{"bare pattern defaults to rx", `test`, Operator{Name: "rx", Argument: "test"}},
+		{"negated bare pattern", `!test`, Operator{Name: "rx", Argument: "test", Negated: true}},
+		{"no argument", `@unconditionalMatch`, Operator{Name: "unconditionalMatch"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directives, err := Parse(`SecRule ARGS "` + tt.operator + `" "id:1,deny"`)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := *directives[0].Operator
+			got.Position = Position{}
+			if got != tt.want {
+				t.Errorf("operator = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables string
+		want      []Variable
+	}{
+		{"plain key", `ARGS:id`, []Variable{{Name: "ARGS", Key: "id"}}},
+		{"regex key", `ARGS:/^id_/`, []Variable{{Name: "ARGS", Key: "^id_", KeyRegex: true}}},
+		{"quoted key", `ARGS:'/path'`, []Variable{{Name: "ARGS", Key: "/path"}}},
+		{"count and exclusion", `&ARGS|ARGS|!ARGS:id`, []Variable{{Name: "ARGS", Count: true}, {Name: "ARGS"}, {Name: "ARGS", Key: "id", Exclude: true}}},
+		{"xml xpath", `REQUEST_HEADERS|XML:/*`, []Variable{{Name: "REQUEST_HEADERS"}, {Name: "XML", Key: "/*"}}},
+		{"xml xpath with path", `XML://user/name/text()`, []Variable{{Name: "XML", Key: "//user/name/text()"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directives, err := Parse(`SecRule ` + tt.variables + ` "@rx a" "id:1,deny"`)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := directives[0].Variables
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d variables, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				got[i].Position = Position{}
+				if got[i] != tt.want[i] {
+					t.Errorf("variable %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseDraftAllowsMissingID(t *testing.T) {
+	if _, err := ParseDraft(`SecRule ARGS "@rx a" "phase:2,deny"`); err != nil {
+		t.Errorf("ParseDraft() error = %v", err)
+	}
+	if _, err := Parse(`SecRule ARGS "@rx a" "phase:2,deny"`); err == nil || !strings.Contains(err.Error(), "missing required action id") {
+		t.Errorf("Parse() error = %v, want missing id", err)
+	}
+}
@@ -0,0 +1,58 @@
+package seclang
+
+import "strings"
+
+// Policy 저장할 수 있는 지시어와 액션의 범위
+type Policy struct {
+	// Directives 허용 지시어 (비어 있으면 전체 허용)
+	Directives []string
+	// AllowedActions 허용 액션 (비어 있으면 알려진 액션 전체 허용)
+	AllowedActions []string
+	// DeniedActions 금지 액션. "exec"처럼 이름만 쓰거나 "ctl:ruleEngine"처럼 값의 접두어까지 지정
+	DeniedActions []string
+}
+
+// Check 파싱된 지시어가 정책을 위반하면 위치가 포함된 ErrorList 반환
+func (p *Policy) Check(directives []*Directive) error {
+	var errs ErrorList
+	for _, directive := range directives {
+		for _, rule := range directive.Rules() {
+			if len(p.Directives) > 0 && !containsFold(p.Directives, rule.Name) {
+				errs.add(rule.Position, "directive %s is not allowed", rule.Name)
+			}
+			for _, action := range rule.Actions {
+				if len(p.AllowedActions) > 0 && !containsFold(p.AllowedActions, action.Name) {
+					errs.add(action.Position, "action %s is not allowed", action.Name)
+					continue
+				}
+				if denied := p.deniedBy(action); denied != "" {
+					errs.add(action.Position, "action %s is denied by policy", denied)
+				}
+			}
+		}
+	}
+	return errs.err()
+}
+
+// deniedBy 액션을 금지하는 정책 항목 (없으면 빈 문자열)
+func (p *Policy) deniedBy(action Action) string {
+	for _, entry := range p.DeniedActions {
+		name, prefix, hasPrefix := strings.Cut(entry, ":")
+		if !strings.EqualFold(name, action.Name) {
+			continue
+		}
+		if !hasPrefix || strings.HasPrefix(strings.ToLower(action.Value), strings.ToLower(prefix)) {
+			return entry
+		}
+	}
+	return ""
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package seclang
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		text   string
+		want   []string
+	}{
+		{
+			name:   "exec denied by name",
+			policy: Policy{DeniedActions: []string{"exec"}},
+			text:   `SecRule ARGS "@rx a" "id:1,deny,exec:/bin/sh"`,
+			want:   []string{"action exec is denied by policy"},
+		},
+		{
+			name:   "exec denied inside chain",
+			policy: Policy{DeniedActions: []string{"exec"}},
+			text:   "SecRule ARGS \"@rx a\" \"id:1,deny,chain\"\nSecRule ARGS \"@rx b\" \"exec:/tmp/x.lua\"",
+			want:   []string{"action exec is denied by policy"},
+		},
+		{
+			name:   "denied value prefix",
+			policy: Policy{DeniedActions: []string{"ctl:ruleEngine"}},
+			text:   `SecRule ARGS "@rx a" "id:1,pass,ctl:ruleEngine=Off,ctl:ruleRemoveById=942100"`,
+			want:   []string{"action ctl:ruleEngine is denied by policy"},
+		},
+		{
+			name:   "ctl:ruleRemove prefix covers every remove option",
+			policy: Policy{DeniedActions: []string{"exec", "ctl:ruleEngine", "ctl:ruleRemove"}},
+			text:   `SecRule ARGS "@rx a" "id:1,pass,ctl:ruleRemoveById=942100,ctl:ruleRemoveByTag=attack-sqli,ctl:ruleRemoveTargetById=942100;ARGS:q"`,
+			want: []string{
+				"action ctl:ruleRemove is denied by policy",
+				"action ctl:ruleRemove is denied by policy",
+				"action ctl:ruleRemove is denied by policy",
+			},
+		},
+		{
+			name:   "allowed actions",
+			policy: Policy{AllowedActions: []string{"id", "deny"}},
+			text:   `SecRule ARGS "@rx a" "id:1,deny,log"`,
+			want:   []string{"action log is not allowed"},
+		},
+		{
+			name:   "allowed directives",
+			policy: Policy{Directives: []string{"SecRule"}},
+			text:   "SecRule ARGS \"@rx a\" \"id:1,deny\"\nSecAction \"id:2,pass\"",
+			want:   []string{"directive SecAction is not allowed"},
+		},
+		{
+			name:   "no violations",
+			policy: Policy{DeniedActions: []string{"exec", "ctl:ruleEngine"}},
+			text:   `SecRule ARGS "@rx a" "id:1,deny,ctl:ruleRemoveById=942100"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directives, err := Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			err = tt.policy.Check(directives)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Check() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs ErrorList
+			if !errors.As(err, &errs) {
+				t.Fatalf("Check() error = %v, want ErrorList", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Check() returned %d errors (%v), want %d", len(errs), errs, len(tt.want))
+			}
+			for i, msg := range tt.want {
+				if errs[i].Message != msg {
+					t.Errorf("error %d = %q, want %q", i, errs[i].Message, msg)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package seclang
+
+// token 지시어 인자 하나. pos는 text의 각 바이트가 원본에서 위치한 곳
+type token struct {
+	text  string
+	pos   []Position
+	start Position
+	off   int // 원본에서 내용이 시작하는 바이트 오프셋 (따옴표 다음)
+	end   int // 원본에서 토큰이 끝나는 바이트 오프셋 (닫는 따옴표 다음)
+}
+
+// at text의 i번째 바이트 위치 (범위를 벗어나면 토큰 시작 위치)
+func (t token) at(i int) Position {
+	if i >= 0 && i < len(t.pos) {
+		return t.pos[i]
+	}
+	return t.start
+}
+
+// split sep으로 토큰을 나눔 (quotes가 true면 작은따옴표 안의 sep은 무시)
+func (t token) split(sep byte, quotes bool) []token {
+	var parts []token
+	begin := 0
+	inQuote := false
+	for i := 0; i <= len(t.text); i++ {
+		if i < len(t.text) {
+			c := t.text[i]
+			if quotes && c == '\'' && (i == 0 || t.text[i-1] != '\\') {
+				inQuote = !inQuote
+			}
+			if c != sep || inQuote {
+				continue
+			}
+		}
+		parts = append(parts, token{
+			text:  t.text[begin:i],
+			pos:   t.pos[begin:i],
+			start: t.at(begin),
+		})
+		begin = i + 1
+	}
+	return parts
+}
+
+// scanner 원본 텍스트를 지시어 단위로 토큰화 (줄 끝의 \는 다음 줄과 이어짐)
+type scanner struct {
+	src       string
+	off       int
+	line, col int
+	errs      *ErrorList
+}
+
+func (s *scanner) eof() bool {
+	return s.off >= len(s.src)
+}
+
+func (s *scanner) peek() byte {
+	return s.src[s.off]
+}
+
+func (s *scanner) pos() Position {
+	return Position{Line: s.line, Column: s.col}
+}
+
+func (s *scanner) advance() {
+	if s.src[s.off] == '\n' {
+		s.line++
+		s.col = 1
+	} else {
+		s.col++
+	}
+	s.off++
+}
+
+// skipContinuation 현재 위치가 줄 이음(\ + 개행)이면 건너뛰고 true
+func (s *scanner) skipContinuation() bool {
+	if s.src[s.off] != '\\' {
+		return false
+	}
+	rest := s.src[s.off+1:]
+	switch {
+	case len(rest) > 0 && rest[0] == '\n':
+		s.advance()
+		s.advance()
+	case len(rest) > 1 && rest[0] == '\r' && rest[1] == '\n':
+		s.advance()
+		s.advance()
+		s.advance()
+	default:
+		return false
+	}
+	return true
+}
+
+// scanDirective 지시어 하나의 토큰들. 입력 끝에 도달하면 done이 true
+func (s *scanner) scanDirective() (tokens []token, done bool) {
+	for {
+		// 공백과 줄 이음 건너뛰기
+		for !s.eof() {
+			c := s.peek()
+			if c == ' ' || c == '\t' || c == '\r' {
+				s.advance()
+			} else if !s.skipContinuation() {
+				break
+			}
+		}
+
+		if s.eof() {
+			return tokens, true
+		}
+
+		switch c := s.peek(); {
+		case c == '\n':
+			s.advance()
+			return tokens, false
+		case c == '#' && len(tokens) == 0:
+			// 주석은 줄 끝까지
+			for !s.eof() && s.peek() != '\n' {
+				s.advance()
+			}
+		case c == '"':
+			if tok, ok := s.scanQuoted(); ok {
+				tokens = append(tokens, tok)
+			} else {
+				// 닫히지 않은 문자열이면 나머지 줄을 버림
+				for !s.eof() && s.peek() != '\n' {
+					s.advance()
+				}
+				return nil, s.eof()
+			}
+		default:
+			tokens = append(tokens, s.scanBare())
+		}
+	}
+}
+
+// scanQuoted "..." 토큰. \"는 따옴표로, 나머지 역슬래시는 그대로 유지
+func (s *scanner) scanQuoted() (token, bool) {
+	tok := token{start: s.pos()}
+	s.advance()
+	tok.off = s.off
+
+	var text []byte
+	for !s.eof() {
+		if s.skipContinuation() {
+			continue
+		}
+		c := s.peek()
+		switch {
+		case c == '"':
+			s.advance()
+			tok.text = string(text)
+			tok.end = s.off
+			return tok, true
+		case c == '\n':
+			s.errs.add(tok.start, "unterminated quoted string")
+			return tok, false
+		case c == '\\' && s.off+1 < len(s.src) && (s.src[s.off+1] == '"' || s.src[s.off+1] == '\\'):
+			// 이스케이프된 따옴표만 풀고 \\는 정규식 등에서 의미가 있으므로 그대로 둠
+			if s.src[s.off+1] == '"' {
+				s.advance()
+				tok.pos = append(tok.pos, s.pos())
+				text = append(text, '"')
+				s.advance()
+				continue
+			}
+			tok.pos = append(tok.pos, s.pos())
+			text = append(text, c)
+			s.advance()
+			tok.pos = append(tok.pos, s.pos())
+			text = append(text, s.peek())
+			s.advance()
+			continue
+		}
+		tok.pos = append(tok.pos, s.pos())
+		text = append(text, c)
+		s.advance()
+	}
+
+	s.errs.add(tok.start, "unterminated quoted string")
+	return tok, false
+}
+
+// scanBare 따옴표 없는 토큰 (공백이나 줄 끝까지)
+func (s *scanner) scanBare() token {
+	tok := token{start: s.pos(), off: s.off}
+	var text []byte
+	for !s.eof() {
+		c := s.peek()
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			break
+		}
+		if s.skipContinuation() {
+			break
+		}
+		tok.pos = append(tok.pos, s.pos())
+		text = append(text, c)
+		s.advance()
+	}
+	tok.text = string(text)
+	tok.end = s.off
+	return tok
+}
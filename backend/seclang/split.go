@@ -0,0 +1,57 @@
+package seclang
+
+import "strings"
+
+// Segment .conf 파일에서 잘라낸 룰 하나 (chain으로 이어진 SecRule까지 포함)
+type Segment struct {
+	Text     string   `json:"text"`               // 원본 텍스트 (줄 이음 포함)
+	Line     int      `json:"line"`               // 시작 줄 번호
+	Comments []string `json:"comments,omitempty"` // 바로 위에 붙어 있는 주석 (# 제외)
+}
+
+// Split SecLang 텍스트를 룰 단위로 나눔. 문법 오류가 있는 지시어도 그대로 잘라서 돌려주므로
+// 룰마다 따로 검증할 수 있음. 빈 줄은 주석 묶음을 끊고, chain 중간의 주석은 버림
+func Split(text string) []Segment {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var segments []Segment
+	var comments []string
+	chained := false
+	for i := 0; i < len(lines); i++ {
+		start := i
+		for strings.HasSuffix(lines[i], "\\") && i+1 < len(lines) {
+			i++
+		}
+		raw := strings.Join(lines[start:i+1], "\n")
+
+		switch trimmed := strings.TrimSpace(raw); {
+		case trimmed == "":
+			comments = nil
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			comments = append(comments, strings.TrimSpace(strings.TrimPrefix(trimmed, "#")))
+			continue
+		}
+
+		if chained && len(segments) > 0 {
+			last := &segments[len(segments)-1]
+			last.Text += "\n" + strings.TrimRight(raw, " \t")
+		} else {
+			segments = append(segments, Segment{
+				Text:     strings.TrimSpace(raw),
+				Line:     start + 1,
+				Comments: comments,
+			})
+		}
+		comments = nil
+		chained = endsWithChain(raw)
+	}
+
+	return segments
+}
+
+// endsWithChain 지시어 한 줄이 chain 액션으로 끝나서 다음 SecRule과 묶이는지
+func endsWithChain(text string) bool {
+	directives, _ := parse(text, false)
+	return len(directives) > 0 && directives[0].Name == "SecRule" && directives[0].Action("chain") != nil
+}
@@ -0,0 +1,305 @@
+package seclang
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// transformations t: 액션 구현 (소문자 이름 → 함수). 없는 이름은 평가 시 그대로 통과시키고 노트에 기록
+var transformations = map[string]func(string) string{
+	"lowercase":          strings.ToLower,
+	"uppercase":          strings.ToUpper,
+	"urldecode":          urlDecode,
+	"urldecodeuni":       urlDecodeUni,
+	"urlencode":          urlEncode,
+	"htmlentitydecode":   html.UnescapeString,
+	"compresswhitespace": compressWhitespace,
+	"removewhitespace":   removeWhitespace,
+	"removenulls":        func(s string) string { return strings.ReplaceAll(s, "\x00", "") },
+	"replacenulls":       func(s string) string { return strings.ReplaceAll(s, "\x00", " ") },
+	"trim":               func(s string) string { return strings.TrimFunc(s, unicode.IsSpace) },
+	"trimleft":           func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) },
+	"trimright":          func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) },
+	"length":             func(s string) string { return strconv.Itoa(len(s)) },
+	"base64decode":       base64Decode,
+	"base64decodeext":    base64Decode,
+	"base64encode":       func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"hexdecode":          hexDecode,
+	"hexencode":          func(s string) string { return hex.EncodeToString([]byte(s)) },
+	"md5":                func(s string) string { sum := md5.Sum([]byte(s)); return string(sum[:]) },
+	"sha1":               func(s string) string { sum := sha1.Sum([]byte(s)); return string(sum[:]) },
+	"normalisepath":      normalisePath,
+	"normalizepath":      normalisePath,
+	"normalisepathwin":   func(s string) string { return normalisePath(strings.ReplaceAll(s, `\`, "/")) },
+	"normalizepathwin":   func(s string) string { return normalisePath(strings.ReplaceAll(s, `\`, "/")) },
+	"removecomments":     func(s string) string { return commentPattern.ReplaceAllString(s, "") },
+	"replacecomments":    func(s string) string { return blockCommentPattern.ReplaceAllString(s, " ") },
+	"removecommentschar": removeCommentsChar,
+	"cmdline":            cmdLine,
+	"jsdecode":           jsDecode,
+	"cssdecode":          cssDecode,
+	"escapeseqdecode":    jsDecode,
+	"sqlhexdecode":       sqlHexDecode,
+	"utf8tounicode":      utf8ToUnicode,
+}
+
+var (
+	commentPattern      = regexp.MustCompile(`(?s)/\*.*?(\*/|$)|<!--.*?(-->|$)|--[^\n]*|#[^\n]*`)
+	blockCommentPattern = regexp.MustCompile(`(?s)/\*.*?(\*/|$)`)
+	sqlHexPattern       = regexp.MustCompile(`(?i)0x([0-9a-f]{2})+`)
+	escapeChars         = map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', 'b': '\b', 'f': '\f', 'v': '\v', '0': 0}
+)
+
+func urlDecode(s string) string {
+	return percentDecode(s, false)
+}
+
+func urlDecodeUni(s string) string {
+	return percentDecode(s, true)
+}
+
+// percentDecode %XX (uni면 %uXXXX도)와 +를 디코딩. 잘못된 인코딩은 그대로 둠
+func percentDecode(s string, uni bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '+':
+			b.WriteByte(' ')
+		case c == '%' && uni && i+5 < len(s) && (s[i+1] == 'u' || s[i+1] == 'U') && isHex(s[i+2:i+6]):
+			r, _ := strconv.ParseUint(s[i+2:i+6], 16, 32)
+			b.WriteRune(rune(r))
+			i += 5
+		case c == '%' && i+2 < len(s) && isHex(s[i+1:i+3]):
+			v, _ := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			b.WriteByte(byte(v))
+			i += 2
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func urlEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ':
+			b.WriteByte('+')
+		case c < 0x80 && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || strings.IndexByte("-_.*", c) >= 0):
+			b.WriteByte(c)
+		default:
+			b.WriteString("%" + strings.ToLower(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", rune(s[i])) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// compressWhitespace 연속된 공백 문자를 공백 하나로
+func compressWhitespace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func removeWhitespace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func base64Decode(s string) string {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	if decoded, err := base64.RawStdEncoding.DecodeString(s); err == nil {
+		return string(decoded)
+	}
+	if decoded, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return string(decoded)
+	}
+	return s
+}
+
+func hexDecode(s string) string {
+	if decoded, err := hex.DecodeString(s); err == nil {
+		return string(decoded)
+	}
+	return s
+}
+
+// normalisePath ./와 ../를 정리하고 연속된 /를 하나로 (끝의 /는 유지)
+func normalisePath(s string) string {
+	if s == "" {
+		return s
+	}
+	absolute := strings.HasPrefix(s, "/")
+	trailing := strings.HasSuffix(s, "/") || strings.HasSuffix(s, "/.") || strings.HasSuffix(s, "/..")
+
+	var parts []string
+	for _, part := range strings.Split(s, "/") {
+		switch part {
+		case "", ".":
+		case "..":
+			if len(parts) > 0 && parts[len(parts)-1] != ".." {
+				parts = parts[:len(parts)-1]
+			} else if !absolute {
+				parts = append(parts, part)
+			}
+		default:
+			parts = append(parts, part)
+		}
+	}
+
+	result := strings.Join(parts, "/")
+	if absolute {
+		result = "/" + result
+	}
+	if trailing && !strings.HasSuffix(result, "/") {
+		result += "/"
+	}
+	return result
+}
+
+func removeCommentsChar(s string) string {
+	for _, token := range []string{"/*", "*/", "<!--", "-->", "--", "#"} {
+		s = strings.ReplaceAll(s, token, "")
+	}
+	return s
+}
+
+// cmdLine 명령어 우회 기법 정규화 (\ " ' ^ 제거, / ( 앞 공백 제거, , ;를 공백으로, 소문자)
+func cmdLine(s string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\', '"', '\'', '^':
+			continue
+		case ' ', '\t', '\r', '\n', ',', ';':
+			space = true
+			continue
+		case '/', '(':
+			space = false
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(byte(unicode.ToLower(rune(c))))
+	}
+	return b.String()
+}
+
+// jsDecode \xHH, \uHHHH, \n 같은 JavaScript/C 이스케이프 디코딩
+func jsDecode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		next := s[i+1]
+		switch {
+		case (next == 'x' || next == 'X') && i+3 < len(s) && isHex(s[i+2:i+4]):
+			v, _ := strconv.ParseUint(s[i+2:i+4], 16, 8)
+			b.WriteByte(byte(v))
+			i += 3
+		case (next == 'u' || next == 'U') && i+5 < len(s) && isHex(s[i+2:i+6]):
+			v, _ := strconv.ParseUint(s[i+2:i+6], 16, 32)
+			b.WriteRune(rune(v))
+			i += 5
+		default:
+			if decoded, ok := escapeChars[next]; ok {
+				b.WriteByte(decoded)
+			} else {
+				b.WriteByte(next)
+			}
+			i++
+		}
+	}
+	return b.String()
+}
+
+// cssDecode \HH..(최대 6자리) 형태의 CSS 이스케이프 디코딩
+func cssDecode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		j := i + 1
+		for j < len(s) && j < i+7 && isHex(s[j:j+1]) {
+			j++
+		}
+		if j == i+1 {
+			b.WriteByte(s[j])
+			i = j
+			continue
+		}
+		v, _ := strconv.ParseUint(s[i+1:j], 16, 32)
+		b.WriteRune(rune(v))
+		if j < len(s) && s[j] == ' ' {
+			j++
+		}
+		i = j - 1
+	}
+	return b.String()
+}
+
+func sqlHexDecode(s string) string {
+	return sqlHexPattern.ReplaceAllStringFunc(s, func(match string) string {
+		return hexDecode(match[2:])
+	})
+}
+
+func utf8ToUnicode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+		} else {
+			b.WriteString(fmt.Sprintf("%%u%04x", r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,1271 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+
+	// match 타입 룰에 window가 없을 때 해제 판단 기준
+	defaultAlertWindow = 5 * time.Minute
+	// 보관할 해제된 알림 수
+	maxAlertHistory = 500
+
+	// 알림 발송: 고정된 수의 worker가 큐에서 꺼내 보냄 (큐가 가득 차면 버림)
+	notificationWorkers   = 4
+	notificationQueueSize = 1000
+)
+
+type AlertService struct {
+	log           *logrus.Logger
+	db            *gorm.DB
+	wafService    *WAFService
+	tenantService *TenantService
+	rules         map[string]*dto.AlertRule
+	channels      map[string]*dto.NotificationChannel
+	active        map[string]*dto.Alert // key: 룰 ID + 그룹 키
+	history       []dto.Alert
+	windows       map[string][]time.Time // threshold 평가용 이벤트 시각
+	cooldowns     map[string]time.Time   // 해제 후 재발송 금지 시각
+	mutex         sync.RWMutex
+	client        *http.Client
+	dialer        *net.Dialer
+	notifications chan pendingNotification
+}
+
+func NewAlertService(log *logrus.Logger, db *gorm.DB, wafService *WAFService, tenantService *TenantService) *AlertService {
+	dialer := newNotificationDialer()
+	service := &AlertService{
+		log:           log,
+		db:            db,
+		wafService:    wafService,
+		tenantService: tenantService,
+		rules:         make(map[string]*dto.AlertRule),
+		channels:      make(map[string]*dto.NotificationChannel),
+		active:        make(map[string]*dto.Alert),
+		history:       make([]dto.Alert, 0),
+		windows:       make(map[string][]time.Time),
+		cooldowns:     make(map[string]time.Time),
+		client:        newWebhookClient(dialer),
+		dialer:        dialer,
+		notifications: make(chan pendingNotification, notificationQueueSize),
+	}
+	service.load()
+
+	for i := 0; i < notificationWorkers; i++ {
+		go service.sendNotifications()
+	}
+
+	// 수집되는 로그 스트림에 대해 알림 룰 평가
+	wafService.AddLogListener(service.evaluate)
+
+	// 주기적으로 해제 조건 확인
+	go service.monitorResolutions()
+
+	return service
+}
+
+// load DB에 저장된 알림 룰과 채널 로드
+func (s *AlertService) load() {
+	var channels []*models.NotificationChannel
+	if err := s.db.Find(&channels).Error; err != nil {
+		s.log.WithError(err).Error("Failed to load notification channels")
+	}
+	for _, model := range channels {
+		channel := channelFromModel(model)
+		s.channels[channel.ID] = channel
+	}
+
+	var rules []*models.AlertRule
+	if err := s.db.Find(&rules).Error; err != nil {
+		s.log.WithError(err).Error("Failed to load alert rules")
+	}
+	for _, model := range rules {
+		rule := alertRuleFromModel(model)
+		s.rules[rule.ID] = rule
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"rules":    len(s.rules),
+		"channels": len(s.channels),
+	}).Info("Alert rules loaded")
+}
+
+// ---- 알림 룰 관리 ----
+
+func (s *AlertService) CreateRule(userID, email string, req *dto.AlertRuleRequest) (*dto.AlertRule, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.validateRule(userID, req); err != nil {
+		return nil, err
+	}
+
+	rule := &dto.AlertRule{
+		ID:         generateAlertRuleID(),
+		UserID:     userID,
+		OwnerEmail: email,
+		CreatedAt:  time.Now(),
+	}
+	applyAlertRuleRequest(rule, req)
+
+	if err := s.db.Create(alertRuleToModel(rule)).Error; err != nil {
+		return nil, fmt.Errorf("failed to save alert rule: %w", err)
+	}
+	s.rules[rule.ID] = rule
+
+	s.log.WithFields(logrus.Fields{
+		"alert_rule_id": rule.ID,
+		"user_id":       userID,
+		"type":          rule.Type,
+	}).Info("Alert rule created")
+
+	copied := *rule
+	return &copied, nil
+}
+
+func (s *AlertService) GetRules(userID string) []*dto.AlertRule {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*dto.AlertRule, 0)
+	for _, rule := range s.rules {
+		if rule.UserID == userID {
+			copied := *rule
+			result = append(result, &copied)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result
+}
+
+func (s *AlertService) GetRule(userID, ruleID string) (*dto.AlertRule, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rule, exists := s.rules[ruleID]
+	if !exists {
+		return nil, fmt.Errorf("alert rule not found")
+	}
+
+	if rule.UserID != userID {
+		return nil, fmt.Errorf("access denied")
+	}
+
+	copied := *rule
+	return &copied, nil
+}
+
+func (s *AlertService) UpdateRule(userID, email, ruleID string, req *dto.AlertRuleRequest) (*dto.AlertRule, error) {
+	s.mutex.Lock()
+
+	rule, exists := s.rules[ruleID]
+	if !exists {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("alert rule not found")
+	}
+
+	if rule.UserID != userID {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("access denied")
+	}
+
+	if err := s.validateRule(userID, req); err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
+
+	updated := *rule
+	applyAlertRuleRequest(&updated, req)
+	updated.OwnerEmail = email
+	if err := s.db.Save(alertRuleToModel(&updated)).Error; err != nil {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("failed to save alert rule: %w", err)
+	}
+
+	// 조건이 바뀌므로 발생 중인 알림은 기존 채널로 해제 알림을 보내고 window는 초기화
+	notifications := s.resolveRuleAlertsLocked(rule, time.Now())
+	s.clearRuleStateLocked(rule.ID)
+	*rule = updated
+
+	s.log.WithFields(logrus.Fields{
+		"alert_rule_id": rule.ID,
+		"user_id":       userID,
+	}).Info("Alert rule updated")
+
+	copied := *rule
+	s.mutex.Unlock()
+
+	s.dispatch(notifications)
+	return &copied, nil
+}
+
+func (s *AlertService) DeleteRule(userID, ruleID string) error {
+	s.mutex.Lock()
+
+	rule, exists := s.rules[ruleID]
+	if !exists {
+		s.mutex.Unlock()
+		return fmt.Errorf("alert rule not found")
+	}
+
+	if rule.UserID != userID {
+		s.mutex.Unlock()
+		return fmt.Errorf("access denied")
+	}
+
+	if err := s.db.Delete(&models.AlertRule{}, "id = ?", ruleID).Error; err != nil {
+		s.mutex.Unlock()
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+
+	notifications := s.resolveRuleAlertsLocked(rule, time.Now())
+	delete(s.rules, ruleID)
+	s.clearRuleStateLocked(ruleID)
+
+	s.log.WithFields(logrus.Fields{
+		"alert_rule_id": ruleID,
+		"user_id":       userID,
+	}).Info("Alert rule deleted")
+	s.mutex.Unlock()
+
+	s.dispatch(notifications)
+	return nil
+}
+
+func (s *AlertService) validateRule(userID string, req *dto.AlertRuleRequest) error {
+	switch req.Type {
+	case "threshold":
+		if req.Threshold <= 0 {
+			return fmt.Errorf("threshold must be greater than 0")
+		}
+		if req.WindowMinutes <= 0 {
+			return fmt.Errorf("window_minutes must be greater than 0")
+		}
+	case "match":
+		if len(req.Filter.RuleIDs) == 0 && len(req.Filter.Severities) == 0 &&
+			len(req.Filter.AttackTypes) == 0 && len(req.Filter.ClientIPs) == 0 {
+			return fmt.Errorf("match rule requires at least one filter condition")
+		}
+	default:
+		return fmt.Errorf("unsupported alert rule type '%s'", req.Type)
+	}
+
+	for _, channelID := range req.ChannelIDs {
+		channel, exists := s.channels[channelID]
+		if !exists || channel.UserID != userID {
+			return fmt.Errorf("notification channel '%s' not found", channelID)
+		}
+	}
+
+	return nil
+}
+
+func applyAlertRuleRequest(rule *dto.AlertRule, req *dto.AlertRuleRequest) {
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.Type = req.Type
+	rule.Filter = req.Filter
+	rule.Threshold = req.Threshold
+	rule.WindowMinutes = req.WindowMinutes
+	rule.GroupBy = req.GroupBy
+	rule.CooldownMinutes = req.CooldownMinutes
+	rule.ChannelIDs = req.ChannelIDs
+	rule.Enabled = req.Enabled
+	rule.UpdatedAt = time.Now()
+}
+
+// resolveRuleAlertsLocked 룰의 발생 중인 알림을 모두 해제하고 resolve 발송 목록 반환 (mutex 보유 상태에서 호출)
+func (s *AlertService) resolveRuleAlertsLocked(rule *dto.AlertRule, now time.Time) []pendingNotification {
+	var notifications []pendingNotification
+	prefix := rule.ID + "|"
+	for key, alert := range s.active {
+		if strings.HasPrefix(key, prefix) {
+			notifications = append(notifications, s.resolveAlertLocked(key, alert, rule, now)...)
+		}
+	}
+	return notifications
+}
+
+// resolveAlertLocked 알림 하나를 해제 이력으로 옮기고 resolve 발송 목록 반환 (mutex 보유 상태에서 호출)
+func (s *AlertService) resolveAlertLocked(key string, alert *dto.Alert, rule *dto.AlertRule, now time.Time) []pendingNotification {
+	resolvedAt := now
+	alert.Status = AlertStatusResolved
+	alert.ResolvedAt = &resolvedAt
+	delete(s.active, key)
+
+	s.history = append(s.history, *alert)
+	if len(s.history) > maxAlertHistory {
+		s.history = s.history[len(s.history)-maxAlertHistory:]
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"alert_id":      alert.ID,
+		"alert_rule_id": rule.ID,
+		"group_key":     alert.GroupKey,
+	}).Info("Alert resolved")
+
+	return s.buildNotificationsLocked(rule, *alert)
+}
+
+// purgeSubject 정보주체와 관련된 알림 기록과 IP 그룹 집계 삭제 (dryRun이면 개수만 반환)
+func (s *AlertService) purgeSubject(m *subjectMatcher, dryRun bool) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	related := func(alert *dto.Alert) bool {
+		if m.ip != "" && alert.GroupKey == m.ip {
+			return true
+		}
+		return alert.LastEvent != nil && (m.eventIDs[alert.LastEvent.ID] || m.matchLog(alert.LastEvent))
+	}
+
+	count := 0
+	kept := s.history[:0:0]
+	for i := range s.history {
+		if related(&s.history[i]) {
+			count++
+			continue
+		}
+		kept = append(kept, s.history[i])
+	}
+	for key, alert := range s.active {
+		if related(alert) {
+			count++
+			if !dryRun {
+				delete(s.active, key)
+			}
+		}
+	}
+	if dryRun {
+		return count
+	}
+	s.history = kept
+
+	// client_ip로 그룹화된 window/cooldown 키는 "룰 ID|IP"
+	if m.ip != "" {
+		suffix := "|" + m.ip
+		for key := range s.windows {
+			if strings.HasSuffix(key, suffix) {
+				delete(s.windows, key)
+			}
+		}
+		for key := range s.cooldowns {
+			if strings.HasSuffix(key, suffix) {
+				delete(s.cooldowns, key)
+			}
+		}
+	}
+
+	return count
+}
+
+// clearRuleStateLocked 룰에 딸린 window, cooldown, 활성 알림 제거 (mutex 보유 상태에서 호출)
+func (s *AlertService) clearRuleStateLocked(ruleID string) {
+	prefix := ruleID + "|"
+	for key := range s.windows {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.windows, key)
+		}
+	}
+	for key := range s.cooldowns {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.cooldowns, key)
+		}
+	}
+	for key := range s.active {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.active, key)
+		}
+	}
+}
+
+// ---- 알림 채널 관리 ----
+
+func (s *AlertService) CreateChannel(userID string, req *dto.NotificationChannelRequest) (*dto.NotificationChannel, error) {
+	if err := validateChannel(req); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	channel := &dto.NotificationChannel{
+		ID:        generateChannelID(),
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+	applyChannelRequest(channel, req)
+
+	if err := s.db.Create(channelToModel(channel)).Error; err != nil {
+		return nil, fmt.Errorf("failed to save notification channel: %w", err)
+	}
+	s.channels[channel.ID] = channel
+
+	s.log.WithFields(logrus.Fields{
+		"channel_id": channel.ID,
+		"user_id":    userID,
+		"type":       channel.Type,
+	}).Info("Notification channel created")
+
+	return channelToResponse(channel), nil
+}
+
+func (s *AlertService) GetChannels(userID string) []*dto.NotificationChannel {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*dto.NotificationChannel, 0)
+	for _, channel := range s.channels {
+		if channel.UserID == userID {
+			result = append(result, channelToResponse(channel))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result
+}
+
+func (s *AlertService) UpdateChannel(userID, channelID string, req *dto.NotificationChannelRequest) (*dto.NotificationChannel, error) {
+	if err := validateChannel(req); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	channel, exists := s.channels[channelID]
+	if !exists {
+		return nil, fmt.Errorf("notification channel not found")
+	}
+
+	if channel.UserID != userID {
+		return nil, fmt.Errorf("access denied")
+	}
+
+	// 비밀번호를 다시 보내지 않으면 기존 값 유지
+	if req.SMTP != nil && req.SMTP.Password == "" && channel.SMTP != nil {
+		req.SMTP.Password = channel.SMTP.Password
+	}
+
+	updated := *channel
+	applyChannelRequest(&updated, req)
+	if err := s.db.Save(channelToModel(&updated)).Error; err != nil {
+		return nil, fmt.Errorf("failed to save notification channel: %w", err)
+	}
+	*channel = updated
+
+	s.log.WithFields(logrus.Fields{
+		"channel_id": channel.ID,
+		"user_id":    userID,
+	}).Info("Notification channel updated")
+
+	return channelToResponse(channel), nil
+}
+
+func (s *AlertService) DeleteChannel(userID, channelID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	channel, exists := s.channels[channelID]
+	if !exists {
+		return fmt.Errorf("notification channel not found")
+	}
+
+	if channel.UserID != userID {
+		return fmt.Errorf("access denied")
+	}
+
+	for _, rule := range s.rules {
+		for _, id := range rule.ChannelIDs {
+			if id == channelID {
+				return fmt.Errorf("channel is used by alert rule '%s'", rule.Name)
+			}
+		}
+	}
+
+	if err := s.db.Delete(&models.NotificationChannel{}, "id = ?", channelID).Error; err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+	delete(s.channels, channelID)
+
+	s.log.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"user_id":    userID,
+	}).Info("Notification channel deleted")
+
+	return nil
+}
+
+// TestChannel 샘플 알림을 채널로 즉시 전송
+func (s *AlertService) TestChannel(userID, channelID string) error {
+	s.mutex.RLock()
+	channel, exists := s.channels[channelID]
+	if !exists {
+		s.mutex.RUnlock()
+		return fmt.Errorf("notification channel not found")
+	}
+	if channel.UserID != userID {
+		s.mutex.RUnlock()
+		return fmt.Errorf("access denied")
+	}
+	copied := *channel
+	s.mutex.RUnlock()
+
+	notification := dto.AlertNotification{
+		Status: AlertStatusFiring,
+		Alert: dto.Alert{
+			ID:         "test",
+			RuleName:   "Test notification",
+			Status:     AlertStatusFiring,
+			EventCount: 1,
+			Message:    "This is a test notification from WAF SaaS",
+			FiredAt:    time.Now(),
+			LastSeenAt: time.Now(),
+			UserID:     userID,
+		},
+		Timestamp: time.Now(),
+	}
+
+	return s.send(&copied, notification)
+}
+
+func validateChannel(req *dto.NotificationChannelRequest) error {
+	switch req.Type {
+	case "webhook", "slack":
+		parsed, err := url.Parse(req.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("a valid http(s) url is required for %s channels", req.Type)
+		}
+		if err := checkNotificationHost(parsed.Hostname()); err != nil {
+			return err
+		}
+	case "smtp":
+		if req.SMTP == nil || req.SMTP.Host == "" || req.SMTP.From == "" || len(req.SMTP.To) == 0 {
+			return fmt.Errorf("smtp channels require host, from and at least one recipient")
+		}
+		if err := checkNotificationHost(req.SMTP.Host); err != nil {
+			return err
+		}
+		if req.SMTP.Port == 0 {
+			req.SMTP.Port = 587
+		}
+	default:
+		return fmt.Errorf("unsupported channel type '%s'", req.Type)
+	}
+	return nil
+}
+
+func applyChannelRequest(channel *dto.NotificationChannel, req *dto.NotificationChannelRequest) {
+	channel.Name = req.Name
+	channel.Type = req.Type
+	channel.URL = req.URL
+	channel.Headers = req.Headers
+	channel.SMTP = req.SMTP
+	channel.Enabled = req.Enabled
+	channel.UpdatedAt = time.Now()
+}
+
+// channelToResponse 응답용 복사본 (SMTP 비밀번호 제외)
+func channelToResponse(channel *dto.NotificationChannel) *dto.NotificationChannel {
+	copied := *channel
+	if channel.SMTP != nil {
+		smtpCopy := *channel.SMTP
+		smtpCopy.Password = ""
+		copied.SMTP = &smtpCopy
+	}
+	return &copied
+}
+
+// ---- 발생한 알림 조회 ----
+
+// GetAlerts 사용자의 알림 목록 (status가 비어있으면 활성 + 해제 이력 전체)
+func (s *AlertService) GetAlerts(userID, status string) []dto.Alert {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]dto.Alert, 0)
+
+	if status == "" || status == AlertStatusFiring {
+		for _, alert := range s.active {
+			if alert.UserID == userID {
+				result = append(result, *alert)
+			}
+		}
+	}
+
+	if status == "" || status == AlertStatusResolved {
+		for _, alert := range s.history {
+			if alert.UserID == userID {
+				result = append(result, alert)
+			}
+		}
+	}
+
+	// 최신 먼저
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FiredAt.After(result[j].FiredAt)
+	})
+
+	return result
+}
+
+// ---- 평가 엔진 ----
+
+// evaluate 새 로그 하나에 대해 모든 활성 룰을 평가 (WAFService 리스너)
+func (s *AlertService) evaluate(wafLog dto.WAFLog) {
+	now := time.Now()
+	var notifications []pendingNotification
+	// 룰 소유자가 볼 수 없는 테넌트의 이벤트는 평가하지 않음
+	scopes := make(map[string]LogFilter)
+
+	s.mutex.Lock()
+	for _, rule := range s.rules {
+		if !rule.Enabled || !matchesAlertFilter(&rule.Filter, &wafLog) {
+			continue
+		}
+
+		scope, cached := scopes[rule.OwnerEmail]
+		if !cached {
+			scope = s.tenantService.ScopeFor(rule.OwnerEmail)
+			scopes[rule.OwnerEmail] = scope
+		}
+		if scope != nil && !scope(&wafLog) {
+			continue
+		}
+
+		groupKey := alertGroupKey(rule.GroupBy, &wafLog)
+		key := rule.ID + "|" + groupKey
+		eventTime := wafLog.Timestamp
+		if eventTime.IsZero() || eventTime.After(now) {
+			eventTime = now
+		}
+
+		count := 1
+		if rule.Type == "threshold" {
+			window := time.Duration(rule.WindowMinutes) * time.Minute
+			if eventTime.Before(now.Add(-window)) {
+				// window 밖의 오래된 이벤트는 집계하지 않음
+				continue
+			}
+			s.windows[key] = pruneWindow(append(s.windows[key], eventTime), now.Add(-window))
+			count = len(s.windows[key])
+		}
+
+		// 이미 발생 중인 알림이면 중복 발송하지 않고 갱신만
+		if alert, firing := s.active[key]; firing {
+			alert.EventCount++
+			alert.LastSeenAt = now
+			logCopy := wafLog
+			alert.LastEvent = &logCopy
+			continue
+		}
+
+		if rule.Type == "threshold" && count <= rule.Threshold {
+			continue
+		}
+
+		if until, cooling := s.cooldowns[key]; cooling && now.Before(until) {
+			continue
+		}
+		delete(s.cooldowns, key)
+
+		logCopy := wafLog
+		alert := &dto.Alert{
+			ID:         generateAlertID(),
+			RuleID:     rule.ID,
+			RuleName:   rule.Name,
+			GroupKey:   groupKey,
+			Status:     AlertStatusFiring,
+			EventCount: count,
+			Message:    describeAlert(rule, groupKey, count),
+			LastEvent:  &logCopy,
+			FiredAt:    now,
+			LastSeenAt: now,
+			UserID:     rule.UserID,
+		}
+		s.active[key] = alert
+		notifications = append(notifications, s.buildNotificationsLocked(rule, *alert)...)
+
+		s.log.WithFields(logrus.Fields{
+			"alert_id":      alert.ID,
+			"alert_rule_id": rule.ID,
+			"group_key":     groupKey,
+			"event_count":   count,
+		}).Warn("Alert fired")
+	}
+	s.mutex.Unlock()
+
+	s.dispatch(notifications)
+}
+
+func (s *AlertService) monitorResolutions() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.checkResolutions()
+	}
+}
+
+// checkResolutions 조건이 더 이상 충족되지 않는 알림을 해제하고 resolve 알림 발송
+func (s *AlertService) checkResolutions() {
+	now := time.Now()
+	var notifications []pendingNotification
+
+	s.mutex.Lock()
+	for key, alert := range s.active {
+		rule, exists := s.rules[alert.RuleID]
+		if !exists {
+			delete(s.active, key)
+			continue
+		}
+
+		resolved := false
+		if rule.Type == "threshold" {
+			window := time.Duration(rule.WindowMinutes) * time.Minute
+			s.windows[key] = pruneWindow(s.windows[key], now.Add(-window))
+			resolved = len(s.windows[key]) <= rule.Threshold
+		} else {
+			window := time.Duration(rule.WindowMinutes) * time.Minute
+			if window == 0 {
+				window = defaultAlertWindow
+			}
+			resolved = now.Sub(alert.LastSeenAt) >= window
+		}
+
+		if !resolved {
+			continue
+		}
+
+		if len(s.windows[key]) == 0 {
+			delete(s.windows, key)
+		}
+
+		if rule.CooldownMinutes > 0 {
+			s.cooldowns[key] = now.Add(time.Duration(rule.CooldownMinutes) * time.Minute)
+		}
+
+		notifications = append(notifications, s.resolveAlertLocked(key, alert, rule, now)...)
+	}
+
+	// 만료된 cooldown 정리
+	for key, until := range s.cooldowns {
+		if now.After(until) {
+			delete(s.cooldowns, key)
+		}
+	}
+	s.mutex.Unlock()
+
+	s.dispatch(notifications)
+}
+
+func matchesAlertFilter(filter *dto.AlertFilter, wafLog *dto.WAFLog) bool {
+	if filter.BlockedOnly && !wafLog.Blocked {
+		return false
+	}
+
+	if len(filter.RuleIDs) > 0 {
+		matched := false
+		for _, pattern := range filter.RuleIDs {
+			if matchRuleIDPattern(pattern, wafLog.RuleID) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(filter.Severities) > 0 && !containsFold(filter.Severities, wafLog.Severity) {
+		return false
+	}
+
+	if len(filter.AttackTypes) > 0 && !containsFold(filter.AttackTypes, wafLog.AttackType) {
+		return false
+	}
+
+	if len(filter.ClientIPs) > 0 && !containsFold(filter.ClientIPs, wafLog.ClientIP) {
+		return false
+	}
+
+	return true
+}
+
+// matchRuleIDPattern 룰 ID 패턴 비교 ("932xxx"의 x는 숫자 한 자리, "*"는 나머지 전체)
+func matchRuleIDPattern(pattern, ruleID string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || ruleID == "" {
+		return false
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '*':
+			return true
+		case i >= len(ruleID):
+			return false
+		case c == 'x' || c == 'X':
+			if ruleID[i] < '0' || ruleID[i] > '9' {
+				return false
+			}
+		case c != ruleID[i]:
+			return false
+		}
+	}
+
+	return len(pattern) == len(ruleID)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func alertGroupKey(groupBy string, wafLog *dto.WAFLog) string {
+	switch groupBy {
+	case "client_ip":
+		return wafLog.ClientIP
+	case "rule_id":
+		return wafLog.RuleID
+	case "attack_type":
+		return wafLog.AttackType
+	default:
+		return "all"
+	}
+}
+
+func pruneWindow(events []time.Time, cutoff time.Time) []time.Time {
+	kept := events[:0]
+	for _, t := range events {
+		if !t.Before(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+func describeAlert(rule *dto.AlertRule, groupKey string, count int) string {
+	if rule.Type == "threshold" {
+		target := "all traffic"
+		if rule.GroupBy != "" {
+			target = fmt.Sprintf("%s %s", rule.GroupBy, groupKey)
+		}
+		return fmt.Sprintf("%d matching events from %s within %d minutes (threshold %d)",
+			count, target, rule.WindowMinutes, rule.Threshold)
+	}
+	return fmt.Sprintf("Matching WAF event detected for '%s'", rule.Name)
+}
+
+// ---- 알림 발송 ----
+
+type pendingNotification struct {
+	channel      dto.NotificationChannel
+	notification dto.AlertNotification
+}
+
+// buildNotificationsLocked 룰에 연결된 활성 채널별 발송 목록 생성 (mutex 보유 상태에서 호출)
+func (s *AlertService) buildNotificationsLocked(rule *dto.AlertRule, alert dto.Alert) []pendingNotification {
+	var result []pendingNotification
+	for _, channelID := range rule.ChannelIDs {
+		channel, exists := s.channels[channelID]
+		if !exists || !channel.Enabled {
+			continue
+		}
+		result = append(result, pendingNotification{
+			channel: *channel,
+			notification: dto.AlertNotification{
+				Status:    alert.Status,
+				Alert:     alert,
+				Timestamp: time.Now(),
+			},
+		})
+	}
+	return result
+}
+
+// dispatch 알림을 발송 큐에 넣음 (로그 수집 경로를 막지 않도록, 큐가 가득 차면 버림)
+func (s *AlertService) dispatch(notifications []pendingNotification) {
+	for _, pending := range notifications {
+		select {
+		case s.notifications <- pending:
+		default:
+			s.log.WithFields(logrus.Fields{
+				"channel_id": pending.channel.ID,
+				"alert_id":   pending.notification.Alert.ID,
+			}).Error("Notification queue is full, dropping alert notification")
+		}
+	}
+}
+
+// sendNotifications 발송 worker
+func (s *AlertService) sendNotifications() {
+	for p := range s.notifications {
+		if err := s.send(&p.channel, p.notification); err != nil {
+			s.log.WithError(err).WithFields(logrus.Fields{
+				"channel_id": p.channel.ID,
+				"type":       p.channel.Type,
+				"alert_id":   p.notification.Alert.ID,
+			}).Error("Failed to send alert notification")
+		}
+	}
+}
+
+func (s *AlertService) send(channel *dto.NotificationChannel, notification dto.AlertNotification) error {
+	switch channel.Type {
+	case "webhook":
+		return s.sendWebhook(channel, notification)
+	case "slack":
+		return s.sendSlack(channel, notification)
+	case "smtp":
+		return s.sendEmail(channel, notification)
+	default:
+		return fmt.Errorf("unsupported channel type '%s'", channel.Type)
+	}
+}
+
+func (s *AlertService) sendWebhook(channel *dto.NotificationChannel, notification dto.AlertNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	return s.postJSON(channel.URL, channel.Headers, body)
+}
+
+func (s *AlertService) sendSlack(channel *dto.NotificationChannel, notification dto.AlertNotification) error {
+	body, err := json.Marshal(map[string]string{
+		"text": formatAlertText(notification),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack payload: %w", err)
+	}
+	return s.postJSON(channel.URL, channel.Headers, body)
+}
+
+func (s *AlertService) postJSON(targetURL string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest("POST", targetURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *AlertService) sendEmail(channel *dto.NotificationChannel, notification dto.AlertNotification) error {
+	settings := channel.SMTP
+	if settings == nil {
+		return fmt.Errorf("smtp settings missing")
+	}
+
+	// 헤더 값에 줄바꿈이 섞이면 임의 헤더가 주입되므로 제거
+	subject := fmt.Sprintf("[WAF %s] %s", strings.ToUpper(notification.Status), notification.Alert.RuleName)
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		headerValue(settings.From), headerValue(strings.Join(settings.To, ", ")), headerValue(subject), formatAlertText(notification))
+
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)
+	}
+
+	// webhook과 같은 dialer로 연결해서 내부망 SMTP 서버로는 보내지 않음
+	addr := net.JoinHostPort(settings.Host, strconv.Itoa(settings.Port))
+	conn, err := s.dialer.Dial("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if err := sendMail(conn, settings.Host, auth, settings.From, settings.To, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// sendMail 연결된 conn으로 smtp.SendMail과 같은 순서로 발송 (STARTTLS 지원 시 사용)
+func sendMail(conn net.Conn, host string, auth smtp.Auth, from string, to []string, message []byte) error {
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// headerValue 메일 헤더에 넣을 값에서 CR/LF 제거
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+func formatAlertText(notification dto.AlertNotification) string {
+	alert := notification.Alert
+	var b strings.Builder
+
+	if notification.Status == AlertStatusResolved {
+		fmt.Fprintf(&b, "RESOLVED: %s", alert.RuleName)
+	} else {
+		fmt.Fprintf(&b, "FIRING: %s", alert.RuleName)
+	}
+	if alert.GroupKey != "" && alert.GroupKey != "all" {
+		fmt.Fprintf(&b, " [%s]", alert.GroupKey)
+	}
+	fmt.Fprintf(&b, "\n%s\nEvents: %d\nFired at: %s", alert.Message, alert.EventCount, alert.FiredAt.Format(time.RFC3339))
+	if alert.ResolvedAt != nil {
+		fmt.Fprintf(&b, "\nResolved at: %s", alert.ResolvedAt.Format(time.RFC3339))
+	}
+	if alert.LastEvent != nil {
+		fmt.Fprintf(&b, "\nLast event: %s %s from %s (rule %s, %s)",
+			alert.LastEvent.Method, alert.LastEvent.URL, alert.LastEvent.ClientIP,
+			alert.LastEvent.RuleID, alert.LastEvent.Severity)
+	}
+
+	return b.String()
+}
+
+// ---- 알림 대상 제한 ----
+
+// newNotificationDialer 내부망 주소로는 연결하지 않는 dialer (webhook, SMTP 공용)
+// DNS 응답이 바뀌거나 redirect 되더라도 실제 연결 시점의 IP로 검사
+func newNotificationDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("notification destination %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+}
+
+func newWebhookClient(dialer *net.Dialer) *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// checkNotificationHost 채널 저장 시점에 명백한 내부 주소를 거부 (최종 검사는 연결 시점)
+func checkNotificationHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("notification host must not be a local address")
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return fmt.Errorf("notification host must not be a private, loopback or link-local address")
+	}
+	return nil
+}
+
+// isPublicIP 사설, loopback, link-local, CGNAT 등 내부 대역이 아닌 주소인지
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+		return false
+	}
+	return true
+}
+
+func alertRuleToModel(rule *dto.AlertRule) *models.AlertRule {
+	filter, _ := json.Marshal(rule.Filter)
+	return &models.AlertRule{
+		ID:              rule.ID,
+		Name:            rule.Name,
+		Description:     rule.Description,
+		Type:            rule.Type,
+		Filter:          string(filter),
+		Threshold:       rule.Threshold,
+		WindowMinutes:   rule.WindowMinutes,
+		GroupBy:         rule.GroupBy,
+		CooldownMinutes: rule.CooldownMinutes,
+		ChannelIDs:      encodeStrings(rule.ChannelIDs),
+		Enabled:         rule.Enabled,
+		UserID:          rule.UserID,
+		OwnerEmail:      rule.OwnerEmail,
+		CreatedAt:       rule.CreatedAt,
+		UpdatedAt:       rule.UpdatedAt,
+	}
+}
+
+func alertRuleFromModel(model *models.AlertRule) *dto.AlertRule {
+	rule := &dto.AlertRule{
+		ID:              model.ID,
+		Name:            model.Name,
+		Description:     model.Description,
+		Type:            model.Type,
+		Threshold:       model.Threshold,
+		WindowMinutes:   model.WindowMinutes,
+		GroupBy:         model.GroupBy,
+		CooldownMinutes: model.CooldownMinutes,
+		ChannelIDs:      decodeStrings(model.ChannelIDs),
+		Enabled:         model.Enabled,
+		UserID:          model.UserID,
+		OwnerEmail:      model.OwnerEmail,
+		CreatedAt:       model.CreatedAt,
+		UpdatedAt:       model.UpdatedAt,
+	}
+	if model.Filter != "" {
+		json.Unmarshal([]byte(model.Filter), &rule.Filter)
+	}
+	return rule
+}
+
+func channelToModel(channel *dto.NotificationChannel) *models.NotificationChannel {
+	model := &models.NotificationChannel{
+		ID:        channel.ID,
+		Name:      channel.Name,
+		Type:      channel.Type,
+		URL:       channel.URL,
+		Enabled:   channel.Enabled,
+		UserID:    channel.UserID,
+		CreatedAt: channel.CreatedAt,
+		UpdatedAt: channel.UpdatedAt,
+	}
+	if len(channel.Headers) > 0 {
+		headers, _ := json.Marshal(channel.Headers)
+		model.Headers = string(headers)
+	}
+	if channel.SMTP != nil {
+		settings, _ := json.Marshal(channel.SMTP)
+		model.SMTP = string(settings)
+	}
+	return model
+}
+
+func channelFromModel(model *models.NotificationChannel) *dto.NotificationChannel {
+	channel := &dto.NotificationChannel{
+		ID:        model.ID,
+		Name:      model.Name,
+		Type:      model.Type,
+		URL:       model.URL,
+		Enabled:   model.Enabled,
+		UserID:    model.UserID,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+	if model.Headers != "" {
+		json.Unmarshal([]byte(model.Headers), &channel.Headers)
+	}
+	if model.SMTP != "" {
+		json.Unmarshal([]byte(model.SMTP), &channel.SMTP)
+	}
+	return channel
+}
+
+func encodeStrings(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+func decodeStrings(encoded string) []string {
+	var values []string
+	if encoded != "" {
+		json.Unmarshal([]byte(encoded), &values)
+	}
+	return values
+}
+
+func generateAlertRuleID() string {
+	return fmt.Sprintf("alert_rule_%d", time.Now().UnixNano())
+}
+
+func generateChannelID() string {
+	return fmt.Sprintf("channel_%d", time.Now().UnixNano())
+}
+
+func generateAlertID() string {
+	return fmt.Sprintf("alert_%d", time.Now().UnixNano())
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// newTestAlertService worker와 로그 리스너 없이 DB에서 로드한 AlertService (발송 목록은 큐에 남음)
+func newTestAlertService(t *testing.T, db *gorm.DB) *AlertService {
+	t.Helper()
+	s := &AlertService{
+		log:           newTestLogger(),
+		db:            db,
+		tenantService: newTestTenantService(t, db),
+		rules:         make(map[string]*dto.AlertRule),
+		channels:      make(map[string]*dto.NotificationChannel),
+		active:        make(map[string]*dto.Alert),
+		history:       make([]dto.Alert, 0),
+		windows:       make(map[string][]time.Time),
+		cooldowns:     make(map[string]time.Time),
+		notifications: make(chan pendingNotification, 100),
+	}
+	s.load()
+	return s
+}
+
+func TestMatchRuleIDPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ruleID  string
+		want    bool
+	}{
+		{"942100", "942100", true},
+		{"942100", "942101", false},
+		{"942xxx", "942150", true},
+		{"942XXX", "942150", true},
+		{"942xxx", "94215", false},
+		{"942xxx", "942abc", false},
+		{"9421*", "942130", true},
+		{"9421*", "942230", false},
+		{"*", "1", true},
+		{"", "942100", false},
+		{"942100", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.ruleID, func(t *testing.T) {
+			if got := matchRuleIDPattern(tt.pattern, tt.ruleID); got != tt.want {
+				t.Errorf("matchRuleIDPattern(%q, %q) = %v, want %v", tt.pattern, tt.ruleID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesAlertFilter(t *testing.T) {
+	event := dto.WAFLog{RuleID: "942100", Severity: "CRITICAL", AttackType: "SQL Injection", ClientIP: "203.0.113.7", Blocked: true}
+	tests := []struct {
+		name   string
+		filter dto.AlertFilter
+		event  dto.WAFLog
+		want   bool
+	}{
+		{"empty filter", dto.AlertFilter{}, event, true},
+		{"rule pattern", dto.AlertFilter{RuleIDs: []string{"941xxx", "942xxx"}}, event, true},
+		{"rule mismatch", dto.AlertFilter{RuleIDs: []string{"941xxx"}}, event, false},
+		{"severity case-insensitive", dto.AlertFilter{Severities: []string{"critical"}}, event, true},
+		{"attack type mismatch", dto.AlertFilter{AttackTypes: []string{"XSS"}}, event, false},
+		{"client ip", dto.AlertFilter{ClientIPs: []string{"203.0.113.7"}}, event, true},
+		{"blocked only", dto.AlertFilter{BlockedOnly: true}, dto.WAFLog{RuleID: "942100"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesAlertFilter(&tt.filter, &tt.event); got != tt.want {
+				t.Errorf("matchesAlertFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlertServiceEvaluate(t *testing.T) {
+	event := dto.WAFLog{ID: "evt", RuleID: "942100", ClientIP: "203.0.113.7", Severity: "CRITICAL"}
+	tests := []struct {
+		name        string
+		rule        dto.AlertRule
+		events      int
+		wantFiring  bool
+		wantCount   int
+		wantMessage string
+		wantSent    int
+	}{
+		{
+			name:       "threshold below",
+			rule:       dto.AlertRule{Type: "threshold", Threshold: 3, WindowMinutes: 5, GroupBy: "client_ip", Enabled: true},
+			events:     3,
+			wantFiring: false,
+		},
+		{
+			name:        "threshold exceeded",
+			rule:        dto.AlertRule{Type: "threshold", Threshold: 3, WindowMinutes: 5, GroupBy: "client_ip", Enabled: true},
+			events:      5,
+			wantFiring:  true,
+			wantCount:   5,
+			wantMessage: "4 matching events from client_ip 203.0.113.7 within 5 minutes (threshold 3)",
+			wantSent:    1,
+		},
+		{
+			name:        "match rule fires once",
+			rule:        dto.AlertRule{Name: "sqli", Type: "match", Filter: dto.AlertFilter{RuleIDs: []string{"942xxx"}}, Enabled: true},
+			events:      3,
+			wantFiring:  true,
+			wantCount:   3,
+			wantMessage: "Matching WAF event detected for 'sqli'",
+			wantSent:    1,
+		},
+		{
+			name:       "filter mismatch",
+			rule:       dto.AlertRule{Type: "match", Filter: dto.AlertFilter{RuleIDs: []string{"941xxx"}}, Enabled: true},
+			events:     3,
+			wantFiring: false,
+		},
+		{
+			name:       "disabled",
+			rule:       dto.AlertRule{Type: "match", Filter: dto.AlertFilter{RuleIDs: []string{"942xxx"}}},
+			events:     3,
+			wantFiring: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestAlertService(t, newTestDB(t, &models.AlertRule{}, &models.NotificationChannel{}))
+			s.channels["ch"] = &dto.NotificationChannel{ID: "ch", Type: "webhook", Enabled: true, UserID: "user_a"}
+			rule := tt.rule
+			rule.ID, rule.UserID, rule.OwnerEmail, rule.ChannelIDs = "rule", "user_a", testAdminEmail, []string{"ch"}
+			s.rules[rule.ID] = &rule
+
+			for i := 0; i < tt.events; i++ {
+				s.evaluate(event)
+			}
+
+			alerts := s.GetAlerts("user_a", AlertStatusFiring)
+			if firing := len(alerts) == 1; firing != tt.wantFiring {
+				t.Fatalf("firing = %v (%d alerts), want %v", firing, len(alerts), tt.wantFiring)
+			}
+			if len(s.notifications) != tt.wantSent {
+				t.Errorf("queued notifications = %d, want %d", len(s.notifications), tt.wantSent)
+			}
+			if !tt.wantFiring {
+				return
+			}
+			if alerts[0].EventCount != tt.wantCount || alerts[0].Message != tt.wantMessage {
+				t.Errorf("alert = %d %q, want %d %q", alerts[0].EventCount, alerts[0].Message, tt.wantCount, tt.wantMessage)
+			}
+			if other := s.GetAlerts("user_b", ""); len(other) != 0 {
+				t.Errorf("GetAlerts(other user) = %d alerts, want 0", len(other))
+			}
+		})
+	}
+}
+
+// 룰을 수정하면 발생 중인 알림은 resolve 알림과 함께 해제
+func TestAlertServiceUpdateRuleResolvesAlerts(t *testing.T) {
+	s := newTestAlertService(t, newTestDB(t, &models.AlertRule{}, &models.NotificationChannel{}))
+	channel, err := s.CreateChannel("user_a", &dto.NotificationChannelRequest{Name: "hook", Type: "webhook", URL: "https://hooks.example.com/a", Enabled: true})
+	if err != nil {
+		t.Fatalf("CreateChannel() error = %v", err)
+	}
+	req := &dto.AlertRuleRequest{Name: "sqli", Type: "match", Filter: dto.AlertFilter{RuleIDs: []string{"942xxx"}}, ChannelIDs: []string{channel.ID}, Enabled: true}
+	rule, err := s.CreateRule("user_a", testAdminEmail, req)
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	s.evaluate(dto.WAFLog{RuleID: "942100"})
+	<-s.notifications
+
+	if _, err := s.UpdateRule("user_a", testAdminEmail, rule.ID, req); err != nil {
+		t.Fatalf("UpdateRule() error = %v", err)
+	}
+	if firing := s.GetAlerts("user_a", AlertStatusFiring); len(firing) != 0 {
+		t.Errorf("firing alerts after update = %d, want 0", len(firing))
+	}
+	select {
+	case pending := <-s.notifications:
+		if pending.notification.Status != AlertStatusResolved {
+			t.Errorf("notification status = %q, want resolved", pending.notification.Status)
+		}
+	default:
+		t.Error("no resolve notification queued")
+	}
+}
+
+// 룰은 만든 사용자의 테넌트 호스트 이벤트만 평가
+func TestAlertServiceEvaluateTenantScope(t *testing.T) {
+	s := newTestAlertService(t, newTestDB(t, &models.AlertRule{}, &models.NotificationChannel{}))
+	if _, err := s.tenantService.CreateTenant(&dto.TenantRequest{Name: "shop", Hosts: []string{"*.shop.example.com"}, Members: []string{"kim@example.com"}}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		email      string
+		host       string
+		wantFiring bool
+	}{
+		{"member own host", "kim@example.com", "www.shop.example.com", true},
+		{"member other host", "kim@example.com", "blog.example.com", false},
+		{"user without tenant", "lee@example.com", "www.shop.example.com", false},
+		{"admin", testAdminEmail, "blog.example.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := s.CreateRule("user_a", tt.email, &dto.AlertRuleRequest{Name: tt.name, Type: "match", Filter: dto.AlertFilter{RuleIDs: []string{"942100"}}, Enabled: true})
+			if err != nil {
+				t.Fatalf("CreateRule() error = %v", err)
+			}
+			defer s.DeleteRule("user_a", rule.ID)
+
+			s.evaluate(dto.WAFLog{RuleID: "942100", Host: tt.host})
+			firing := false
+			for _, alert := range s.GetAlerts("user_a", AlertStatusFiring) {
+				firing = firing || alert.RuleID == rule.ID
+			}
+			if firing != tt.wantFiring {
+				t.Errorf("firing = %v, want %v", firing, tt.wantFiring)
+			}
+		})
+	}
+}
+
+func TestAlertServiceAccessChecks(t *testing.T) {
+	s := newTestAlertService(t, newTestDB(t, &models.AlertRule{}, &models.NotificationChannel{}))
+	channel, err := s.CreateChannel("user_a", &dto.NotificationChannelRequest{Name: "hook", Type: "webhook", URL: "https://hooks.example.com/a", Enabled: true})
+	if err != nil {
+		t.Fatalf("CreateChannel() error = %v", err)
+	}
+	rule, err := s.CreateRule("user_a", testAdminEmail, &dto.AlertRuleRequest{Name: "r", Type: "threshold", Threshold: 1, WindowMinutes: 1})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr string
+	}{
+		{"get other user's rule", func() error { _, err := s.GetRule("user_b", rule.ID); return err }, "access denied"},
+		{"delete other user's rule", func() error { return s.DeleteRule("user_b", rule.ID) }, "access denied"},
+		{"use other user's channel", func() error {
+			_, err := s.CreateRule("user_b", "b@example.com", &dto.AlertRuleRequest{Name: "r", Type: "threshold", Threshold: 1, WindowMinutes: 1, ChannelIDs: []string{channel.ID}})
+			return err
+		}, "not found"},
+		{"delete other user's channel", func() error { return s.DeleteChannel("user_b", channel.ID) }, "access denied"},
+		{"test other user's channel", func() error { return s.TestChannel("user_b", channel.ID) }, "access denied"},
+		{"match rule without filter", func() error {
+			_, err := s.CreateRule("user_a", testAdminEmail, &dto.AlertRuleRequest{Name: "r", Type: "match"})
+			return err
+		}, "at least one filter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// 저장한 룰과 채널이 새 서비스에서 그대로 로드됨
+func TestAlertServicePersistence(t *testing.T) {
+	db := newTestDB(t, &models.AlertRule{}, &models.NotificationChannel{})
+	s := newTestAlertService(t, db)
+
+	channels := []*dto.NotificationChannelRequest{
+		{Name: "hook", Type: "webhook", URL: "https://hooks.example.com/a", Headers: map[string]string{"X-Token": "t"}, Enabled: true},
+		{Name: "mail", Type: "smtp", SMTP: &dto.SMTPSettings{Host: "smtp.example.com", Username: "u", Password: "secret", From: "waf@example.com", To: []string{"ops@example.com"}}},
+	}
+	var ids []string
+	for _, req := range channels {
+		channel, err := s.CreateChannel("user_a", req)
+		if err != nil {
+			t.Fatalf("CreateChannel(%s) error = %v", req.Name, err)
+		}
+		ids = append(ids, channel.ID)
+	}
+	rule, err := s.CreateRule("user_a", testAdminEmail, &dto.AlertRuleRequest{
+		Name: "bursts", Type: "threshold", Filter: dto.AlertFilter{Severities: []string{"CRITICAL"}},
+		Threshold: 10, WindowMinutes: 5, GroupBy: "client_ip", CooldownMinutes: 15, ChannelIDs: ids, Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	reloaded := newTestAlertService(t, db)
+	got, err := reloaded.GetRule("user_a", rule.ID)
+	if err != nil {
+		t.Fatalf("GetRule() after reload error = %v", err)
+	}
+	rule.CreatedAt, rule.UpdatedAt = got.CreatedAt, got.UpdatedAt
+	if !reflect.DeepEqual(got, rule) {
+		t.Errorf("reloaded rule = %+v, want %+v", got, rule)
+	}
+
+	mail := reloaded.channels[ids[1]]
+	if mail == nil || mail.SMTP == nil || mail.SMTP.Password != "secret" || mail.SMTP.Port != 587 {
+		t.Errorf("reloaded smtp channel = %+v", mail)
+	}
+	if hook := reloaded.channels[ids[0]]; hook == nil || hook.Headers["X-Token"] != "t" {
+		t.Errorf("reloaded webhook channel = %+v", hook)
+	}
+	for _, channel := range reloaded.GetChannels("user_a") {
+		if channel.SMTP != nil && channel.SMTP.Password != "" {
+			t.Error("GetChannels() exposes the smtp password")
+		}
+	}
+
+	if err := reloaded.DeleteRule("user_a", rule.ID); err != nil {
+		t.Fatalf("DeleteRule() error = %v", err)
+	}
+	if _, err := newTestAlertService(t, db).GetRule("user_a", rule.ID); err == nil {
+		t.Error("deleted rule is still loaded")
+	}
+}
+
+func TestCheckNotificationHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{"hooks.example.com", false},
+		{"203.0.113.10", false},
+		{"localhost", true},
+		{"api.localhost.", true},
+		{"127.0.0.1", true},
+		{"10.0.0.5", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"::1", true},
+		{"fd00::1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if err := checkNotificationHost(tt.host); (err != nil) != tt.wantErr {
+				t.Errorf("checkNotificationHost(%q) error = %v, wantErr %v", tt.host, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := newNotificationDialer().Control("tcp", "127.0.0.1:25", nil); err == nil {
+		t.Error("dialer allows loopback destinations")
+	}
+	if isPublicIP(net.ParseIP("8.8.8.8")) == false {
+		t.Error("isPublicIP(8.8.8.8) = false")
+	}
+}
+
+func TestFormatAlertText(t *testing.T) {
+	fired := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	resolved := fired.Add(10 * time.Minute)
+	tests := []struct {
+		name         string
+		notification dto.AlertNotification
+		want         string
+	}{
+		{
+			name: "firing",
+			notification: dto.AlertNotification{Status: AlertStatusFiring, Alert: dto.Alert{
+				RuleName: "bursts", GroupKey: "203.0.113.7", Message: "msg", EventCount: 11, FiredAt: fired,
+				LastEvent: &dto.WAFLog{Method: "GET", URL: "/x", ClientIP: "203.0.113.7", RuleID: "942100", Severity: "CRITICAL"},
+			}},
+			want: "FIRING: bursts [203.0.113.7]\nmsg\nEvents: 11\nFired at: 2024-05-01T10:00:00Z\nLast event: GET /x from 203.0.113.7 (rule 942100, CRITICAL)",
+		},
+		{
+			name: "resolved",
+			notification: dto.AlertNotification{Status: AlertStatusResolved, Alert: dto.Alert{
+				RuleName: "sqli", GroupKey: "all", Message: "msg", EventCount: 1, FiredAt: fired, ResolvedAt: &resolved,
+			}},
+			want: "RESOLVED: sqli\nmsg\nEvents: 1\nFired at: 2024-05-01T10:00:00Z\nResolved at: 2024-05-01T10:10:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatAlertText(tt.notification); got != tt.want {
+				t.Errorf("formatAlertText() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if got := headerValue("alert\r\nBcc: victim@example.com"); strings.ContainsAny(got, "\r\n") {
+		t.Errorf("headerValue() = %q, still contains CR/LF", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"waf-backend/config"
@@ -22,7 +23,10 @@ import (
 type AuthService struct {
 	oauthConfig *oauth2.Config
 	jwtSecret   string
-	log         *logrus.Logger
+	adminEmails map[string]bool
+	// reviewerEmails 룰 변경 세트 승인 권한 (관리자 외 추가 지정)
+	reviewerEmails map[string]bool
+	log            *logrus.Logger
 }
 
 func NewAuthService(cfg *config.Config, log *logrus.Logger) *AuthService {
@@ -34,13 +38,38 @@ func NewAuthService(cfg *config.Config, log *logrus.Logger) *AuthService {
 		Endpoint:     google.Endpoint,
 	}
 
+	adminEmails := make(map[string]bool)
+	for _, email := range cfg.Security.AdminEmails {
+		adminEmails[strings.ToLower(email)] = true
+	}
+	if len(adminEmails) == 0 {
+		log.Warn("ADMIN_EMAILS not set, admin API endpoints will be unavailable")
+	}
+
+	reviewerEmails := make(map[string]bool)
+	for _, email := range cfg.Rules.ReviewerEmails {
+		reviewerEmails[strings.ToLower(email)] = true
+	}
+
 	return &AuthService{
-		oauthConfig: oauthConfig,
-		jwtSecret:   cfg.Security.JWTSecret,
-		log:         log,
+		oauthConfig:    oauthConfig,
+		jwtSecret:      cfg.Security.JWTSecret,
+		adminEmails:    adminEmails,
+		reviewerEmails: reviewerEmails,
+		log:            log,
 	}
 }
 
+// IsAdmin ADMIN_EMAILS에 등록된 사용자인지 확인
+func (s *AuthService) IsAdmin(email string) bool {
+	return email != "" && s.adminEmails[strings.ToLower(email)]
+}
+
+// IsRuleReviewer 룰 변경 세트를 승인/반려할 수 있는 사용자인지 확인 (관리자 또는 RULE_REVIEWER_EMAILS)
+func (s *AuthService) IsRuleReviewer(email string) bool {
+	return s.IsAdmin(email) || (email != "" && s.reviewerEmails[strings.ToLower(email)])
+}
+
 func (s *AuthService) GetAuthURL(state string) string {
 	return s.oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline)
 }
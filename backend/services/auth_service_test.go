@@ -0,0 +1,27 @@
+package services
+
+import (
+	"testing"
+	"waf-backend/config"
+)
+
+func TestAuthServiceIsAdmin(t *testing.T) {
+	s := NewAuthService(&config.Config{Security: config.SecurityConfig{AdminEmails: []string{"Admin@Example.com"}}}, newTestLogger())
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"admin@example.com", true},
+		{"ADMIN@example.com", true},
+		{"user@example.com", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			if got := s.IsAdmin(tt.email); got != tt.want {
+				t.Errorf("IsAdmin(%q) = %v, want %v", tt.email, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,713 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// 자동 차단 룰에 사용하는 관리형 룰 ID 범위 (9900 ~ 9949)
+	banRuleIDBase = 9900
+	banRuleIDMax  = 9949
+	// 하나의 @ipMatch 룰에 넣을 최대 IP 수
+	banIPsPerRule = 100
+	// 배포할 수 있는 최대 활성 차단 수 (넘으면 새 차단을 거부)
+	maxActiveBans = (banRuleIDMax - banRuleIDBase + 1) * banIPsPerRule
+	// 수동 차단에 허용하는 가장 넓은 CIDR
+	minBanPrefixIPv4 = 16
+	minBanPrefixIPv6 = 48
+	// 차단/해제/만료를 모아서 배포하는 간격 (배포마다 NGINX가 재시작되므로 공격 중 배포 폭주 방지)
+	banDeployDelay = 30 * time.Second
+)
+
+type BanService struct {
+	log           *logrus.Logger
+	db            *gorm.DB
+	ruleService   *RuleService
+	policies      map[string]*dto.BanPolicy
+	bans          map[string]*dto.IPBan  // key: ban ID
+	windows       map[string][]time.Time // key: 정책 ID + IP
+	deployPending chan struct{}
+	mutex         sync.RWMutex
+}
+
+func NewBanService(log *logrus.Logger, db *gorm.DB, wafService *WAFService, ruleService *RuleService) *BanService {
+	service := &BanService{
+		log:           log,
+		db:            db,
+		ruleService:   ruleService,
+		policies:      make(map[string]*dto.BanPolicy),
+		bans:          make(map[string]*dto.IPBan),
+		windows:       make(map[string][]time.Time),
+		deployPending: make(chan struct{}, 1),
+	}
+
+	service.load()
+
+	// 활성 차단 목록을 배포 설정에 포함
+	ruleService.RegisterManagedSnippet("IP auto-ban", service.renderBanRules)
+
+	// 수집되는 로그로 차단 정책 평가
+	wafService.AddLogListener(service.evaluate)
+
+	// 만료된 차단 항목 정리
+	go service.monitorExpirations()
+	go service.deployLoop()
+
+	return service
+}
+
+// load DB에 저장된 정책과 아직 만료되지 않은 차단 항목을 불러옴
+func (s *BanService) load() {
+	var policies []models.BanPolicy
+	if err := s.db.Find(&policies).Error; err != nil {
+		s.log.WithError(err).Error("Failed to load ban policies")
+	}
+	for i := range policies {
+		s.policies[policies[i].ID] = banPolicyFromModel(&policies[i])
+	}
+
+	var bans []models.IPBan
+	if err := s.db.Find(&bans).Error; err != nil {
+		s.log.WithError(err).Error("Failed to load IP bans")
+	}
+	now := time.Now()
+	var expired []string
+	for i := range bans {
+		if now.After(bans[i].ExpiresAt) {
+			expired = append(expired, bans[i].ID)
+			continue
+		}
+		s.bans[bans[i].ID] = ipBanFromModel(&bans[i])
+	}
+	if len(expired) > 0 {
+		if err := s.db.Delete(&models.IPBan{}, "id IN ?", expired).Error; err != nil {
+			s.log.WithError(err).Error("Failed to delete expired IP bans")
+		}
+	}
+}
+
+// ---- 차단 정책 관리 ----
+
+func (s *BanService) CreatePolicy(userID string, req *dto.BanPolicyRequest) (*dto.BanPolicy, error) {
+	if err := validateExemptIPs(req.ExemptIPs); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	policy := &dto.BanPolicy{
+		ID:        generateBanPolicyID(),
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+	applyBanPolicyRequest(policy, req)
+
+	if err := s.db.Create(banPolicyToModel(policy)).Error; err != nil {
+		return nil, fmt.Errorf("failed to save ban policy: %w", err)
+	}
+	s.policies[policy.ID] = policy
+
+	s.log.WithFields(logrus.Fields{
+		"policy_id": policy.ID,
+		"user_id":   userID,
+		"threshold": policy.Threshold,
+	}).Info("Ban policy created")
+
+	copied := *policy
+	return &copied, nil
+}
+
+func (s *BanService) GetPolicies() []*dto.BanPolicy {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*dto.BanPolicy, 0, len(s.policies))
+	for _, policy := range s.policies {
+		copied := *policy
+		result = append(result, &copied)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result
+}
+
+func (s *BanService) UpdatePolicy(policyID string, req *dto.BanPolicyRequest) (*dto.BanPolicy, error) {
+	if err := validateExemptIPs(req.ExemptIPs); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	policy, exists := s.policies[policyID]
+	if !exists {
+		return nil, fmt.Errorf("ban policy not found")
+	}
+
+	updated := *policy
+	applyBanPolicyRequest(&updated, req)
+	if err := s.db.Save(banPolicyToModel(&updated)).Error; err != nil {
+		return nil, fmt.Errorf("failed to save ban policy: %w", err)
+	}
+	*policy = updated
+	s.clearPolicyWindowsLocked(policyID)
+
+	s.log.WithField("policy_id", policyID).Info("Ban policy updated")
+
+	copied := *policy
+	return &copied, nil
+}
+
+func (s *BanService) DeletePolicy(policyID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.policies[policyID]; !exists {
+		return fmt.Errorf("ban policy not found")
+	}
+
+	if err := s.db.Delete(&models.BanPolicy{}, "id = ?", policyID).Error; err != nil {
+		return fmt.Errorf("failed to delete ban policy: %w", err)
+	}
+	delete(s.policies, policyID)
+	s.clearPolicyWindowsLocked(policyID)
+
+	s.log.WithField("policy_id", policyID).Info("Ban policy deleted")
+	return nil
+}
+
+func applyBanPolicyRequest(policy *dto.BanPolicy, req *dto.BanPolicyRequest) {
+	policy.Name = req.Name
+	policy.Filter = req.Filter
+	policy.Threshold = req.Threshold
+	policy.WindowMinutes = req.WindowMinutes
+	policy.BanMinutes = req.BanMinutes
+	policy.ExemptIPs = req.ExemptIPs
+	policy.Enabled = req.Enabled
+	policy.UpdatedAt = time.Now()
+}
+
+func (s *BanService) clearPolicyWindowsLocked(policyID string) {
+	prefix := policyID + "|"
+	for key := range s.windows {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.windows, key)
+		}
+	}
+}
+
+// purgeSubject IP별 정책 집계 window 삭제 (dryRun이면 개수만 반환)
+// 활성 차단은 보안 목적으로 유지하고 countSubjectBans로 따로 보고
+func (s *BanService) purgeSubject(m *subjectMatcher, dryRun bool) int {
+	if m.ip == "" {
+		return 0
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	suffix := "|" + m.ip
+	for key := range s.windows {
+		if strings.HasSuffix(key, suffix) {
+			count++
+			if !dryRun {
+				delete(s.windows, key)
+			}
+		}
+	}
+	return count
+}
+
+// countSubjectBans 정보주체 IP를 포함하는 활성 차단 개수
+func (s *BanService) countSubjectBans(m *subjectMatcher) int {
+	if m.ip == "" {
+		return 0
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	count := 0
+	for _, ban := range s.bans {
+		if ipInList(m.ip, []string{ban.IP}) {
+			count++
+		}
+	}
+	return count
+}
+
+// ---- 차단 항목 관리 ----
+
+func (s *BanService) GetBans() []*dto.IPBan {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*dto.IPBan, 0, len(s.bans))
+	for _, ban := range s.bans {
+		copied := *ban
+		result = append(result, &copied)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ExpiresAt.Before(result[j].ExpiresAt)
+	})
+
+	return result
+}
+
+// CreateBan 수동 차단 추가 (이미 차단된 IP면 만료 시각만 연장)
+// 요청자 자신의 IP를 포함하는 대상은 관리 화면 접근까지 막으므로 거부
+func (s *BanService) CreateBan(userID, callerIP string, req *dto.IPBanRequest) (*dto.IPBan, error) {
+	ip, err := normalizeBanTarget(req.IP)
+	if err != nil {
+		return nil, err
+	}
+	if ipInList(callerIP, []string{ip}) {
+		return nil, fmt.Errorf("refusing to ban %s: it includes your own IP address", ip)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "Manual ban"
+	}
+
+	ban, created, err := s.addBan(ip, reason, "", "user:"+userID, time.Duration(req.DurationMinutes)*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		s.requestDeploy()
+	}
+
+	return ban, nil
+}
+
+// ExtendBan 만료 시각 연장 (배포된 IP 목록은 그대로이므로 재배포 불필요)
+func (s *BanService) ExtendBan(banID string, minutes int) (*dto.IPBan, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ban, exists := s.bans[banID]
+	if !exists {
+		return nil, fmt.Errorf("ban not found")
+	}
+
+	expiresAt := ban.ExpiresAt.Add(time.Duration(minutes) * time.Minute)
+	if err := s.db.Model(&models.IPBan{}).Where("id = ?", banID).Update("expires_at", expiresAt).Error; err != nil {
+		return nil, fmt.Errorf("failed to extend ban: %w", err)
+	}
+	ban.ExpiresAt = expiresAt
+
+	s.log.WithFields(logrus.Fields{
+		"ban_id":     banID,
+		"ip":         ban.IP,
+		"expires_at": ban.ExpiresAt,
+	}).Info("IP ban extended")
+
+	copied := *ban
+	return &copied, nil
+}
+
+// LiftBan 차단 해제 후 재배포 예약
+func (s *BanService) LiftBan(banID string) error {
+	s.mutex.Lock()
+	ban, exists := s.bans[banID]
+	if !exists {
+		s.mutex.Unlock()
+		return fmt.Errorf("ban not found")
+	}
+	if err := s.db.Delete(&models.IPBan{}, "id = ?", banID).Error; err != nil {
+		s.mutex.Unlock()
+		return fmt.Errorf("failed to lift ban: %w", err)
+	}
+	delete(s.bans, banID)
+	s.mutex.Unlock()
+
+	s.log.WithFields(logrus.Fields{
+		"ban_id": banID,
+		"ip":     ban.IP,
+	}).Info("IP ban lifted")
+
+	s.requestDeploy()
+	return nil
+}
+
+// addBan 차단 항목 추가 (새로 생성된 경우 true)
+func (s *BanService) addBan(ip, reason, policyID, createdBy string, duration time.Duration) (*dto.IPBan, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.addBanLocked(ip, reason, policyID, createdBy, duration)
+}
+
+func (s *BanService) addBanLocked(ip, reason, policyID, createdBy string, duration time.Duration) (*dto.IPBan, bool, error) {
+	now := time.Now()
+	expiresAt := now.Add(duration)
+
+	for _, ban := range s.bans {
+		if ban.IP == ip {
+			if expiresAt.After(ban.ExpiresAt) {
+				if err := s.db.Model(&models.IPBan{}).Where("id = ?", ban.ID).Update("expires_at", expiresAt).Error; err != nil {
+					return nil, false, fmt.Errorf("failed to extend ban: %w", err)
+				}
+				ban.ExpiresAt = expiresAt
+			}
+			copied := *ban
+			return &copied, false, nil
+		}
+	}
+
+	// 배포되지 않는 차단이 활성으로 보이지 않도록 용량을 넘으면 거부
+	active := 0
+	for _, ban := range s.bans {
+		if now.Before(ban.ExpiresAt) {
+			active++
+		}
+	}
+	if active >= maxActiveBans {
+		return nil, false, fmt.Errorf("ban list is full: at most %d active bans can be deployed", maxActiveBans)
+	}
+
+	ban := &dto.IPBan{
+		ID:        generateBanID(),
+		IP:        ip,
+		Reason:    reason,
+		PolicyID:  policyID,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.db.Create(ipBanToModel(ban)).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to save ban: %w", err)
+	}
+	s.bans[ban.ID] = ban
+
+	s.log.WithFields(logrus.Fields{
+		"ban_id":     ban.ID,
+		"ip":         ip,
+		"policy_id":  policyID,
+		"created_by": createdBy,
+		"expires_at": expiresAt,
+	}).Warn("IP banned")
+
+	copied := *ban
+	return &copied, true, nil
+}
+
+// ---- 정책 평가 및 만료 처리 ----
+
+// evaluate 새 로그에 대해 차단 정책 평가 (WAFService 리스너)
+func (s *BanService) evaluate(wafLog dto.WAFLog) {
+	if wafLog.ClientIP == "" || net.ParseIP(wafLog.ClientIP) == nil {
+		return
+	}
+
+	now := time.Now()
+	created := false
+
+	s.mutex.Lock()
+	for _, policy := range s.policies {
+		if !policy.Enabled || !matchesAlertFilter(&policy.Filter, &wafLog) {
+			continue
+		}
+		if ipInList(wafLog.ClientIP, policy.ExemptIPs) {
+			continue
+		}
+
+		eventTime := wafLog.Timestamp
+		if eventTime.IsZero() || eventTime.After(now) {
+			eventTime = now
+		}
+		window := time.Duration(policy.WindowMinutes) * time.Minute
+		if eventTime.Before(now.Add(-window)) {
+			continue
+		}
+
+		key := policy.ID + "|" + wafLog.ClientIP
+		s.windows[key] = pruneWindow(append(s.windows[key], eventTime), now.Add(-window))
+		count := len(s.windows[key])
+		if count <= policy.Threshold {
+			continue
+		}
+
+		reason := fmt.Sprintf("%s: %d matching events within %d minutes", policy.Name, count, policy.WindowMinutes)
+		_, isNew, err := s.addBanLocked(wafLog.ClientIP, reason, policy.ID, "policy:"+policy.ID,
+			time.Duration(policy.BanMinutes)*time.Minute)
+		if err != nil {
+			s.log.WithError(err).WithField("ip", wafLog.ClientIP).Error("Failed to create IP ban")
+			continue
+		}
+		if isNew {
+			created = true
+		}
+		delete(s.windows, key)
+	}
+	s.mutex.Unlock()
+
+	if created {
+		s.requestDeploy()
+	}
+}
+
+func (s *BanService) monitorExpirations() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.removeExpired()
+	}
+}
+
+// removeExpired 만료된 차단 항목 삭제 후 변경이 있으면 재배포
+func (s *BanService) removeExpired() {
+	now := time.Now()
+	var expired []string
+
+	s.mutex.Lock()
+	for id, ban := range s.bans {
+		if now.After(ban.ExpiresAt) {
+			expired = append(expired, id)
+		}
+	}
+	if len(expired) > 0 {
+		if err := s.db.Delete(&models.IPBan{}, "id IN ?", expired).Error; err != nil {
+			// 다음 주기에 다시 시도
+			s.log.WithError(err).Error("Failed to delete expired IP bans")
+			expired = nil
+		}
+	}
+	for _, id := range expired {
+		s.log.WithFields(logrus.Fields{
+			"ban_id": id,
+			"ip":     s.bans[id].IP,
+		}).Info("IP ban expired")
+		delete(s.bans, id)
+	}
+
+	// 오래된 window 정리
+	for key, events := range s.windows {
+		policyID := strings.SplitN(key, "|", 2)[0]
+		policy, exists := s.policies[policyID]
+		if !exists {
+			delete(s.windows, key)
+			continue
+		}
+		kept := pruneWindow(events, now.Add(-time.Duration(policy.WindowMinutes)*time.Minute))
+		if len(kept) == 0 {
+			delete(s.windows, key)
+		} else {
+			s.windows[key] = kept
+		}
+	}
+	s.mutex.Unlock()
+
+	if len(expired) > 0 {
+		s.requestDeploy()
+	}
+}
+
+// requestDeploy 차단 목록 배포 예약 (이미 예약되어 있으면 함께 배포됨)
+func (s *BanService) requestDeploy() {
+	select {
+	case s.deployPending <- struct{}{}:
+	default:
+	}
+}
+
+// deployLoop 예약된 변경을 banDeployDelay 동안 모아서 한 번만 재배포
+// 만료 루프/로그 리스너에서도 예약되므로 요청 context와 분리
+func (s *BanService) deployLoop() {
+	for range s.deployPending {
+		time.Sleep(banDeployDelay)
+
+		// 기다리는 동안 들어온 예약은 이번 배포에 포함됨
+		select {
+		case <-s.deployPending:
+		default:
+		}
+
+		if err := s.ruleService.Redeploy(context.Background()); err != nil {
+			s.log.WithError(err).Error("Failed to redeploy IP ban list")
+		}
+	}
+}
+
+// renderBanRules 활성 차단 목록을 @ipMatch 룰로 렌더링 (RuleService 배포 시 호출)
+func (s *BanService) renderBanRules() string {
+	s.mutex.RLock()
+	now := time.Now()
+	ips := make([]string, 0, len(s.bans))
+	for _, ban := range s.bans {
+		if now.Before(ban.ExpiresAt) {
+			ips = append(ips, ban.IP)
+		}
+	}
+	s.mutex.RUnlock()
+
+	if len(ips) == 0 {
+		return ""
+	}
+	sort.Strings(ips)
+
+	var rules []string
+	for i, ruleID := 0, banRuleIDBase; i < len(ips); i, ruleID = i+banIPsPerRule, ruleID+1 {
+		if ruleID > banRuleIDMax {
+			s.log.WithField("ban_count", len(ips)).Warn("Too many active bans, some IPs are not deployed")
+			break
+		}
+		end := i + banIPsPerRule
+		if end > len(ips) {
+			end = len(ips)
+		}
+		rules = append(rules, fmt.Sprintf(
+			`SecRule REMOTE_ADDR "@ipMatch %s" "id:%d,phase:1,deny,status:403,log,msg:'Temporarily banned IP (auto-ban)',tag:'waf-saas/auto-ban'"`,
+			strings.Join(ips[i:end], ","), ruleID))
+	}
+
+	return strings.Join(rules, "\n")
+}
+
+// normalizeBanTarget 차단 대상 IP 또는 CIDR 검증 및 정규화 (IPv4 /16, IPv6 /48보다 넓은 대역은 거부)
+func normalizeBanTarget(target string) (string, error) {
+	normalized, err := normalizeIPOrCIDR(target)
+	if err != nil {
+		return "", err
+	}
+
+	if _, network, err := net.ParseCIDR(normalized); err == nil {
+		ones, bits := network.Mask.Size()
+		minPrefix := minBanPrefixIPv6
+		if bits == 32 {
+			minPrefix = minBanPrefixIPv4
+		}
+		if ones < minPrefix {
+			return "", fmt.Errorf("CIDR %s is too broad to ban (minimum prefix /%d)", normalized, minPrefix)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeIPOrCIDR IP 또는 CIDR 검증 및 정규화
+func normalizeIPOrCIDR(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if ip := net.ParseIP(target); ip != nil {
+		return ip.String(), nil
+	}
+	if _, network, err := net.ParseCIDR(target); err == nil {
+		return network.String(), nil
+	}
+	return "", fmt.Errorf("invalid IP address or CIDR: %s", target)
+}
+
+// validateExemptIPs 예외 목록은 사내 대역 같은 넓은 CIDR도 허용
+func validateExemptIPs(entries []string) error {
+	for _, entry := range entries {
+		if _, err := normalizeIPOrCIDR(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ipInList IP가 목록의 IP 또는 CIDR에 포함되는지 확인
+func ipInList(ipStr string, entries []string) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range entries {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if other := net.ParseIP(entry); other != nil && other.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func banPolicyToModel(policy *dto.BanPolicy) *models.BanPolicy {
+	filter, _ := json.Marshal(policy.Filter)
+	return &models.BanPolicy{
+		ID:            policy.ID,
+		Name:          policy.Name,
+		Filter:        string(filter),
+		Threshold:     policy.Threshold,
+		WindowMinutes: policy.WindowMinutes,
+		BanMinutes:    policy.BanMinutes,
+		ExemptIPs:     encodeStrings(policy.ExemptIPs),
+		Enabled:       policy.Enabled,
+		UserID:        policy.UserID,
+		CreatedAt:     policy.CreatedAt,
+		UpdatedAt:     policy.UpdatedAt,
+	}
+}
+
+func banPolicyFromModel(model *models.BanPolicy) *dto.BanPolicy {
+	policy := &dto.BanPolicy{
+		ID:            model.ID,
+		Name:          model.Name,
+		Threshold:     model.Threshold,
+		WindowMinutes: model.WindowMinutes,
+		BanMinutes:    model.BanMinutes,
+		ExemptIPs:     decodeStrings(model.ExemptIPs),
+		Enabled:       model.Enabled,
+		UserID:        model.UserID,
+		CreatedAt:     model.CreatedAt,
+		UpdatedAt:     model.UpdatedAt,
+	}
+	if model.Filter != "" {
+		json.Unmarshal([]byte(model.Filter), &policy.Filter)
+	}
+	return policy
+}
+
+func ipBanToModel(ban *dto.IPBan) *models.IPBan {
+	return &models.IPBan{
+		ID:        ban.ID,
+		IP:        ban.IP,
+		Reason:    ban.Reason,
+		PolicyID:  ban.PolicyID,
+		CreatedBy: ban.CreatedBy,
+		CreatedAt: ban.CreatedAt,
+		ExpiresAt: ban.ExpiresAt,
+	}
+}
+
+func ipBanFromModel(model *models.IPBan) *dto.IPBan {
+	return &dto.IPBan{
+		ID:        model.ID,
+		IP:        model.IP,
+		Reason:    model.Reason,
+		PolicyID:  model.PolicyID,
+		CreatedBy: model.CreatedBy,
+		CreatedAt: model.CreatedAt,
+		ExpiresAt: model.ExpiresAt,
+	}
+}
+
+func generateBanPolicyID() string {
+	return fmt.Sprintf("ban_policy_%d", time.Now().UnixNano())
+}
+
+func generateBanID() string {
+	return fmt.Sprintf("ban_%d", time.Now().UnixNano())
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// newTestBanService 배포 루프와 로그 리스너 없이 DB에서 로드한 BanService
+func newTestBanService(t *testing.T, db *gorm.DB) *BanService {
+	t.Helper()
+	s := &BanService{
+		log:           newTestLogger(),
+		db:            db,
+		policies:      make(map[string]*dto.BanPolicy),
+		bans:          make(map[string]*dto.IPBan),
+		windows:       make(map[string][]time.Time),
+		deployPending: make(chan struct{}, 1),
+	}
+	s.load()
+	return s
+}
+
+func TestNormalizeBanTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		want    string
+		wantErr string
+	}{
+		{"203.0.113.7", "203.0.113.7", ""},
+		{" 203.0.113.7 ", "203.0.113.7", ""},
+		{"203.0.113.0/24", "203.0.113.0/24", ""},
+		{"203.0.113.9/24", "203.0.113.0/24", ""},
+		{"10.1.0.0/16", "10.1.0.0/16", ""},
+		{"10.0.0.0/8", "", "too broad"},
+		{"0.0.0.0/0", "", "too broad"},
+		{"2001:db8::1", "2001:db8::1", ""},
+		{"2001:db8:1::/48", "2001:db8:1::/48", ""},
+		{"2001:db8::/32", "", "too broad"},
+		{"not-an-ip", "", "invalid IP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := normalizeBanTarget(tt.target)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("normalizeBanTarget() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("normalizeBanTarget() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPInList(t *testing.T) {
+	tests := []struct {
+		ip      string
+		entries []string
+		want    bool
+	}{
+		{"203.0.113.7", []string{"203.0.113.7"}, true},
+		{"203.0.113.7", []string{"203.0.113.0/24"}, true},
+		{"203.0.114.7", []string{"203.0.113.0/24"}, false},
+		{"2001:db8::1", []string{"2001:db8::/64"}, true},
+		{"bad", []string{"0.0.0.0/0"}, false},
+		{"203.0.113.7", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := ipInList(tt.ip, tt.entries); got != tt.want {
+				t.Errorf("ipInList(%q, %v) = %v, want %v", tt.ip, tt.entries, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBanServiceCreateBan(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		callerIP string
+		wantErr  string
+	}{
+		{"single ip", "203.0.113.7", "198.51.100.1", ""},
+		{"cidr", "203.0.113.0/24", "198.51.100.1", ""},
+		{"own ip", "198.51.100.1", "198.51.100.1", "your own IP"},
+		{"range with own ip", "198.51.100.0/24", "198.51.100.1", "your own IP"},
+		{"too broad", "198.0.0.0/8", "203.0.113.1", "too broad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestBanService(t, newTestDB(t, &models.BanPolicy{}, &models.IPBan{}))
+			ban, err := s.CreateBan("user_a", tt.callerIP, &dto.IPBanRequest{IP: tt.target, DurationMinutes: 10})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CreateBan() error = %v, want %q", err, tt.wantErr)
+				}
+				if len(s.GetBans()) != 0 {
+					t.Error("rejected ban is listed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateBan() error = %v", err)
+			}
+			if ban.Reason != "Manual ban" || ban.CreatedBy != "user:user_a" {
+				t.Errorf("ban = %+v", ban)
+			}
+			if len(s.deployPending) != 1 {
+				t.Error("new ban did not schedule a deploy")
+			}
+		})
+	}
+}
+
+func TestBanServiceRejectsBansWhenListIsFull(t *testing.T) {
+	s := newTestBanService(t, newTestDB(t, &models.BanPolicy{}, &models.IPBan{}))
+	for i := 0; i < maxActiveBans; i++ {
+		id := fmt.Sprintf("ban_%d", i)
+		s.bans[id] = &dto.IPBan{ID: id, IP: fmt.Sprintf("10.%d.%d.1", i/256, i%256), ExpiresAt: time.Now().Add(time.Hour)}
+	}
+
+	if _, err := s.CreateBan("user_a", "198.51.100.1", &dto.IPBanRequest{IP: "203.0.113.7", DurationMinutes: 10}); err == nil || !strings.Contains(err.Error(), "ban list is full") {
+		t.Errorf("CreateBan() error = %v, want ban list is full", err)
+	}
+	// 이미 차단된 IP는 연장만 하므로 허용
+	if _, err := s.CreateBan("user_a", "198.51.100.1", &dto.IPBanRequest{IP: "10.0.0.1", DurationMinutes: 120}); err != nil {
+		t.Errorf("CreateBan(existing ip) error = %v", err)
+	}
+}
+
+func TestBanServiceEvaluate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  dto.BanPolicy
+		event   dto.WAFLog
+		events  int
+		wantBan bool
+	}{
+		{"threshold exceeded", dto.BanPolicy{Threshold: 3, WindowMinutes: 5, BanMinutes: 60, Enabled: true}, dto.WAFLog{ClientIP: "203.0.113.7"}, 4, true},
+		{"threshold not exceeded", dto.BanPolicy{Threshold: 3, WindowMinutes: 5, BanMinutes: 60, Enabled: true}, dto.WAFLog{ClientIP: "203.0.113.7"}, 3, false},
+		{"exempt ip", dto.BanPolicy{Threshold: 1, WindowMinutes: 5, BanMinutes: 60, Enabled: true, ExemptIPs: []string{"203.0.113.0/24"}}, dto.WAFLog{ClientIP: "203.0.113.7"}, 5, false},
+		{"filter mismatch", dto.BanPolicy{Threshold: 1, WindowMinutes: 5, BanMinutes: 60, Enabled: true, Filter: dto.AlertFilter{BlockedOnly: true}}, dto.WAFLog{ClientIP: "203.0.113.7"}, 5, false},
+		{"disabled", dto.BanPolicy{Threshold: 1, WindowMinutes: 5, BanMinutes: 60}, dto.WAFLog{ClientIP: "203.0.113.7"}, 5, false},
+		{"invalid client ip", dto.BanPolicy{Threshold: 1, WindowMinutes: 5, BanMinutes: 60, Enabled: true}, dto.WAFLog{ClientIP: "unknown"}, 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestBanService(t, newTestDB(t, &models.BanPolicy{}, &models.IPBan{}))
+			policy := tt.policy
+			policy.ID, policy.Name = "policy", "bursts"
+			s.policies[policy.ID] = &policy
+
+			for i := 0; i < tt.events; i++ {
+				s.evaluate(tt.event)
+			}
+
+			bans := s.GetBans()
+			if (len(bans) == 1) != tt.wantBan {
+				t.Fatalf("bans = %+v, want ban %v", bans, tt.wantBan)
+			}
+			if tt.wantBan && (bans[0].IP != tt.event.ClientIP || bans[0].PolicyID != "policy" || bans[0].Reason != "bursts: 4 matching events within 5 minutes") {
+				t.Errorf("ban = %+v", bans[0])
+			}
+		})
+	}
+}
+
+func TestRenderBanRules(t *testing.T) {
+	tests := []struct {
+		name string
+		ips  int
+		want []string
+	}{
+		{"no bans", 0, nil},
+		{"one rule", 2, []string{`SecRule REMOTE_ADDR "@ipMatch 10.0.0.0,10.0.0.1" "id:9900,phase:1,deny,status:403,log,msg:'Temporarily banned IP (auto-ban)',tag:'waf-saas/auto-ban'"`}},
+		{"split into rules", banIPsPerRule + 1, []string{"id:9900,", "id:9901,"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestBanService(t, newTestDB(t, &models.BanPolicy{}, &models.IPBan{}))
+			for i := 0; i < tt.ips; i++ {
+				id := fmt.Sprintf("ban_%d", i)
+				s.bans[id] = &dto.IPBan{ID: id, IP: fmt.Sprintf("10.0.%d.%d", i/100, i%100), ExpiresAt: time.Now().Add(time.Hour)}
+			}
+			// 만료된 차단은 렌더링하지 않음
+			s.bans["expired"] = &dto.IPBan{ID: "expired", IP: "192.0.2.1", ExpiresAt: time.Now().Add(-time.Minute)}
+
+			got := s.renderBanRules()
+			lines := strings.Split(got, "\n")
+			if got == "" {
+				lines = nil
+			}
+			if len(lines) != len(tt.want) {
+				t.Fatalf("renderBanRules() =\n%s\nwant %d rules", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(lines[i], want) {
+					t.Errorf("rule %d = %s, want %s", i, lines[i], want)
+				}
+			}
+			if strings.Contains(got, "192.0.2.1") {
+				t.Error("expired ban is rendered")
+			}
+		})
+	}
+}
+
+// 정책과 만료되지 않은 차단이 새 서비스에서 그대로 로드됨
+func TestBanServicePersistence(t *testing.T) {
+	db := newTestDB(t, &models.BanPolicy{}, &models.IPBan{})
+	s := newTestBanService(t, db)
+
+	policy, err := s.CreatePolicy("user_a", &dto.BanPolicyRequest{
+		Name: "bursts", Filter: dto.AlertFilter{Severities: []string{"CRITICAL"}},
+		Threshold: 5, WindowMinutes: 2, BanMinutes: 30, ExemptIPs: []string{"10.0.0.0/8"}, Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("CreatePolicy() error = %v", err)
+	}
+	ban, err := s.CreateBan("user_a", "198.51.100.1", &dto.IPBanRequest{IP: "203.0.113.7", Reason: "scanner", DurationMinutes: 10})
+	if err != nil {
+		t.Fatalf("CreateBan() error = %v", err)
+	}
+	if _, err := s.ExtendBan(ban.ID, 20); err != nil {
+		t.Fatalf("ExtendBan() error = %v", err)
+	}
+	expired := &dto.IPBan{ID: "ban_expired", IP: "192.0.2.1", CreatedAt: time.Now().Add(-time.Hour), ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := db.Create(ipBanToModel(expired)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := newTestBanService(t, db)
+	policies := reloaded.GetPolicies()
+	if len(policies) != 1 {
+		t.Fatalf("reloaded policies = %d, want 1", len(policies))
+	}
+	policy.CreatedAt, policy.UpdatedAt = policies[0].CreatedAt, policies[0].UpdatedAt
+	if !reflect.DeepEqual(policies[0], policy) {
+		t.Errorf("reloaded policy = %+v, want %+v", policies[0], policy)
+	}
+
+	bans := reloaded.GetBans()
+	if len(bans) != 1 || bans[0].ID != ban.ID || bans[0].Reason != "scanner" {
+		t.Fatalf("reloaded bans = %+v, want only %s", bans, ban.ID)
+	}
+	if remaining := time.Until(bans[0].ExpiresAt); remaining < 29*time.Minute {
+		t.Errorf("extended ban expires in %v, want about 30m", remaining)
+	}
+	var count int64
+	db.Model(&models.IPBan{}).Where("id = ?", expired.ID).Count(&count)
+	if count != 0 {
+		t.Error("expired ban was not deleted on load")
+	}
+
+	if err := reloaded.LiftBan(ban.ID); err != nil {
+		t.Fatalf("LiftBan() error = %v", err)
+	}
+	if bans := newTestBanService(t, db).GetBans(); len(bans) != 0 {
+		t.Errorf("lifted ban is still loaded: %+v", bans)
+	}
+}
@@ -0,0 +1,557 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/tracing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+const (
+	// 경로 예외 룰 ID 범위: 예외마다 하나 (만들 때 할당, 예외가 있는 동안 바뀌지 않음)
+	bypassRuleIDBase = 96100
+	bypassRuleIDMax  = 96999
+	maxBypasses      = bypassRuleIDMax - bypassRuleIDBase + 1
+
+	maxBypassRuleIDs = 50
+	maxBypassTags    = 20
+
+	BypassStatusActive   = "active"
+	BypassStatusDisabled = "disabled"
+	BypassStatusExpired  = "expired"
+)
+
+var (
+	bypassMethodPattern = regexp.MustCompile(`^[A-Z]+$`)
+	bypassTagPattern    = regexp.MustCompile(`^[A-Za-z0-9_\-./]+$`)
+)
+
+// defaultBypasses 예전 기본 설정에 고정되어 있던 예외. 처음 시작할 때 한 번만 만들어서 보이고 지울 수 있게 함
+// /api/는 엔진을 끄지 않고 CRS 기본 허용 메서드(GET, HEAD, POST, OPTIONS) 밖의 메서드 차단 룰만 끔
+var defaultBypasses = []models.PathBypass{
+	{ID: "bypass_default_oauth_callback", Name: "OAuth callback", PathPrefix: "/auth/callback", DisableEngine: true},
+	{ID: "bypass_default_api", Name: "Dashboard API methods", PathPrefix: "/api/", Methods: `["PUT","PATCH","DELETE"]`, RuleIDs: `[911100]`},
+}
+
+// BypassService 경로/메서드/호스트 단위 WAF 예외 (엔진 끄기 또는 룰 ID/태그만 끄기), 소유자와 만료
+type BypassService struct {
+	log         *logrus.Logger
+	db          *gorm.DB
+	ruleService *RuleService
+	bypasses    map[string]*models.PathBypass
+	lastCheck   time.Time
+	mutex       sync.RWMutex
+}
+
+func NewBypassService(log *logrus.Logger, db *gorm.DB, ruleService *RuleService) *BypassService {
+	service := &BypassService{
+		log:         log,
+		db:          db,
+		ruleService: ruleService,
+		bypasses:    make(map[string]*models.PathBypass),
+		lastCheck:   time.Now(),
+	}
+
+	service.seedDefaults()
+	service.narrowDefaultAPIBypass()
+
+	var bypasses []*models.PathBypass
+	if err := db.Find(&bypasses).Error; err != nil {
+		log.WithError(err).Error("Failed to load path bypasses")
+	}
+	for _, bypass := range bypasses {
+		service.bypasses[bypass.ID] = bypass
+	}
+	service.assignModsecIDs()
+
+	// 커스텀 룰과 CRS보다 먼저 실행되도록 crs-tuning.conf에 배포
+	// 엔진 모드 룰보다 뒤에 등록해야 호스트 모드가 예외의 ctl:ruleEngine=Off를 덮어쓰지 않음
+	ruleService.RegisterCRSSetup("Path bypasses", service.renderBypasses)
+
+	// 만료된 예외를 배포에서 제거
+	go service.monitorExpirations()
+
+	return service
+}
+
+// seedDefaults 예외가 한 번도 없었으면 (삭제된 것 포함) 기본 예외 생성
+func (s *BypassService) seedDefaults() {
+	var total int64
+	if err := s.db.Unscoped().Model(&models.PathBypass{}).Count(&total).Error; err != nil || total > 0 {
+		return
+	}
+	for _, bypass := range defaultBypasses {
+		bypass.Reason = "Migrated from the built-in base configuration"
+		bypass.Owner = "system"
+		bypass.Enabled = true
+		bypass.CreatedBy = "system"
+		if err := s.db.Create(&bypass).Error; err != nil {
+			s.log.WithError(err).WithField("path_prefix", bypass.PathPrefix).Error("Failed to create default path bypass")
+		}
+	}
+	s.log.Warn("Created default path bypasses for /auth/callback and /api/, review them under /api/v1/bypasses")
+}
+
+// narrowDefaultAPIBypass 예전에 엔진 끄기로 만들어진 /api/ 기본 예외를 아무도 수정하지 않았으면 룰 ID 예외로 좁힘
+func (s *BypassService) narrowDefaultAPIBypass() {
+	var api models.PathBypass
+	for _, bypass := range defaultBypasses {
+		if bypass.ID == "bypass_default_api" {
+			api = bypass
+		}
+	}
+
+	result := s.db.Model(&models.PathBypass{}).
+		Where("id = ? AND disable_engine = ? AND created_by = ? AND (updated_by = '' OR updated_by IS NULL)", api.ID, true, "system").
+		Select("name", "methods", "disable_engine", "rule_ids").
+		Updates(&api)
+	if result.Error != nil {
+		s.log.WithError(result.Error).Error("Failed to narrow the default /api/ path bypass")
+		return
+	}
+	if result.RowsAffected > 0 {
+		s.log.Warn("Default /api/ path bypass no longer disables the engine, it now only removes rule 911100 for PUT, PATCH and DELETE")
+	}
+}
+
+// assignModsecIDs ID가 없는 예외(이전 버전에서 만든 것)에 생성 순서대로 룰 ID 할당
+func (s *BypassService) assignModsecIDs() {
+	for _, bypass := range s.sortedLocked() {
+		if bypass.ModsecID != 0 {
+			continue
+		}
+		id, err := s.allocateModsecIDLocked()
+		if err != nil {
+			s.log.WithError(err).WithField("bypass_id", bypass.ID).Error("Failed to assign rule ID to path bypass")
+			continue
+		}
+		if err := s.db.Model(bypass).UpdateColumn("modsec_id", id).Error; err != nil {
+			s.log.WithError(err).WithField("bypass_id", bypass.ID).Error("Failed to save path bypass rule ID")
+			continue
+		}
+		bypass.ModsecID = id
+	}
+}
+
+// allocateModsecIDLocked 남아 있는 예외가 쓰지 않는 가장 작은 룰 ID (삭제된 예외의 ID는 다시 쓸 수 있음)
+func (s *BypassService) allocateModsecIDLocked() (int, error) {
+	used := make(map[int]bool, len(s.bypasses))
+	for _, bypass := range s.bypasses {
+		used[bypass.ModsecID] = true
+	}
+	for id := bypassRuleIDBase; id <= bypassRuleIDMax; id++ {
+		if !used[id] {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("at most %d bypasses are allowed", maxBypasses)
+}
+
+// GetBypasses 예외 목록 (생성 순서)
+func (s *BypassService) GetBypasses() []dto.Bypass {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	ruleTexts := s.renderedLocked(now)
+	result := make([]dto.Bypass, 0, len(s.bypasses))
+	for _, bypass := range s.sortedLocked() {
+		result = append(result, bypassToResponse(bypass, now, ruleTexts[bypass.ID]))
+	}
+	return result
+}
+
+func (s *BypassService) GetBypass(id string) (*dto.Bypass, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	bypass, exists := s.bypasses[id]
+	if !exists {
+		return nil, fmt.Errorf("bypass not found")
+	}
+	now := time.Now()
+	response := bypassToResponse(bypass, now, s.renderedLocked(now)[id])
+	return &response, nil
+}
+
+// CreateBypass 예외를 만들고 배포. owner를 비우면 요청한 사용자
+func (s *BypassService) CreateBypass(ctx context.Context, userID, userEmail string, req *dto.BypassRequest) (*dto.Bypass, error) {
+	ctx, span := tracing.Start(ctx, "BypassService.CreateBypass", attribute.String("path_prefix", req.PathPrefix))
+	defer span.End()
+
+	bypass, err := bypassFromRequest(req, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	bypass.ID = fmt.Sprintf("bypass_%d", time.Now().UnixNano())
+	bypass.CreatedBy = userID
+	bypass.UpdatedBy = userID
+
+	s.mutex.Lock()
+	modsecID, err := s.allocateModsecIDLocked()
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
+	bypass.ModsecID = modsecID
+	if err := s.db.WithContext(ctx).Create(bypass).Error; err != nil {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("failed to save bypass: %w", err)
+	}
+	s.bypasses[bypass.ID] = bypass
+	s.mutex.Unlock()
+
+	s.redeploy(ctx)
+
+	s.log.WithFields(logrus.Fields{
+		"user_id":        userID,
+		"bypass_id":      bypass.ID,
+		"path_prefix":    bypass.PathPrefix,
+		"disable_engine": bypass.DisableEngine,
+		"expires_at":     bypass.ExpiresAt,
+	}).Warn("WAF path bypass created")
+
+	return s.GetBypass(bypass.ID)
+}
+
+// UpdateBypass 예외 전체를 교체하고 배포
+func (s *BypassService) UpdateBypass(ctx context.Context, userID, userEmail, id string, req *dto.BypassRequest) (*dto.Bypass, error) {
+	ctx, span := tracing.Start(ctx, "BypassService.UpdateBypass", attribute.String("bypass_id", id))
+	defer span.End()
+
+	updated, err := bypassFromRequest(req, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	current, exists := s.bypasses[id]
+	if !exists {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("bypass not found")
+	}
+	updated.ID = id
+	updated.ModsecID = current.ModsecID
+	updated.CreatedBy = current.CreatedBy
+	updated.CreatedAt = current.CreatedAt
+	updated.UpdatedBy = userID
+	if err := s.db.WithContext(ctx).Save(updated).Error; err != nil {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("failed to save bypass: %w", err)
+	}
+	s.bypasses[id] = updated
+	s.mutex.Unlock()
+
+	s.redeploy(ctx)
+
+	s.log.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"bypass_id":   id,
+		"path_prefix": updated.PathPrefix,
+		"enabled":     updated.Enabled,
+	}).Warn("WAF path bypass updated")
+
+	return s.GetBypass(id)
+}
+
+func (s *BypassService) DeleteBypass(ctx context.Context, userID, id string) error {
+	ctx, span := tracing.Start(ctx, "BypassService.DeleteBypass", attribute.String("bypass_id", id))
+	defer span.End()
+
+	s.mutex.Lock()
+	bypass, exists := s.bypasses[id]
+	if !exists {
+		s.mutex.Unlock()
+		return fmt.Errorf("bypass not found")
+	}
+	// 누가 지웠는지 남긴 뒤 soft delete
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(bypass).Update("updated_by", userID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(bypass).Error
+	}); err != nil {
+		s.mutex.Unlock()
+		return fmt.Errorf("failed to delete bypass: %w", err)
+	}
+	delete(s.bypasses, id)
+	s.mutex.Unlock()
+
+	s.redeploy(ctx)
+
+	s.log.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"bypass_id":   id,
+		"path_prefix": bypass.PathPrefix,
+	}).Warn("WAF path bypass deleted")
+	return nil
+}
+
+func (s *BypassService) monitorExpirations() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.checkExpirations()
+	}
+}
+
+// checkExpirations 지난 확인 이후 만료된 활성 예외가 있으면 재배포 (렌더링에서 빠짐)
+func (s *BypassService) checkExpirations() {
+	now := time.Now()
+
+	s.mutex.Lock()
+	var expired []string
+	for _, bypass := range s.bypasses {
+		if bypass.Enabled && bypass.ExpiresAt != nil && bypass.ExpiresAt.After(s.lastCheck) && !bypass.ExpiresAt.After(now) {
+			expired = append(expired, bypass.ID)
+		}
+	}
+	s.lastCheck = now
+	s.mutex.Unlock()
+
+	if len(expired) > 0 {
+		s.log.WithField("bypass_ids", expired).Warn("WAF path bypasses expired, removing from deployment")
+		s.redeploy(context.Background())
+	}
+}
+
+// redeploy 렌더링 시 RuleService가 이 서비스의 락을 잡으므로 락을 놓은 뒤 호출
+func (s *BypassService) redeploy(ctx context.Context) {
+	if err := s.ruleService.Redeploy(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to deploy path bypasses")
+	}
+}
+
+func (s *BypassService) renderBypasses() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rendered := s.renderedLocked(time.Now())
+	var b strings.Builder
+	for _, bypass := range s.sortedLocked() {
+		b.WriteString(rendered[bypass.ID])
+	}
+	return b.String()
+}
+
+// renderedLocked 활성 예외의 룰 (ID가 할당되지 않은 예외는 배포하지 않음)
+func (s *BypassService) renderedLocked(now time.Time) map[string]string {
+	result := make(map[string]string)
+	for _, bypass := range s.sortedLocked() {
+		if bypassStatus(bypass, now) != BypassStatusActive || bypass.ModsecID == 0 {
+			continue
+		}
+		result[bypass.ID] = renderBypass(bypass, bypass.ModsecID)
+	}
+	return result
+}
+
+func (s *BypassService) sortedLocked() []*models.PathBypass {
+	bypasses := make([]*models.PathBypass, 0, len(s.bypasses))
+	for _, bypass := range s.bypasses {
+		bypasses = append(bypasses, bypass)
+	}
+	sort.Slice(bypasses, func(i, j int) bool {
+		if !bypasses[i].CreatedAt.Equal(bypasses[j].CreatedAt) {
+			return bypasses[i].CreatedAt.Before(bypasses[j].CreatedAt)
+		}
+		return bypasses[i].ID < bypasses[j].ID
+	})
+	return bypasses
+}
+
+// renderBypass 경로 → 메서드 → 호스트 순서의 chain, 마지막 룰에 ctl 액션
+func renderBypass(bypass *models.PathBypass, id int) string {
+	conditions := []string{fmt.Sprintf("REQUEST_FILENAME \"@beginsWith %s\"", bypass.PathPrefix)}
+	if methods := decodeStrings(bypass.Methods); len(methods) > 0 {
+		conditions = append(conditions, fmt.Sprintf("REQUEST_METHOD \"@rx ^(?:%s)$\"", strings.Join(methods, "|")))
+	}
+	if bypass.Host != "" {
+		conditions = append(conditions, fmt.Sprintf("REQUEST_HEADERS:Host \"@rx %s\"", hostRegex([]string{bypass.Host})))
+	}
+
+	var actions []string
+	if bypass.DisableEngine {
+		actions = append(actions, "ctl:ruleEngine=Off")
+	}
+	for _, ruleID := range decodeInts(bypass.RuleIDs) {
+		actions = append(actions, fmt.Sprintf("ctl:ruleRemoveById=%d", ruleID))
+	}
+	for _, tag := range decodeStrings(bypass.Tags) {
+		actions = append(actions, "ctl:ruleRemoveByTag="+tag)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Bypass: %s (owner: %s", singleLine(bypass.Name), singleLine(bypass.Owner))
+	if bypass.ExpiresAt != nil {
+		fmt.Fprintf(&b, ", expires: %s", bypass.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	b.WriteString(")\n")
+	for i, condition := range conditions {
+		var ruleActions []string
+		if i == 0 {
+			ruleActions = append(ruleActions, fmt.Sprintf("id:%d", id), "phase:1", "pass", "nolog")
+		} else {
+			b.WriteString("    ")
+		}
+		if strings.HasPrefix(condition, "REQUEST_HEADERS:Host") {
+			ruleActions = append(ruleActions, "t:lowercase")
+		}
+		if i < len(conditions)-1 {
+			ruleActions = append(ruleActions, "chain")
+		} else {
+			ruleActions = append(ruleActions, actions...)
+		}
+		fmt.Fprintf(&b, "SecRule %s \"%s\"\n", condition, strings.Join(ruleActions, ","))
+	}
+	return b.String()
+}
+
+// bypassFromRequest 요청 검증과 정리 (호스트 소문자, 메서드 대문자, 중복 제거)
+func bypassFromRequest(req *dto.BypassRequest, userEmail string) (*models.PathBypass, error) {
+	host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(req.Host)), ".")
+	if host != "" && !targetHostPattern.MatchString(host) {
+		return nil, fmt.Errorf("invalid host %q", req.Host)
+	}
+	pathPrefix := strings.TrimSpace(req.PathPrefix)
+	if !targetPathPattern.MatchString(pathPrefix) {
+		return nil, fmt.Errorf("invalid path_prefix %q", req.PathPrefix)
+	}
+
+	var methods []string
+	for _, method := range req.Methods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if !bypassMethodPattern.MatchString(method) {
+			return nil, fmt.Errorf("invalid method %q", method)
+		}
+		if !containsString(methods, method) {
+			methods = append(methods, method)
+		}
+	}
+
+	ruleIDs := uniqueSortedInts(req.RuleIDs)
+	if len(ruleIDs) > maxBypassRuleIDs {
+		return nil, fmt.Errorf("at most %d rule_ids are allowed", maxBypassRuleIDs)
+	}
+	for _, id := range ruleIDs {
+		if id <= 0 {
+			return nil, fmt.Errorf("invalid rule id %d", id)
+		}
+	}
+
+	var tags []string
+	for _, tag := range req.Tags {
+		tag = strings.TrimSpace(tag)
+		if !bypassTagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		if !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxBypassTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxBypassTags)
+	}
+
+	// 엔진 끄기와 부분 예외 중 하나만
+	if req.DisableEngine && (len(ruleIDs) > 0 || len(tags) > 0) {
+		return nil, fmt.Errorf("disable_engine cannot be combined with rule_ids or tags")
+	}
+	if !req.DisableEngine && len(ruleIDs) == 0 && len(tags) == 0 {
+		return nil, fmt.Errorf("either disable_engine or at least one rule id or tag is required")
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+
+	owner := strings.TrimSpace(req.Owner)
+	if owner == "" {
+		owner = userEmail
+	}
+	if owner == "" {
+		return nil, fmt.Errorf("owner is required")
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &models.PathBypass{
+		Name:          strings.TrimSpace(req.Name),
+		Host:          host,
+		PathPrefix:    pathPrefix,
+		Methods:       encodeStrings(methods),
+		DisableEngine: req.DisableEngine,
+		RuleIDs:       encodeInts(ruleIDs),
+		Tags:          encodeStrings(tags),
+		Reason:        req.Reason,
+		Owner:         owner,
+		Enabled:       enabled,
+		ExpiresAt:     req.ExpiresAt,
+	}, nil
+}
+
+func bypassStatus(bypass *models.PathBypass, now time.Time) string {
+	switch {
+	case !bypass.Enabled:
+		return BypassStatusDisabled
+	case bypass.ExpiresAt != nil && !bypass.ExpiresAt.After(now):
+		return BypassStatusExpired
+	default:
+		return BypassStatusActive
+	}
+}
+
+func bypassToResponse(bypass *models.PathBypass, now time.Time, ruleText string) dto.Bypass {
+	return dto.Bypass{
+		ID:            bypass.ID,
+		Name:          bypass.Name,
+		Host:          bypass.Host,
+		PathPrefix:    bypass.PathPrefix,
+		ModsecID:      bypass.ModsecID,
+		Methods:       decodeStrings(bypass.Methods),
+		DisableEngine: bypass.DisableEngine,
+		RuleIDs:       decodeInts(bypass.RuleIDs),
+		Tags:          decodeStrings(bypass.Tags),
+		Reason:        bypass.Reason,
+		Owner:         bypass.Owner,
+		Enabled:       bypass.Enabled,
+		ExpiresAt:     bypass.ExpiresAt,
+		Status:        bypassStatus(bypass, now),
+		RuleText:      ruleText,
+		CreatedBy:     bypass.CreatedBy,
+		UpdatedBy:     bypass.UpdatedBy,
+		CreatedAt:     bypass.CreatedAt,
+		UpdatedAt:     bypass.UpdatedAt,
+	}
+}
+
+func encodeInts(values []int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+func decodeInts(encoded string) []int {
+	var values []int
+	if encoded != "" {
+		json.Unmarshal([]byte(encoded), &values)
+	}
+	return values
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"gorm.io/gorm"
+)
+
+func newTestBypassService(t *testing.T, db *gorm.DB) *BypassService {
+	t.Helper()
+	if err := db.AutoMigrate(&models.PathBypass{}); err != nil {
+		t.Fatalf("failed to migrate path bypasses: %v", err)
+	}
+	return NewBypassService(newTestLogger(), db, newTestRuleService(t, db, nil))
+}
+
+func TestRenderBypass(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		bypass models.PathBypass
+		want   string
+	}{
+		{
+			name:   "engine off",
+			bypass: models.PathBypass{Name: "OAuth callback", Owner: "system", PathPrefix: "/auth/callback", DisableEngine: true},
+			want:   "# Bypass: OAuth callback (owner: system)\nSecRule REQUEST_FILENAME \"@beginsWith /auth/callback\" \"id:96100,phase:1,pass,nolog,ctl:ruleEngine=Off\"\n",
+		},
+		{
+			name:   "rules and tags for methods on host",
+			bypass: models.PathBypass{Name: "uploads", Owner: "a@example.com", Host: "shop.example.com", PathPrefix: "/upload", Methods: `["PUT","POST"]`, RuleIDs: `[920420,942100]`, Tags: `["attack-sqli"]`, ExpiresAt: &expires},
+			want: "# Bypass: uploads (owner: a@example.com, expires: 2030-01-02T03:04:05Z)\n" +
+				"SecRule REQUEST_FILENAME \"@beginsWith /upload\" \"id:96100,phase:1,pass,nolog,chain\"\n" +
+				"    SecRule REQUEST_METHOD \"@rx ^(?:PUT|POST)$\" \"chain\"\n" +
+				"    SecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"t:lowercase,ctl:ruleRemoveById=920420,ctl:ruleRemoveById=942100,ctl:ruleRemoveByTag=attack-sqli\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderBypass(&tt.bypass, bypassRuleIDBase); got != tt.want {
+				t.Errorf("renderBypass() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBypassFromRequest(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name    string
+		req     dto.BypassRequest
+		email   string
+		wantErr string
+	}{
+		{"valid", dto.BypassRequest{Name: "api", Host: "Shop.Example.com.", PathPrefix: "/api/", Methods: []string{"put", "PUT"}, RuleIDs: []int{911100}}, "a@example.com", ""},
+		{"invalid host", dto.BypassRequest{Host: "shop example", PathPrefix: "/api/", DisableEngine: true}, "a@example.com", "invalid host"},
+		{"quoted path", dto.BypassRequest{PathPrefix: "/api\" \"id:1", DisableEngine: true}, "a@example.com", "invalid path_prefix"},
+		{"relative path", dto.BypassRequest{PathPrefix: "api", DisableEngine: true}, "a@example.com", "invalid path_prefix"},
+		{"invalid method", dto.BypassRequest{PathPrefix: "/api/", Methods: []string{"GET|POST"}, DisableEngine: true}, "a@example.com", "invalid method"},
+		{"invalid tag", dto.BypassRequest{PathPrefix: "/api/", Tags: []string{"a,ctl:ruleEngine=Off"}}, "a@example.com", "invalid tag"},
+		{"engine and rules", dto.BypassRequest{PathPrefix: "/api/", DisableEngine: true, RuleIDs: []int{942100}}, "a@example.com", "cannot be combined"},
+		{"nothing to bypass", dto.BypassRequest{PathPrefix: "/api/"}, "a@example.com", "either disable_engine"},
+		{"expired", dto.BypassRequest{PathPrefix: "/api/", DisableEngine: true, ExpiresAt: &past}, "a@example.com", "in the future"},
+		{"no owner", dto.BypassRequest{PathPrefix: "/api/", DisableEngine: true}, "", "owner is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bypass, err := bypassFromRequest(&tt.req, tt.email)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("bypassFromRequest() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("bypassFromRequest() error = %v", err)
+			}
+			if bypass.Host != "shop.example.com" || bypass.Methods != `["PUT"]` || bypass.Owner != tt.email || !bypass.Enabled {
+				t.Errorf("bypassFromRequest() = %+v", bypass)
+			}
+		})
+	}
+}
+
+func TestBypassServiceDefaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   *models.PathBypass
+		wantEngine bool // /api/ 기본 예외가 엔진을 끄는지
+		wantID     int
+	}{
+		{"fresh install", nil, false, bypassRuleIDBase + 1},
+		{"old engine bypass is narrowed", &models.PathBypass{ID: "bypass_default_api", Name: "API", PathPrefix: "/api/", DisableEngine: true, Owner: "system", Enabled: true, CreatedBy: "system"}, false, bypassRuleIDBase},
+		{"edited engine bypass is kept", &models.PathBypass{ID: "bypass_default_api", Name: "API", PathPrefix: "/api/", DisableEngine: true, Owner: "system", Enabled: true, CreatedBy: "system", UpdatedBy: "admin"}, true, bypassRuleIDBase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, &models.PathBypass{})
+			if tt.existing != nil {
+				if err := db.Create(tt.existing).Error; err != nil {
+					t.Fatalf("failed to seed bypass: %v", err)
+				}
+			}
+			s := newTestBypassService(t, db)
+
+			api, err := s.GetBypass("bypass_default_api")
+			if err != nil {
+				t.Fatalf("GetBypass() error = %v", err)
+			}
+			if api.DisableEngine != tt.wantEngine || api.ModsecID != tt.wantID {
+				t.Errorf("default /api/ bypass = %+v", api)
+			}
+			if tt.wantEngine {
+				return
+			}
+			if strings.Join(api.Methods, ",") != "PUT,PATCH,DELETE" || len(api.RuleIDs) != 1 || api.RuleIDs[0] != 911100 {
+				t.Errorf("default /api/ bypass = %+v, want rule 911100 for PUT, PATCH, DELETE", api)
+			}
+			if !strings.Contains(s.renderBypasses(), "ctl:ruleRemoveById=911100") {
+				t.Errorf("renderBypasses() =\n%s", s.renderBypasses())
+			}
+		})
+	}
+
+	// 기본 예외를 지운 뒤에는 다시 만들지 않음
+	db := newTestDB(t)
+	s := newTestBypassService(t, db)
+	for _, bypass := range s.GetBypasses() {
+		if err := s.DeleteBypass(context.Background(), "admin", bypass.ID); err != nil {
+			t.Fatalf("DeleteBypass() error = %v", err)
+		}
+	}
+	if got := newTestBypassService(t, db).GetBypasses(); len(got) != 0 {
+		t.Errorf("bypasses after restart = %+v, want defaults not recreated", got)
+	}
+}
+
+func TestBypassServicePersistence(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := newTestBypassService(t, db)
+	for _, bypass := range s.GetBypasses() {
+		if err := s.DeleteBypass(ctx, "admin", bypass.ID); err != nil {
+			t.Fatalf("DeleteBypass() error = %v", err)
+		}
+	}
+
+	created, err := s.CreateBypass(ctx, "user_1", "a@example.com", &dto.BypassRequest{Name: "upload", PathPrefix: "/upload", RuleIDs: []int{920420}, Reason: "large files"})
+	if err != nil {
+		t.Fatalf("CreateBypass() error = %v", err)
+	}
+	// 삭제된 예외의 룰 ID는 다시 사용
+	if created.ModsecID != bypassRuleIDBase || created.Status != BypassStatusActive || created.Owner != "a@example.com" || created.RuleText == "" {
+		t.Errorf("CreateBypass() = %+v", created)
+	}
+
+	disabled := false
+	updated, err := s.UpdateBypass(ctx, "user_2", "b@example.com", created.ID, &dto.BypassRequest{Name: "upload", PathPrefix: "/upload", RuleIDs: []int{920420}, Owner: "a@example.com", Enabled: &disabled})
+	if err != nil {
+		t.Fatalf("UpdateBypass() error = %v", err)
+	}
+	if updated.ModsecID != created.ModsecID || updated.Status != BypassStatusDisabled || updated.RuleText != "" || updated.CreatedBy != "user_1" || updated.UpdatedBy != "user_2" {
+		t.Errorf("UpdateBypass() = %+v", updated)
+	}
+	if s.renderBypasses() != "" {
+		t.Errorf("renderBypasses() = %q, want disabled bypass left out", s.renderBypasses())
+	}
+	if _, err := s.UpdateBypass(ctx, "user_2", "b@example.com", "missing", &dto.BypassRequest{Name: "x", PathPrefix: "/x", DisableEngine: true}); err == nil {
+		t.Error("UpdateBypass(missing) succeeded")
+	}
+
+	reloaded, err := newTestBypassService(t, db).GetBypass(created.ID)
+	if err != nil {
+		t.Fatalf("GetBypass() after reload error = %v", err)
+	}
+	if reloaded.ModsecID != updated.ModsecID || reloaded.Enabled || reloaded.Reason != "" || reloaded.UpdatedBy != "user_2" {
+		t.Errorf("reloaded bypass = %+v, want %+v", reloaded, updated)
+	}
+
+	// 만료된 예외는 배포에서 빠짐
+	expires := time.Now().Add(time.Hour)
+	if _, err := s.UpdateBypass(ctx, "user_2", "b@example.com", created.ID, &dto.BypassRequest{Name: "upload", PathPrefix: "/upload", DisableEngine: true, ExpiresAt: &expires}); err != nil {
+		t.Fatalf("UpdateBypass() error = %v", err)
+	}
+	if !strings.Contains(s.renderBypasses(), "ctl:ruleEngine=Off") {
+		t.Errorf("renderBypasses() = %q, want active bypass", s.renderBypasses())
+	}
+	past := time.Now().Add(-time.Minute)
+	s.bypasses[created.ID].ExpiresAt = &past
+	if got, _ := s.GetBypass(created.ID); got.Status != BypassStatusExpired || s.renderBypasses() != "" {
+		t.Errorf("expired bypass = %+v, rendered %q", got, s.renderBypasses())
+	}
+}
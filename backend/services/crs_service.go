@@ -0,0 +1,468 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"waf-backend/config"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/tracing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+const (
+	// CRS 설정 룰 ID 범위: 전역 SecAction 하나 + 호스트마다 하나
+	crsTuningRuleIDBase = 9950
+	crsTuningRuleIDMax  = 9997
+	maxCRSHostScopes    = crsTuningRuleIDMax - crsTuningRuleIDBase
+
+	// 룰 ConfigMap에서 CRS보다 먼저 Include되는 파일
+	crsTuningConfKey = "crs-tuning.conf"
+
+	// 전역 설정 범위 (CRS 설정, 엔진 모드)
+	GlobalScope = "global"
+)
+
+var crsPluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// CRSService OWASP CRS paranoia level, anomaly 임계값, 플러그인, 룰 끄기를 전역/호스트별로 관리
+type CRSService struct {
+	log         *logrus.Logger
+	db          *gorm.DB
+	ruleService *RuleService
+	plugins     []string // 설치된 플러그인 (비어 있으면 확인 불가)
+	version     int      // CRS 메이저 버전 (3 또는 4)
+	settings    map[string]*models.CRSSetting
+	mutex       sync.RWMutex
+}
+
+func NewCRSService(cfg *config.Config, log *logrus.Logger, db *gorm.DB, ruleService *RuleService) *CRSService {
+	service := &CRSService{
+		log:         log,
+		db:          db,
+		ruleService: ruleService,
+		plugins:     loadCRSPlugins(log, cfg.Rules.CRSPluginsDir),
+		version:     crsMajorVersion(log, cfg.Rules.CRSVersion),
+		settings:    make(map[string]*models.CRSSetting),
+	}
+
+	var settings []*models.CRSSetting
+	if err := db.Find(&settings).Error; err != nil {
+		log.WithError(err).Error("Failed to load CRS settings, using CRS defaults")
+	}
+	for _, setting := range settings {
+		service.settings[setting.Scope] = setting
+	}
+
+	// paranoia level 등은 CRS 초기화 전에, 룰 끄기(SecRuleRemoveById)는 CRS 룰이 로드된 뒤에 들어가야 함
+	ruleService.RegisterCRSSetup("CRS tuning", service.renderSetup)
+	ruleService.RegisterManagedSnippet("CRS rule removals", service.renderRemovals)
+
+	log.WithFields(logrus.Fields{
+		"scopes":      len(service.settings),
+		"plugins":     len(service.plugins),
+		"crs_version": service.version,
+	}).Info("CRS settings loaded")
+
+	return service
+}
+
+// crsMajorVersion CRS_VERSION("3.3.4", "v4.0.0", "OWASP_CRS/4.1.0")의 메이저 버전. 알 수 없으면 3
+func crsMajorVersion(log *logrus.Logger, version string) int {
+	trimmed := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "owasp_crs/")
+	trimmed = strings.TrimPrefix(trimmed, "v")
+	major, err := strconv.Atoi(strings.SplitN(trimmed, ".", 2)[0])
+	if err != nil || major < 3 || major > 4 {
+		log.WithField("crs_version", version).Warn("Unsupported CRS version, using CRS 3 variable names")
+		return 3
+	}
+	return major
+}
+
+// loadCRSPlugins 플러그인 디렉토리의 *-config.conf에서 플러그인 이름 수집
+func loadCRSPlugins(log *logrus.Logger, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*-config.conf"))
+	if err != nil || len(files) == 0 {
+		if _, statErr := os.Stat(dir); statErr != nil {
+			log.WithField("dir", dir).Debug("CRS plugins directory not available, plugin names will not be checked")
+		}
+		return nil
+	}
+
+	plugins := make([]string, 0, len(files))
+	for _, file := range files {
+		plugins = append(plugins, strings.TrimSuffix(filepath.Base(file), "-config.conf"))
+	}
+	sort.Strings(plugins)
+	return plugins
+}
+
+// GetOverview 모든 범위의 설정과 렌더링된 배포 설정
+func (s *CRSService) GetOverview() *dto.CRSOverview {
+	s.mutex.RLock()
+	settings := make([]dto.CRSSettings, 0, len(s.settings))
+	for _, scope := range s.sortedScopesLocked() {
+		settings = append(settings, crsSettingToResponse(s.settings[scope]))
+	}
+	s.mutex.RUnlock()
+
+	plugins := s.plugins
+	if plugins == nil {
+		plugins = []string{}
+	}
+	return &dto.CRSOverview{
+		CRSVersion:       s.version,
+		Settings:         settings,
+		AvailablePlugins: plugins,
+		Setup:            s.renderSetup(),
+		Removals:         s.renderRemovals(),
+	}
+}
+
+// GetSettings 한 범위의 설정 (전역은 저장된 값이 없으면 빈 설정 = CRS 기본값)
+func (s *CRSService) GetSettings(scope string) (*dto.CRSSettings, error) {
+	scope, err := normalizeScope(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	setting, exists := s.settings[scope]
+	if !exists {
+		if scope == GlobalScope {
+			return &dto.CRSSettings{Scope: scope}, nil
+		}
+		return nil, fmt.Errorf("CRS settings not found")
+	}
+	response := crsSettingToResponse(setting)
+	return &response, nil
+}
+
+// UpdateSettings 한 범위의 설정을 교체하고 재배포
+func (s *CRSService) UpdateSettings(ctx context.Context, userID, scope string, req *dto.CRSSettingsRequest) (*dto.CRSSettings, error) {
+	ctx, span := tracing.Start(ctx, "CRSService.UpdateSettings", attribute.String("scope", scope))
+	defer span.End()
+
+	scope, err := normalizeScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	_, exists := s.settings[scope]
+	if !exists && scope != GlobalScope && s.hostScopeCountLocked() >= maxCRSHostScopes {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("at most %d hosts can have their own CRS settings", maxCRSHostScopes)
+	}
+
+	plugins, _ := json.Marshal(req.Plugins)
+	ruleIDs, _ := json.Marshal(uniqueSortedInts(req.DisabledRuleIDs))
+	setting := &models.CRSSetting{
+		Scope:                    scope,
+		BlockingParanoiaLevel:    req.BlockingParanoiaLevel,
+		DetectionParanoiaLevel:   req.DetectionParanoiaLevel,
+		InboundAnomalyThreshold:  req.InboundAnomalyThreshold,
+		OutboundAnomalyThreshold: req.OutboundAnomalyThreshold,
+		Plugins:                  string(plugins),
+		DisabledRuleIDs:          string(ruleIDs),
+		UpdatedBy:                userID,
+		UpdatedAt:                time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Save(setting).Error; err != nil {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("failed to save CRS settings: %w", err)
+	}
+	s.settings[scope] = setting
+	s.mutex.Unlock()
+
+	// 렌더링 시 RuleService가 이 서비스의 락을 잡으므로 락을 놓은 뒤 배포
+	if err := s.ruleService.Redeploy(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to deploy CRS settings")
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"user_id":            userID,
+		"scope":              scope,
+		"blocking_paranoia":  setting.BlockingParanoiaLevel,
+		"detection_paranoia": setting.DetectionParanoiaLevel,
+		"inbound_threshold":  setting.InboundAnomalyThreshold,
+		"outbound_threshold": setting.OutboundAnomalyThreshold,
+		"disabled_rules":     len(req.DisabledRuleIDs),
+	}).Info("CRS settings updated")
+
+	response := crsSettingToResponse(setting)
+	return &response, nil
+}
+
+// DeleteSettings 호스트 설정 삭제 (전역이면 CRS 기본값으로 초기화)
+func (s *CRSService) DeleteSettings(ctx context.Context, userID, scope string) error {
+	ctx, span := tracing.Start(ctx, "CRSService.DeleteSettings", attribute.String("scope", scope))
+	defer span.End()
+
+	scope, err := normalizeScope(scope)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	if _, exists := s.settings[scope]; !exists {
+		s.mutex.Unlock()
+		return fmt.Errorf("CRS settings not found")
+	}
+	if err := s.db.WithContext(ctx).Delete(&models.CRSSetting{}, "scope = ?", scope).Error; err != nil {
+		s.mutex.Unlock()
+		return fmt.Errorf("failed to delete CRS settings: %w", err)
+	}
+	delete(s.settings, scope)
+	s.mutex.Unlock()
+
+	if err := s.ruleService.Redeploy(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to deploy CRS settings")
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"scope":   scope,
+	}).Info("CRS settings deleted")
+	return nil
+}
+
+func (s *CRSService) validateRequest(req *dto.CRSSettingsRequest) error {
+	if req.DetectionParanoiaLevel > 0 && req.DetectionParanoiaLevel < req.BlockingParanoiaLevel {
+		return fmt.Errorf("detection_paranoia_level must not be lower than blocking_paranoia_level")
+	}
+
+	if len(req.Plugins) > 0 && s.version < 4 {
+		return fmt.Errorf("plugins require CRS 4, the deployed CRS is version %d", s.version)
+	}
+	for name := range req.Plugins {
+		if !crsPluginNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid plugin name %q", name)
+		}
+		if s.plugins != nil && !containsString(s.plugins, name) {
+			return fmt.Errorf("plugin %s is not installed", name)
+		}
+	}
+
+	for _, id := range req.DisabledRuleIDs {
+		if id < 900000 || id > 999999 {
+			return fmt.Errorf("rule id %d is not an OWASP CRS rule", id)
+		}
+		// 초기화 룰을 끄면 anomaly 점수와 paranoia level이 설정되지 않음
+		if id <= 901999 {
+			return fmt.Errorf("rule %d is a CRS setup rule and cannot be disabled", id)
+		}
+		if !s.ruleService.crsRuleInstalled(id) {
+			return fmt.Errorf("rule %d is not in the installed CRS", id)
+		}
+	}
+	return nil
+}
+
+// normalizeScope "global" 또는 소문자 호스트
+func normalizeScope(scope string) (string, error) {
+	scope = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(scope)), ".")
+	if scope == GlobalScope {
+		return scope, nil
+	}
+	if !targetHostPattern.MatchString(scope) {
+		return "", fmt.Errorf("scope must be global or a host name")
+	}
+	return scope, nil
+}
+
+func (s *CRSService) hostScopeCountLocked() int {
+	count := len(s.settings)
+	if _, exists := s.settings[GlobalScope]; exists {
+		count--
+	}
+	return count
+}
+
+func (s *CRSService) sortedScopesLocked() []string {
+	scopes := make([]string, 0, len(s.settings))
+	for scope := range s.settings {
+		scopes = append(scopes, scope)
+	}
+	sortScopes(scopes)
+	return scopes
+}
+
+// sortScopes 전역, 와일드카드 호스트, 일반 호스트 순서 (뒤에 오는 설정이 우선하도록)
+func sortScopes(scopes []string) {
+	rank := func(scope string) int {
+		switch {
+		case scope == GlobalScope:
+			return 0
+		case strings.HasPrefix(scope, "*."):
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		if rank(scopes[i]) != rank(scopes[j]) {
+			return rank(scopes[i]) < rank(scopes[j])
+		}
+		return scopes[i] < scopes[j]
+	})
+}
+
+// renderSetup CRS보다 먼저 로드되는 설정 (crs-tuning.conf)
+// CRS 초기화 룰은 값이 없을 때만 기본값을 쓰므로 여기서 먼저 tx 변수를 설정. 호스트 룰이 뒤에 와서 전역 값을 덮어씀
+func (s *CRSService) renderSetup() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var b strings.Builder
+	for i, scope := range s.sortedScopesLocked() {
+		setting := s.settings[scope]
+		actions := crsSetupActions(setting, s.version)
+		if scope == GlobalScope {
+			if len(actions) > 0 {
+				fmt.Fprintf(&b, "# CRS tuning: global\nSecAction \"id:%d,phase:1,pass,nolog,%s\"\n", crsTuningRuleIDBase, strings.Join(actions, ","))
+			}
+			continue
+		}
+
+		// 호스트별 룰 끄기는 CRS 룰보다 먼저 실행되는 ctl로 처리
+		for _, id := range decodeCRSRuleIDs(setting.DisabledRuleIDs) {
+			actions = append(actions, "ctl:ruleRemoveById="+strconv.Itoa(id))
+		}
+		if len(actions) == 0 {
+			continue
+		}
+		id := crsTuningRuleIDBase + i
+		if _, hasGlobal := s.settings[GlobalScope]; !hasGlobal {
+			id++
+		}
+		fmt.Fprintf(&b, "# CRS tuning: %s\nSecRule REQUEST_HEADERS:Host \"@rx %s\" \"id:%d,phase:1,pass,nolog,t:lowercase,%s\"\n",
+			scope, hostRegex([]string{scope}), id, strings.Join(actions, ","))
+	}
+	return b.String()
+}
+
+// renderRemovals CRS 룰이 로드된 뒤에 적용되는 전역 룰 끄기
+func (s *CRSService) renderRemovals() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	global, exists := s.settings[GlobalScope]
+	if !exists {
+		return ""
+	}
+	ids := decodeCRSRuleIDs(global.DisabledRuleIDs)
+	if len(ids) == 0 {
+		return ""
+	}
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.Itoa(id))
+	}
+	return "SecRuleRemoveById " + strings.Join(values, " ")
+}
+
+// crsSetupActions 설정된 값만 setvar로 (플러그인은 tx.<이름>-plugin_enabled)
+// CRS 3은 paranoia_level/executing_paranoia_level, CRS 4는 blocking_/detection_paranoia_level을 읽음
+func crsSetupActions(setting *models.CRSSetting, version int) []string {
+	blockingVar, detectionVar := "blocking_paranoia_level", "detection_paranoia_level"
+	if version < 4 {
+		blockingVar, detectionVar = "paranoia_level", "executing_paranoia_level"
+	}
+
+	var actions []string
+	vars := []struct {
+		name  string
+		value int
+	}{
+		{blockingVar, setting.BlockingParanoiaLevel},
+		{detectionVar, setting.DetectionParanoiaLevel},
+		{"inbound_anomaly_score_threshold", setting.InboundAnomalyThreshold},
+		{"outbound_anomaly_score_threshold", setting.OutboundAnomalyThreshold},
+	}
+	for _, v := range vars {
+		if v.value > 0 {
+			actions = append(actions, fmt.Sprintf("setvar:tx.%s=%d", v.name, v.value))
+		}
+	}
+
+	// CRS 3에는 플러그인이 없음 (CRS 4에서 저장한 설정이 남아 있어도 렌더링하지 않음)
+	if version < 4 {
+		return actions
+	}
+	plugins := decodeCRSPlugins(setting.Plugins)
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		enabled := 0
+		if plugins[name] {
+			enabled = 1
+		}
+		actions = append(actions, fmt.Sprintf("setvar:'tx.%s-plugin_enabled=%d'", name, enabled))
+	}
+	return actions
+}
+
+func crsSettingToResponse(setting *models.CRSSetting) dto.CRSSettings {
+	updatedAt := setting.UpdatedAt
+	return dto.CRSSettings{
+		Scope:                    setting.Scope,
+		BlockingParanoiaLevel:    setting.BlockingParanoiaLevel,
+		DetectionParanoiaLevel:   setting.DetectionParanoiaLevel,
+		InboundAnomalyThreshold:  setting.InboundAnomalyThreshold,
+		OutboundAnomalyThreshold: setting.OutboundAnomalyThreshold,
+		Plugins:                  decodeCRSPlugins(setting.Plugins),
+		DisabledRuleIDs:          decodeCRSRuleIDs(setting.DisabledRuleIDs),
+		UpdatedBy:                setting.UpdatedBy,
+		UpdatedAt:                &updatedAt,
+	}
+}
+
+func decodeCRSPlugins(encoded string) map[string]bool {
+	var plugins map[string]bool
+	if encoded != "" {
+		json.Unmarshal([]byte(encoded), &plugins)
+	}
+	return plugins
+}
+
+func decodeCRSRuleIDs(encoded string) []int {
+	var ids []int
+	if encoded != "" {
+		json.Unmarshal([]byte(encoded), &ids)
+	}
+	return ids
+}
+
+func uniqueSortedInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	result := make([]int, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"waf-backend/config"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"gorm.io/gorm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// newTestCRSService CRS 버전과 설치된 플러그인(pluginDir의 *-config.conf)을 지정한 CRSService
+func newTestCRSService(t *testing.T, db *gorm.DB, k8sClient kubernetes.Interface, version string, plugins ...string) *CRSService {
+	t.Helper()
+	if err := db.AutoMigrate(&models.CRSSetting{}); err != nil {
+		t.Fatalf("failed to migrate CRS settings: %v", err)
+	}
+	pluginDir := t.TempDir()
+	for _, plugin := range plugins {
+		if err := os.WriteFile(filepath.Join(pluginDir, plugin+"-config.conf"), nil, 0o644); err != nil {
+			t.Fatalf("failed to write plugin config: %v", err)
+		}
+	}
+	cfg := &config.Config{Rules: config.RulesConfig{CRSVersion: version, CRSPluginsDir: pluginDir}}
+	return NewCRSService(cfg, newTestLogger(), db, newTestRuleService(t, db, k8sClient))
+}
+
+func TestCRSMajorVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    int
+	}{
+		{"3.3.4", 3},
+		{"v4.0.0", 4},
+		{"OWASP_CRS/4.1.0", 4},
+		{"", 3},
+		{"5.0", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := crsMajorVersion(newTestLogger(), tt.version); got != tt.want {
+				t.Errorf("crsMajorVersion(%q) = %d, want %d", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCRSServiceRender(t *testing.T) {
+	global := dto.CRSSettingsRequest{BlockingParanoiaLevel: 2, DetectionParanoiaLevel: 3, InboundAnomalyThreshold: 10, DisabledRuleIDs: []int{920350, 920170, 920350}}
+	host := dto.CRSSettingsRequest{BlockingParanoiaLevel: 1, DisabledRuleIDs: []int{942100}}
+	tests := []struct {
+		name         string
+		version      string
+		settings     map[string]dto.CRSSettingsRequest
+		wantSetup    string
+		wantRemovals string
+	}{
+		{
+			name:     "crs 3 global and host",
+			version:  "3.3.4",
+			settings: map[string]dto.CRSSettingsRequest{"global": global, "Shop.Example.com": host},
+			wantSetup: "# CRS tuning: global\nSecAction \"id:9950,phase:1,pass,nolog,setvar:tx.paranoia_level=2,setvar:tx.executing_paranoia_level=3,setvar:tx.inbound_anomaly_score_threshold=10\"\n" +
+				"# CRS tuning: shop.example.com\nSecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"id:9951,phase:1,pass,nolog,t:lowercase,setvar:tx.paranoia_level=1,ctl:ruleRemoveById=942100\"\n",
+			wantRemovals: "SecRuleRemoveById 920170 920350",
+		},
+		{
+			name:     "crs 4 plugins, wildcard before host, global id reserved",
+			version:  "4.0.0",
+			settings: map[string]dto.CRSSettingsRequest{"shop.example.com": host, "*.example.com": {Plugins: map[string]bool{"wordpress-rule-exclusions": true, "fake-bot": false}}},
+			wantSetup: "# CRS tuning: *.example.com\nSecRule REQUEST_HEADERS:Host \"@rx ^(?:.+\\.example\\.com)(?::[0-9]+)?$\" \"id:9951,phase:1,pass,nolog,t:lowercase,setvar:'tx.fake-bot-plugin_enabled=0',setvar:'tx.wordpress-rule-exclusions-plugin_enabled=1'\"\n" +
+				"# CRS tuning: shop.example.com\nSecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"id:9952,phase:1,pass,nolog,t:lowercase,setvar:tx.blocking_paranoia_level=1,ctl:ruleRemoveById=942100\"\n",
+		},
+		{"defaults", "3.3.4", nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestCRSService(t, newTestDB(t), nil, tt.version, "wordpress-rule-exclusions", "fake-bot")
+			for scope, req := range tt.settings {
+				req := req
+				if _, err := s.UpdateSettings(context.Background(), "admin", scope, &req); err != nil {
+					t.Fatalf("UpdateSettings(%s) error = %v", scope, err)
+				}
+			}
+			if got := s.renderSetup(); got != tt.wantSetup {
+				t.Errorf("renderSetup() =\n%s\nwant\n%s", got, tt.wantSetup)
+			}
+			if got := s.renderRemovals(); got != tt.wantRemovals {
+				t.Errorf("renderRemovals() = %q, want %q", got, tt.wantRemovals)
+			}
+		})
+	}
+}
+
+func TestCRSServiceValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		scope   string
+		req     dto.CRSSettingsRequest
+		wantErr string
+	}{
+		{"detection below blocking", "4.0.0", "global", dto.CRSSettingsRequest{BlockingParanoiaLevel: 3, DetectionParanoiaLevel: 2}, "must not be lower"},
+		{"plugins on crs 3", "3.3.4", "global", dto.CRSSettingsRequest{Plugins: map[string]bool{"fake-bot": true}}, "require CRS 4"},
+		{"unknown plugin", "4.0.0", "global", dto.CRSSettingsRequest{Plugins: map[string]bool{"missing": true}}, "not installed"},
+		{"invalid plugin name", "4.0.0", "global", dto.CRSSettingsRequest{Plugins: map[string]bool{"a b": true}}, "invalid plugin name"},
+		{"not a crs rule", "4.0.0", "global", dto.CRSSettingsRequest{DisabledRuleIDs: []int{1001}}, "not an OWASP CRS rule"},
+		{"setup rule", "4.0.0", "global", dto.CRSSettingsRequest{DisabledRuleIDs: []int{901100}}, "CRS setup rule"},
+		{"invalid scope", "4.0.0", "shop example", dto.CRSSettingsRequest{}, "global or a host name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestCRSService(t, newTestDB(t), nil, tt.version, "fake-bot")
+			if _, err := s.UpdateSettings(context.Background(), "admin", tt.scope, &tt.req); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("UpdateSettings() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(s.settings) != 0 {
+				t.Errorf("settings = %v, want none saved", s.settings)
+			}
+		})
+	}
+}
+
+func TestCRSServicePersistence(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	client := newFakeCluster("")
+	s := newTestCRSService(t, db, client, "4.0.0")
+
+	req := dto.CRSSettingsRequest{BlockingParanoiaLevel: 2, OutboundAnomalyThreshold: 5, DisabledRuleIDs: []int{942100}}
+	if _, err := s.UpdateSettings(ctx, "admin", "global", &req); err != nil {
+		t.Fatalf("UpdateSettings(global) error = %v", err)
+	}
+	if _, err := s.UpdateSettings(ctx, "admin", "shop.example.com", &dto.CRSSettingsRequest{BlockingParanoiaLevel: 1}); err != nil {
+		t.Fatalf("UpdateSettings(host) error = %v", err)
+	}
+	configMap, err := client.CoreV1().ConfigMaps("default").Get(ctx, "modsecurity-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ConfigMap: %v", err)
+	}
+	if got := configMap.Data[crsTuningConfKey]; !strings.Contains(got, s.renderSetup()) {
+		t.Errorf("deployed %s = %q, want it to contain %q", crsTuningConfKey, got, s.renderSetup())
+	}
+	if !strings.Contains(configMap.Data["custom-rules.conf"], "SecRuleRemoveById 942100") {
+		t.Errorf("custom-rules.conf does not disable 942100:\n%s", configMap.Data["custom-rules.conf"])
+	}
+
+	reloaded := newTestCRSService(t, db, nil, "4.0.0")
+	got, err := reloaded.GetSettings("GLOBAL")
+	if err != nil {
+		t.Fatalf("GetSettings() after reload error = %v", err)
+	}
+	if got.BlockingParanoiaLevel != 2 || got.OutboundAnomalyThreshold != 5 || !reflect.DeepEqual(got.DisabledRuleIDs, []int{942100}) || got.UpdatedBy != "admin" {
+		t.Errorf("reloaded settings = %+v", got)
+	}
+	if reloaded.renderSetup() != s.renderSetup() {
+		t.Errorf("reloaded setup =\n%s\nwant\n%s", reloaded.renderSetup(), s.renderSetup())
+	}
+
+	if err := reloaded.DeleteSettings(ctx, "admin", "shop.example.com"); err != nil {
+		t.Fatalf("DeleteSettings() error = %v", err)
+	}
+	if _, err := reloaded.GetSettings("shop.example.com"); err == nil {
+		t.Error("GetSettings(deleted host) succeeded")
+	}
+	if err := reloaded.DeleteSettings(ctx, "admin", "shop.example.com"); err == nil {
+		t.Error("DeleteSettings(missing) succeeded")
+	}
+	if _, err := newTestCRSService(t, db, nil, "4.0.0").GetSettings("shop.example.com"); err == nil {
+		t.Error("deleted host settings were reloaded")
+	}
+}
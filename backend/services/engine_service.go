@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/tracing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+const (
+	EngineModeOn            = "On"
+	EngineModeDetectionOnly = "DetectionOnly"
+	EngineModeOff           = "Off"
+
+	// 호스트 설정이 없음 (전역 모드를 따름)
+	engineModeInherit = "inherit"
+
+	// 엔진 모드 전환 룰 ID 범위: 전역 전환 하나 + 호스트마다 하나
+	engineRuleIDBase       = 96000
+	engineRuleIDMax        = 96099
+	maxEngineHostOverrides = engineRuleIDMax - engineRuleIDBase
+
+	defaultEngineAuditLimit = 100
+)
+
+// EngineService 전역/호스트별 SecRuleEngine 모드와 임시 변경(만료 시 자동 복귀), 변경 감사 기록
+type EngineService struct {
+	log         *logrus.Logger
+	db          *gorm.DB
+	ruleService *RuleService
+	modes       map[string]*models.EngineMode
+	mutex       sync.RWMutex
+}
+
+func NewEngineService(log *logrus.Logger, db *gorm.DB, ruleService *RuleService) *EngineService {
+	service := &EngineService{
+		log:         log,
+		db:          db,
+		ruleService: ruleService,
+		modes:       make(map[string]*models.EngineMode),
+	}
+
+	var modes []*models.EngineMode
+	if err := db.Find(&modes).Error; err != nil {
+		log.WithError(err).Error("Failed to load engine modes, using SecRuleEngine On")
+	}
+	for _, mode := range modes {
+		service.modes[mode.Scope] = mode
+	}
+
+	// SecRuleEngine 값과, 호스트별 전환은 CRS보다 먼저 실행되는 ctl:ruleEngine 룰로 배포
+	ruleService.RegisterEngineMode(service.engineDirective)
+	ruleService.RegisterCRSSetup("Engine mode", service.renderHostModes)
+
+	// 임시 변경 만료 시 이전 모드로 복귀
+	go service.monitorExpirations()
+
+	return service
+}
+
+// GetModes 전역과 호스트별 현재 엔진 모드
+func (s *EngineService) GetModes() *dto.EngineModes {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := &dto.EngineModes{
+		Global: dto.EngineModeSetting{Scope: GlobalScope, Mode: EngineModeOn},
+		Hosts:  []dto.EngineModeSetting{},
+	}
+	for _, scope := range s.sortedScopesLocked() {
+		if scope == GlobalScope {
+			result.Global = engineModeToResponse(s.modes[scope])
+		} else {
+			result.Hosts = append(result.Hosts, engineModeToResponse(s.modes[scope]))
+		}
+	}
+	return result
+}
+
+// SetMode 엔진 모드 변경. durationMinutes > 0이면 만료 후 변경 전 모드로 돌아감
+// 임시 변경 중에 다시 임시 변경하면 처음 모드로 돌아감
+func (s *EngineService) SetMode(ctx context.Context, userID, scope string, req *dto.EngineModeRequest) (*dto.EngineModeSetting, error) {
+	ctx, span := tracing.Start(ctx, "EngineService.SetMode",
+		attribute.String("scope", scope), attribute.String("mode", req.Mode))
+	defer span.End()
+
+	scope, err := normalizeScope(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	current, exists := s.modes[scope]
+	if !exists && scope != GlobalScope && s.hostCountLocked() >= maxEngineHostOverrides {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("at most %d hosts can have their own engine mode", maxEngineHostOverrides)
+	}
+
+	now := time.Now()
+	mode := &models.EngineMode{
+		Scope:     scope,
+		Mode:      req.Mode,
+		Reason:    req.Reason,
+		UpdatedBy: userID,
+		UpdatedAt: now,
+	}
+	from := s.modeOfLocked(scope)
+	if req.DurationMinutes > 0 {
+		expiresAt := now.Add(time.Duration(req.DurationMinutes) * time.Minute)
+		mode.ExpiresAt = &expiresAt
+		mode.RevertMode = from
+		if exists && current.ExpiresAt != nil {
+			mode.RevertMode = current.RevertMode
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(mode).Error; err != nil {
+			return err
+		}
+		return recordEngineModeChange(tx, scope, from, req.Mode, mode.ExpiresAt, req.Reason, userID)
+	}); err != nil {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("failed to save engine mode: %w", err)
+	}
+	s.modes[scope] = mode
+	s.mutex.Unlock()
+
+	s.redeploy(ctx)
+
+	s.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"scope":      scope,
+		"from":       from,
+		"to":         req.Mode,
+		"expires_at": mode.ExpiresAt,
+		"reason":     req.Reason,
+	}).Warn("WAF engine mode changed")
+
+	response := engineModeToResponse(mode)
+	return &response, nil
+}
+
+// ClearMode 호스트 설정 삭제 (전역 모드를 따름). 전역이면 On으로 초기화
+func (s *EngineService) ClearMode(ctx context.Context, userID, scope, reason string) error {
+	ctx, span := tracing.Start(ctx, "EngineService.ClearMode", attribute.String("scope", scope))
+	defer span.End()
+
+	scope, err := normalizeScope(scope)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	if _, exists := s.modes[scope]; !exists {
+		s.mutex.Unlock()
+		return fmt.Errorf("engine mode not found")
+	}
+	from := s.modeOfLocked(scope)
+	to := engineModeInherit
+	if scope == GlobalScope {
+		to = EngineModeOn
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.EngineMode{}, "scope = ?", scope).Error; err != nil {
+			return err
+		}
+		return recordEngineModeChange(tx, scope, from, to, nil, reason, userID)
+	}); err != nil {
+		s.mutex.Unlock()
+		return fmt.Errorf("failed to delete engine mode: %w", err)
+	}
+	delete(s.modes, scope)
+	s.mutex.Unlock()
+
+	s.redeploy(ctx)
+
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"scope":   scope,
+		"from":    from,
+		"to":      to,
+	}).Warn("WAF engine mode reset")
+	return nil
+}
+
+// GetAudit 엔진 모드 변경 기록 (최신순, scope를 주면 그 범위만)
+func (s *EngineService) GetAudit(ctx context.Context, scope string, limit int) ([]dto.EngineModeChange, error) {
+	if limit <= 0 || limit > defaultEngineAuditLimit {
+		limit = defaultEngineAuditLimit
+	}
+
+	query := s.db.WithContext(ctx).Order("id desc").Limit(limit)
+	if scope != "" {
+		normalized, err := normalizeScope(scope)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("scope = ?", normalized)
+	}
+
+	var changes []models.EngineModeChange
+	if err := query.Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load engine mode changes: %w", err)
+	}
+	result := make([]dto.EngineModeChange, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, dto.EngineModeChange{
+			ID:        change.ID,
+			Scope:     change.Scope,
+			From:      change.FromMode,
+			To:        change.ToMode,
+			ExpiresAt: change.ExpiresAt,
+			Reason:    change.Reason,
+			ChangedBy: change.ChangedBy,
+			CreatedAt: change.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (s *EngineService) monitorExpirations() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.revertExpired()
+	}
+}
+
+// revertExpired 만료된 임시 변경을 이전 모드로 되돌리고 변경이 있으면 재배포
+func (s *EngineService) revertExpired() {
+	now := time.Now()
+	reverted := 0
+
+	s.mutex.Lock()
+	for _, scope := range s.sortedScopesLocked() {
+		mode := s.modes[scope]
+		if mode.ExpiresAt == nil || now.Before(*mode.ExpiresAt) {
+			continue
+		}
+
+		// 호스트 설정이 없던 상태로 돌아가면 삭제
+		var restored *models.EngineMode
+		if mode.RevertMode != engineModeInherit {
+			restored = &models.EngineMode{
+				Scope:     scope,
+				Mode:      mode.RevertMode,
+				Reason:    "temporary mode expired",
+				UpdatedBy: "system",
+				UpdatedAt: now,
+			}
+		}
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if restored != nil {
+				err = tx.Save(restored).Error
+			} else {
+				err = tx.Delete(&models.EngineMode{}, "scope = ?", scope).Error
+			}
+			if err != nil {
+				return err
+			}
+			return recordEngineModeChange(tx, scope, mode.Mode, mode.RevertMode, nil, "temporary mode expired", "system")
+		})
+		if err != nil {
+			s.log.WithError(err).WithField("scope", scope).Error("Failed to revert temporary engine mode")
+			continue
+		}
+		if restored != nil {
+			s.modes[scope] = restored
+		} else {
+			delete(s.modes, scope)
+		}
+		reverted++
+
+		s.log.WithFields(logrus.Fields{
+			"scope": scope,
+			"from":  mode.Mode,
+			"to":    mode.RevertMode,
+		}).Warn("Temporary WAF engine mode expired, reverted")
+	}
+	s.mutex.Unlock()
+
+	if reverted > 0 {
+		s.redeploy(context.Background())
+	}
+}
+
+// redeploy 렌더링 시 RuleService가 이 서비스의 락을 잡으므로 락을 놓은 뒤 호출
+func (s *EngineService) redeploy(ctx context.Context) {
+	if err := s.ruleService.Redeploy(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to deploy engine mode")
+	}
+}
+
+// engineDirective 배포할 SecRuleEngine 값
+// 전역이 Off여도 켜진 호스트가 있으면 On으로 두고 ctl:ruleEngine으로 전환 (Off면 룰이 실행되지 않아 전환할 수 없음)
+func (s *EngineService) engineDirective() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.engineDirectiveLocked()
+}
+
+func (s *EngineService) engineDirectiveLocked() string {
+	global := s.modeOfLocked(GlobalScope)
+	if global != EngineModeOff {
+		return global
+	}
+	for scope, mode := range s.modes {
+		if scope != GlobalScope && mode.Mode != EngineModeOff {
+			return EngineModeOn
+		}
+	}
+	return global
+}
+
+// renderHostModes 호스트별 엔진 전환 룰
+// ctl:ruleEngine=Off 이후의 룰은 실행이 보장되지 않으므로 룰끼리 겹치지 않게 생성:
+// 전역 전환은 설정된 호스트를 제외하고, 와일드카드는 뒤에 오는(더 우선하는) 호스트를 제외
+func (s *EngineService) renderHostModes() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var hosts []string
+	for _, scope := range s.sortedScopesLocked() {
+		if scope != GlobalScope {
+			hosts = append(hosts, scope)
+		}
+	}
+
+	var b strings.Builder
+	global := s.modeOfLocked(GlobalScope)
+	if directive := s.engineDirectiveLocked(); directive != global {
+		fmt.Fprintf(&b, "SecRule REQUEST_HEADERS:Host \"!@rx %s\" \"id:%d,phase:1,pass,nolog,t:lowercase,ctl:ruleEngine=%s\"\n",
+			hostRegex(hosts), engineRuleIDBase, global)
+	}
+	for i, host := range hosts {
+		id := engineRuleIDBase + 1 + i
+		action := "ctl:ruleEngine=" + s.modes[host].Mode
+		if strings.HasPrefix(host, "*.") && i+1 < len(hosts) {
+			fmt.Fprintf(&b, "SecRule REQUEST_HEADERS:Host \"@rx %s\" \"id:%d,phase:1,pass,nolog,t:lowercase,chain\"\n", hostRegex([]string{host}), id)
+			fmt.Fprintf(&b, "    SecRule REQUEST_HEADERS:Host \"!@rx %s\" \"t:lowercase,%s\"\n", hostRegex(hosts[i+1:]), action)
+			continue
+		}
+		fmt.Fprintf(&b, "SecRule REQUEST_HEADERS:Host \"@rx %s\" \"id:%d,phase:1,pass,nolog,t:lowercase,%s\"\n", hostRegex([]string{host}), id, action)
+	}
+	return b.String()
+}
+
+// modeOfLocked 범위의 현재 모드 (설정이 없으면 전역은 On, 호스트는 inherit)
+func (s *EngineService) modeOfLocked(scope string) string {
+	if mode, exists := s.modes[scope]; exists {
+		return mode.Mode
+	}
+	if scope == GlobalScope {
+		return EngineModeOn
+	}
+	return engineModeInherit
+}
+
+func (s *EngineService) hostCountLocked() int {
+	count := len(s.modes)
+	if _, exists := s.modes[GlobalScope]; exists {
+		count--
+	}
+	return count
+}
+
+func (s *EngineService) sortedScopesLocked() []string {
+	scopes := make([]string, 0, len(s.modes))
+	for scope := range s.modes {
+		scopes = append(scopes, scope)
+	}
+	sortScopes(scopes)
+	return scopes
+}
+
+func recordEngineModeChange(tx *gorm.DB, scope, from, to string, expiresAt *time.Time, reason, changedBy string) error {
+	return tx.Create(&models.EngineModeChange{
+		Scope:     scope,
+		FromMode:  from,
+		ToMode:    to,
+		ExpiresAt: expiresAt,
+		Reason:    reason,
+		ChangedBy: changedBy,
+		CreatedAt: time.Now(),
+	}).Error
+}
+
+func engineModeToResponse(mode *models.EngineMode) dto.EngineModeSetting {
+	updatedAt := mode.UpdatedAt
+	return dto.EngineModeSetting{
+		Scope:     mode.Scope,
+		Mode:      mode.Mode,
+		RevertTo:  mode.RevertMode,
+		ExpiresAt: mode.ExpiresAt,
+		Reason:    mode.Reason,
+		UpdatedBy: mode.UpdatedBy,
+		UpdatedAt: &updatedAt,
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"gorm.io/gorm"
+)
+
+func newTestEngineService(t *testing.T, db *gorm.DB) *EngineService {
+	t.Helper()
+	if err := db.AutoMigrate(&models.EngineMode{}, &models.EngineModeChange{}); err != nil {
+		t.Fatalf("failed to migrate engine modes: %v", err)
+	}
+	return NewEngineService(newTestLogger(), db, newTestRuleService(t, db, nil))
+}
+
+func TestEngineServiceRender(t *testing.T) {
+	tests := []struct {
+		name          string
+		modes         map[string]string
+		wantDirective string
+		wantRules     string
+	}{
+		{"default", nil, "On", ""},
+		{"global detection only", map[string]string{"global": "DetectionOnly"}, "DetectionOnly", ""},
+		{
+			name:          "global off with enabled host",
+			modes:         map[string]string{"global": "Off", "shop.example.com": "On"},
+			wantDirective: "On",
+			wantRules: "SecRule REQUEST_HEADERS:Host \"!@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"id:96000,phase:1,pass,nolog,t:lowercase,ctl:ruleEngine=Off\"\n" +
+				"SecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"id:96001,phase:1,pass,nolog,t:lowercase,ctl:ruleEngine=On\"\n",
+		},
+		{"global off with disabled host", map[string]string{"global": "Off", "shop.example.com": "Off"}, "Off",
+			"SecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"id:96001,phase:1,pass,nolog,t:lowercase,ctl:ruleEngine=Off\"\n"},
+		{
+			name:          "wildcard excludes more specific host",
+			modes:         map[string]string{"*.example.com": "Off", "shop.example.com": "DetectionOnly"},
+			wantDirective: "On",
+			wantRules: "SecRule REQUEST_HEADERS:Host \"@rx ^(?:.+\\.example\\.com)(?::[0-9]+)?$\" \"id:96001,phase:1,pass,nolog,t:lowercase,chain\"\n" +
+				"    SecRule REQUEST_HEADERS:Host \"!@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"t:lowercase,ctl:ruleEngine=Off\"\n" +
+				"SecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"id:96002,phase:1,pass,nolog,t:lowercase,ctl:ruleEngine=DetectionOnly\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestEngineService(t, newTestDB(t))
+			for scope, mode := range tt.modes {
+				if _, err := s.SetMode(context.Background(), "admin", scope, &dto.EngineModeRequest{Mode: mode}); err != nil {
+					t.Fatalf("SetMode(%s) error = %v", scope, err)
+				}
+			}
+			if got := s.engineDirective(); got != tt.wantDirective {
+				t.Errorf("engineDirective() = %s, want %s", got, tt.wantDirective)
+			}
+			if got := s.renderHostModes(); got != tt.wantRules {
+				t.Errorf("renderHostModes() =\n%s\nwant\n%s", got, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestEngineServiceTimedRevert(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		initial    string // 임시 변경 전 호스트 모드 ("" 이면 설정 없음)
+		temporary  []string
+		wantRevert string
+	}{
+		{"host without setting", "", []string{"Off"}, engineModeInherit},
+		{"host setting", "DetectionOnly", []string{"Off"}, "DetectionOnly"},
+		{"repeated temporary change keeps first mode", "DetectionOnly", []string{"Off", "On"}, "DetectionOnly"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			s := newTestEngineService(t, db)
+			if tt.initial != "" {
+				if _, err := s.SetMode(ctx, "admin", "shop.example.com", &dto.EngineModeRequest{Mode: tt.initial}); err != nil {
+					t.Fatalf("SetMode() error = %v", err)
+				}
+			}
+			var setting *dto.EngineModeSetting
+			for _, mode := range tt.temporary {
+				var err error
+				setting, err = s.SetMode(ctx, "admin", "shop.example.com", &dto.EngineModeRequest{Mode: mode, DurationMinutes: 30, Reason: "incident"})
+				if err != nil {
+					t.Fatalf("SetMode(%s) error = %v", mode, err)
+				}
+			}
+			if setting.RevertTo != tt.wantRevert || setting.ExpiresAt == nil {
+				t.Fatalf("SetMode() = %+v, want revert to %s", setting, tt.wantRevert)
+			}
+
+			// 만료 전에는 그대로
+			s.revertExpired()
+			if got := s.modes["shop.example.com"].Mode; got != tt.temporary[len(tt.temporary)-1] {
+				t.Errorf("mode before expiry = %s", got)
+			}
+
+			expired := time.Now().Add(-time.Minute)
+			s.modes["shop.example.com"].ExpiresAt = &expired
+			s.revertExpired()
+			mode, exists := newTestEngineService(t, db).modes["shop.example.com"]
+			if tt.wantRevert == engineModeInherit {
+				if exists {
+					t.Errorf("reverted host setting = %+v, want deleted", mode)
+				}
+			} else if !exists || mode.Mode != tt.wantRevert || mode.ExpiresAt != nil || mode.UpdatedBy != "system" {
+				t.Errorf("reverted host setting = %+v, want %s", mode, tt.wantRevert)
+			}
+
+			audit, err := s.GetAudit(ctx, "shop.example.com", 0)
+			if err != nil {
+				t.Fatalf("GetAudit() error = %v", err)
+			}
+			if len(audit) == 0 || audit[0].ChangedBy != "system" || audit[0].To != tt.wantRevert {
+				t.Errorf("latest audit entry = %+v, want system revert to %s", audit, tt.wantRevert)
+			}
+		})
+	}
+}
+
+func TestEngineServicePersistence(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := newTestEngineService(t, db)
+
+	if _, err := s.SetMode(ctx, "admin", "global", &dto.EngineModeRequest{Mode: "DetectionOnly", Reason: "rollout"}); err != nil {
+		t.Fatalf("SetMode(global) error = %v", err)
+	}
+	if _, err := s.SetMode(ctx, "admin", "Shop.Example.com", &dto.EngineModeRequest{Mode: "Off"}); err != nil {
+		t.Fatalf("SetMode(host) error = %v", err)
+	}
+	if _, err := s.SetMode(ctx, "admin", "shop example", &dto.EngineModeRequest{Mode: "Off"}); err == nil {
+		t.Error("SetMode(invalid scope) succeeded")
+	}
+
+	reloaded := newTestEngineService(t, db)
+	modes := reloaded.GetModes()
+	if modes.Global.Mode != "DetectionOnly" || modes.Global.Reason != "rollout" || modes.Global.UpdatedBy != "admin" {
+		t.Errorf("reloaded global = %+v", modes.Global)
+	}
+	if len(modes.Hosts) != 1 || modes.Hosts[0].Scope != "shop.example.com" || modes.Hosts[0].Mode != "Off" {
+		t.Errorf("reloaded hosts = %+v", modes.Hosts)
+	}
+
+	if err := reloaded.ClearMode(ctx, "admin", "shop.example.com", "done"); err != nil {
+		t.Fatalf("ClearMode() error = %v", err)
+	}
+	if err := reloaded.ClearMode(ctx, "admin", "shop.example.com", "again"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("ClearMode(missing) error = %v", err)
+	}
+	if got := newTestEngineService(t, db).GetModes().Hosts; len(got) != 0 {
+		t.Errorf("hosts after ClearMode = %+v", got)
+	}
+
+	tests := []struct {
+		scope string
+		want  string // 최신순 "scope from→to"
+	}{
+		{"", "shop.example.com Off→inherit,shop.example.com inherit→Off,global On→DetectionOnly"},
+		{"global", "global On→DetectionOnly"},
+		{"SHOP.example.com", "shop.example.com Off→inherit,shop.example.com inherit→Off"},
+	}
+	for _, tt := range tests {
+		t.Run("audit "+tt.scope, func(t *testing.T) {
+			audit, err := reloaded.GetAudit(ctx, tt.scope, 0)
+			if err != nil {
+				t.Fatalf("GetAudit() error = %v", err)
+			}
+			var got []string
+			for _, change := range audit {
+				got = append(got, change.Scope+" "+change.From+"→"+change.To)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("GetAudit(%q) = %v, want %s", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"waf-backend/dto"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// 오탐 예외 룰(ctl 모드)에 할당하는 룰 ID 범위
+	exclusionRuleIDMin = 95000
+	exclusionRuleIDMax = 95999
+
+	// RuleService에 저장될 때의 관리형 룰 출처
+	ExclusionRuleSource = "fp-triage"
+)
+
+var (
+	// ctl 액션이나 따옴표 안에서 문제가 되는 문자(; , " ' 공백)를 제외한 변수 이름
+	exclusionVariableRegex = regexp.MustCompile(`^[A-Z_]+(:[A-Za-z0-9_\-.\[\]/]+)?$`)
+	numericRuleIDRegex     = regexp.MustCompile(`^\d+$`)
+	// 호스트 이름 또는 *.example.com 패턴
+	targetHostPattern = regexp.MustCompile(`^(\*\.)?[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+)
+
+type ExclusionService struct {
+	log         *logrus.Logger
+	wafService  *WAFService
+	ruleService *RuleService
+	mutex       sync.Mutex // ID 할당과 저장 사이의 경합 방지
+}
+
+func NewExclusionService(log *logrus.Logger, wafService *WAFService, ruleService *RuleService) *ExclusionService {
+	return &ExclusionService{
+		log:         log,
+		wafService:  wafService,
+		ruleService: ruleService,
+	}
+}
+
+// PreviewExclusion 이벤트의 룰 ID와 매칭 변수로 예외 룰을 생성해서 반환 (저장하지 않음)
+// 이벤트는 호출자가 볼 수 있는 범위(scope) 안에 있어야 하며, 모든 호스트에 적용되는
+// update_target 모드와 이벤트와 다른 룰 ID 지정은 관리자만 가능
+func (s *ExclusionService) PreviewExclusion(eventID string, scope LogFilter, admin bool, req *dto.ExclusionRequest) (*dto.ExclusionPreview, error) {
+	event, err := s.wafService.GetLogByID(eventID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if !admin {
+		if req.Mode == "update_target" {
+			return nil, fmt.Errorf("update_target mode applies to every protected host and requires admin privileges")
+		}
+		if req.RuleID != "" && req.RuleID != event.RuleID {
+			return nil, fmt.Errorf("only admins can exclude a rule other than the one that matched the event")
+		}
+	}
+
+	preview, err := buildExclusionPreview(event, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if preview.Mode == "ctl" {
+		id, err := s.ruleService.AllocateRuleID(exclusionRuleIDMin, exclusionRuleIDMax)
+		if err != nil {
+			return nil, err
+		}
+		preview.ExclusionID = id
+	}
+	preview.RuleText = renderExclusionRule(preview)
+
+	return preview, nil
+}
+
+// CreateExclusion 미리보기와 동일한 예외 룰을 관리형 룰로 저장하고 배포
+func (s *ExclusionService) CreateExclusion(ctx context.Context, userID, eventID string, scope LogFilter, admin bool, req *dto.ExclusionRequest) (*dto.CustomRuleResponse, *dto.ExclusionPreview, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	preview, err := s.PreviewExclusion(eventID, scope, admin, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rule, err := s.ruleService.CreateManagedRule(ctx, userID, ExclusionRuleSource, &dto.CustomRuleRequest{
+		Name:        preview.Name,
+		Description: preview.Description,
+		RuleText:    preview.RuleText,
+		Enabled:     true,
+		Severity:    "LOW",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"event_id":    eventID,
+		"rule_id":     rule.ID,
+		"crs_rule_id": preview.RuleID,
+		"variable":    preview.Variable,
+		"mode":        preview.Mode,
+	}).Info("False-positive exclusion rule created")
+
+	return rule, preview, nil
+}
+
+func buildExclusionPreview(event *dto.WAFLog, req *dto.ExclusionRequest) (*dto.ExclusionPreview, error) {
+	preview := &dto.ExclusionPreview{
+		EventID:  event.ID,
+		Mode:     req.Mode,
+		Scope:    req.Scope,
+		URI:      req.URI,
+		RuleID:   req.RuleID,
+		Variable: req.Variable,
+	}
+
+	if preview.Mode == "" {
+		preview.Mode = "ctl"
+	}
+	if preview.RuleID == "" {
+		preview.RuleID = event.RuleID
+	}
+	if preview.Variable == "" {
+		preview.Variable = event.MatchedVar
+	}
+
+	if !numericRuleIDRegex.MatchString(preview.RuleID) {
+		return nil, fmt.Errorf("event has no numeric rule ID, specify rule_id explicitly")
+	}
+	if ruleIDInt, _ := strconv.Atoi(preview.RuleID); ruleIDInt >= 949000 && ruleIDInt <= 959999 {
+		return nil, fmt.Errorf("rule %s is an anomaly score evaluation rule; exclude the rule that contributed to the score instead", preview.RuleID)
+	}
+
+	if preview.Variable == "" {
+		return nil, fmt.Errorf("event has no matched variable, specify variable explicitly")
+	}
+	if strings.HasPrefix(preview.Variable, "TX:") {
+		return nil, fmt.Errorf("transaction variable %s cannot be excluded", preview.Variable)
+	}
+	if !exclusionVariableRegex.MatchString(preview.Variable) {
+		return nil, fmt.Errorf("unsupported variable name: %s", preview.Variable)
+	}
+	if !strings.Contains(preview.Variable, ":") {
+		preview.Warnings = append(preview.Warnings,
+			fmt.Sprintf("variable %s has no key; the whole collection will be excluded from rule %s", preview.Variable, preview.RuleID))
+	}
+
+	if preview.Mode == "ctl" {
+		// 다른 테넌트의 사이트에 영향을 주지 않도록 이벤트의 호스트로 제한
+		preview.Host = normalizeHost(event.Host)
+		if preview.Host == "" || !targetHostPattern.MatchString(preview.Host) {
+			return nil, fmt.Errorf("event has no valid host, the exclusion cannot be scoped to a site")
+		}
+		if preview.Scope == "" {
+			preview.Scope = "exact"
+		}
+		if preview.URI == "" {
+			preview.URI = strings.SplitN(event.URL, "?", 2)[0]
+		}
+		if preview.URI == "" || !strings.HasPrefix(preview.URI, "/") {
+			return nil, fmt.Errorf("event has no request path, specify uri explicitly")
+		}
+		if strings.ContainsAny(preview.URI, "\"\\\n") {
+			return nil, fmt.Errorf("uri contains unsupported characters")
+		}
+	} else {
+		// SecRuleUpdateTargetById는 URI로 범위를 좁힐 수 없음
+		preview.Scope = ""
+		preview.URI = ""
+		preview.Warnings = append(preview.Warnings,
+			"update_target mode applies to every URI on every protected host")
+	}
+
+	preview.Name = req.Name
+	if preview.Name == "" {
+		preview.Name = fmt.Sprintf("FP exclusion: %s for rule %s", preview.Variable, preview.RuleID)
+	}
+	preview.Description = req.Description
+	if preview.Description == "" {
+		preview.Description = fmt.Sprintf("Generated from event %s (%s %s)", event.ID, event.Method, event.URL)
+	}
+
+	return preview, nil
+}
+
+func renderExclusionRule(preview *dto.ExclusionPreview) string {
+	if preview.Mode == "update_target" {
+		return fmt.Sprintf(`SecRuleUpdateTargetById %s "!%s"`, preview.RuleID, preview.Variable)
+	}
+
+	operator := "@streq"
+	if preview.Scope == "prefix" {
+		operator = "@beginsWith"
+	}
+
+	return fmt.Sprintf("SecRule REQUEST_FILENAME \"%s %s\" \"id:%d,phase:1,pass,nolog,chain\"\n"+
+		"    SecRule REQUEST_HEADERS:Host \"@rx %s\" \"t:lowercase,ctl:ruleRemoveTargetById=%s;%s\"",
+		operator, preview.URI, preview.ExclusionID, hostRegex([]string{preview.Host}), preview.RuleID, preview.Variable)
+}
+
+// normalizeHost 소문자, 포트와 끝의 "." 제거
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// hostRegex 호스트 목록과 일치하는 Host 헤더 정규식 (포트 허용, *.example.com은 하위 도메인 전체)
+func hostRegex(hosts []string) string {
+	alternatives := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if strings.HasPrefix(host, "*.") {
+			alternatives = append(alternatives, ".+"+regexp.QuoteMeta(host[1:]))
+		} else {
+			alternatives = append(alternatives, regexp.QuoteMeta(host))
+		}
+	}
+	return "^(?:" + strings.Join(alternatives, "|") + ")(?::[0-9]+)?$"
+}
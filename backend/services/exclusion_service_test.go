@@ -0,0 +1,124 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"waf-backend/dto"
+	"waf-backend/models"
+)
+
+func newTestExclusionService(events ...dto.WAFLog) *ExclusionService {
+	return &ExclusionService{
+		log:         newTestLogger(),
+		wafService:  &WAFService{log: newTestLogger(), logs: events},
+		ruleService: &RuleService{log: newTestLogger(), rules: make(map[string]*models.CustomRule)},
+	}
+}
+
+func TestBuildExclusionPreview(t *testing.T) {
+	event := &dto.WAFLog{ID: "evt", Method: "POST", Host: "Shop.Example.com:8443", URL: "/api/login?next=/", RuleID: "942100", MatchedVar: "ARGS:password"}
+	tests := []struct {
+		name     string
+		event    *dto.WAFLog
+		req      dto.ExclusionRequest
+		wantRule string
+		wantErr  string
+	}{
+		{
+			name:     "ctl exact from event",
+			event:    event,
+			wantRule: "SecRule REQUEST_FILENAME \"@streq /api/login\" \"id:95000,phase:1,pass,nolog,chain\"\n    SecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"t:lowercase,ctl:ruleRemoveTargetById=942100;ARGS:password\"",
+		},
+		{
+			name:     "ctl prefix",
+			event:    event,
+			req:      dto.ExclusionRequest{Scope: "prefix", URI: "/api/"},
+			wantRule: "SecRule REQUEST_FILENAME \"@beginsWith /api/\" \"id:95000,phase:1,pass,nolog,chain\"\n    SecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"t:lowercase,ctl:ruleRemoveTargetById=942100;ARGS:password\"",
+		},
+		{
+			name:     "update target",
+			event:    event,
+			req:      dto.ExclusionRequest{Mode: "update_target", Variable: "REQUEST_COOKIES:session"},
+			wantRule: `SecRuleUpdateTargetById 942100 "!REQUEST_COOKIES:session"`,
+		},
+		{"event without host", &dto.WAFLog{URL: "/x", RuleID: "942100", MatchedVar: "ARGS:a"}, dto.ExclusionRequest{}, "", "no valid host"},
+		{"anomaly rule", event, dto.ExclusionRequest{RuleID: "949110"}, "", "anomaly score"},
+		{"non numeric rule", &dto.WAFLog{Host: "a.example.com", URL: "/x", MatchedVar: "ARGS:a"}, dto.ExclusionRequest{}, "", "no numeric rule ID"},
+		{"tx variable", event, dto.ExclusionRequest{Variable: "TX:anomaly_score"}, "", "cannot be excluded"},
+		{"injected variable", event, dto.ExclusionRequest{Variable: "ARGS:a,ctl:ruleEngine=Off"}, "", "unsupported variable"},
+		{"quoted uri", event, dto.ExclusionRequest{URI: "/a\" \"id:1"}, "", "unsupported characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview, err := buildExclusionPreview(tt.event, &tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildExclusionPreview() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildExclusionPreview() error = %v", err)
+			}
+			if preview.Mode == "ctl" {
+				preview.ExclusionID = exclusionRuleIDMin
+			}
+			if got := renderExclusionRule(preview); got != tt.wantRule {
+				t.Errorf("renderExclusionRule() =\n%s\nwant\n%s", got, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestPreviewExclusionPermissions(t *testing.T) {
+	s := newTestExclusionService(dto.WAFLog{ID: "evt", Host: "shop.example.com", URL: "/login", RuleID: "942100", MatchedVar: "ARGS:q"})
+	tests := []struct {
+		name    string
+		admin   bool
+		req     dto.ExclusionRequest
+		wantErr string
+	}{
+		{"user ctl", false, dto.ExclusionRequest{}, ""},
+		{"user update target", false, dto.ExclusionRequest{Mode: "update_target"}, "requires admin"},
+		{"user other rule", false, dto.ExclusionRequest{RuleID: "941100"}, "only admins"},
+		{"user same rule", false, dto.ExclusionRequest{RuleID: "942100"}, ""},
+		{"admin update target", true, dto.ExclusionRequest{Mode: "update_target"}, ""},
+		{"admin other rule", true, dto.ExclusionRequest{RuleID: "941100"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.PreviewExclusion("evt", nil, tt.admin, &tt.req)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("PreviewExclusion() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("PreviewExclusion() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := s.PreviewExclusion("missing", nil, true, &dto.ExclusionRequest{}); err == nil {
+		t.Error("PreviewExclusion(unknown event) succeeded")
+	}
+	// 호출자의 테넌트 범위 밖 이벤트는 없는 것으로 처리
+	otherTenant := func(wafLog *dto.WAFLog) bool { return wafLog.Host == "blog.example.com" }
+	if _, err := s.PreviewExclusion("evt", otherTenant, false, &dto.ExclusionRequest{}); err == nil {
+		t.Error("PreviewExclusion(event outside scope) succeeded")
+	}
+}
+
+// ctl 모드 ID는 기존 룰이 쓰지 않는 가장 낮은 번호
+func TestPreviewExclusionAllocatesFreeID(t *testing.T) {
+	s := newTestExclusionService(dto.WAFLog{ID: "evt", Host: "shop.example.com", URL: "/login", RuleID: "942100", MatchedVar: "ARGS:q"})
+	s.ruleService.rules["r1"] = &models.CustomRule{ID: "r1", RuleText: `SecRule ARGS "@rx a" "id:95000,phase:1,pass"`}
+
+	preview, err := s.PreviewExclusion("evt", nil, false, &dto.ExclusionRequest{})
+	if err != nil {
+		t.Fatalf("PreviewExclusion() error = %v", err)
+	}
+	if preview.ExclusionID != 95001 || !strings.Contains(preview.RuleText, "id:95001,") {
+		t.Errorf("ExclusionID = %d, rule %s", preview.ExclusionID, preview.RuleText)
+	}
+}
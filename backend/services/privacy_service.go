@@ -0,0 +1,329 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"waf-backend/config"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	erasedValue    = "[ERASED]"
+	anonymizedIP   = "0.0.0.0"
+	maxSearchItems = 100
+
+	// 너무 짧은 식별자는 무관한 이벤트까지 지울 수 있으므로 거부
+	minIdentifierLength = 3
+
+	// 증명서 서명 키 최소 길이 (HMAC-SHA256 블록 기준)
+	minErasureSigningKeyLength = 32
+)
+
+// subjectMatcher 정보주체(IP/식별자)와 관련된 데이터를 찾는 매처
+type subjectMatcher struct {
+	ip         string
+	identifier string
+	ipRegex    *regexp.Regexp
+	eventIDs   map[string]bool // 매칭된 이벤트 ID (파생 데이터 정리에 사용)
+}
+
+func newSubjectMatcher(subject dto.ErasureSubject) (*subjectMatcher, error) {
+	m := &subjectMatcher{
+		identifier: strings.TrimSpace(subject.Identifier),
+		eventIDs:   make(map[string]bool),
+	}
+
+	if subject.IP != "" {
+		ip := net.ParseIP(strings.TrimSpace(subject.IP))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address: %s", subject.IP)
+		}
+		m.ip = ip.String()
+		// 다른 IP의 일부로 매칭되지 않도록 경계 확인 (예: 1.2.3.4 vs 11.2.3.45)
+		m.ipRegex = regexp.MustCompile(`(^|[^0-9A-Fa-f.:])` + regexp.QuoteMeta(m.ip) + `($|[^0-9A-Fa-f.:])`)
+	}
+
+	if m.identifier != "" && len(m.identifier) < minIdentifierLength {
+		return nil, fmt.Errorf("identifier must be at least %d characters", minIdentifierLength)
+	}
+
+	if m.ip == "" && m.identifier == "" {
+		return nil, fmt.Errorf("ip or identifier is required")
+	}
+
+	return m, nil
+}
+
+func (m *subjectMatcher) matchText(text string) bool {
+	if text == "" {
+		return false
+	}
+	if m.ipRegex != nil && m.ipRegex.MatchString(text) {
+		return true
+	}
+	if m.identifier != "" {
+		lower := strings.ToLower(text)
+		needle := strings.ToLower(m.identifier)
+		if strings.Contains(lower, needle) {
+			return true
+		}
+		if decoded, err := url.QueryUnescape(text); err == nil && strings.Contains(strings.ToLower(decoded), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *subjectMatcher) matchLog(wafLog *dto.WAFLog) bool {
+	if m.ip != "" && wafLog.ClientIP == m.ip {
+		return true
+	}
+	return m.matchText(wafLog.URL) || m.matchText(wafLog.RawLog) ||
+		m.matchText(wafLog.UserAgent) || m.matchText(wafLog.Message)
+}
+
+// anonymize 이벤트에서 정보주체 관련 값을 제거 (통계용 필드는 유지)
+func (m *subjectMatcher) anonymize(wafLog *dto.WAFLog) {
+	if m.ip != "" && wafLog.ClientIP == m.ip {
+		wafLog.ClientIP = anonymizedIP
+	}
+	wafLog.URL = m.scrub(wafLog.URL)
+	wafLog.RawLog = m.scrub(wafLog.RawLog)
+	wafLog.UserAgent = m.scrub(wafLog.UserAgent)
+	wafLog.Message = m.scrub(wafLog.Message)
+}
+
+func (m *subjectMatcher) scrub(text string) string {
+	if m.ipRegex != nil {
+		text = m.ipRegex.ReplaceAllString(text, "${1}"+erasedValue+"${2}")
+	}
+	if m.identifier != "" {
+		text = regexp.MustCompile(`(?i)`+regexp.QuoteMeta(m.identifier)).ReplaceAllString(text, erasedValue)
+		text = regexp.MustCompile(`(?i)`+regexp.QuoteMeta(url.QueryEscape(m.identifier))).ReplaceAllString(text, erasedValue)
+	}
+	return text
+}
+
+type PrivacyService struct {
+	log           *logrus.Logger
+	db            *gorm.DB
+	secret        []byte // ERASURE_SIGNING_KEY, 없으면 삭제/검증 불가
+	wafService    *WAFService
+	alertService  *AlertService
+	banService    *BanService
+	replayService *ReplayService
+	ruleService   *RuleService
+	mutex         sync.Mutex // 삭제 요청은 한 번에 하나씩 처리
+}
+
+func NewPrivacyService(cfg *config.Config, log *logrus.Logger, db *gorm.DB, wafService *WAFService, alertService *AlertService,
+	banService *BanService, replayService *ReplayService, ruleService *RuleService) *PrivacyService {
+	service := &PrivacyService{
+		log:           log,
+		db:            db,
+		wafService:    wafService,
+		alertService:  alertService,
+		banService:    banService,
+		replayService: replayService,
+		ruleService:   ruleService,
+	}
+
+	// 재시작마다 바뀌는 키로 서명하면 이전 증명서를 검증할 수 없으므로 임의 생성하지 않음
+	if len(cfg.Security.ErasureSigningKey) >= minErasureSigningKeyLength {
+		service.secret = []byte(cfg.Security.ErasureSigningKey)
+	} else {
+		log.Errorf("ERASURE_SIGNING_KEY must be set to at least %d characters; data subject erasure is disabled", minErasureSigningKeyLength)
+	}
+
+	return service
+}
+
+// Search 모든 저장소에서 정보주체와 관련된 데이터를 찾아서 개수와 이벤트 목록 반환
+func (s *PrivacyService) Search(subject dto.ErasureSubject) (*dto.ErasureSearchResponse, error) {
+	matcher, err := newSubjectMatcher(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	events := s.wafService.FindLogs(matcher.matchLog)
+	for _, event := range events {
+		matcher.eventIDs[event.ID] = true
+	}
+
+	response := &dto.ErasureSearchResponse{
+		Matches: map[string]int{
+			"waf_events":     len(events),
+			"alerts":         s.alertService.purgeSubject(matcher, true),
+			"ban_counters":   s.banService.purgeSubject(matcher, true),
+			"replay_results": s.replayService.purgeSubject(matcher, true),
+			"rule_hits":      s.ruleService.purgeSubjectHits(matcher, true),
+			"active_bans":    s.banService.countSubjectBans(matcher),
+		},
+		Events: events,
+	}
+	if len(response.Events) > maxSearchItems {
+		response.Events = response.Events[:maxSearchItems]
+	}
+
+	return response, nil
+}
+
+// Erase 이벤트는 삭제 또는 익명화, 파생 데이터(알림, 집계 window, 재전송 결과, 룰 매칭 기록)는 삭제 후 증명서 발급
+func (s *PrivacyService) Erase(requestedBy string, req *dto.ErasureRequest) (*dto.ErasureCertificate, error) {
+	if s.secret == nil {
+		return nil, fmt.Errorf("erasure signing key (ERASURE_SIGNING_KEY) is not configured")
+	}
+	matcher, err := newSubjectMatcher(req.ErasureSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var anonymize func(*dto.WAFLog)
+	if req.Mode == "anonymize" {
+		anonymize = matcher.anonymize
+	}
+	for _, id := range s.wafService.EraseLogs(matcher.matchLog, anonymize) {
+		matcher.eventIDs[id] = true
+	}
+
+	cert := dto.ErasureCertificate{
+		ID:          generateErasureID(),
+		SubjectHash: s.hashSubject(matcher),
+		Mode:        req.Mode,
+		Reason:      req.Reason,
+		RequestedBy: requestedBy,
+		Erased: map[string]int{
+			"waf_events":     len(matcher.eventIDs),
+			"alerts":         s.alertService.purgeSubject(matcher, false),
+			"ban_counters":   s.banService.purgeSubject(matcher, false),
+			"replay_results": s.replayService.purgeSubject(matcher, false),
+			"rule_hits":      s.ruleService.purgeSubjectHits(matcher, false),
+		},
+		Retained: map[string]int{
+			"active_bans": s.banService.countSubjectBans(matcher),
+		},
+		IssuedAt: time.Now().UTC(),
+	}
+	if matcher.ip != "" {
+		cert.SubjectType = append(cert.SubjectType, "ip")
+	}
+	if matcher.identifier != "" {
+		cert.SubjectType = append(cert.SubjectType, "identifier")
+	}
+	cert.Signature = s.sign(cert)
+
+	// 데이터는 이미 지워졌으므로 저장 실패는 감사 기록 누락으로 보고
+	if err := s.db.Create(erasureCertificateToModel(&cert)).Error; err != nil {
+		s.log.WithError(err).WithField("erasure_id", cert.ID).Error("Failed to save erasure certificate")
+		return nil, fmt.Errorf("data was erased but the certificate could not be saved: %v", err)
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"erasure_id":   cert.ID,
+		"requested_by": requestedBy,
+		"mode":         req.Mode,
+		"erased":       cert.Erased,
+		"subject_hash": cert.SubjectHash,
+	}).Warn("Data subject erasure completed")
+
+	return &cert, nil
+}
+
+// GetCertificates 감사 기록 (최신 먼저)
+func (s *PrivacyService) GetCertificates() ([]dto.ErasureCertificate, error) {
+	var rows []models.ErasureCertificate
+	if err := s.db.Order("issued_at desc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.ErasureCertificate, 0, len(rows))
+	for i := range rows {
+		result = append(result, *erasureCertificateFromModel(&rows[i]))
+	}
+	return result, nil
+}
+
+func (s *PrivacyService) GetCertificate(id string) (*dto.ErasureCertificate, error) {
+	var row models.ErasureCertificate
+	if err := s.db.Where("id = ?", id).First(&row).Error; err != nil {
+		return nil, fmt.Errorf("erasure certificate not found")
+	}
+	return erasureCertificateFromModel(&row), nil
+}
+
+// VerifyCertificate 증명서 서명 검증
+func (s *PrivacyService) VerifyCertificate(cert dto.ErasureCertificate) bool {
+	if s.secret == nil {
+		return false
+	}
+	expected := s.sign(cert)
+	return hmac.Equal([]byte(expected), []byte(cert.Signature))
+}
+
+func (s *PrivacyService) hashSubject(m *subjectMatcher) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(m.ip + "|" + strings.ToLower(m.identifier)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sign 서명 필드를 제외한 증명서 내용에 대한 HMAC-SHA256
+func (s *PrivacyService) sign(cert dto.ErasureCertificate) string {
+	cert.Signature = ""
+	payload, _ := json.Marshal(cert)
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func erasureCertificateToModel(cert *dto.ErasureCertificate) *models.ErasureCertificate {
+	erased, _ := json.Marshal(cert.Erased)
+	retained, _ := json.Marshal(cert.Retained)
+	return &models.ErasureCertificate{
+		ID:          cert.ID,
+		SubjectHash: cert.SubjectHash,
+		SubjectType: encodeStrings(cert.SubjectType),
+		Mode:        cert.Mode,
+		Reason:      cert.Reason,
+		RequestedBy: cert.RequestedBy,
+		Erased:      string(erased),
+		Retained:    string(retained),
+		IssuedAt:    cert.IssuedAt,
+		Signature:   cert.Signature,
+	}
+}
+
+func erasureCertificateFromModel(model *models.ErasureCertificate) *dto.ErasureCertificate {
+	cert := &dto.ErasureCertificate{
+		ID:          model.ID,
+		SubjectHash: model.SubjectHash,
+		SubjectType: decodeStrings(model.SubjectType),
+		Mode:        model.Mode,
+		Reason:      model.Reason,
+		RequestedBy: model.RequestedBy,
+		IssuedAt:    model.IssuedAt.UTC(), // 서명할 때와 같은 표현으로 맞춤
+		Signature:   model.Signature,
+	}
+	json.Unmarshal([]byte(model.Erased), &cert.Erased)
+	json.Unmarshal([]byte(model.Retained), &cert.Retained)
+	return cert
+}
+
+func generateErasureID() string {
+	return fmt.Sprintf("erasure_%d", time.Now().UnixNano())
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"waf-backend/config"
+	"waf-backend/dto"
+	"waf-backend/models"
+)
+
+const testErasureSigningKey = "0123456789abcdef0123456789abcdef"
+
+func newTestPrivacyService(t *testing.T, signingKey string, events ...dto.WAFLog) *PrivacyService {
+	t.Helper()
+	db := newTestDB(t, &models.AlertRule{}, &models.NotificationChannel{}, &models.BanPolicy{}, &models.IPBan{}, &models.ErasureCertificate{})
+	wafService := &WAFService{log: newTestLogger(), logs: events, erased: make(map[string]bool)}
+	return NewPrivacyService(&config.Config{Security: config.SecurityConfig{ErasureSigningKey: signingKey}}, newTestLogger(), db,
+		wafService, newTestAlertService(t, db), newTestBanService(t, db), newTestReplayService("http://app:3000"), newTestRuleService(t, db, nil))
+}
+
+func TestSubjectMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject dto.ErasureSubject
+		text    string
+		want    bool
+		wantErr string
+	}{
+		{"ip in raw log", dto.ErasureSubject{IP: "1.2.3.4"}, "client: 1.2.3.4, server", true, ""},
+		{"ip prefix of other ip", dto.ErasureSubject{IP: "1.2.3.4"}, "client: 11.2.3.45", false, ""},
+		{"ipv6 normalized", dto.ErasureSubject{IP: "2001:DB8::1"}, "from 2001:db8::1 ", true, ""},
+		{"identifier case insensitive", dto.ErasureSubject{Identifier: "Kim@Example.com"}, "/login?user=kim@example.com", true, ""},
+		{"identifier url encoded", dto.ErasureSubject{Identifier: "kim@example.com"}, "/login?user=kim%40example.com", true, ""},
+		{"unrelated", dto.ErasureSubject{Identifier: "kim@example.com"}, "/login?user=lee", false, ""},
+		{"invalid ip", dto.ErasureSubject{IP: "1.2.3"}, "", false, "invalid IP"},
+		{"short identifier", dto.ErasureSubject{Identifier: "ab"}, "", false, "at least"},
+		{"empty subject", dto.ErasureSubject{}, "", false, "required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newSubjectMatcher(tt.subject)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newSubjectMatcher() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newSubjectMatcher() error = %v", err)
+			}
+			if got := m.matchText(tt.text); got != tt.want {
+				t.Errorf("matchText(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrivacyServiceErase(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		wantLogs   int
+		wantClient string
+		wantURL    string
+	}{
+		{"delete", "delete", 1, "", ""},
+		{"anonymize", "anonymize", 2, anonymizedIP, "/login?user=[ERASED]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPrivacyService(t, testErasureSigningKey,
+				dto.WAFLog{ID: "e1", ClientIP: "1.2.3.4", URL: "/login?user=1.2.3.4", RawLog: "raw1"},
+				dto.WAFLog{ID: "e2", ClientIP: "5.6.7.8", URL: "/other", RawLog: "raw2"},
+			)
+			s.alertService.history = []dto.Alert{{ID: "a1", GroupKey: "1.2.3.4"}, {ID: "a2", GroupKey: "5.6.7.8"}}
+			s.alertService.windows["rule|1.2.3.4"] = []time.Time{time.Now()}
+			s.banService.bans["b1"] = &dto.IPBan{ID: "b1", IP: "1.2.3.0/24"}
+			s.banService.windows["policy|1.2.3.4"] = []time.Time{time.Now()}
+			s.replayService.reports = []*dto.ReplayReport{{
+				ID:      "r1",
+				Summary: map[string]int{ReplayOutcomeNowAllowed: 2},
+				Results: []dto.ReplayResult{{EventID: "e1", Outcome: ReplayOutcomeNowAllowed}, {EventID: "e2", Outcome: ReplayOutcomeNowAllowed}},
+			}}
+
+			cert, err := s.Erase("admin@example.com", &dto.ErasureRequest{ErasureSubject: dto.ErasureSubject{IP: "1.2.3.4"}, Mode: tt.mode, Reason: "GDPR request"})
+			if err != nil {
+				t.Fatalf("Erase() error = %v", err)
+			}
+
+			wantErased := map[string]int{"waf_events": 1, "alerts": 1, "ban_counters": 1, "replay_results": 1}
+			for store, count := range wantErased {
+				if cert.Erased[store] != count {
+					t.Errorf("Erased[%s] = %d, want %d", store, cert.Erased[store], count)
+				}
+			}
+			if cert.Retained["active_bans"] != 1 {
+				t.Errorf("Retained[active_bans] = %d, want 1", cert.Retained["active_bans"])
+			}
+
+			logs := s.wafService.logs
+			if len(logs) != tt.wantLogs {
+				t.Fatalf("remaining logs = %d, want %d", len(logs), tt.wantLogs)
+			}
+			if tt.mode == "anonymize" && (logs[0].ClientIP != tt.wantClient || logs[0].URL != tt.wantURL) {
+				t.Errorf("anonymized log = %+v", logs[0])
+			}
+			if len(s.alertService.history) != 1 || len(s.alertService.windows) != 0 || len(s.banService.windows) != 0 {
+				t.Errorf("derived data not purged: history %v, windows %v, ban windows %v",
+					s.alertService.history, s.alertService.windows, s.banService.windows)
+			}
+			if report := s.replayService.reports[0]; report.Total != 1 || report.Summary[ReplayOutcomeNowAllowed] != 1 {
+				t.Errorf("replay report = %+v", report)
+			}
+
+			// 지워진 원본은 다시 수집되지 않음
+			if s.wafService.storeLog(dto.WAFLog{ID: "e1-again", ClientIP: "1.2.3.4", RawLog: "raw1"}, true) {
+				t.Error("erased log was collected again")
+			}
+		})
+	}
+}
+
+func TestPrivacyServiceCertificates(t *testing.T) {
+	s := newTestPrivacyService(t, testErasureSigningKey, dto.WAFLog{ID: "e1", ClientIP: "1.2.3.4", RawLog: "raw"})
+	cert, err := s.Erase("admin@example.com", &dto.ErasureRequest{ErasureSubject: dto.ErasureSubject{IP: "1.2.3.4", Identifier: "kim@example.com"}, Mode: "delete", Reason: "request"})
+	if err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+	if strings.Contains(cert.SubjectHash, "1.2.3.4") || len(cert.SubjectType) != 2 {
+		t.Errorf("certificate subject = %s %v", cert.SubjectHash, cert.SubjectType)
+	}
+
+	stored, err := s.GetCertificate(cert.ID)
+	if err != nil {
+		t.Fatalf("GetCertificate() error = %v", err)
+	}
+	certs, err := s.GetCertificates()
+	if err != nil || len(certs) != 1 || certs[0].ID != cert.ID {
+		t.Fatalf("GetCertificates() = %v, %v", certs, err)
+	}
+
+	tampered := *stored
+	tampered.Erased = map[string]int{"waf_events": 0}
+	other := NewPrivacyService(&config.Config{Security: config.SecurityConfig{ErasureSigningKey: strings.Repeat("x", 32)}}, newTestLogger(), s.db, s.wafService, s.alertService, s.banService, s.replayService, s.ruleService)
+
+	tests := []struct {
+		name    string
+		service *PrivacyService
+		cert    dto.ErasureCertificate
+		want    bool
+	}{
+		{"stored certificate", s, *stored, true},
+		{"tampered", s, tampered, false},
+		{"other key", other, *stored, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.service.VerifyCertificate(tt.cert); got != tt.want {
+				t.Errorf("VerifyCertificate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.GetCertificate("missing"); err == nil {
+		t.Error("GetCertificate(missing) succeeded")
+	}
+}
+
+func TestPrivacyServiceRequiresSigningKey(t *testing.T) {
+	s := newTestPrivacyService(t, "too-short", dto.WAFLog{ID: "e1", ClientIP: "1.2.3.4", RawLog: "raw"})
+
+	_, err := s.Erase("admin@example.com", &dto.ErasureRequest{ErasureSubject: dto.ErasureSubject{IP: "1.2.3.4"}, Mode: "delete", Reason: "request"})
+	if err == nil || !strings.Contains(err.Error(), "ERASURE_SIGNING_KEY") {
+		t.Fatalf("Erase() error = %v, want signing key error", err)
+	}
+	if len(s.wafService.logs) != 1 {
+		t.Error("Erase() without a signing key removed events")
+	}
+	if s.VerifyCertificate(dto.ErasureCertificate{}) {
+		t.Error("VerifyCertificate() without a signing key succeeded")
+	}
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"waf-backend/config"
+	"waf-backend/dto"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redactedValue = "[REDACTED]"
+
+// 기본 제공 값 탐지기
+var builtinDetectors = map[string]*regexp.Regexp{
+	"credit_card": regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+	"email":       regexp.MustCompile(`[A-Za-z0-9._+-]+(?:@|%40)[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	"jwt":         regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+}
+
+var (
+	// name=value 형태 (쿼리 스트링, form 본문)
+	formParamRegex = regexp.MustCompile(`([?&;\s]|^)([^=&;?\s"']+)=([^&;\s"'#]*)`)
+	// "name": "value" 형태 (JSON 본문)
+	jsonParamRegex = regexp.MustCompile(`"([^"\\]+)"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+)
+
+type RedactionService struct {
+	log       *logrus.Logger
+	config    dto.RedactionConfig
+	headers   *regexp.Regexp
+	params    []*regexp.Regexp
+	detectors map[string]*regexp.Regexp
+	mutex     sync.RWMutex
+}
+
+func NewRedactionService(cfg *config.Config, log *logrus.Logger) *RedactionService {
+	service := &RedactionService{log: log}
+
+	initial := dto.RedactionConfig{
+		Enabled:       cfg.Redaction.Enabled,
+		HeaderNames:   cfg.Redaction.HeaderNames,
+		ParamPatterns: cfg.Redaction.ParamPatterns,
+		Detectors:     cfg.Redaction.Detectors,
+	}
+	if err := service.UpdateConfig(initial); err != nil {
+		// 잘못된 환경변수 설정이어도 기본 탐지기는 동작하도록 유지
+		log.WithError(err).Error("Invalid redaction configuration, falling back to built-in detectors only")
+		initial.ParamPatterns = nil
+		initial.Detectors = []string{"credit_card", "email", "jwt"}
+		service.UpdateConfig(initial)
+	}
+
+	log.WithFields(logrus.Fields{
+		"enabled":   initial.Enabled,
+		"headers":   len(initial.HeaderNames),
+		"params":    len(initial.ParamPatterns),
+		"detectors": initial.Detectors,
+	}).Info("Redaction service initialized")
+
+	return service
+}
+
+func (s *RedactionService) GetConfig() dto.RedactionConfig {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.config
+}
+
+// UpdateConfig 설정을 검증/컴파일 후 교체 (이후 수집되는 이벤트부터 적용)
+func (s *RedactionService) UpdateConfig(cfg dto.RedactionConfig) error {
+	var headers *regexp.Regexp
+	if len(cfg.HeaderNames) > 0 {
+		quoted := make([]string, 0, len(cfg.HeaderNames))
+		for _, name := range cfg.HeaderNames {
+			quoted = append(quoted, regexp.QuoteMeta(strings.TrimSpace(name)))
+		}
+		// "Header: value" 형태, 값은 줄 끝/따옴표/쉼표 전까지
+		headers = regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)(\s*:\s*)([^"\r\n,]+)`)
+	}
+
+	params := make([]*regexp.Regexp, 0, len(cfg.ParamPatterns))
+	for _, pattern := range cfg.ParamPatterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid parameter pattern '%s': %w", pattern, err)
+		}
+		params = append(params, compiled)
+	}
+
+	detectors := make(map[string]*regexp.Regexp)
+	for _, name := range cfg.Detectors {
+		detector, exists := builtinDetectors[name]
+		if !exists {
+			return fmt.Errorf("unknown detector '%s'", name)
+		}
+		detectors[name] = detector
+	}
+	for name, pattern := range cfg.CustomDetectors {
+		if _, exists := builtinDetectors[name]; exists {
+			return fmt.Errorf("custom detector '%s' conflicts with a built-in detector", name)
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid detector pattern '%s': %w", name, err)
+		}
+		detectors[name] = compiled
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.config = cfg
+	s.headers = headers
+	s.params = params
+	s.detectors = detectors
+
+	return nil
+}
+
+// RedactLog 저장/브로드캐스트/전달 전에 이벤트의 민감 정보를 마스킹하고 마스킹된 필드를 기록
+func (s *RedactionService) RedactLog(wafLog *dto.WAFLog) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.config.Enabled {
+		return
+	}
+
+	found := make(map[string]bool)
+	wafLog.URL = s.redactText("url", wafLog.URL, found)
+	wafLog.UserAgent = s.redactText("user_agent", wafLog.UserAgent, found)
+	wafLog.Message = s.redactText("message", wafLog.Message, found)
+	wafLog.RawLog = s.redactText("raw_log", wafLog.RawLog, found)
+
+	if len(found) > 0 {
+		for field := range found {
+			wafLog.Redactions = append(wafLog.Redactions, field)
+		}
+		sort.Strings(wafLog.Redactions)
+	}
+}
+
+// redactText 헤더 -> 파라미터 -> 값 탐지기 순서로 마스킹 (found에 "필드:항목" 기록)
+func (s *RedactionService) redactText(field, text string, found map[string]bool) string {
+	if text == "" {
+		return text
+	}
+
+	if s.headers != nil {
+		text = s.headers.ReplaceAllStringFunc(text, func(match string) string {
+			parts := s.headers.FindStringSubmatch(match)
+			found[field+":header:"+strings.ToLower(parts[1])] = true
+			return parts[1] + parts[2] + redactedValue
+		})
+	}
+
+	if len(s.params) > 0 {
+		text = formParamRegex.ReplaceAllStringFunc(text, func(match string) string {
+			parts := formParamRegex.FindStringSubmatch(match)
+			if parts[3] == "" || parts[3] == redactedValue || !s.isSensitiveParam(parts[2]) {
+				return match
+			}
+			found[field+":param:"+parts[2]] = true
+			return parts[1] + parts[2] + "=" + redactedValue
+		})
+		text = jsonParamRegex.ReplaceAllStringFunc(text, func(match string) string {
+			parts := jsonParamRegex.FindStringSubmatch(match)
+			if parts[2] == "" || !s.isSensitiveParam(parts[1]) {
+				return match
+			}
+			found[field+":param:"+parts[1]] = true
+			return `"` + parts[1] + `":"` + redactedValue + `"`
+		})
+	}
+
+	for name, detector := range s.detectors {
+		text = detector.ReplaceAllStringFunc(text, func(match string) string {
+			if name == "credit_card" && !luhnValid(match) {
+				return match
+			}
+			found[field+":"+name] = true
+			return "[REDACTED:" + name + "]"
+		})
+	}
+
+	return text
+}
+
+func (s *RedactionService) isSensitiveParam(name string) bool {
+	if decoded, err := url.QueryUnescape(name); err == nil {
+		name = decoded
+	}
+	for _, pattern := range s.params {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// luhnValid 카드 번호 체크섬 검증 (숫자 이외 문자는 무시)
+func luhnValid(value string) bool {
+	var digits []int
+	for _, c := range value {
+		if c >= '0' && c <= '9' {
+			digits = append(digits, int(c-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"waf-backend/config"
+	"waf-backend/dto"
+)
+
+func newTestRedactionService(t *testing.T) *RedactionService {
+	t.Helper()
+	return NewRedactionService(&config.Config{Redaction: config.RedactionConfig{
+		Enabled:       true,
+		HeaderNames:   []string{"Authorization", "Cookie"},
+		ParamPatterns: []string{`(?i)^(password|token|api[_-]?key)$`},
+		Detectors:     []string{"credit_card", "email", "jwt"},
+	}}, newTestLogger())
+}
+
+func TestRedactionServiceRedactLog(t *testing.T) {
+	s := newTestRedactionService(t)
+	tests := []struct {
+		name           string
+		log            dto.WAFLog
+		wantURL        string
+		wantRaw        string
+		wantRedactions []string
+	}{
+		{
+			name:           "query params",
+			log:            dto.WAFLog{URL: "/login?user=kim&password=hunter2&token=abc"},
+			wantURL:        "/login?user=kim&password=[REDACTED]&token=[REDACTED]",
+			wantRedactions: []string{"url:param:password", "url:param:token"},
+		},
+		{
+			name:           "header and json body",
+			log:            dto.WAFLog{URL: "/api", RawLog: "Authorization: Bearer xyz\n" + `{"api_key": "k-123", "name": "a"}`},
+			wantURL:        "/api",
+			wantRaw:        "Authorization: [REDACTED]\n" + `{"api_key":"[REDACTED]", "name": "a"}`,
+			wantRedactions: []string{"raw_log:header:authorization", "raw_log:param:api_key"},
+		},
+		{
+			name:           "detectors",
+			log:            dto.WAFLog{URL: "/pay?card=4111 1111 1111 1111&mail=a.b%40example.com"},
+			wantURL:        "/pay?card=[REDACTED:credit_card]&mail=[REDACTED:email]",
+			wantRedactions: []string{"url:credit_card", "url:email"},
+		},
+		{
+			name:    "invalid card number kept",
+			log:     dto.WAFLog{URL: "/order?id=1234567890123"},
+			wantURL: "/order?id=1234567890123",
+		},
+		{
+			name:    "empty value kept",
+			log:     dto.WAFLog{URL: "/login?password="},
+			wantURL: "/login?password=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.log
+			s.RedactLog(&got)
+			if got.URL != tt.wantURL {
+				t.Errorf("URL = %q, want %q", got.URL, tt.wantURL)
+			}
+			if got.RawLog != tt.wantRaw {
+				t.Errorf("RawLog = %q, want %q", got.RawLog, tt.wantRaw)
+			}
+			if !reflect.DeepEqual(got.Redactions, tt.wantRedactions) {
+				t.Errorf("Redactions = %v, want %v", got.Redactions, tt.wantRedactions)
+			}
+		})
+	}
+}
+
+func TestRedactionServiceDisabled(t *testing.T) {
+	s := newTestRedactionService(t)
+	cfg := s.GetConfig()
+	cfg.Enabled = false
+	if err := s.UpdateConfig(cfg); err != nil {
+		t.Fatalf("UpdateConfig() error = %v", err)
+	}
+
+	log := dto.WAFLog{URL: "/login?password=hunter2"}
+	s.RedactLog(&log)
+	if log.URL != "/login?password=hunter2" || log.Redactions != nil {
+		t.Errorf("disabled redaction changed the event: %+v", log)
+	}
+}
+
+func TestRedactionServiceUpdateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     dto.RedactionConfig
+		wantErr string
+	}{
+		{"valid", dto.RedactionConfig{Enabled: true, ParamPatterns: []string{`^x{1,3}$`}, Detectors: []string{"jwt"}, CustomDetectors: map[string]string{"phone": `010-\d{4}-\d{4}`}}, ""},
+		{"invalid param pattern", dto.RedactionConfig{ParamPatterns: []string{`(`}}, "invalid parameter pattern"},
+		{"unknown detector", dto.RedactionConfig{Detectors: []string{"ssn"}}, "unknown detector"},
+		{"custom shadows builtin", dto.RedactionConfig{CustomDetectors: map[string]string{"email": `.+`}}, "conflicts with a built-in"},
+		{"invalid custom detector", dto.RedactionConfig{CustomDetectors: map[string]string{"x": `[`}}, "invalid detector pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestRedactionService(t)
+			before := s.GetConfig()
+			err := s.UpdateConfig(tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("UpdateConfig() error = %v", err)
+				}
+				if !reflect.DeepEqual(s.GetConfig(), tt.cfg) {
+					t.Errorf("GetConfig() = %+v, want %+v", s.GetConfig(), tt.cfg)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("UpdateConfig() error = %v, want %q", err, tt.wantErr)
+			}
+			// 실패하면 기존 설정 유지
+			if !reflect.DeepEqual(s.GetConfig(), before) {
+				t.Errorf("failed UpdateConfig() replaced the config with %+v", s.GetConfig())
+			}
+		})
+	}
+}
+
+func TestLuhnValid(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"4111111111111111", true},
+		{"4111-1111-1111-1111", true},
+		{"4111111111111112", false},
+		{"411111111111", false},
+	}
+
+	for _, tt := range tests {
+		if got := luhnValid(tt.value); got != tt.want {
+			t.Errorf("luhnValid(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
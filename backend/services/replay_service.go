@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/tracing"
+	"waf-backend/utils"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	ReplayOutcomeStillBlocked = "still_blocked"
+	ReplayOutcomeNowAllowed   = "now_allowed"
+	ReplayOutcomeNowBlocked   = "now_blocked"
+	ReplayOutcomeStillAllowed = "still_allowed"
+	ReplayOutcomeError        = "error"
+
+	defaultReplayLimit = 100
+	maxReplayReports   = 50
+)
+
+type ReplayService struct {
+	log            *logrus.Logger
+	wafService     *WAFService
+	targetURL      string
+	allowedTargets map[string]bool // TARGET_URL + REPLAY_TARGET_URLS (정규화된 URL)
+	client         *http.Client
+	reports        []*dto.ReplayReport
+	mutex          sync.RWMutex
+}
+
+func NewReplayService(log *logrus.Logger, wafService *WAFService) *ReplayService {
+	targetURL := utils.GetEnv("TARGET_URL", "http://host.docker.internal:3000")
+
+	// 재전송 대상은 운영자가 설정한 URL로만 제한 (요청 값으로 임의 주소에 보내지 않도록)
+	allowedTargets := make(map[string]bool)
+	for _, entry := range append([]string{targetURL}, strings.Split(utils.GetEnv("REPLAY_TARGET_URLS", ""), ",")...) {
+		if base, err := parseReplayTarget(entry); err == nil {
+			allowedTargets[base.String()] = true
+		}
+	}
+
+	return &ReplayService{
+		log:            log,
+		wafService:     wafService,
+		targetURL:      targetURL,
+		allowedTargets: allowedTargets,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: tracing.Transport(nil),
+			// 리다이렉트는 따라가지 않고 원래 응답 코드를 그대로 비교
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Replay 선택된 이벤트의 원래 요청을 재구성해서 대상 URL로 재전송하고 차단 여부를 비교
+func (s *ReplayService) Replay(ctx context.Context, userID string, scope LogFilter, req *dto.ReplayRequest) (*dto.ReplayReport, error) {
+	ctx, span := tracing.Start(ctx, "ReplayService.Replay", attribute.String("user_id", userID))
+	defer span.End()
+
+	targetURL := req.TargetURL
+	if targetURL == "" {
+		targetURL = s.targetURL
+	}
+	base, err := parseReplayTarget(targetURL)
+	if err != nil {
+		return nil, err
+	}
+	if !s.allowedTargets[base.String()] {
+		return nil, fmt.Errorf("target_url %s is not an allowed replay target (TARGET_URL or REPLAY_TARGET_URLS)", base.String())
+	}
+
+	events, err := s.selectEvents(scope, req)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.ReplayReport{
+		ID:        generateReplayID(),
+		TargetURL: base.String(),
+		UserID:    userID,
+		StartedAt: time.Now(),
+		Summary:   make(map[string]int),
+		Results:   make([]dto.ReplayResult, 0, len(events)),
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"replay_id":  report.ID,
+		"user_id":    userID,
+		"target_url": report.TargetURL,
+		"events":     len(events),
+	}).Info("Replaying captured requests")
+
+	for i := range events {
+		result := s.replayEvent(ctx, base, &events[i])
+		report.Results = append(report.Results, result)
+		report.Summary[result.Outcome]++
+	}
+
+	report.Total = len(report.Results)
+	report.FinishedAt = time.Now()
+
+	s.mutex.Lock()
+	s.reports = append(s.reports, report)
+	if len(s.reports) > maxReplayReports {
+		s.reports = s.reports[len(s.reports)-maxReplayReports:]
+	}
+	s.mutex.Unlock()
+
+	s.log.WithFields(logrus.Fields{
+		"replay_id": report.ID,
+		"summary":   report.Summary,
+	}).Info("Replay completed")
+
+	return copyReplayReport(report), nil
+}
+
+// parseReplayTarget http(s) URL 검증 및 정규화 (끝의 / 제거)
+func parseReplayTarget(targetURL string) (*url.URL, error) {
+	base, err := url.Parse(strings.TrimRight(strings.TrimSpace(targetURL), "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("target_url must be a valid http(s) URL")
+	}
+	base.Scheme = strings.ToLower(base.Scheme)
+	base.Host = strings.ToLower(base.Host)
+	return base, nil
+}
+
+func (s *ReplayService) GetReports(userID string) []*dto.ReplayReport {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*dto.ReplayReport, 0)
+	for i := len(s.reports) - 1; i >= 0; i-- {
+		if s.reports[i].UserID == userID {
+			result = append(result, copyReplayReport(s.reports[i]))
+		}
+	}
+	return result
+}
+
+func (s *ReplayService) GetReport(userID, reportID string) (*dto.ReplayReport, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, report := range s.reports {
+		if report.ID == reportID {
+			if report.UserID != userID {
+				return nil, fmt.Errorf("access denied")
+			}
+			return copyReplayReport(report), nil
+		}
+	}
+	return nil, fmt.Errorf("replay report not found")
+}
+
+// copyReplayReport 락 밖에서 써도 되는 복사본 (저장된 리포트와 Summary/Results를 공유하지 않음)
+func copyReplayReport(report *dto.ReplayReport) *dto.ReplayReport {
+	copied := *report
+	copied.Summary = make(map[string]int, len(report.Summary))
+	for outcome, count := range report.Summary {
+		copied.Summary[outcome] = count
+	}
+	copied.Results = append([]dto.ReplayResult(nil), report.Results...)
+	return &copied
+}
+
+// purgeSubject 정보주체 이벤트에서 파생된 재전송 결과 삭제 (dryRun이면 개수만 반환)
+func (s *ReplayService) purgeSubject(m *subjectMatcher, dryRun bool) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	for _, report := range s.reports {
+		kept := report.Results[:0:0]
+		for _, result := range report.Results {
+			if m.eventIDs[result.EventID] || m.matchText(result.URL) {
+				count++
+				if !dryRun {
+					report.Summary[result.Outcome]--
+				}
+				continue
+			}
+			kept = append(kept, result)
+		}
+		if !dryRun {
+			report.Results = kept
+			report.Total = len(kept)
+		}
+	}
+	return count
+}
+
+func (s *ReplayService) selectEvents(scope LogFilter, req *dto.ReplayRequest) ([]dto.WAFLog, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultReplayLimit
+	}
+
+	var events []dto.WAFLog
+	if len(req.EventIDs) > 0 {
+		for _, id := range req.EventIDs {
+			event, err := s.wafService.GetLogByID(id, scope)
+			if err != nil {
+				return nil, fmt.Errorf("event %s not found", id)
+			}
+			events = append(events, *event)
+		}
+	} else if req.From != nil || req.To != nil {
+		var from, to time.Time
+		if req.From != nil {
+			from = *req.From
+		}
+		if req.To != nil {
+			to = *req.To
+		}
+		events = s.wafService.GetLogsInRange(from, to, scope)
+	} else {
+		return nil, fmt.Errorf("either event_ids or a time range (from/to) is required")
+	}
+
+	// 요청 라인이 없는 이벤트는 재구성할 수 없음
+	replayable := make([]dto.WAFLog, 0, len(events))
+	for _, event := range events {
+		if event.Method != "" && strings.HasPrefix(event.URL, "/") {
+			replayable = append(replayable, event)
+		}
+	}
+
+	if len(replayable) == 0 {
+		return nil, fmt.Errorf("no replayable events found")
+	}
+	if len(replayable) > limit {
+		replayable = replayable[:limit]
+	}
+
+	return replayable, nil
+}
+
+// replayEvent 감사 기록의 메서드, URI, Host, User-Agent로 원래 요청을 재구성해서 전송
+func (s *ReplayService) replayEvent(ctx context.Context, base *url.URL, event *dto.WAFLog) dto.ReplayResult {
+	result := dto.ReplayResult{
+		EventID:         event.ID,
+		Method:          event.Method,
+		URL:             event.URL,
+		Redacted:        strings.Contains(event.URL, "[REDACTED"), // [REDACTED], [REDACTED:email] ...
+		Host:            event.Host,
+		RuleID:          event.RuleID,
+		OriginalBlocked: event.Blocked,
+	}
+
+	start := time.Now()
+	defer func() {
+		result.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, event.Method, base.String()+event.URL, nil)
+	if err != nil {
+		result.Outcome = ReplayOutcomeError
+		result.Error = fmt.Sprintf("failed to reconstruct request: %v", err)
+		return result
+	}
+
+	// 원래 Host로 보내야 같은 Ingress 규칙이 적용됨
+	if event.Host != "" {
+		req.Host = event.Host
+	}
+	if event.UserAgent != "" {
+		req.Header.Set("User-Agent", event.UserAgent)
+	} else {
+		req.Header.Set("User-Agent", "WAF-Replay/1.0")
+	}
+	req.Header.Set("X-WAF-Replay", event.ID)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		result.Outcome = ReplayOutcomeError
+		result.Error = fmt.Sprintf("request failed: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	result.StatusCode = resp.StatusCode
+	result.Blocked = isBlockedResponse(resp.StatusCode, string(body))
+	result.Outcome = compareReplayOutcome(event.Blocked, result.Blocked)
+
+	return result
+}
+
+// isBlockedResponse 403/406 또는 ModSecurity 응답 본문이면 WAF 차단으로 판단
+func isBlockedResponse(statusCode int, body string) bool {
+	return statusCode == 403 || statusCode == 406 ||
+		strings.Contains(body, "ModSecurity") ||
+		strings.Contains(body, "Access denied")
+}
+
+func compareReplayOutcome(originalBlocked, blocked bool) string {
+	switch {
+	case originalBlocked && blocked:
+		return ReplayOutcomeStillBlocked
+	case originalBlocked && !blocked:
+		return ReplayOutcomeNowAllowed
+	case !originalBlocked && blocked:
+		return ReplayOutcomeNowBlocked
+	default:
+		return ReplayOutcomeStillAllowed
+	}
+}
+
+func generateReplayID() string {
+	return fmt.Sprintf("replay_%d", time.Now().UnixNano())
+}
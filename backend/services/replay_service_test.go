@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"waf-backend/dto"
+)
+
+func newTestReplayService(targetURL string, events ...dto.WAFLog) *ReplayService {
+	s := &ReplayService{
+		log:            newTestLogger(),
+		wafService:     &WAFService{log: newTestLogger(), logs: events},
+		targetURL:      targetURL,
+		allowedTargets: make(map[string]bool),
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	if base, err := parseReplayTarget(targetURL); err == nil {
+		s.allowedTargets[base.String()] = true
+	}
+	return s
+}
+
+func TestParseReplayTarget(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"http://app:3000", "http://app:3000", false},
+		{" HTTPS://App.Example.com/ ", "https://app.example.com", false},
+		{"http://app/base/", "http://app/base", false},
+		{"ftp://app", "", true},
+		{"app:3000", "", true},
+		{"http://", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseReplayTarget(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseReplayTarget(%q) = %v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseReplayTarget(%q) error = %v", tt.input, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("parseReplayTarget(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareReplayOutcome(t *testing.T) {
+	tests := []struct {
+		originalBlocked, blocked bool
+		want                     string
+	}{
+		{true, true, ReplayOutcomeStillBlocked},
+		{true, false, ReplayOutcomeNowAllowed},
+		{false, true, ReplayOutcomeNowBlocked},
+		{false, false, ReplayOutcomeStillAllowed},
+	}
+
+	for _, tt := range tests {
+		if got := compareReplayOutcome(tt.originalBlocked, tt.blocked); got != tt.want {
+			t.Errorf("compareReplayOutcome(%v, %v) = %s, want %s", tt.originalBlocked, tt.blocked, got, tt.want)
+		}
+	}
+}
+
+func TestReplayServiceReplay(t *testing.T) {
+	var gotHost, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/blocked") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		gotHost = r.Host
+		gotHeader = r.Header.Get("X-WAF-Replay")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := newTestReplayService(server.URL,
+		dto.WAFLog{ID: "e1", Method: "GET", Host: "shop.example.com", URL: "/blocked?q=1", Blocked: true},
+		dto.WAFLog{ID: "e2", Method: "GET", Host: "shop.example.com", URL: "/ok", Blocked: true},
+		dto.WAFLog{ID: "e3", URL: "/no-method"},
+		dto.WAFLog{ID: "e4", Method: "GET", URL: "/ok?password=[REDACTED]"},
+	)
+
+	tests := []struct {
+		name        string
+		scope       LogFilter
+		req         dto.ReplayRequest
+		wantSummary map[string]int
+		wantErr     string
+	}{
+		{"by id", nil, dto.ReplayRequest{EventIDs: []string{"e1", "e2"}}, map[string]int{ReplayOutcomeStillBlocked: 1, ReplayOutcomeNowAllowed: 1}, ""},
+		{"limit", nil, dto.ReplayRequest{EventIDs: []string{"e1", "e2"}, Limit: 1}, map[string]int{ReplayOutcomeStillBlocked: 1}, ""},
+		{"unknown event", nil, dto.ReplayRequest{EventIDs: []string{"missing"}}, nil, "not found"},
+		{"not replayable", nil, dto.ReplayRequest{EventIDs: []string{"e3"}}, nil, "no replayable events"},
+		{"no selection", nil, dto.ReplayRequest{}, nil, "either event_ids or a time range"},
+		{"other target", nil, dto.ReplayRequest{EventIDs: []string{"e1"}, TargetURL: "http://169.254.169.254"}, nil, "not an allowed replay target"},
+		{"invalid target", nil, dto.ReplayRequest{EventIDs: []string{"e1"}, TargetURL: "file:///etc/passwd"}, nil, "valid http(s) URL"},
+		{"outside scope", func(wafLog *dto.WAFLog) bool { return wafLog.Host == "blog.example.com" }, dto.ReplayRequest{EventIDs: []string{"e1"}}, nil, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := s.Replay(context.Background(), "user_a", tt.scope, &tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Replay() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Replay() error = %v", err)
+			}
+			if report.Total != len(report.Results) || len(report.Summary) != len(tt.wantSummary) {
+				t.Fatalf("report total %d, summary %v, want %v", report.Total, report.Summary, tt.wantSummary)
+			}
+			for outcome, count := range tt.wantSummary {
+				if report.Summary[outcome] != count {
+					t.Errorf("Summary[%s] = %d, want %d", outcome, report.Summary[outcome], count)
+				}
+			}
+		})
+	}
+
+	if gotHost != "shop.example.com" || gotHeader != "e2" {
+		t.Errorf("replayed request Host = %q, X-WAF-Replay = %q", gotHost, gotHeader)
+	}
+
+	// 마스킹된 값이 그대로 재전송된 결과는 표시
+	report, err := s.Replay(context.Background(), "user_a", nil, &dto.ReplayRequest{EventIDs: []string{"e2", "e4"}})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if report.Results[0].Redacted || !report.Results[1].Redacted {
+		t.Errorf("Redacted = %v, %v, want false, true", report.Results[0].Redacted, report.Results[1].Redacted)
+	}
+}
+
+func TestReplayServiceReportAccess(t *testing.T) {
+	s := newTestReplayService("http://app:3000")
+	s.reports = []*dto.ReplayReport{
+		{ID: "r1", UserID: "user_a", Summary: map[string]int{ReplayOutcomeNowAllowed: 1}, Results: []dto.ReplayResult{{EventID: "e1"}}},
+		{ID: "r2", UserID: "user_b", Summary: map[string]int{}},
+		{ID: "r3", UserID: "user_a", Summary: map[string]int{}},
+	}
+
+	tests := []struct {
+		name     string
+		userID   string
+		reportID string
+		wantErr  string
+	}{
+		{"owner", "user_a", "r1", ""},
+		{"other user", "user_b", "r1", "access denied"},
+		{"missing", "user_a", "r9", "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := s.GetReport(tt.userID, tt.reportID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetReport() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetReport() error = %v", err)
+			}
+			// 반환된 복사본을 바꿔도 저장된 리포트는 그대로
+			report.Summary[ReplayOutcomeNowAllowed] = 99
+			report.Results[0].EventID = "changed"
+			if s.reports[0].Summary[ReplayOutcomeNowAllowed] != 1 || s.reports[0].Results[0].EventID != "e1" {
+				t.Error("GetReport() returned a report sharing state with the stored one")
+			}
+		})
+	}
+
+	reports := s.GetReports("user_a")
+	if len(reports) != 2 || reports[0].ID != "r3" || reports[1].ID != "r1" {
+		t.Errorf("GetReports(user_a) = %v, want [r3 r1]", reports)
+	}
+}
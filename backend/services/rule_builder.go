@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+	"waf-backend/seclang"
+)
+
+// BuildRule 구조화된 룰 정의를 SecRule 텍스트로 변환하고 사용자 룰 정책으로 검증 (id는 없어도 됨)
+func (s *RuleService) BuildRule(specs []seclang.RuleSpec) (string, error) {
+	ruleText, err := seclang.Build(specs)
+	if err != nil {
+		return "", err
+	}
+
+	directives, err := seclang.ParseDraft(ruleText)
+	if err == nil {
+		err = s.userPolicy.Check(directives)
+	}
+	if err != nil {
+		return ruleText, err
+	}
+	return ruleText, nil
+}
+
+// DescribeRule SecRule 텍스트를 폼 편집기용 구조로 변환 (BuildRule로 다시 만들면 같은 구조)
+func (s *RuleService) DescribeRule(ruleText string) ([]seclang.RuleSpec, error) {
+	directives, err := seclang.ParseDraft(ruleText)
+	if err != nil {
+		return nil, err
+	}
+	if len(directives) == 0 {
+		return nil, fmt.Errorf("rule text contains no directives")
+	}
+	return seclang.SpecsOf(directives)
+}
+
+// ruleTextFrom 요청에 구조화된 rules가 있으면 SecRule 텍스트로 변환 (rule_text와 함께 쓸 수 없음)
+func ruleTextFrom(ruleText string, specs []seclang.RuleSpec) (string, error) {
+	if len(specs) == 0 {
+		return ruleText, nil
+	}
+	if ruleText != "" {
+		return "", fmt.Errorf("rule_text and rules cannot be used together")
+	}
+	built, err := seclang.Build(specs)
+	if err != nil {
+		return "", fmt.Errorf("invalid rules: %w", err)
+	}
+	return built, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"waf-backend/dto"
+	"waf-backend/seclang"
+)
+
+func TestRuleServiceBuildRule(t *testing.T) {
+	s := newTestRuleService(t, newTestDB(t), nil)
+	args := []seclang.VariableSpec{{Name: "ARGS"}}
+
+	tests := []struct {
+		name    string
+		specs   []seclang.RuleSpec
+		want    string
+		wantErr string
+	}{
+		{"without id", []seclang.RuleSpec{{Variables: args, Operator: "contains", Argument: "attack", Phase: 2, Action: "deny", Status: 403}}, `SecRule ARGS "@contains attack" "phase:2,deny,status:403"`, ""},
+		{"with id", []seclang.RuleSpec{{ID: 1001, Variables: args, Argument: "a", Action: "deny"}}, `SecRule ARGS "@rx a" "id:1001,deny"`, ""},
+		{"denied action", []seclang.RuleSpec{{Variables: args, Argument: "a", Action: "deny", Actions: []seclang.ActionSpec{{Name: "exec", Value: "/bin/sh"}}}}, "", "action exec is denied by policy"},
+		{"no variables", []seclang.RuleSpec{{Argument: "a", Action: "deny"}}, "", "rules[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleText, err := s.BuildRule(tt.specs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("BuildRule() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildRule() error = %v", err)
+			}
+			if ruleText != tt.want {
+				t.Errorf("BuildRule() = %s, want %s", ruleText, tt.want)
+			}
+		})
+	}
+}
+
+// parse 결과를 그대로 저장하면 원본 텍스트가 유지되고, rule_text와 rules는 함께 쓸 수 없음
+func TestRuleServiceCreateRuleFromSpecs(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	original := "# block probes\n" + testRuleText
+	specs, err := s.DescribeRule(original)
+	if err != nil {
+		t.Fatalf("DescribeRule() error = %v", err)
+	}
+	if len(specs) != 1 || specs[0].ID != 1001 || specs[0].Comment != "# block probes" {
+		t.Fatalf("DescribeRule() = %+v", specs)
+	}
+
+	rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", Rules: specs, Enabled: true, Severity: "HIGH"})
+	if err != nil {
+		t.Fatalf("CreateRule(rules) error = %v", err)
+	}
+	if rule.RuleText != original {
+		t.Errorf("RuleText = %q, want %q", rule.RuleText, original)
+	}
+	if _, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "both", RuleText: testRuleText, Rules: specs}); err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+		t.Errorf("CreateRule(rule_text and rules) error = %v", err)
+	}
+	if _, err := s.DescribeRule("# only a comment"); err == nil {
+		t.Error("DescribeRule(no directives) succeeded")
+	}
+}
@@ -0,0 +1,716 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/seclang"
+	"waf-backend/tracing"
+	"waf-backend/utils"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+const (
+	ChangesetDraft     = "draft"
+	ChangesetInReview  = "in_review"
+	ChangesetApproved  = "approved"
+	ChangesetRejected  = "rejected"
+	ChangesetPublished = "published"
+	ChangesetDiscarded = "discarded"
+
+	RuleChangeCreate = "create"
+	RuleChangeUpdate = "update"
+	RuleChangeDelete = "delete"
+)
+
+// ErrReviewRequired RULES_REQUIRE_REVIEW가 켜져 있으면 사용자 룰은 변경 세트로만 바꿀 수 있음
+var ErrReviewRequired = errors.New("rule changes require an approved changeset")
+
+// changesetOp 변경 하나를 적용했을 때 룰의 전/후 (create면 before, delete면 after가 nil)
+type changesetOp struct {
+	change *models.RuleChange
+	before *models.CustomRule
+	after  *models.CustomRule
+}
+
+// CreateChangeset 빈 draft 변경 세트 생성. 작성자의 룰 ID 범위를 함께 저장
+func (s *RuleService) CreateChangeset(ctx context.Context, userID string, idRange dto.RuleIDRange, req *dto.RuleChangesetRequest) (*dto.RuleChangeset, error) {
+	changeset := &models.RuleChangeset{
+		ID:          fmt.Sprintf("changeset_%d", time.Now().UnixNano()),
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      ChangesetDraft,
+		UserID:      userID,
+		RuleIDStart: idRange.Start,
+		RuleIDEnd:   idRange.End,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(changeset).Error; err != nil {
+		return nil, fmt.Errorf("failed to save changeset: %w", err)
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"changeset_id": changeset.ID,
+		"user_id":      userID,
+	}).Info("Rule changeset created")
+
+	return changesetToResponse(changeset, nil), nil
+}
+
+// ListChangesets 본인 변경 세트 목록. 검토자는 다른 사용자가 제출한 변경 세트도 조회 (draft 제외)
+func (s *RuleService) ListChangesets(ctx context.Context, userID string, reviewer bool, status string) ([]*dto.RuleChangeset, error) {
+	query := s.db.WithContext(ctx).Preload("Changes", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+	if reviewer {
+		query = query.Where("user_id = ? OR status <> ?", userID, ChangesetDraft)
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var changesets []models.RuleChangeset
+	if err := query.Order("created_at DESC").Find(&changesets).Error; err != nil {
+		return nil, fmt.Errorf("failed to load changesets: %w", err)
+	}
+
+	result := make([]*dto.RuleChangeset, 0, len(changesets))
+	for i := range changesets {
+		result = append(result, changesetToResponse(&changesets[i], nil))
+	}
+	return result, nil
+}
+
+// GetChangeset 변경 세트와 각 변경의 현재 배포 대비 diff
+func (s *RuleService) GetChangeset(ctx context.Context, userID string, reviewer bool, changesetID string) (*dto.RuleChangeset, error) {
+	changeset, err := s.loadChangeset(ctx, userID, reviewer, changesetID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	// 배포가 끝났거나 폐기된 변경 세트는 현재 룰과 비교할 의미가 없음
+	if changeset.Status == ChangesetPublished || changeset.Status == ChangesetDiscarded {
+		return changesetToResponse(changeset, nil), nil
+	}
+
+	_, ops, errs := s.planChangesetLocked(ctx, changeset)
+	return changesetToResponse(changeset, changeDetails(changeset, ops, errs)), nil
+}
+
+// AddChange draft 변경 세트에 룰 변경 추가. 앞선 변경들을 적용한 상태 기준으로 검증하고 ID를 배정
+// 반려된 변경 세트를 수정하면 다시 draft가 됨. allowedHosts가 있으면 호스트 대상은 그 안에서만 지정 가능
+func (s *RuleService) AddChange(ctx context.Context, userID, changesetID string, allowedHosts []string, req *dto.RuleChangeRequest) (*dto.RuleChangeset, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.AddChange",
+		attribute.String("user_id", userID), attribute.String("changeset_id", changesetID))
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changeset, err := s.loadEditableChangeset(ctx, userID, changesetID)
+	if err != nil {
+		return nil, err
+	}
+	ruleText, err := ruleTextFrom(req.RuleText, req.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &models.RuleChange{
+		ChangesetID: changeset.ID,
+		Action:      req.Action,
+		RuleID:      req.RuleID,
+		Name:        req.Name,
+		Description: req.Description,
+		RuleText:    ruleText,
+		Enabled:     req.Enabled,
+		Severity:    req.Severity,
+		Reason:      req.Reason,
+		CreatedAt:   time.Now(),
+	}
+	switch req.Action {
+	case RuleChangeCreate:
+		change.RuleID = generateRuleID()
+	case RuleChangeUpdate, RuleChangeDelete:
+		if req.RuleID == "" {
+			return nil, fmt.Errorf("rule_id is required for %s", req.Action)
+		}
+		if change.BaseRevision, err = latestRevision(s.db.WithContext(ctx), req.RuleID); err != nil {
+			return nil, fmt.Errorf("failed to load revisions: %w", err)
+		}
+	}
+
+	// 앞선 변경들을 적용한 룰 집합 위에서 렌더링/검증 (같은 세트 안의 ID 충돌도 잡힘)
+	working, _, _ := s.planChangesetLocked(ctx, changeset)
+	if err := s.applyChangeTargets(working, change, req, allowedHosts); err != nil {
+		return nil, err
+	}
+	if req.TemplateID != "" || req.TemplateParams != nil {
+		if err := s.renderChangeTemplate(changeset, working, change, req); err != nil {
+			return nil, err
+		}
+	}
+	if req.Action != RuleChangeDelete && (change.Name == "" || change.RuleText == "" || change.Severity == "") {
+		return nil, fmt.Errorf("name, rule_text and severity are required for %s", req.Action)
+	}
+
+	op, err := s.applyChangeLocked(ctx, changeset, working, change)
+	if err != nil {
+		return nil, err
+	}
+	if op.after != nil {
+		// 배정된 ID가 포함된 텍스트를 저장해서 검토자가 배포될 내용 그대로 보게 함
+		change.RuleText = op.after.RuleText
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		return reopenChangeset(tx, changeset)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save change: %w", err)
+	}
+	changeset.Changes = append(changeset.Changes, *change)
+
+	_, ops, errs := s.planChangesetLocked(ctx, changeset)
+	return changesetToResponse(changeset, changeDetails(changeset, ops, errs)), nil
+}
+
+// RemoveChange draft 변경 세트에서 변경 하나 제거
+func (s *RuleService) RemoveChange(ctx context.Context, userID, changesetID string, changeID uint) (*dto.RuleChangeset, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changeset, err := s.loadEditableChangeset(ctx, userID, changesetID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i := range changeset.Changes {
+		if changeset.Changes[i].ID == changeID {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("change not found")
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.RuleChange{}, changeID).Error; err != nil {
+			return err
+		}
+		return reopenChangeset(tx, changeset)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to remove change: %w", err)
+	}
+	changeset.Changes = append(changeset.Changes[:index], changeset.Changes[index+1:]...)
+
+	_, ops, errs := s.planChangesetLocked(ctx, changeset)
+	return changesetToResponse(changeset, changeDetails(changeset, ops, errs)), nil
+}
+
+// ValidateChangeset 변경 세트를 현재 룰에 적용해 보고 오류와 배포될 설정의 diff 반환 (아무것도 저장하지 않음)
+func (s *RuleService) ValidateChangeset(ctx context.Context, userID string, reviewer bool, changesetID string) (*dto.RuleChangesetValidation, error) {
+	changeset, err := s.loadChangeset(ctx, userID, reviewer, changesetID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.validateChangesetLocked(ctx, changeset), nil
+}
+
+// SubmitChangeset 작성자가 draft 변경 세트를 검토 요청 (검증을 통과해야 함)
+func (s *RuleService) SubmitChangeset(ctx context.Context, userID, changesetID string) (*dto.RuleChangeset, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changeset, err := s.loadChangeset(ctx, userID, false, changesetID)
+	if err != nil {
+		return nil, err
+	}
+	if changeset.Status != ChangesetDraft {
+		return nil, fmt.Errorf("only draft changesets can be submitted (status is %s)", changeset.Status)
+	}
+	if len(changeset.Changes) == 0 {
+		return nil, fmt.Errorf("changeset has no changes")
+	}
+	if err := s.requireValidLocked(ctx, changeset); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.setChangesetStatus(ctx, changeset, ChangesetInReview, map[string]interface{}{"submitted_at": now}); err != nil {
+		return nil, err
+	}
+	changeset.SubmittedAt = &now
+
+	s.log.WithFields(logrus.Fields{
+		"changeset_id": changesetID,
+		"user_id":      userID,
+		"changes":      len(changeset.Changes),
+	}).Info("Rule changeset submitted for review")
+
+	return changesetToResponse(changeset, nil), nil
+}
+
+// ApproveChangeset 검토자가 검토 중인 변경 세트를 승인 (작성자 본인은 승인할 수 없음)
+func (s *RuleService) ApproveChangeset(ctx context.Context, reviewerID, changesetID, comment string) (*dto.RuleChangeset, error) {
+	return s.reviewChangeset(ctx, reviewerID, changesetID, comment, ChangesetApproved)
+}
+
+// RejectChangeset 검토자가 변경 세트를 반려. 작성자는 수정 후 다시 제출할 수 있음
+func (s *RuleService) RejectChangeset(ctx context.Context, reviewerID, changesetID, comment string) (*dto.RuleChangeset, error) {
+	return s.reviewChangeset(ctx, reviewerID, changesetID, comment, ChangesetRejected)
+}
+
+func (s *RuleService) reviewChangeset(ctx context.Context, reviewerID, changesetID, comment, status string) (*dto.RuleChangeset, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changeset, err := s.loadChangeset(ctx, reviewerID, true, changesetID)
+	if err != nil {
+		return nil, err
+	}
+	if changeset.UserID == reviewerID {
+		return nil, fmt.Errorf("changesets must be reviewed by someone other than the author")
+	}
+
+	switch {
+	case status == ChangesetApproved && changeset.Status != ChangesetInReview:
+		return nil, fmt.Errorf("only changesets in review can be approved (status is %s)", changeset.Status)
+	case status == ChangesetRejected && changeset.Status != ChangesetInReview && changeset.Status != ChangesetApproved:
+		return nil, fmt.Errorf("only changesets in review or approved can be rejected (status is %s)", changeset.Status)
+	}
+	if status == ChangesetApproved {
+		if err := s.requireValidLocked(ctx, changeset); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if err := s.setChangesetStatus(ctx, changeset, status, map[string]interface{}{
+		"reviewed_by":    reviewerID,
+		"review_comment": comment,
+		"reviewed_at":    now,
+	}); err != nil {
+		return nil, err
+	}
+	changeset.ReviewedBy = reviewerID
+	changeset.ReviewComment = comment
+	changeset.ReviewedAt = &now
+
+	s.log.WithFields(logrus.Fields{
+		"changeset_id": changesetID,
+		"reviewer_id":  reviewerID,
+		"status":       status,
+	}).Info("Rule changeset reviewed")
+
+	return changesetToResponse(changeset, nil), nil
+}
+
+// PublishChangeset 승인된 변경 세트의 모든 변경을 한 트랜잭션으로 저장하고 한 번에 배포
+// 작성자나 검토자가 호출. 승인 이후 룰이 바뀌어 더 이상 적용할 수 없으면 아무것도 바꾸지 않음
+func (s *RuleService) PublishChangeset(ctx context.Context, userID string, reviewer bool, changesetID string) (*dto.RuleChangeset, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.PublishChangeset",
+		attribute.String("user_id", userID), attribute.String("changeset_id", changesetID))
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changeset, err := s.loadChangeset(ctx, userID, reviewer, changesetID)
+	if err != nil {
+		return nil, err
+	}
+	if changeset.Status != ChangesetApproved {
+		return nil, fmt.Errorf("only approved changesets can be published (status is %s)", changeset.Status)
+	}
+
+	working, ops, errs := s.planChangesetLocked(ctx, changeset)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("changeset no longer applies cleanly (%d invalid changes), validate it for details", len(errs))
+	}
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, op := range ops {
+			reason := fmt.Sprintf("changeset %s", changeset.ID)
+			if op.change.Reason != "" {
+				reason += ": " + op.change.Reason
+			}
+
+			switch {
+			case op.after == nil:
+				if err := tx.Delete(&models.CustomRule{}, "id = ?", op.before.ID).Error; err != nil {
+					return err
+				}
+				if err := recordRevision(tx, op.before, RuleRevisionDelete, changeset.UserID, reason); err != nil {
+					return err
+				}
+			case op.before == nil:
+				if err := tx.Create(op.after).Error; err != nil {
+					return err
+				}
+				if err := recordRevision(tx, op.after, RuleRevisionCreate, changeset.UserID, reason); err != nil {
+					return err
+				}
+			default:
+				if err := tx.Save(op.after).Error; err != nil {
+					return err
+				}
+				if err := recordRevision(tx, op.after, revisionActionFor(op.before, op.after), changeset.UserID, reason); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Model(&models.RuleChangeset{}).Where("id = ?", changeset.ID).Updates(map[string]interface{}{
+			"status":       ChangesetPublished,
+			"published_by": userID,
+			"published_at": now,
+			"updated_at":   now,
+		}).Error
+	}); err != nil {
+		return nil, fmt.Errorf("failed to publish changeset: %w", err)
+	}
+	s.rules = working
+	s.rebuildModsecIndexLocked()
+	changeset.Status = ChangesetPublished
+	changeset.PublishedBy = userID
+	changeset.PublishedAt = &now
+	changeset.UpdatedAt = now
+
+	// 모든 변경이 반영된 뒤 한 번만 배포
+	if err := s.updateConfigMap(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update ConfigMap")
+	}
+	if err := s.updateIngressAnnotation(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update Ingress annotation")
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"changeset_id": changesetID,
+		"user_id":      userID,
+		"changes":      len(ops),
+	}).Info("Rule changeset published")
+
+	return changesetToResponse(changeset, nil), nil
+}
+
+// DiscardChangeset 작성자가 배포 전 변경 세트를 폐기 (이력을 위해 삭제하지 않고 상태만 변경)
+func (s *RuleService) DiscardChangeset(ctx context.Context, userID, changesetID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	changeset, err := s.loadChangeset(ctx, userID, false, changesetID)
+	if err != nil {
+		return err
+	}
+	if changeset.Status == ChangesetPublished || changeset.Status == ChangesetDiscarded {
+		return fmt.Errorf("changeset is already %s", changeset.Status)
+	}
+	return s.setChangesetStatus(ctx, changeset, ChangesetDiscarded, nil)
+}
+
+// planChangesetLocked 변경들을 순서대로 현재 룰에 적용한 결과 (s.rules와 DB는 바꾸지 않음)
+// 적용할 수 없는 변경은 건너뛰고 오류 목록에 추가
+func (s *RuleService) planChangesetLocked(ctx context.Context, changeset *models.RuleChangeset) (map[string]*models.CustomRule, []changesetOp, []dto.RuleChangeError) {
+	working := make(map[string]*models.CustomRule, len(s.rules))
+	for id, rule := range s.rules {
+		working[id] = rule
+	}
+
+	var ops []changesetOp
+	var errs []dto.RuleChangeError
+	for i := range changeset.Changes {
+		change := &changeset.Changes[i]
+		op, err := s.applyChangeLocked(ctx, changeset, working, change)
+		if err != nil {
+			errs = append(errs, dto.RuleChangeError{
+				ChangeID: change.ID,
+				RuleID:   change.RuleID,
+				Error:    err.Error(),
+				Details:  seclangErrors(err),
+			})
+			continue
+		}
+		ops = append(ops, *op)
+	}
+	return working, ops, errs
+}
+
+// applyChangeLocked 변경 하나를 working에 적용. 사용자 룰과 같은 정책, ID 범위, 충돌 기준으로 검증
+func (s *RuleService) applyChangeLocked(ctx context.Context, changeset *models.RuleChangeset, working map[string]*models.CustomRule, change *models.RuleChange) (*changesetOp, error) {
+	idRange := &dto.RuleIDRange{Start: changeset.RuleIDStart, End: changeset.RuleIDEnd}
+	op := &changesetOp{change: change}
+
+	if change.Action != RuleChangeCreate {
+		current, exists := working[change.RuleID]
+		if !exists {
+			return nil, fmt.Errorf("rule not found")
+		}
+		if current.UserID != changeset.UserID {
+			return nil, fmt.Errorf("access denied")
+		}
+		if current.Source != "" {
+			return nil, fmt.Errorf("managed rules cannot be changed through a changeset")
+		}
+		// 작성 이후 다른 경로로 룰이 바뀌었으면 덮어쓰지 않음
+		if change.BaseRevision > 0 {
+			latest, err := latestRevision(s.db.WithContext(ctx), change.RuleID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load revisions: %w", err)
+			}
+			if latest != change.BaseRevision {
+				return nil, fmt.Errorf("rule changed after this change was drafted (revision %d, now %d)", change.BaseRevision, latest)
+			}
+		}
+		op.before = current
+	}
+
+	switch change.Action {
+	case RuleChangeDelete:
+		delete(working, change.RuleID)
+		return op, nil
+	case RuleChangeCreate:
+		ruleText, err := s.prepareRuleTextLocked(working, change.RuleText, s.userPolicy, idRange, "", nil, change.Enabled)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule syntax: %w", err)
+		}
+		op.after = &models.CustomRule{
+			ID:          change.RuleID,
+			Name:        change.Name,
+			Description: change.Description,
+			RuleText:    ruleText,
+			Enabled:     change.Enabled,
+			Severity:    change.Severity,
+			RuleGroup:   change.RuleGroup,
+			Targets:     change.Targets,
+			Priority:    nextRulePriority(working),
+			UserID:      changeset.UserID,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		templateLink{id: change.TemplateID, params: change.TemplateParams}.applyTo(op.after, "")
+	case RuleChangeUpdate:
+		ruleText, err := s.prepareRuleTextLocked(working, change.RuleText, s.userPolicy, idRange, change.RuleID, s.ruleIDsOf(op.before.RuleText), change.Enabled)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule syntax: %w", err)
+		}
+		updated := *op.before
+		updated.Name = change.Name
+		updated.Description = change.Description
+		updated.RuleText = ruleText
+		updated.Enabled = change.Enabled
+		updated.Severity = change.Severity
+		updated.RuleGroup = change.RuleGroup
+		updated.Targets = change.Targets
+		updated.UpdatedAt = time.Now()
+		templateLink{id: change.TemplateID, params: change.TemplateParams}.applyTo(&updated, op.before.RuleText)
+		op.after = &updated
+	default:
+		return nil, fmt.Errorf("unknown change action %q", change.Action)
+	}
+
+	if err := checkTargetable(op.after, decodeRuleTargets(op.after.Targets)); err != nil {
+		return nil, err
+	}
+	working[change.RuleID] = op.after
+	return op, nil
+}
+
+// applyChangeTargets 요청의 그룹/대상을 변경에 반영 (update에서 생략한 값은 현재 룰 값 유지)
+func (s *RuleService) applyChangeTargets(working map[string]*models.CustomRule, change *models.RuleChange, req *dto.RuleChangeRequest, allowedHosts []string) error {
+	if current, exists := working[change.RuleID]; exists && req.Action == RuleChangeUpdate {
+		change.RuleGroup = current.RuleGroup
+		change.Targets = current.Targets
+	}
+	if req.Group != nil {
+		change.RuleGroup = strings.TrimSpace(*req.Group)
+	}
+	if req.Targets != nil {
+		targets, err := s.normalizeRuleTargets(*req.Targets, allowedHosts)
+		if err != nil {
+			return err
+		}
+		change.Targets = encodeRuleTargets(targets)
+	}
+	if req.Action != RuleChangeDelete {
+		targets, err := s.scopeRuleTargets(decodeRuleTargets(change.Targets), allowedHosts)
+		if err != nil {
+			return err
+		}
+		change.Targets = encodeRuleTargets(targets)
+	}
+	return nil
+}
+
+func (s *RuleService) validateChangesetLocked(ctx context.Context, changeset *models.RuleChangeset) *dto.RuleChangesetValidation {
+	working, _, errs := s.planChangesetLocked(ctx, changeset)
+	if errs == nil {
+		errs = make([]dto.RuleChangeError, 0)
+	}
+	return &dto.RuleChangesetValidation{
+		ChangesetID: changeset.ID,
+		Valid:       len(errs) == 0,
+		Errors:      errs,
+		ConfigDiff:  utils.LineDiff(s.renderCustomRulesConf(s.rules), s.renderCustomRulesConf(working), "deployed", changeset.ID),
+	}
+}
+
+// requireValidLocked 적용할 수 없는 변경이 하나라도 있으면 오류
+func (s *RuleService) requireValidLocked(ctx context.Context, changeset *models.RuleChangeset) error {
+	if _, _, errs := s.planChangesetLocked(ctx, changeset); len(errs) > 0 {
+		return fmt.Errorf("changeset has %d invalid changes, validate it for details", len(errs))
+	}
+	return nil
+}
+
+// loadChangeset DB에서 변경 세트와 변경 목록을 읽고 조회 권한 확인
+// 작성자는 항상, 검토자는 제출된 (draft가 아닌) 변경 세트만 볼 수 있음
+func (s *RuleService) loadChangeset(ctx context.Context, userID string, reviewer bool, changesetID string) (*models.RuleChangeset, error) {
+	var changeset models.RuleChangeset
+	err := s.db.WithContext(ctx).
+		Preload("Changes", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&changeset, "id = ?", changesetID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("changeset not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load changeset: %w", err)
+	}
+
+	if changeset.UserID != userID && !(reviewer && changeset.Status != ChangesetDraft) {
+		return nil, fmt.Errorf("access denied")
+	}
+	return &changeset, nil
+}
+
+// loadEditableChangeset 작성자만, draft나 반려된 변경 세트만 수정 가능
+func (s *RuleService) loadEditableChangeset(ctx context.Context, userID, changesetID string) (*models.RuleChangeset, error) {
+	changeset, err := s.loadChangeset(ctx, userID, false, changesetID)
+	if err != nil {
+		return nil, err
+	}
+	if changeset.Status != ChangesetDraft && changeset.Status != ChangesetRejected {
+		return nil, fmt.Errorf("only draft or rejected changesets can be edited (status is %s)", changeset.Status)
+	}
+	return changeset, nil
+}
+
+func (s *RuleService) setChangesetStatus(ctx context.Context, changeset *models.RuleChangeset, status string, fields map[string]interface{}) error {
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	fields["status"] = status
+	fields["updated_at"] = time.Now()
+	if err := s.db.WithContext(ctx).Model(&models.RuleChangeset{}).Where("id = ?", changeset.ID).Updates(fields).Error; err != nil {
+		return fmt.Errorf("failed to update changeset: %w", err)
+	}
+	changeset.Status = status
+	changeset.UpdatedAt = fields["updated_at"].(time.Time)
+	return nil
+}
+
+// reopenChangeset 내용이 바뀐 변경 세트는 이전 검토 결과를 지우고 draft로 되돌림
+func reopenChangeset(tx *gorm.DB, changeset *models.RuleChangeset) error {
+	changeset.Status = ChangesetDraft
+	changeset.ReviewedBy = ""
+	changeset.ReviewComment = ""
+	changeset.SubmittedAt = nil
+	changeset.ReviewedAt = nil
+	changeset.UpdatedAt = time.Now()
+	return tx.Model(&models.RuleChangeset{}).Where("id = ?", changeset.ID).Updates(map[string]interface{}{
+		"status":         ChangesetDraft,
+		"reviewed_by":    "",
+		"review_comment": "",
+		"submitted_at":   nil,
+		"reviewed_at":    nil,
+		"updated_at":     changeset.UpdatedAt,
+	}).Error
+}
+
+// changeDetails 각 변경의 diff와 오류 (changesetToResponse에 전달)
+func changeDetails(changeset *models.RuleChangeset, ops []changesetOp, errs []dto.RuleChangeError) map[uint]dto.RuleChange {
+	details := make(map[uint]dto.RuleChange, len(changeset.Changes))
+	for _, op := range ops {
+		var before, after string
+		if op.before != nil {
+			before = op.before.RuleText
+		}
+		if op.after != nil {
+			after = op.after.RuleText
+		}
+		details[op.change.ID] = dto.RuleChange{Diff: utils.LineDiff(before, after, "deployed", "changeset")}
+	}
+	for _, e := range errs {
+		details[e.ChangeID] = dto.RuleChange{Error: e.Error}
+	}
+	return details
+}
+
+func changesetToResponse(changeset *models.RuleChangeset, details map[uint]dto.RuleChange) *dto.RuleChangeset {
+	changes := make([]dto.RuleChange, 0, len(changeset.Changes))
+	for _, change := range changeset.Changes {
+		detail := details[change.ID]
+		changes = append(changes, dto.RuleChange{
+			ID:             change.ID,
+			Action:         change.Action,
+			RuleID:         change.RuleID,
+			BaseRevision:   change.BaseRevision,
+			Name:           change.Name,
+			Description:    change.Description,
+			RuleText:       change.RuleText,
+			Enabled:        change.Enabled,
+			Severity:       change.Severity,
+			Reason:         change.Reason,
+			TemplateID:     change.TemplateID,
+			TemplateParams: decodeTemplateParams(change.TemplateParams),
+			Group:          change.RuleGroup,
+			Targets:        decodeRuleTargets(change.Targets),
+			Diff:           detail.Diff,
+			Error:          detail.Error,
+		})
+	}
+
+	return &dto.RuleChangeset{
+		ID:            changeset.ID,
+		Title:         changeset.Title,
+		Description:   changeset.Description,
+		Status:        changeset.Status,
+		Author:        changeset.UserID,
+		ReviewedBy:    changeset.ReviewedBy,
+		ReviewComment: changeset.ReviewComment,
+		PublishedBy:   changeset.PublishedBy,
+		Changes:       changes,
+		SubmittedAt:   changeset.SubmittedAt,
+		ReviewedAt:    changeset.ReviewedAt,
+		PublishedAt:   changeset.PublishedAt,
+		CreatedAt:     changeset.CreatedAt,
+		UpdatedAt:     changeset.UpdatedAt,
+	}
+}
+
+// seclangErrors 위치가 포함된 SecLang 오류면 그 목록, 아니면 nil
+func seclangErrors(err error) seclang.ErrorList {
+	var errs seclang.ErrorList
+	if errors.As(err, &errs) {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"waf-backend/dto"
+)
+
+// 작성 → 제출 → 승인 → 배포 순서와 단계마다의 권한 확인
+func TestRuleServiceChangesetWorkflow(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	changeset, err := s.CreateChangeset(ctx, "user_a", testRuleIDRange, &dto.RuleChangesetRequest{Title: "block attacks"})
+	if err != nil {
+		t.Fatalf("CreateChangeset() error = %v", err)
+	}
+	changeset, err = s.AddChange(ctx, "user_a", changeset.ID, nil, &dto.RuleChangeRequest{Action: RuleChangeCreate, Name: "block", RuleText: `SecRule ARGS "@rx attack" "phase:2,deny"`, Enabled: true, Severity: "HIGH"})
+	if err != nil {
+		t.Fatalf("AddChange() error = %v", err)
+	}
+	if got := changeset.Changes[0].RuleText; !strings.Contains(got, "id:1000,") {
+		t.Errorf("change rule text = %s, want assigned id 1000", got)
+	}
+
+	steps := []struct {
+		name    string
+		call    func() (*dto.RuleChangeset, error)
+		wantErr string
+		want    string
+	}{
+		{"reviewer cannot see draft", func() (*dto.RuleChangeset, error) { return s.GetChangeset(ctx, "user_r", true, changeset.ID) }, "access denied", ""},
+		{"other user cannot submit", func() (*dto.RuleChangeset, error) { return s.SubmitChangeset(ctx, "user_b", changeset.ID) }, "access denied", ""},
+		{"publish draft", func() (*dto.RuleChangeset, error) { return s.PublishChangeset(ctx, "user_a", false, changeset.ID) }, "only approved", ""},
+		{"submit", func() (*dto.RuleChangeset, error) { return s.SubmitChangeset(ctx, "user_a", changeset.ID) }, "", ChangesetInReview},
+		{"edit in review", func() (*dto.RuleChangeset, error) {
+			return s.AddChange(ctx, "user_a", changeset.ID, nil, &dto.RuleChangeRequest{Action: RuleChangeCreate, Name: "x", RuleText: testRuleText, Severity: "LOW"})
+		}, "only draft or rejected", ""},
+		{"author approves", func() (*dto.RuleChangeset, error) { return s.ApproveChangeset(ctx, "user_a", changeset.ID, "") }, "other than the author", ""},
+		{"non reviewer approves", func() (*dto.RuleChangeset, error) {
+			return s.reviewChangeset(ctx, "user_b", changeset.ID, "", ChangesetApproved)
+		}, "", ChangesetApproved},
+		{"non reviewer publishes", func() (*dto.RuleChangeset, error) { return s.PublishChangeset(ctx, "user_b", false, changeset.ID) }, "access denied", ""},
+		{"publish", func() (*dto.RuleChangeset, error) { return s.PublishChangeset(ctx, "user_a", false, changeset.ID) }, "", ChangesetPublished},
+		{"publish twice", func() (*dto.RuleChangeset, error) { return s.PublishChangeset(ctx, "user_a", false, changeset.ID) }, "only approved", ""},
+	}
+
+	for _, step := range steps {
+		got, err := step.call()
+		if step.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), step.wantErr) {
+				t.Fatalf("%s: error = %v, want %q", step.name, err, step.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: error = %v", step.name, err)
+		}
+		if got.Status != step.want {
+			t.Fatalf("%s: status = %s, want %s", step.name, got.Status, step.want)
+		}
+	}
+
+	rules, _ := s.GetRules(ctx, "user_a")
+	if len(rules) != 1 || rules[0].ID != changeset.Changes[0].RuleID {
+		t.Fatalf("published rules = %+v", rules)
+	}
+	revisions, err := s.GetRevisions(ctx, "user_a", rules[0].ID)
+	if err != nil || len(revisions) != 1 || !strings.HasPrefix(revisions[0].Reason, "changeset "+changeset.ID) {
+		t.Errorf("revisions after publish = %+v, %v", revisions, err)
+	}
+}
+
+// 작성 이후 다른 경로로 바뀐 룰이나 같은 세트 안의 ID 충돌은 적용할 수 없는 변경으로 보고
+func TestRuleServiceChangesetConflicts(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true, Severity: "HIGH"})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	changeset, err := s.CreateChangeset(ctx, "user_a", testRuleIDRange, &dto.RuleChangesetRequest{Title: "tune"})
+	if err != nil {
+		t.Fatalf("CreateChangeset() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		req     dto.RuleChangeRequest
+		wantErr string
+	}{
+		{"update", "user_a", dto.RuleChangeRequest{Action: RuleChangeUpdate, RuleID: rule.ID, Name: "block", RuleText: `SecRule ARGS "@contains attack2" "id:1001,phase:2,deny"`, Severity: "LOW", Enabled: true}, ""},
+		{"duplicate id in set", "user_a", dto.RuleChangeRequest{Action: RuleChangeCreate, Name: "dup", RuleText: testRuleText, Severity: "LOW", Enabled: true}, "already used"},
+		{"missing fields", "user_a", dto.RuleChangeRequest{Action: RuleChangeCreate, Name: "x"}, "are required"},
+		{"update without rule id", "user_a", dto.RuleChangeRequest{Action: RuleChangeUpdate, Name: "x", RuleText: testRuleText, Severity: "LOW"}, "rule_id is required"},
+		{"unknown rule", "user_a", dto.RuleChangeRequest{Action: RuleChangeDelete, RuleID: "rule_missing"}, "rule not found"},
+		{"other user's changeset", "user_b", dto.RuleChangeRequest{Action: RuleChangeDelete, RuleID: rule.ID}, "access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.AddChange(ctx, tt.userID, changeset.ID, nil, &tt.req)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("AddChange() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("AddChange() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	validation, err := s.ValidateChangeset(ctx, "user_a", false, changeset.ID)
+	if err != nil || !validation.Valid || !strings.Contains(validation.ConfigDiff, "+SecRule ARGS \"@contains attack2\"") {
+		t.Fatalf("ValidateChangeset() = %+v, %v", validation, err)
+	}
+
+	// 초안 이후 룰을 직접 수정하면 변경이 더 이상 적용되지 않음
+	if _, err := s.UpdateRule(ctx, "user_a", rule.ID, testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Severity: "MEDIUM", Enabled: true}); err != nil {
+		t.Fatalf("UpdateRule() error = %v", err)
+	}
+	validation, _ = s.ValidateChangeset(ctx, "user_a", false, changeset.ID)
+	if validation.Valid || len(validation.Errors) != 1 || !strings.Contains(validation.Errors[0].Error, "rule changed after this change was drafted") {
+		t.Errorf("ValidateChangeset() after direct update = %+v", validation)
+	}
+	if _, err := s.SubmitChangeset(ctx, "user_a", changeset.ID); err == nil || !strings.Contains(err.Error(), "invalid changes") {
+		t.Errorf("SubmitChangeset() error = %v, want invalid changes", err)
+	}
+}
+
+// RULES_REQUIRE_REVIEW이면 사용자 룰 직접 변경은 거부하고 관리형 룰은 허용
+func TestRuleServiceRequireReview(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	s.requireReview = true
+
+	if _, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText}); !errors.Is(err, ErrReviewRequired) {
+		t.Errorf("CreateRule() error = %v, want ErrReviewRequired", err)
+	}
+	if _, err := s.CreateManagedRule(ctx, "user_a", "exclusion", &dto.CustomRuleRequest{Name: "exclusion", RuleText: `SecRuleUpdateTargetById 942100 "!ARGS:q"`}); err != nil {
+		t.Errorf("CreateManagedRule() error = %v", err)
+	}
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultHitWindow = 24 * time.Hour
+	defaultHitBucket = time.Hour
+	minHitBucket     = time.Minute
+	maxHitBuckets    = 500
+	topHitItems      = 10
+
+	// 매칭 기록은 큐에 모았다가 한 번에 저장 (로그 수집이 DB 쓰기를 기다리지 않도록)
+	hitQueueSize     = 10000
+	hitBatchSize     = 500
+	hitFlushInterval = 2 * time.Second
+)
+
+// RecordHit 수집된 WAF 이벤트의 룰 ID가 커스텀 룰이면 매칭 기록을 저장 큐에 넣음 (WAFService 로그 리스너)
+func (s *RuleService) RecordHit(wafLog dto.WAFLog) {
+	modsecID, err := strconv.Atoi(wafLog.RuleID)
+	if err != nil || modsecID <= 0 {
+		return
+	}
+
+	ruleID := s.ruleForModsecID(modsecID)
+	if ruleID == "" {
+		return
+	}
+
+	// SQLite는 시각을 문자열로 비교하므로 UTC로 저장
+	timestamp := wafLog.Timestamp.UTC()
+	if wafLog.Timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+	hit := &models.RuleHit{
+		RuleID:       ruleID,
+		ModsecRuleID: modsecID,
+		EventID:      wafLog.ID,
+		ClientIP:     wafLog.ClientIP,
+		Method:       wafLog.Method,
+		Host:         wafLog.Host,
+		URI:          wafLog.URL,
+		Blocked:      wafLog.Blocked,
+		Timestamp:    timestamp,
+	}
+	select {
+	case s.hitQueue <- hit:
+	default:
+		atomic.AddInt64(&s.hitsDropped, 1)
+	}
+}
+
+// ruleForModsecID ModSecurity 룰 ID를 쓰는 커스텀 룰 (비활성 룰 포함, CRS 룰이거나 없으면 "")
+func (s *RuleService) ruleForModsecID(modsecID int) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if _, isCRS := s.crsRuleIDs[modsecID]; isCRS {
+		return ""
+	}
+	return s.modsecIndex[modsecID]
+}
+
+// rebuildModsecIndexLocked s.rules를 바꾼 뒤 호출 (이벤트마다 모든 룰을 파싱하지 않도록)
+func (s *RuleService) rebuildModsecIndexLocked() {
+	index := make(map[int]string, len(s.rules))
+	for _, rule := range s.rules {
+		for _, id := range s.ruleIDsOf(rule.RuleText) {
+			index[id] = rule.ID
+		}
+	}
+	s.modsecIndex = index
+}
+
+// writeHits 큐의 매칭 기록을 hitBatchSize개가 모이거나 hitFlushInterval마다 저장
+func (s *RuleService) writeHits() {
+	ticker := time.NewTicker(hitFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.RuleHit, 0, hitBatchSize)
+	for {
+		select {
+		case hit := <-s.hitQueue:
+			batch = append(batch, hit)
+			if len(batch) < hitBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if dropped := atomic.SwapInt64(&s.hitsDropped, 0); dropped > 0 {
+				s.log.WithField("count", dropped).Warn("Rule hit queue is full, dropped rule hits")
+			}
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		s.flushHits(batch)
+		batch = batch[:0]
+	}
+}
+
+// flushHits 매칭 기록을 한 번에 저장하고 룰별 요약 갱신
+func (s *RuleService) flushHits(hits []*models.RuleHit) {
+	if err := s.db.CreateInBatches(hits, hitBatchSize).Error; err != nil {
+		s.log.WithError(err).WithField("count", len(hits)).Warn("Failed to record rule hits")
+		return
+	}
+
+	s.hitMutex.Lock()
+	defer s.hitMutex.Unlock()
+	for _, hit := range hits {
+		summary, exists := s.hitSummaries[hit.RuleID]
+		if !exists {
+			summary = &dto.RuleHitSummary{}
+			s.hitSummaries[hit.RuleID] = summary
+		}
+		summary.Count++
+		if summary.LastHitAt == nil || hit.Timestamp.After(*summary.LastHitAt) {
+			timestamp := hit.Timestamp
+			summary.LastHitAt = &timestamp
+		}
+	}
+}
+
+// hitSummary 룰 응답에 넣을 매칭 요약 (매칭이 없으면 count 0)
+func (s *RuleService) hitSummary(ruleID string) *dto.RuleHitSummary {
+	s.hitMutex.Lock()
+	defer s.hitMutex.Unlock()
+
+	if summary, exists := s.hitSummaries[ruleID]; exists {
+		copied := *summary
+		return &copied
+	}
+	return &dto.RuleHitSummary{}
+}
+
+// GetRuleHitAnalytics 룰 하나의 매칭 분석: 구간별 매칭 수, 상위 IP/URI, 마지막 매칭
+// scope가 있으면 (테넌트 사용자) 볼 수 있는 호스트의 매칭만 집계. total은 호스트와 관계없는 룰 전체 요약
+func (s *RuleService) GetRuleHitAnalytics(ctx context.Context, userID, ruleID string, scope LogFilter, window, bucket time.Duration) (*dto.RuleHitAnalytics, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.GetRuleHitAnalytics",
+		attribute.String("user_id", userID), attribute.String("rule_id", ruleID))
+	defer span.End()
+
+	if window <= 0 {
+		window = defaultHitWindow
+	}
+	if window > s.hitRetention {
+		window = s.hitRetention
+	}
+	if bucket <= 0 {
+		bucket = defaultHitBucket
+	}
+	if bucket < minHitBucket {
+		bucket = minHitBucket
+	}
+	// 구간이 너무 많으면 bucket을 늘림
+	if window/bucket > maxHitBuckets {
+		bucket = (window/maxHitBuckets + minHitBucket - 1).Truncate(minHitBucket)
+	}
+
+	s.mutex.RLock()
+	rule, err := s.ownedRuleLocked(userID, ruleID)
+	if err != nil {
+		s.mutex.RUnlock()
+		return nil, err
+	}
+	analytics := &dto.RuleHitAnalytics{
+		RuleID:    rule.ID,
+		Name:      rule.Name,
+		Enabled:   rule.Enabled,
+		ModsecIDs: s.ruleIDsOf(rule.RuleText),
+	}
+	s.mutex.RUnlock()
+
+	analytics.Total = *s.hitSummary(ruleID)
+	analytics.To = time.Now().UTC()
+	analytics.From = analytics.To.Add(-window).Truncate(bucket)
+	analytics.Bucket = bucket.String()
+
+	var hits []models.RuleHit
+	err = s.db.WithContext(ctx).
+		Select("timestamp", "blocked", "host", "client_ip", "uri").
+		Where("rule_id = ? AND timestamp >= ?", ruleID, analytics.From).
+		Find(&hits).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rule hits: %w", err)
+	}
+	if scope != nil {
+		scoped := hits[:0]
+		for _, hit := range hits {
+			if scope(&dto.WAFLog{Host: hit.Host}) {
+				scoped = append(scoped, hit)
+			}
+		}
+		hits = scoped
+	}
+
+	analytics.Timeline = hitTimeline(hits, analytics.From, analytics.To, bucket)
+	ips := make(map[string]int64)
+	uris := make(map[string]int64)
+	for _, hit := range hits {
+		analytics.Hits++
+		if hit.Blocked {
+			analytics.Blocked++
+		}
+		ips[hit.ClientIP]++
+		uris[hit.URI]++
+	}
+	analytics.TopIPs = topHitValues(ips)
+	analytics.TopURIs = topHitValues(uris)
+	return analytics, nil
+}
+
+// hitTimeline from부터 bucket 간격의 구간별 매칭 수 (매칭이 없는 구간도 0으로 포함)
+func hitTimeline(hits []models.RuleHit, from, to time.Time, bucket time.Duration) []dto.RuleHitBucket {
+	timeline := make([]dto.RuleHitBucket, 0, int(to.Sub(from)/bucket)+1)
+	for t := from; !t.After(to); t = t.Add(bucket) {
+		timeline = append(timeline, dto.RuleHitBucket{Time: t})
+	}
+	for _, hit := range hits {
+		index := int(hit.Timestamp.Sub(from) / bucket)
+		if index < 0 || index >= len(timeline) {
+			continue
+		}
+		timeline[index].Hits++
+		if hit.Blocked {
+			timeline[index].Blocked++
+		}
+	}
+	return timeline
+}
+
+// topHitValues 매칭이 많은 순서 (같으면 값 순서)로 topHitItems개
+func topHitValues(counts map[string]int64) []dto.RuleHitTop {
+	top := make([]dto.RuleHitTop, 0, len(counts))
+	for value, hits := range counts {
+		top = append(top, dto.RuleHitTop{Value: value, Hits: hits})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Hits != top[j].Hits {
+			return top[i].Hits > top[j].Hits
+		}
+		return top[i].Value < top[j].Value
+	})
+	if len(top) > topHitItems {
+		top = top[:topHitItems]
+	}
+	return top
+}
+
+// loadHitSummaries 보관 중인 매칭 기록으로 룰별 요약을 다시 계산
+func (s *RuleService) loadHitSummaries() {
+	var rows []struct {
+		RuleID string
+		Count  int64
+	}
+	err := s.db.Model(&models.RuleHit{}).
+		Select("rule_id, COUNT(*) AS count").
+		Group("rule_id").
+		Scan(&rows).Error
+	if err != nil {
+		s.log.WithError(err).Warn("Failed to load rule hit summaries")
+		return
+	}
+
+	summaries := make(map[string]*dto.RuleHitSummary, len(rows))
+	for _, row := range rows {
+		summary := &dto.RuleHitSummary{Count: row.Count}
+		// MAX()는 SQLite에서 문자열로 읽히므로 마지막 기록을 따로 조회
+		var last models.RuleHit
+		if err := s.db.Select("timestamp").Where("rule_id = ?", row.RuleID).Order("timestamp DESC").First(&last).Error; err == nil {
+			summary.LastHitAt = &last.Timestamp
+		}
+		summaries[row.RuleID] = summary
+	}
+
+	s.hitMutex.Lock()
+	s.hitSummaries = summaries
+	s.hitMutex.Unlock()
+}
+
+// pruneHits 보관 기간이 지난 매칭 기록을 매시간 삭제
+func (s *RuleService) pruneHits() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result := s.db.Where("timestamp < ?", time.Now().UTC().Add(-s.hitRetention)).Delete(&models.RuleHit{})
+		if result.Error != nil {
+			s.log.WithError(result.Error).Warn("Failed to prune rule hits")
+			continue
+		}
+		if result.RowsAffected > 0 {
+			s.log.WithField("count", result.RowsAffected).Info("Pruned expired rule hits")
+			s.loadHitSummaries()
+		}
+	}
+}
+
+// purgeSubjectHits 정보주체 삭제 요청: 관련 매칭 기록 삭제 (dryRun이면 개수만)
+func (s *RuleService) purgeSubjectHits(m *subjectMatcher, dryRun bool) int {
+	var hits []models.RuleHit
+	if err := s.db.Select("id", "event_id", "client_ip", "uri").Find(&hits).Error; err != nil {
+		s.log.WithError(err).Warn("Failed to search rule hits for erasure")
+		return 0
+	}
+
+	var ids []uint
+	for _, hit := range hits {
+		if m.eventIDs[hit.EventID] || (m.ip != "" && hit.ClientIP == m.ip) || m.matchText(hit.URI) {
+			ids = append(ids, hit.ID)
+		}
+	}
+	if dryRun || len(ids) == 0 {
+		return len(ids)
+	}
+
+	if err := s.db.Delete(&models.RuleHit{}, ids).Error; err != nil {
+		s.log.WithError(err).Error("Failed to erase rule hits")
+		return 0
+	}
+	s.loadHitSummaries()
+	return len(ids)
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+)
+
+// queuedHits 저장 큐에 쌓인 매칭 기록을 모두 꺼냄
+func queuedHits(s *RuleService) []*models.RuleHit {
+	var hits []*models.RuleHit
+	for {
+		select {
+		case hit := <-s.hitQueue:
+			hits = append(hits, hit)
+		default:
+			return hits
+		}
+	}
+}
+
+func TestRuleServiceRecordHit(t *testing.T) {
+	tests := []struct {
+		name     string
+		ruleID   string
+		crsIDs   map[int]string
+		wantRule bool
+	}{
+		{"custom rule", "1001", nil, true},
+		{"not a number", "abc", nil, false},
+		{"unknown rule", "1999", nil, false},
+		{"crs rule with same id", "1001", map[int]string{1001: "REQUEST-901-INITIALIZATION.conf"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestRuleService(t, newTestDB(t), nil)
+			rule, err := s.CreateRule(context.Background(), "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true, Severity: "HIGH"})
+			if err != nil {
+				t.Fatalf("CreateRule() error = %v", err)
+			}
+			s.crsRuleIDs = tt.crsIDs
+
+			s.RecordHit(dto.WAFLog{ID: "e1", RuleID: tt.ruleID, ClientIP: "1.2.3.4", Host: "shop.example.com", URL: "/login", Blocked: true})
+			hits := queuedHits(s)
+			if !tt.wantRule {
+				if len(hits) != 0 {
+					t.Errorf("RecordHit() queued %+v, want nothing", hits)
+				}
+				return
+			}
+			if len(hits) != 1 || hits[0].RuleID != rule.ID || hits[0].ModsecRuleID != 1001 || hits[0].EventID != "e1" || hits[0].Timestamp.IsZero() {
+				t.Errorf("RecordHit() queued %+v, want hit for %s", hits, rule.ID)
+			}
+		})
+	}
+}
+
+func TestRuleServiceRecordHitFollowsRuleChanges(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true, Severity: "HIGH"})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	if _, err := s.UpdateRule(ctx, "user_a", rule.ID, testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: `SecRule ARGS "@contains attack" "id:1005,phase:2,deny"`, Enabled: false, Severity: "HIGH"}); err != nil {
+		t.Fatalf("UpdateRule() error = %v", err)
+	}
+
+	// 비활성 룰도 기록하고, 바뀐 룰 ID만 인식
+	if got := s.ruleForModsecID(1005); got != rule.ID {
+		t.Errorf("ruleForModsecID(1005) = %q, want %s", got, rule.ID)
+	}
+	if got := s.ruleForModsecID(1001); got != "" {
+		t.Errorf("ruleForModsecID(1001) = %q, want old id forgotten", got)
+	}
+
+	full := newTestRuleService(t, newTestDB(t), nil)
+	full.hitQueue = make(chan *models.RuleHit, 1)
+	if _, err := full.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true, Severity: "HIGH"}); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		full.RecordHit(dto.WAFLog{RuleID: "1001"})
+	}
+	if len(queuedHits(full)) != 1 || full.hitsDropped != 2 {
+		t.Errorf("hits dropped = %d, want 2 when the queue is full", full.hitsDropped)
+	}
+}
+
+func TestRuleServiceGetRuleHitAnalytics(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true, Severity: "HIGH"})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	now := time.Now().UTC()
+	s.flushHits([]*models.RuleHit{
+		{RuleID: rule.ID, ModsecRuleID: 1001, ClientIP: "1.2.3.4", Host: "shop.example.com", URI: "/login", Blocked: true, Timestamp: now.Add(-10 * time.Minute)},
+		{RuleID: rule.ID, ModsecRuleID: 1001, ClientIP: "1.2.3.4", Host: "shop.example.com", URI: "/admin", Blocked: true, Timestamp: now.Add(-90 * time.Minute)},
+		{RuleID: rule.ID, ModsecRuleID: 1001, ClientIP: "5.6.7.8", Host: "blog.example.com", URI: "/login", Timestamp: now.Add(-30 * time.Minute)},
+		{RuleID: rule.ID, ModsecRuleID: 1001, ClientIP: "9.9.9.9", Host: "shop.example.com", URI: "/old", Timestamp: now.Add(-48 * time.Hour)},
+	})
+	shopOnly := func(log *dto.WAFLog) bool { return log.Host == "shop.example.com" }
+
+	tests := []struct {
+		name        string
+		userID      string
+		scope       LogFilter
+		window      time.Duration
+		bucket      time.Duration
+		wantHits    int64
+		wantBlocked int64
+		wantTopIP   string
+		wantBucket  string
+		wantErr     string
+	}{
+		{"default window", "user_a", nil, 0, 0, 3, 2, "1.2.3.4", "1h0m0s", ""},
+		{"short window", "user_a", nil, time.Hour, 15 * time.Minute, 2, 1, "1.2.3.4", "15m0s", ""},
+		{"tenant scope", "user_a", shopOnly, 0, 0, 2, 2, "1.2.3.4", "1h0m0s", ""},
+		{"retention window", "user_a", nil, 365 * 24 * time.Hour, time.Minute, 4, 2, "1.2.3.4", "1h27m0s", ""},
+		{"other user's rule", "user_b", nil, 0, 0, 0, 0, "", "", "access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analytics, err := s.GetRuleHitAnalytics(ctx, tt.userID, rule.ID, tt.scope, tt.window, tt.bucket)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetRuleHitAnalytics() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRuleHitAnalytics() error = %v", err)
+			}
+			if analytics.Hits != tt.wantHits || analytics.Blocked != tt.wantBlocked || analytics.Bucket != tt.wantBucket {
+				t.Errorf("analytics = %d hits, %d blocked, bucket %s", analytics.Hits, analytics.Blocked, analytics.Bucket)
+			}
+			if len(analytics.TopIPs) == 0 || analytics.TopIPs[0].Value != tt.wantTopIP {
+				t.Errorf("TopIPs = %+v, want %s first", analytics.TopIPs, tt.wantTopIP)
+			}
+			var timelineHits int64
+			for _, bucket := range analytics.Timeline {
+				timelineHits += bucket.Hits
+			}
+			if timelineHits != tt.wantHits || len(analytics.Timeline) > maxHitBuckets+1 {
+				t.Errorf("timeline has %d hits in %d buckets", timelineHits, len(analytics.Timeline))
+			}
+			// total은 범위와 관계없이 룰 전체
+			if analytics.Total.Count != 4 || analytics.Total.LastHitAt == nil {
+				t.Errorf("Total = %+v, want 4 hits", analytics.Total)
+			}
+		})
+	}
+}
+
+func TestRuleServiceHitPersistence(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := newTestRuleService(t, db, nil)
+	rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true, Severity: "HIGH"})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	last := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.flushHits([]*models.RuleHit{
+		{RuleID: rule.ID, ModsecRuleID: 1001, EventID: "e1", ClientIP: "1.2.3.4", URI: "/login?user=kim@example.com", Timestamp: last.Add(-time.Hour)},
+		{RuleID: rule.ID, ModsecRuleID: 1001, EventID: "e2", ClientIP: "5.6.7.8", URI: "/login", Timestamp: last},
+	})
+
+	reloaded := newTestRuleService(t, db, nil)
+	got, err := reloaded.GetRule("user_a", rule.ID)
+	if err != nil {
+		t.Fatalf("GetRule() after reload error = %v", err)
+	}
+	if got.Hits == nil || got.Hits.Count != 2 || got.Hits.LastHitAt == nil || !got.Hits.LastHitAt.Equal(last) {
+		t.Errorf("reloaded hits = %+v, want 2 hits last at %s", got.Hits, last)
+	}
+
+	tests := []struct {
+		name    string
+		subject dto.ErasureSubject
+		dryRun  bool
+		want    int
+		wantNow int64
+	}{
+		{"dry run", dto.ErasureSubject{IP: "1.2.3.4"}, true, 1, 2},
+		{"identifier in uri", dto.ErasureSubject{Identifier: "kim@example.com"}, false, 1, 1},
+		{"unrelated", dto.ErasureSubject{IP: "9.9.9.9"}, false, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newSubjectMatcher(tt.subject)
+			if err != nil {
+				t.Fatalf("newSubjectMatcher() error = %v", err)
+			}
+			if got := reloaded.purgeSubjectHits(m, tt.dryRun); got != tt.want {
+				t.Errorf("purgeSubjectHits() = %d, want %d", got, tt.want)
+			}
+			if got := reloaded.hitSummary(rule.ID).Count; got != tt.wantNow {
+				t.Errorf("hit count = %d, want %d", got, tt.wantNow)
+			}
+		})
+	}
+}
@@ -0,0 +1,527 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/seclang"
+	"waf-backend/tracing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// 가져오기 결과 상태
+const (
+	RuleImportImported  = "imported"
+	RuleImportStaged    = "staged" // 검토 모드: 변경 세트에 추가됨
+	RuleImportValid     = "valid"  // dry run
+	RuleImportDuplicate = "duplicate"
+	RuleImportError     = "error"
+)
+
+// importEntry 가져올 룰 하나 (.conf 조각 또는 번들 항목)
+type importEntry struct {
+	dto.RuleBundleEntry
+	line int
+}
+
+// ImportConf .conf 텍스트를 룰 단위로 나눠 가져옴
+// 룰 바로 위 주석의 "Name:", "Description:", "Severity:"는 메타데이터로, 나머지 주석은 설명으로 사용
+func (s *RuleService) ImportConf(ctx context.Context, userID string, idRange dto.RuleIDRange, text string, opts *dto.RuleImportOptions) (*dto.RuleImportResult, error) {
+	segments := seclang.Split(text)
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no rules found in the file")
+	}
+
+	entries := make([]importEntry, 0, len(segments))
+	for _, segment := range segments {
+		entries = append(entries, confEntry(segment))
+	}
+	return s.importRules(ctx, userID, idRange, entries, opts)
+}
+
+// ImportBundle ExportRules로 내보낸 JSON 번들을 가져옴 (룰 ID는 새로 발급)
+func (s *RuleService) ImportBundle(ctx context.Context, userID string, idRange dto.RuleIDRange, bundle *dto.RuleBundle, opts *dto.RuleImportOptions) (*dto.RuleImportResult, error) {
+	if bundle.Version > dto.RuleBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	if len(bundle.Rules) == 0 {
+		return nil, fmt.Errorf("bundle contains no rules")
+	}
+
+	entries := make([]importEntry, 0, len(bundle.Rules))
+	for _, rule := range bundle.Rules {
+		entries = append(entries, importEntry{RuleBundleEntry: rule})
+	}
+	return s.importRules(ctx, userID, idRange, entries, opts)
+}
+
+// importRules 룰마다 변경 세트의 create 변경과 같은 기준으로 검증하고, 통과한 룰만 한 번에 저장
+// 같은 내용의 룰(ID, 공백 차이 무시)이 이미 있거나 파일 안에서 반복되면 duplicate로 건너뜀
+func (s *RuleService) importRules(ctx context.Context, userID string, idRange dto.RuleIDRange, entries []importEntry, opts *dto.RuleImportOptions) (*dto.RuleImportResult, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.ImportRules",
+		attribute.String("user_id", userID), attribute.Int("rules", len(entries)))
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	review := s.requireReview && !opts.DryRun
+	changeset := &models.RuleChangeset{
+		ID:          fmt.Sprintf("changeset_%d", time.Now().UnixNano()),
+		Title:       fmt.Sprintf("Import of %d rules", len(entries)),
+		Description: opts.Reason,
+		Status:      ChangesetDraft,
+		UserID:      userID,
+		RuleIDStart: idRange.Start,
+		RuleIDEnd:   idRange.End,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	working := make(map[string]*models.CustomRule, len(s.rules))
+	existing := make(map[string]string)
+	for id, rule := range s.rules {
+		working[id] = rule
+		if rule.UserID == userID {
+			existing[importKey(rule.RuleText)] = id
+		}
+	}
+
+	result := &dto.RuleImportResult{DryRun: opts.DryRun, Total: len(entries)}
+	seen := make(map[string]string)
+	var ops []changesetOp
+	for i, entry := range entries {
+		item := dto.RuleImportItem{Index: i, Line: entry.line, RuleText: entry.RuleText}
+		change := importChange(changeset, working, entry, i, opts)
+		item.Name = change.Name
+
+		key := importKey(entry.RuleText)
+		if ruleID, dup := existing[key]; dup {
+			item.Status, item.DuplicateOf = RuleImportDuplicate, ruleID
+		} else if where, dup := seen[key]; dup {
+			item.Status, item.DuplicateOf = RuleImportDuplicate, where
+		}
+		seen[key] = importLocation(entry, i)
+		if item.Status == RuleImportDuplicate {
+			result.Duplicates++
+			result.Rules = append(result.Rules, item)
+			continue
+		}
+
+		targets, err := s.scopeRuleTargets(entry.Targets, opts.TargetHosts)
+		change.Targets = encodeRuleTargets(targets)
+		var op *changesetOp
+		var reassigned map[string]int
+		if err == nil {
+			op, reassigned, err = s.applyImportLocked(ctx, changeset, working, change, opts.ReassignIDs)
+		}
+		if err != nil {
+			item.Status, item.Error, item.Details = RuleImportError, err.Error(), seclangErrors(err)
+			result.Failed++
+			result.Rules = append(result.Rules, item)
+			continue
+		}
+		change.RuleText = op.after.RuleText
+		item.RuleID, item.RuleText, item.ReassignedIDs = change.RuleID, change.RuleText, reassigned
+		switch {
+		case opts.DryRun:
+			item.Status = RuleImportValid
+		case review:
+			item.Status = RuleImportStaged
+		default:
+			item.Status = RuleImportImported
+		}
+		result.Imported++
+		result.Rules = append(result.Rules, item)
+		ops = append(ops, *op)
+	}
+
+	if opts.DryRun || len(ops) == 0 {
+		return result, nil
+	}
+
+	// 검토 모드면 룰을 바로 만들지 않고 draft 변경 세트로 저장 (제출/승인/배포는 변경 세트 API로)
+	if review {
+		if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(changeset).Error; err != nil {
+				return err
+			}
+			for _, op := range ops {
+				if err := tx.Create(op.change).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to save changeset: %w", err)
+		}
+		result.ChangesetID = changeset.ID
+
+		s.log.WithFields(logrus.Fields{
+			"user_id":      userID,
+			"changeset_id": changeset.ID,
+			"staged":       len(ops),
+		}).Info("Rule import staged in changeset")
+		return result, nil
+	}
+
+	reason := opts.Reason
+	if reason == "" {
+		reason = "imported"
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, op := range ops {
+			if err := tx.Create(op.after).Error; err != nil {
+				return err
+			}
+			if err := recordRevision(tx, op.after, RuleRevisionCreate, userID, reason); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save rules: %w", err)
+	}
+	for _, op := range ops {
+		s.rules[op.after.ID] = op.after
+	}
+	s.rebuildModsecIndexLocked()
+
+	// 룰이 몇 개든 배포는 한 번
+	if err := s.updateConfigMap(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update ConfigMap")
+	}
+	if err := s.updateIngressAnnotation(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update Ingress annotation")
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"imported":   result.Imported,
+		"duplicates": result.Duplicates,
+		"failed":     result.Failed,
+	}).Info("Custom rules imported")
+
+	return result, nil
+}
+
+// applyImportLocked 변경을 working에 적용. reassign이면 쓸 수 없는 ID를 빼고 자동 배정으로 한 번 더 시도
+// 바뀐 ID는 원래 ID → 새 ID로 반환
+func (s *RuleService) applyImportLocked(ctx context.Context, changeset *models.RuleChangeset, working map[string]*models.CustomRule, change *models.RuleChange, reassign bool) (*changesetOp, map[string]int, error) {
+	if strings.TrimSpace(change.RuleText) == "" {
+		return nil, nil, fmt.Errorf("rule_text is required")
+	}
+
+	op, err := s.applyChangeLocked(ctx, changeset, working, change)
+	if err == nil || !reassign {
+		return op, nil, err
+	}
+
+	idRange := dto.RuleIDRange{Start: changeset.RuleIDStart, End: changeset.RuleIDEnd}
+	owners := s.ruleIDOwnersLocked(working, "", false)
+	drop := make(map[int]bool)
+	for _, id := range s.ruleIDsOf(change.RuleText) {
+		_, taken := owners[id]
+		if taken || reservedRuleIDOwner(id) != "" || id < idRange.Start || id > idRange.End {
+			drop[id] = true
+		}
+	}
+	if len(drop) == 0 {
+		return nil, nil, err
+	}
+	ruleText, rerr := withoutRuleIDs(change.RuleText, drop)
+	if rerr != nil {
+		return nil, nil, err
+	}
+
+	retry := *change
+	retry.RuleText = ruleText
+	op, err = s.applyChangeLocked(ctx, changeset, working, &retry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reassigned := make(map[string]int)
+	assigned := directiveIDs(op.after.RuleText)
+	for i, id := range directiveIDs(change.RuleText) {
+		if drop[id] && i < len(assigned) {
+			reassigned[strconv.Itoa(id)] = assigned[i]
+		}
+	}
+	*change = retry
+	op.change = change
+	return op, reassigned, nil
+}
+
+// importChange 항목을 create 변경으로 변환. enabled는 옵션 > 번들 값 > true,
+// severity는 번들/주석 값 > severity 액션 > 옵션 > MEDIUM 순서
+func importChange(changeset *models.RuleChangeset, working map[string]*models.CustomRule, entry importEntry, index int, opts *dto.RuleImportOptions) *models.RuleChange {
+	enabled := true
+	if entry.Enabled != nil {
+		enabled = *entry.Enabled
+	}
+	if opts.Enabled != nil {
+		enabled = *opts.Enabled
+	}
+
+	severity := entry.Severity
+	if !validSeverity(severity) {
+		severity = severityOf(entry.RuleText)
+	}
+	if severity == "" {
+		severity = opts.Severity
+	}
+	if severity == "" {
+		severity = "MEDIUM"
+	}
+
+	name := entry.Name
+	if name == "" {
+		name = importName(entry, index)
+	}
+
+	change := &models.RuleChange{
+		ChangesetID: changeset.ID,
+		Action:      RuleChangeCreate,
+		RuleID:      generateRuleID(),
+		Name:        name,
+		Description: entry.Description,
+		RuleText:    entry.RuleText,
+		Enabled:     enabled,
+		Severity:    severity,
+		RuleGroup:   strings.TrimSpace(entry.Group),
+		Reason:      opts.Reason,
+		CreatedAt:   time.Now(),
+	}
+	for working[change.RuleID] != nil {
+		change.RuleID = generateRuleID()
+	}
+
+	// 이 서버에 없는 템플릿이면 연결 없이 일반 룰로 가져옴
+	if entry.TemplateID != "" {
+		if _, err := findRuleTemplate(entry.TemplateID); err == nil {
+			params, _ := json.Marshal(entry.TemplateParams)
+			change.TemplateID, change.TemplateParams = entry.TemplateID, string(params)
+		}
+	}
+	return change
+}
+
+// confEntry .conf 조각의 주석에서 메타데이터 추출
+func confEntry(segment seclang.Segment) importEntry {
+	entry := importEntry{line: segment.Line}
+	entry.RuleText = segment.Text
+
+	var description []string
+	for _, comment := range segment.Comments {
+		key, value, found := strings.Cut(comment, ":")
+		value = strings.TrimSpace(value)
+		switch {
+		case found && strings.EqualFold(key, "name"):
+			entry.Name = value
+		case found && strings.EqualFold(key, "description"):
+			description = append(description, value)
+		case found && strings.EqualFold(key, "severity"):
+			entry.Severity = strings.ToUpper(value)
+		default:
+			description = append(description, comment)
+		}
+	}
+	entry.Description = strings.Join(description, "\n")
+	return entry
+}
+
+// importName 이름이 없으면 마커 이름, msg 액션, 룰 ID, 위치 순으로 이름을 만듦
+func importName(entry importEntry, index int) string {
+	directives, _ := seclang.ParseDraft(entry.RuleText)
+	if len(directives) > 0 {
+		if directives[0].Name == "SecMarker" && len(directives[0].Args) > 0 {
+			return "Marker " + directives[0].Args[0]
+		}
+		if msg := directives[0].Action("msg"); msg != nil && msg.Value != "" {
+			return msg.Value
+		}
+		if id := directives[0].ID(); id > 0 {
+			return fmt.Sprintf("Imported rule %d", id)
+		}
+	}
+	return "Imported rule (" + importLocation(entry, index) + ")"
+}
+
+func importLocation(entry importEntry, index int) string {
+	if entry.line > 0 {
+		return fmt.Sprintf("line %d", entry.line)
+	}
+	return fmt.Sprintf("rule #%d", index+1)
+}
+
+// importKey 중복 판단용 정규화 텍스트 (ID, 공백, 줄 이음, 액션 순서 차이는 무시)
+func importKey(ruleText string) string {
+	if directives, err := seclang.ParseDraft(ruleText); err == nil {
+		if specs, err := seclang.SpecsOf(directives); err == nil {
+			for i := range specs {
+				specs[i].ID = 0
+			}
+			if built, err := seclang.Build(specs); err == nil {
+				return built
+			}
+		}
+	}
+	return strings.Join(strings.Fields(strings.ReplaceAll(ruleText, "\\\n", " ")), " ")
+}
+
+// withoutRuleIDs drop에 있는 시작 룰 ID를 뺀 텍스트 (구조화된 형태로 다시 씀)
+func withoutRuleIDs(ruleText string, drop map[int]bool) (string, error) {
+	directives, err := seclang.ParseDraft(ruleText)
+	if err != nil {
+		return "", err
+	}
+	specs, err := seclang.SpecsOf(directives)
+	if err != nil {
+		return "", err
+	}
+	for i := range specs {
+		if drop[specs[i].ID] {
+			specs[i].ID = 0
+		}
+	}
+	return seclang.Build(specs)
+}
+
+// directiveIDs 시작 룰(SecRule, SecAction)마다의 ID (없으면 0)
+func directiveIDs(ruleText string) []int {
+	directives, _ := seclang.ParseDraft(ruleText)
+	var ids []int
+	for _, directive := range directives {
+		if directive.Name == "SecRule" || directive.Name == "SecAction" {
+			ids = append(ids, directive.ID())
+		}
+	}
+	return ids
+}
+
+// severityOf ModSecurity severity 액션 (이름 또는 0-7) → 룰 심각도
+func severityOf(ruleText string) string {
+	directives, _ := seclang.ParseDraft(ruleText)
+	for _, directive := range directives {
+		action := directive.Action("severity")
+		if action == nil {
+			continue
+		}
+		switch strings.ToUpper(action.Value) {
+		case "0", "1", "2", "EMERGENCY", "ALERT", "CRITICAL":
+			return "CRITICAL"
+		case "3", "ERROR":
+			return "HIGH"
+		case "4", "WARNING":
+			return "MEDIUM"
+		case "5", "6", "7", "NOTICE", "INFO", "DEBUG":
+			return "LOW"
+		}
+	}
+	return ""
+}
+
+func validSeverity(severity string) bool {
+	switch severity {
+	case "LOW", "MEDIUM", "HIGH", "CRITICAL":
+		return true
+	}
+	return false
+}
+
+// ExportRules 사용자가 작성한 룰을 JSON 번들로 (관리형 룰 제외). ruleIDs를 주면 해당 룰만
+func (s *RuleService) ExportRules(userID, exportedBy string, idRange dto.RuleIDRange, ruleIDs []string) (*dto.RuleBundle, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	selected := make(map[string]bool, len(ruleIDs))
+	for _, id := range ruleIDs {
+		rule, exists := s.rules[id]
+		if !exists {
+			return nil, fmt.Errorf("rule not found")
+		}
+		if rule.UserID != userID {
+			return nil, fmt.Errorf("access denied")
+		}
+		selected[id] = true
+	}
+
+	bundle := &dto.RuleBundle{
+		Version:     dto.RuleBundleVersion,
+		ExportedAt:  time.Now().UTC(),
+		ExportedBy:  exportedBy,
+		RuleIDRange: &idRange,
+		Rules:       []dto.RuleBundleEntry{},
+	}
+	for _, rule := range sortedRules(s.rules) {
+		if rule.UserID != userID || rule.Source != "" || (len(selected) > 0 && !selected[rule.ID]) {
+			continue
+		}
+		enabled := rule.Enabled
+		createdAt, updatedAt := rule.CreatedAt, rule.UpdatedAt
+		bundle.Rules = append(bundle.Rules, dto.RuleBundleEntry{
+			ID:             rule.ID,
+			Name:           rule.Name,
+			Description:    rule.Description,
+			RuleText:       rule.RuleText,
+			Enabled:        &enabled,
+			Severity:       rule.Severity,
+			TemplateID:     rule.TemplateID,
+			TemplateParams: decodeTemplateParams(rule.TemplateParams),
+			Group:          rule.RuleGroup,
+			Targets:        decodeRuleTargets(rule.Targets),
+			CreatedAt:      &createdAt,
+			UpdatedAt:      &updatedAt,
+		})
+	}
+	bundle.Count = len(bundle.Rules)
+	return bundle, nil
+}
+
+// BundleConf 번들을 .conf 텍스트로. 룰마다 Name/Description/Severity 주석을 붙여서 ImportConf로 다시 가져올 수 있음
+// 비활성 룰은 주석 처리 (다시 가져오면 건너뜀)
+func BundleConf(bundle *dto.RuleBundle) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Custom rules exported at %s", bundle.ExportedAt.Format(time.RFC3339))
+	if bundle.ExportedBy != "" {
+		fmt.Fprintf(&b, " by %s", bundle.ExportedBy)
+	}
+	fmt.Fprintf(&b, "\n# %d rules\n", bundle.Count)
+
+	for _, rule := range bundle.Rules {
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "# Name: %s\n", singleLine(rule.Name))
+		for i, line := range strings.Split(rule.Description, "\n") {
+			switch {
+			case strings.TrimSpace(line) == "":
+			case i == 0:
+				fmt.Fprintf(&b, "# Description: %s\n", line)
+			default:
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+		}
+		fmt.Fprintf(&b, "# Severity: %s\n", rule.Severity)
+		if rule.Enabled != nil && !*rule.Enabled {
+			b.WriteString("# Status: disabled\n")
+			for _, line := range strings.Split(rule.RuleText, "\n") {
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+			continue
+		}
+		b.WriteString(strings.TrimRight(rule.RuleText, "\n"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
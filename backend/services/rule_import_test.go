@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"waf-backend/dto"
+)
+
+const testImportConf = `# Name: block attack
+# Severity: HIGH
+SecRule ARGS "@contains attack" "id:1001,phase:2,deny,status:403"
+
+# same rule with another id
+SecRule ARGS "@contains attack" "id:1002,phase:2,deny,status:403"
+
+SecRule ARGS "@contains probe" "id:1003,phase:2,deny,msg:'probe'"
+
+SecRule ARGS "@contains exec" "id:1004,phase:2,deny,exec:/bin/sh"
+
+SecRule ARGS "@contains outside" "id:99999,phase:2,deny"
+`
+
+func TestRuleServiceImportConf(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         dto.RuleImportOptions
+		wantStatuses []string
+		wantSaved    int
+	}{
+		{"dry run", dto.RuleImportOptions{DryRun: true}, []string{RuleImportValid, RuleImportDuplicate, RuleImportValid, RuleImportError, RuleImportError}, 0},
+		{"import", dto.RuleImportOptions{}, []string{RuleImportImported, RuleImportDuplicate, RuleImportImported, RuleImportError, RuleImportError}, 2},
+		{"reassign ids", dto.RuleImportOptions{ReassignIDs: true}, []string{RuleImportImported, RuleImportDuplicate, RuleImportImported, RuleImportError, RuleImportImported}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestRuleService(t, newTestDB(t), nil)
+			result, err := s.ImportConf(context.Background(), "user_a", testRuleIDRange, testImportConf, &tt.opts)
+			if err != nil {
+				t.Fatalf("ImportConf() error = %v", err)
+			}
+			if len(result.Rules) != len(tt.wantStatuses) {
+				t.Fatalf("ImportConf() returned %d rules, want %d", len(result.Rules), len(tt.wantStatuses))
+			}
+			for i, want := range tt.wantStatuses {
+				if got := result.Rules[i].Status; got != want {
+					t.Errorf("rules[%d].Status = %s, want %s (%s)", i, got, want, result.Rules[i].Error)
+				}
+			}
+			if result.Rules[1].DuplicateOf != "line 3" {
+				t.Errorf("rules[1].DuplicateOf = %q, want line 3", result.Rules[1].DuplicateOf)
+			}
+
+			if got := len(userRules(t, s, "user_a")); got != tt.wantSaved {
+				t.Errorf("saved %d rules, want %d", got, tt.wantSaved)
+			}
+			if got := len(userRules(t, newTestRuleService(t, s.db, nil), "user_a")); got != tt.wantSaved {
+				t.Errorf("reloaded %d rules, want %d", got, tt.wantSaved)
+			}
+		})
+	}
+}
+
+func userRules(t *testing.T, s *RuleService, userID string) []*dto.CustomRuleResponse {
+	t.Helper()
+	rules, err := s.GetRules(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("GetRules() error = %v", err)
+	}
+	return rules
+}
+
+func TestRuleServiceImportMetadata(t *testing.T) {
+	s := newTestRuleService(t, newTestDB(t), nil)
+	result, err := s.ImportConf(context.Background(), "user_a", testRuleIDRange, testImportConf, &dto.RuleImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportConf() error = %v", err)
+	}
+
+	first, probe := result.Rules[0], result.Rules[2]
+	if first.Name != "block attack" || probe.Name != "probe" {
+		t.Errorf("names = %q, %q", first.Name, probe.Name)
+	}
+	rule, err := s.GetRule("user_a", first.RuleID)
+	if err != nil {
+		t.Fatalf("GetRule() error = %v", err)
+	}
+	if rule.Severity != "HIGH" || !rule.Enabled {
+		t.Errorf("rule = %+v, want enabled HIGH", rule)
+	}
+
+	// 같은 파일을 다시 가져오면 모두 기존 룰과 중복
+	again, err := s.ImportConf(context.Background(), "user_a", testRuleIDRange, testImportConf, &dto.RuleImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportConf(again) error = %v", err)
+	}
+	if again.Rules[0].Status != RuleImportDuplicate || again.Rules[0].DuplicateOf != first.RuleID {
+		t.Errorf("rules[0] = %+v, want duplicate of %s", again.Rules[0], first.RuleID)
+	}
+	// 다른 사용자의 룰과는 중복으로 보지 않지만 같은 룰 ID는 쓸 수 없음
+	other, err := s.ImportConf(context.Background(), "user_b", testRuleIDRange, testImportConf, &dto.RuleImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportConf(other user) error = %v", err)
+	}
+	if other.Rules[0].Status != RuleImportError {
+		t.Errorf("rules[0].Status = %s, want error for used rule id", other.Rules[0].Status)
+	}
+
+	if _, err := s.ImportConf(context.Background(), "user_a", testRuleIDRange, "# only comments\n", &dto.RuleImportOptions{}); err == nil {
+		t.Error("ImportConf(no rules) succeeded")
+	}
+}
+
+func TestRuleServiceImportStagesChangeset(t *testing.T) {
+	s := newTestRuleService(t, newTestDB(t), nil)
+	s.requireReview = true
+
+	result, err := s.ImportConf(context.Background(), "user_a", testRuleIDRange, testRuleText, &dto.RuleImportOptions{Reason: "migrate"})
+	if err != nil {
+		t.Fatalf("ImportConf() error = %v", err)
+	}
+	if result.ChangesetID == "" || result.Rules[0].Status != RuleImportStaged {
+		t.Fatalf("ImportConf() = %+v, want staged changeset", result)
+	}
+	if got := len(userRules(t, s, "user_a")); got != 0 {
+		t.Errorf("saved %d rules before publish, want 0", got)
+	}
+	changeset, err := s.GetChangeset(context.Background(), "user_a", false, result.ChangesetID)
+	if err != nil {
+		t.Fatalf("GetChangeset() error = %v", err)
+	}
+	if len(changeset.Changes) != 1 || changeset.Status != ChangesetDraft {
+		t.Errorf("changeset = %+v", changeset)
+	}
+}
+
+func TestRuleServiceExportRules(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	mine, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", Description: "blocks attack\nsecond line", RuleText: testRuleText, Enabled: true, Severity: "HIGH"})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	theirs, err := s.CreateRule(ctx, "user_b", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "other", RuleText: `SecRule ARGS "@contains x" "id:1002,phase:2,deny"`, Enabled: true, Severity: "LOW"})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		ruleIDs   []string
+		wantCount int
+		wantErr   string
+	}{
+		{"all own rules", nil, 1, ""},
+		{"selected", []string{mine.ID}, 1, ""},
+		{"other user's rule", []string{theirs.ID}, 0, "access denied"},
+		{"missing rule", []string{"rule_missing"}, 0, "rule not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := s.ExportRules("user_a", "a@example.com", testRuleIDRange, tt.ruleIDs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ExportRules() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExportRules() error = %v", err)
+			}
+			if bundle.Count != tt.wantCount || len(bundle.Rules) != tt.wantCount || bundle.Rules[0].ID != mine.ID {
+				t.Errorf("ExportRules() = %+v", bundle)
+			}
+		})
+	}
+
+	// 내보낸 번들과 .conf를 다른 서버에 가져오면 같은 룰이 만들어짐
+	bundle, err := s.ExportRules("user_a", "a@example.com", testRuleIDRange, nil)
+	if err != nil {
+		t.Fatalf("ExportRules() error = %v", err)
+	}
+	imports := []struct {
+		name string
+		run  func(*RuleService) (*dto.RuleImportResult, error)
+	}{
+		{"bundle", func(target *RuleService) (*dto.RuleImportResult, error) {
+			return target.ImportBundle(ctx, "user_c", testRuleIDRange, bundle, &dto.RuleImportOptions{})
+		}},
+		{"conf", func(target *RuleService) (*dto.RuleImportResult, error) {
+			return target.ImportConf(ctx, "user_c", testRuleIDRange, BundleConf(bundle), &dto.RuleImportOptions{})
+		}},
+	}
+	for _, tt := range imports {
+		t.Run("round trip "+tt.name, func(t *testing.T) {
+			target := newTestRuleService(t, newTestDB(t), nil)
+			result, err := tt.run(target)
+			if err != nil {
+				t.Fatalf("import error = %v", err)
+			}
+			if result.Imported != 1 {
+				t.Fatalf("import = %+v, want 1 imported", result)
+			}
+			rule, err := target.GetRule("user_c", result.Rules[0].RuleID)
+			if err != nil {
+				t.Fatalf("GetRule() error = %v", err)
+			}
+			if rule.ID == mine.ID || rule.Name != mine.Name || rule.Description != mine.Description ||
+				rule.RuleText != mine.RuleText || rule.Severity != mine.Severity || !rule.Enabled {
+				t.Errorf("imported rule = %+v, want copy of %+v", rule, mine)
+			}
+		})
+	}
+
+	if _, err := s.ImportBundle(ctx, "user_c", testRuleIDRange, &dto.RuleBundle{Version: dto.RuleBundleVersion + 1, Rules: bundle.Rules}, &dto.RuleImportOptions{}); err == nil {
+		t.Error("ImportBundle(newer version) succeeded")
+	}
+}
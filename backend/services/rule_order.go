@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/seclang"
+	"waf-backend/tracing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// rulePriorityStep 새 룰과 재배치한 룰 사이의 priority 간격
+const rulePriorityStep = 100
+
+var rulePhaseNames = map[int]string{
+	0: "markers",
+	1: "request headers",
+	2: "request body",
+	3: "response headers",
+	4: "response body",
+	5: "logging",
+}
+
+// nextRulePriority 모든 룰 뒤에 배포되는 priority
+func nextRulePriority(rules map[string]*models.CustomRule) int {
+	last := 0
+	for _, rule := range rules {
+		if rule.Priority > last {
+			last = rule.Priority
+		}
+	}
+	return last + rulePriorityStep
+}
+
+// rulePhase 룰 텍스트의 첫 SecRule/SecAction이 실행되는 phase (없으면 0)
+func rulePhase(ruleText string) int {
+	directives, _ := seclang.ParseDraft(ruleText)
+	for _, directive := range directives {
+		if directive.Name == "SecRule" || directive.Name == "SecAction" {
+			return directive.Phase()
+		}
+	}
+	return 0
+}
+
+// GetRulesByPhase 내 룰을 phase별로 묶어서 반환. 같은 phase 안에서는 배포 순서
+// (ModSecurity는 phase마다 파일 순서대로 실행하므로 phase 안의 순서가 실제 평가 순서)
+func (s *RuleService) GetRulesByPhase(userID string) []dto.RulePhaseGroup {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	groups := make(map[int]*dto.RulePhaseGroup)
+	for _, rule := range sortedRules(s.rules) {
+		if rule.UserID != userID {
+			continue
+		}
+		response := s.ruleToResponse(rule)
+		group, exists := groups[response.Phase]
+		if !exists {
+			group = &dto.RulePhaseGroup{Phase: response.Phase, Name: rulePhaseNames[response.Phase], Rules: []*dto.CustomRuleResponse{}}
+			groups[response.Phase] = group
+		}
+		group.Rules = append(group.Rules, response)
+	}
+
+	result := make([]dto.RulePhaseGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Phase < result[j].Phase })
+	return result
+}
+
+// ReorderRules ruleIDs의 룰들이 지금 차지한 자리 안에서 주어진 순서가 되도록 재배치
+// 다른 사용자의 룰 위치는 바뀌지 않음
+func (s *RuleService) ReorderRules(ctx context.Context, userID string, ruleIDs []string) ([]*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.ReorderRules", attribute.String("user_id", userID))
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	seen := make(map[string]bool, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		if seen[ruleID] {
+			return nil, fmt.Errorf("rule %s is listed more than once", ruleID)
+		}
+		seen[ruleID] = true
+		if _, err := s.ownedRuleLocked(userID, ruleID); err != nil {
+			return nil, err
+		}
+	}
+	return s.reorderLocked(ctx, userID, ruleIDs)
+}
+
+// MoveRule 룰 하나를 내 룰 중 before 룰 앞, after 룰 뒤, 또는 position 위치로 이동
+func (s *RuleService) MoveRule(ctx context.Context, userID, ruleID string, req *dto.RuleMoveRequest) ([]*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.MoveRule",
+		attribute.String("user_id", userID), attribute.String("rule_id", ruleID))
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	targets := 0
+	for _, set := range []bool{req.Before != "", req.After != "", req.Position != nil} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return nil, fmt.Errorf("exactly one of before, after or position is required")
+	}
+	if _, err := s.ownedRuleLocked(userID, ruleID); err != nil {
+		return nil, err
+	}
+
+	// 옮길 룰을 뺀 내 룰 순서에 끼워 넣음
+	var order []string
+	for _, rule := range sortedRules(s.rules) {
+		if rule.UserID == userID && rule.ID != ruleID {
+			order = append(order, rule.ID)
+		}
+	}
+	index := len(order)
+	switch {
+	case req.Position != nil:
+		if *req.Position < index {
+			index = *req.Position
+		}
+	default:
+		anchor := req.Before
+		if anchor == "" {
+			anchor = req.After
+		}
+		if anchor == ruleID {
+			return nil, fmt.Errorf("a rule cannot be moved relative to itself")
+		}
+		if _, err := s.ownedRuleLocked(userID, anchor); err != nil {
+			return nil, err
+		}
+		for i, id := range order {
+			if id == anchor {
+				index = i
+				if req.After != "" {
+					index++
+				}
+				break
+			}
+		}
+	}
+	order = append(order[:index], append([]string{ruleID}, order[index:]...)...)
+
+	return s.reorderLocked(ctx, userID, order)
+}
+
+// reorderLocked 전체 룰을 현재 순서대로 번호를 다시 매긴 뒤(동점 제거),
+// ruleIDs가 차지한 자리들을 ruleIDs 순서대로 다시 배정하고 바뀐 룰만 저장
+func (s *RuleService) reorderLocked(ctx context.Context, userID string, ruleIDs []string) ([]*dto.CustomRuleResponse, error) {
+	moving := make(map[string]bool, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		moving[ruleID] = true
+		// 변경 세트로만 룰을 바꿀 수 있는 환경에서는 배포 순서도 직접 바꿀 수 없음
+		if s.requireReview && s.rules[ruleID].Source == "" {
+			return nil, ErrReviewRequired
+		}
+	}
+
+	ordered := sortedRules(s.rules)
+	priorities := make(map[string]int, len(ordered))
+	var slots []int
+	for i, rule := range ordered {
+		priorities[rule.ID] = (i + 1) * rulePriorityStep
+		if moving[rule.ID] {
+			slots = append(slots, priorities[rule.ID])
+		}
+	}
+	for i, ruleID := range ruleIDs {
+		priorities[ruleID] = slots[i]
+	}
+
+	var changed []*models.CustomRule
+	for _, rule := range ordered {
+		if rule.Priority != priorities[rule.ID] {
+			updated := *rule
+			updated.Priority = priorities[rule.ID]
+			changed = append(changed, &updated)
+		}
+	}
+	if len(changed) == 0 {
+		return s.userRulesLocked(userID), nil
+	}
+
+	// 순서만 바뀌므로 updated_at과 리비전은 남기지 않음
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rule := range changed {
+			if err := tx.Model(&models.CustomRule{}).Where("id = ?", rule.ID).UpdateColumn("priority", rule.Priority).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save rule order: %w", err)
+	}
+	for _, rule := range changed {
+		s.rules[rule.ID] = rule
+	}
+	s.rebuildModsecIndexLocked()
+
+	if err := s.updateConfigMap(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update ConfigMap")
+	}
+	if err := s.updateIngressAnnotation(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update Ingress annotation")
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"rules":   len(ruleIDs),
+		"changed": len(changed),
+	}).Info("Custom rules reordered")
+
+	return s.userRulesLocked(userID), nil
+}
+
+// userRulesLocked 사용자의 룰 목록 (배포 순서)
+func (s *RuleService) userRulesLocked(userID string) []*dto.CustomRuleResponse {
+	result := []*dto.CustomRuleResponse{}
+	for _, rule := range sortedRules(s.rules) {
+		if rule.UserID == userID {
+			result = append(result, s.ruleToResponse(rule))
+		}
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"waf-backend/dto"
+)
+
+// newOrderedRules user_a 룰 a1..a3 사이에 user_b 룰 b1을 끼워서 만들고 이름 → ID를 반환
+func newOrderedRules(t *testing.T, s *RuleService) map[string]string {
+	t.Helper()
+	ids := make(map[string]string)
+	rules := []struct{ name, userID, ruleText string }{
+		{"a1", "user_a", `SecRule ARGS "@contains a1" "id:1001,phase:2,deny"`},
+		{"b1", "user_b", `SecRule ARGS "@contains b1" "id:1002,phase:2,deny"`},
+		{"a2", "user_a", `SecRule REQUEST_HEADERS "@contains a2" "id:1003,phase:1,deny"`},
+		{"a3", "user_a", `SecMarker END_A3`},
+	}
+	for _, rule := range rules {
+		created, err := s.CreateRule(context.Background(), rule.userID, testRuleIDRange, nil, &dto.CustomRuleRequest{Name: rule.name, RuleText: rule.ruleText, Enabled: true, Severity: "MEDIUM"})
+		if err != nil {
+			t.Fatalf("CreateRule(%s) error = %v", rule.name, err)
+		}
+		ids[rule.name] = created.ID
+	}
+	return ids
+}
+
+// ruleOrder 배포 순서대로 룰 이름
+func ruleOrder(s *RuleService) string {
+	var names []string
+	for _, rule := range sortedRules(s.rules) {
+		names = append(names, rule.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRuleServiceReorderRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    string
+		order     []string
+		wantOrder string
+		wantErr   string
+	}{
+		{"reverse own rules", "user_a", []string{"a3", "a2", "a1"}, "a3,b1,a2,a1", ""},
+		{"subset keeps other slots", "user_a", []string{"a3", "a1"}, "a3,b1,a2,a1", ""},
+		{"same order", "user_a", []string{"a1", "a2"}, "a1,b1,a2,a3", ""},
+		{"duplicate", "user_a", []string{"a1", "a1"}, "", "more than once"},
+		{"other user's rule", "user_a", []string{"a1", "b1"}, "", "access denied"},
+		{"missing rule", "user_a", []string{"missing"}, "", "rule not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestRuleService(t, newTestDB(t), nil)
+			ids := newOrderedRules(t, s)
+			ruleIDs := make([]string, 0, len(tt.order))
+			for _, name := range tt.order {
+				if id, ok := ids[name]; ok {
+					name = id
+				}
+				ruleIDs = append(ruleIDs, name)
+			}
+
+			rules, err := s.ReorderRules(context.Background(), tt.userID, ruleIDs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReorderRules() error = %v, want %q", err, tt.wantErr)
+				}
+				if got := ruleOrder(s); got != "a1,b1,a2,a3" {
+					t.Errorf("order after error = %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReorderRules() error = %v", err)
+			}
+			if got := ruleOrder(s); got != tt.wantOrder {
+				t.Errorf("order = %s, want %s", got, tt.wantOrder)
+			}
+			if len(rules) != 3 {
+				t.Errorf("ReorderRules() returned %d rules, want user_a's 3", len(rules))
+			}
+			if got := ruleOrder(newTestRuleService(t, s.db, nil)); got != tt.wantOrder {
+				t.Errorf("reloaded order = %s, want %s", got, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestRuleServiceMoveRule(t *testing.T) {
+	position := func(p int) *int { return &p }
+	tests := []struct {
+		name      string
+		rule      string
+		req       dto.RuleMoveRequest
+		wantOrder string
+		wantErr   string
+	}{
+		{"before", "a3", dto.RuleMoveRequest{Before: "a1"}, "a3,b1,a1,a2", ""},
+		{"after", "a1", dto.RuleMoveRequest{After: "a3"}, "a2,b1,a3,a1", ""},
+		{"position", "a1", dto.RuleMoveRequest{Position: position(1)}, "a2,b1,a1,a3", ""},
+		{"position past end", "a1", dto.RuleMoveRequest{Position: position(10)}, "a2,b1,a3,a1", ""},
+		{"no target", "a1", dto.RuleMoveRequest{}, "", "exactly one of"},
+		{"two targets", "a1", dto.RuleMoveRequest{Before: "a2", Position: position(0)}, "", "exactly one of"},
+		{"relative to itself", "a1", dto.RuleMoveRequest{Before: "a1"}, "", "relative to itself"},
+		{"other user's anchor", "a1", dto.RuleMoveRequest{After: "b1"}, "", "access denied"},
+		{"other user's rule", "b1", dto.RuleMoveRequest{Position: position(0)}, "", "access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestRuleService(t, newTestDB(t), nil)
+			ids := newOrderedRules(t, s)
+			if id, ok := ids[tt.req.Before]; ok {
+				tt.req.Before = id
+			}
+			if id, ok := ids[tt.req.After]; ok {
+				tt.req.After = id
+			}
+
+			_, err := s.MoveRule(context.Background(), "user_a", ids[tt.rule], &tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("MoveRule() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MoveRule() error = %v", err)
+			}
+			if got := ruleOrder(s); got != tt.wantOrder {
+				t.Errorf("order = %s, want %s", got, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestRuleServiceGetRulesByPhase(t *testing.T) {
+	s := newTestRuleService(t, newTestDB(t), nil)
+	newOrderedRules(t, s)
+
+	var got []string
+	for _, group := range s.GetRulesByPhase("user_a") {
+		var names []string
+		for _, rule := range group.Rules {
+			names = append(names, rule.Name)
+		}
+		got = append(got, fmt.Sprintf("%d %s: %s", group.Phase, group.Name, strings.Join(names, ",")))
+	}
+	want := "0 markers: a3|1 request headers: a2|2 request body: a1"
+	if strings.Join(got, "|") != want {
+		t.Errorf("GetRulesByPhase() = %v, want %s", got, want)
+	}
+}
+
+func TestRuleServiceReorderRequiresReview(t *testing.T) {
+	s := newTestRuleService(t, newTestDB(t), nil)
+	ids := newOrderedRules(t, s)
+	s.requireReview = true
+
+	if _, err := s.ReorderRules(context.Background(), "user_a", []string{ids["a2"], ids["a1"]}); !errors.Is(err, ErrReviewRequired) {
+		t.Errorf("ReorderRules() error = %v, want ErrReviewRequired", err)
+	}
+	if _, err := s.MoveRule(context.Background(), "user_a", ids["a1"], &dto.RuleMoveRequest{After: ids["a3"]}); !errors.Is(err, ErrReviewRequired) {
+		t.Errorf("MoveRule() error = %v, want ErrReviewRequired", err)
+	}
+}
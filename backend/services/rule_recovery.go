@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"waf-backend/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// custom-rules.conf에서 룰 하나를 감싸는 주석 마커
+const (
+	ruleMarkerPrefix = "# waf-rule: "
+	ruleMarkerEnd    = "# waf-rule-end"
+)
+
+// ruleMarker 룰마다 custom-rules.conf에 남기는 메타데이터 (DB를 잃어도 ConfigMap에서 룰을 복구)
+type ruleMarker struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description,omitempty"`
+	Owner          string    `json:"owner"`
+	Severity       string    `json:"severity"`
+	Enabled        bool      `json:"enabled"`
+	Priority       int       `json:"priority"`
+	Source         string    `json:"source,omitempty"`
+	TemplateID     string    `json:"template_id,omitempty"`
+	TemplateParams string    `json:"template_params,omitempty"`
+	Group          string    `json:"group,omitempty"`
+	Targets        string    `json:"targets,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// renderRuleBlock 마커로 감싼 룰 하나. 비활성 룰과 특정 Ingress에만 배포하는 룰은 주석 처리해서 메타데이터와 함께 남김
+func renderRuleBlock(rule *models.CustomRule) string {
+	marker, _ := json.Marshal(ruleMarker{
+		ID:             rule.ID,
+		Name:           rule.Name,
+		Description:    rule.Description,
+		Owner:          rule.UserID,
+		Severity:       rule.Severity,
+		Enabled:        rule.Enabled,
+		Priority:       rule.Priority,
+		Source:         rule.Source,
+		TemplateID:     rule.TemplateID,
+		TemplateParams: rule.TemplateParams,
+		Group:          rule.RuleGroup,
+		Targets:        rule.Targets,
+		CreatedAt:      rule.CreatedAt.UTC(),
+		UpdatedAt:      rule.UpdatedAt.UTC(),
+	})
+
+	var b strings.Builder
+	b.WriteString(headerCommentLines(rule.Name))
+	if rule.Description != "" {
+		b.WriteString(headerCommentLines(rule.Description))
+	}
+	b.WriteString(ruleMarkerPrefix + string(marker) + "\n")
+	if rule.Enabled && !ingressScoped(decodeRuleTargets(rule.Targets)) {
+		b.WriteString(strings.TrimRight(rule.RuleText, "\n") + "\n")
+	} else {
+		b.WriteString(commentLines(strings.TrimRight(rule.RuleText, "\n")))
+	}
+	b.WriteString(ruleMarkerEnd + "\n\n")
+	return b.String()
+}
+
+// commentLines 여러 줄 텍스트를 줄마다 주석 처리 (빈 텍스트는 빈 주석 한 줄)
+func commentLines(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			b.WriteString("#\n")
+			continue
+		}
+		b.WriteString("# " + line + "\n")
+	}
+	return b.String()
+}
+
+// headerCommentLines 룰 이름/설명 주석. 마커로 읽힐 수 있는 줄은 #을 하나 더 붙임 (원래 값은 마커 JSON에 있음)
+func headerCommentLines(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if isRuleMarkerLine(line) {
+			line = "# " + line
+		}
+		b.WriteString(commentLines(line))
+	}
+	return b.String()
+}
+
+// isRuleMarkerLine 블록 경계로 읽힐 수 있는 줄 (룰 텍스트에 들어가면 다른 소유자의 룰을 위조할 수 있음)
+// 주석 처리된 비활성 룰과 \r\n 변환까지 고려해서 앞의 #, 공백을 떼고 비교
+func isRuleMarkerLine(line string) bool {
+	line = strings.TrimLeft(strings.TrimSpace(line), "# \t")
+	return strings.HasPrefix(line, strings.TrimSpace(strings.TrimPrefix(ruleMarkerPrefix, "# "))) ||
+		strings.HasPrefix(line, strings.TrimPrefix(ruleMarkerEnd, "# "))
+}
+
+// parseRuleBlocks custom-rules.conf에서 마커로 감싼 룰 복원. 마커 밖의 내용(관리형 snippet, 마커 이전 형식)은 무시
+// 깨진 블록(잘못된 마커, 닫히지 않은 블록)은 건너뛰고 skipped에 이유를 담아 나머지 룰은 복구
+func parseRuleBlocks(content string) (rules []*models.CustomRule, skipped []error) {
+	var current *models.CustomRule
+	var body []string
+
+	for i, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, ruleMarkerPrefix):
+			if current != nil {
+				skipped = append(skipped, fmt.Errorf("line %d: rule %s is not closed", i+1, current.ID))
+				current = nil
+			}
+			var marker ruleMarker
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, ruleMarkerPrefix)), &marker); err != nil {
+				skipped = append(skipped, fmt.Errorf("line %d: invalid rule marker: %w", i+1, err))
+				continue
+			}
+			if marker.ID == "" || marker.Owner == "" {
+				skipped = append(skipped, fmt.Errorf("line %d: rule marker without id or owner", i+1))
+				continue
+			}
+			current = &models.CustomRule{
+				ID:             marker.ID,
+				Name:           marker.Name,
+				Description:    marker.Description,
+				Enabled:        marker.Enabled,
+				Priority:       marker.Priority,
+				Severity:       marker.Severity,
+				Source:         marker.Source,
+				UserID:         marker.Owner,
+				TemplateID:     marker.TemplateID,
+				TemplateParams: marker.TemplateParams,
+				RuleGroup:      marker.Group,
+				Targets:        marker.Targets,
+				CreatedAt:      marker.CreatedAt,
+				UpdatedAt:      marker.UpdatedAt,
+			}
+			body = nil
+		case line == ruleMarkerEnd && current != nil:
+			current.RuleText = strings.Join(body, "\n")
+			rules = append(rules, current)
+			current = nil
+		case current != nil:
+			if !current.Enabled || ingressScoped(decodeRuleTargets(current.Targets)) {
+				line = strings.TrimPrefix(strings.TrimPrefix(line, "#"), " ")
+			}
+			body = append(body, line)
+		}
+	}
+	if current != nil {
+		skipped = append(skipped, fmt.Errorf("rule %s is not closed", current.ID))
+	}
+	return rules, skipped
+}
+
+// recoverDeployedRulesLocked ConfigMap에는 있지만 DB에 없는 룰을 DB로 복구하고 복구한 수를 반환
+// 리비전이 남아 있는 룰(삭제됐지만 ConfigMap 갱신이 실패한 경우 등)은 되살리지 않음
+func (s *RuleService) recoverDeployedRulesLocked(ctx context.Context, deployed string) (int, error) {
+	rules, skipped := parseRuleBlocks(deployed)
+	for _, err := range skipped {
+		s.log.WithError(err).Warn("Skipping malformed rule block in ConfigMap")
+	}
+
+	var recovered []*models.CustomRule
+	for _, rule := range rules {
+		if _, exists := s.rules[rule.ID]; exists {
+			continue
+		}
+		last, err := latestRevision(s.db.WithContext(ctx), rule.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load revisions: %w", err)
+		}
+		if last > 0 {
+			s.log.WithField("rule_id", rule.ID).Info("Deployed rule has history in the database, not recovering it")
+			continue
+		}
+		recovered = append(recovered, rule)
+	}
+	if len(recovered) == 0 {
+		return 0, nil
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rule := range recovered {
+			if err := tx.Create(rule).Error; err != nil {
+				return err
+			}
+			if err := recordRevision(tx, rule, RuleRevisionRestore, "system", "recovered from ConfigMap"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("failed to save recovered rules: %w", err)
+	}
+	for _, rule := range recovered {
+		s.rules[rule.ID] = rule
+		s.log.WithFields(logrus.Fields{
+			"rule_id": rule.ID,
+			"user_id": rule.UserID,
+			"name":    rule.Name,
+		}).Warn("Recovered rule from ConfigMap")
+	}
+	s.rebuildModsecIndexLocked()
+	return len(recovered), nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"waf-backend/models"
+)
+
+func TestRuleBlockRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rule models.CustomRule
+	}{
+		{"enabled", models.CustomRule{ID: "rule_1", Name: "block admin", RuleText: `SecRule REQUEST_URI "@beginsWith /admin" "id:1001,phase:1,deny"`, Enabled: true, Severity: "HIGH", UserID: "user_a"}},
+		{"disabled", models.CustomRule{ID: "rule_2", Name: "off", Description: "two\nlines", RuleText: "# note\nSecRule ARGS \"@rx a\" \"id:1002,phase:2,deny\"", Severity: "LOW", UserID: "user_a"}},
+		{"ingress scoped", models.CustomRule{ID: "rule_3", Name: "shop only", RuleText: `SecRule ARGS "@rx b" "id:1003,phase:2,deny"`, Enabled: true, Targets: `[{"ingress":"shop/web"}]`, UserID: "user_b"}},
+		{"template", models.CustomRule{ID: "rule_4", Name: "tpl", RuleText: `SecRule ARGS "@rx c" "id:1004,phase:2,deny"`, Enabled: true, TemplateID: "block-path", TemplateParams: `{"path":"/x"}`, RuleGroup: "g", Priority: 300, UserID: "user_c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			rule.CreatedAt, rule.UpdatedAt = created, created
+
+			rules, skipped := parseRuleBlocks("# managed snippet\n" + renderRuleBlock(&rule))
+			if len(skipped) != 0 || len(rules) != 1 {
+				t.Fatalf("parseRuleBlocks() = %d rules, skipped %v", len(rules), skipped)
+			}
+			if got := *rules[0]; got != rule {
+				t.Errorf("parseRuleBlocks() =\n%+v\nwant\n%+v", got, rule)
+			}
+		})
+	}
+}
+
+func TestParseRuleBlocksSkipsMalformedBlocks(t *testing.T) {
+	good := func(id string) string {
+		return renderRuleBlock(&models.CustomRule{ID: id, Name: id, RuleText: `SecAction "id:1001,pass"`, Enabled: true, UserID: "user_a"})
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		skipped int
+	}{
+		{"stray marker before block", "# waf-rule: \n" + good("rule_1"), []string{"rule_1"}, 1},
+		{"marker without owner", "# waf-rule: {\"id\":\"rule_x\"}\nSecAction \"id:1,pass\"\n# waf-rule-end\n" + good("rule_1"), []string{"rule_1"}, 1},
+		{"unclosed block in the middle", "# waf-rule: {\"id\":\"rule_x\",\"owner\":\"u\"}\nSecAction \"id:1,pass\"\n" + good("rule_1") + good("rule_2"), []string{"rule_1", "rule_2"}, 1},
+		{"unclosed block at end", good("rule_1") + "# waf-rule: {\"id\":\"rule_x\",\"owner\":\"u\"}\n", []string{"rule_1"}, 1},
+		{"end marker outside block", "# waf-rule-end\n" + good("rule_1"), []string{"rule_1"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, skipped := parseRuleBlocks(tt.content)
+			var ids []string
+			for _, rule := range rules {
+				ids = append(ids, rule.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") || len(skipped) != tt.skipped {
+				t.Errorf("parseRuleBlocks() = %v, skipped %v; want %v, %d skipped", ids, skipped, tt.want, tt.skipped)
+			}
+		})
+	}
+}
+
+// 이름/설명에 마커를 넣어도 다른 소유자의 룰 블록이 만들어지지 않음
+func TestRenderRuleBlockNeutralizesMarkerLines(t *testing.T) {
+	forged := "# waf-rule: {\"id\":\"rule_forged\",\"owner\":\"victim\",\"enabled\":true}\nSecAction \"id:1,phase:1,pass,ctl:ruleEngine=Off\"\n# waf-rule-end"
+	tests := []struct {
+		name string
+		rule models.CustomRule
+	}{
+		{"name", models.CustomRule{ID: "rule_1", Name: strings.ReplaceAll(forged, "# ", ""), RuleText: `SecAction "id:1001,pass"`, Enabled: true, UserID: "attacker"}},
+		{"description", models.CustomRule{ID: "rule_1", Name: "x", Description: forged, RuleText: `SecAction "id:1001,pass"`, Enabled: true, UserID: "attacker"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, _ := parseRuleBlocks(renderRuleBlock(&tt.rule))
+			if len(rules) != 1 || rules[0].ID != "rule_1" || rules[0].UserID != "attacker" {
+				t.Fatalf("parseRuleBlocks() = %+v, want only rule_1 owned by attacker", rules)
+			}
+			if rules[0].Name != tt.rule.Name || rules[0].Description != tt.rule.Description {
+				t.Errorf("name/description not preserved: %q / %q", rules[0].Name, rules[0].Description)
+			}
+		})
+	}
+}
+
+func TestPrepareRuleTextRejectsMarkerLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		ruleText string
+	}{
+		{"end then forged marker", "SecAction \"id:1001,pass\"\n# waf-rule-end\n# waf-rule: {\"id\":\"rule_x\",\"owner\":\"victim\"}"},
+		{"bare marker", "# waf-rule: \nSecAction \"id:1001,pass\""},
+		{"indented end", "SecAction \"id:1001,pass\"\n   # waf-rule-end"},
+		{"crlf end", "SecAction \"id:1001,pass\"\r\n# waf-rule-end\r\n"},
+		{"uncommented marker (disabled rule)", "waf-rule: {}\nSecAction \"id:1001,pass\""},
+	}
+
+	s := &RuleService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.prepareRuleTextLocked(nil, tt.ruleText, nil, nil, "", nil, true); err == nil || !strings.Contains(err.Error(), "must not contain") {
+				t.Errorf("prepareRuleTextLocked() error = %v, want marker rejection", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/tracing"
+	"waf-backend/utils"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+const (
+	RuleRevisionCreate  = "create"
+	RuleRevisionUpdate  = "update"
+	RuleRevisionEnable  = "enable"
+	RuleRevisionDisable = "disable"
+	RuleRevisionDelete  = "delete"
+	RuleRevisionRestore = "restore"
+)
+
+// maxRuleTextBytes 룰 하나의 최대 텍스트 크기 (리비전/변경 세트 diff와 파싱 비용 제한)
+const maxRuleTextBytes = 64 << 10
+
+// GetRevisions 룰의 모든 리비전 (오래된 순). 삭제된 룰도 조회 가능
+func (s *RuleService) GetRevisions(ctx context.Context, userID, ruleID string) ([]*dto.RuleRevision, error) {
+	revisions, err := s.loadRevisions(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dto.RuleRevision, 0, len(revisions))
+	for i := range revisions {
+		result = append(result, revisionToResponse(&revisions[i]))
+	}
+	return result, nil
+}
+
+// DiffRevisions 두 리비전 사이에 바뀐 필드와 rule_text의 줄 단위 diff
+func (s *RuleService) DiffRevisions(ctx context.Context, userID, ruleID string, from, to int) (*dto.RuleRevisionDiff, error) {
+	revisions, err := s.loadRevisions(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	a, err := findRevision(revisions, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := findRevision(revisions, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &dto.RuleRevisionDiff{
+		RuleID:  ruleID,
+		From:    from,
+		To:      to,
+		Changes: make([]dto.RuleFieldChange, 0),
+		Diff:    utils.LineDiff(a.RuleText, b.RuleText, fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to)),
+	}
+	if a.Name != b.Name {
+		diff.Changes = append(diff.Changes, dto.RuleFieldChange{Field: "name", From: a.Name, To: b.Name})
+	}
+	if a.Description != b.Description {
+		diff.Changes = append(diff.Changes, dto.RuleFieldChange{Field: "description", From: a.Description, To: b.Description})
+	}
+	if a.Enabled != b.Enabled {
+		diff.Changes = append(diff.Changes, dto.RuleFieldChange{Field: "enabled", From: a.Enabled, To: b.Enabled})
+	}
+	if a.Severity != b.Severity {
+		diff.Changes = append(diff.Changes, dto.RuleFieldChange{Field: "severity", From: a.Severity, To: b.Severity})
+	}
+	if a.RuleText != b.RuleText {
+		diff.Changes = append(diff.Changes, dto.RuleFieldChange{Field: "rule_text", From: a.RuleText, To: b.RuleText})
+	}
+	if a.TemplateID != b.TemplateID {
+		diff.Changes = append(diff.Changes, dto.RuleFieldChange{Field: "template_id", From: a.TemplateID, To: b.TemplateID})
+	}
+	if a.TemplateParams != b.TemplateParams {
+		diff.Changes = append(diff.Changes, dto.RuleFieldChange{Field: "template_params", From: decodeTemplateParams(a.TemplateParams), To: decodeTemplateParams(b.TemplateParams)})
+	}
+	if a.RuleGroup != b.RuleGroup {
+		diff.Changes = append(diff.Changes, dto.RuleFieldChange{Field: "group", From: a.RuleGroup, To: b.RuleGroup})
+	}
+	if a.Targets != b.Targets {
+		diff.Changes = append(diff.Changes, dto.RuleFieldChange{Field: "targets", From: decodeRuleTargets(a.Targets), To: decodeRuleTargets(b.Targets)})
+	}
+
+	return diff, nil
+}
+
+// RestoreRevision 리비전 내용으로 룰을 되돌리고 다시 배포 (삭제된 룰이면 같은 ID로 다시 생성)
+func (s *RuleService) RestoreRevision(ctx context.Context, userID, ruleID string, revision int, idRange dto.RuleIDRange, allowedHosts []string, reason string) (*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.RestoreRevision",
+		attribute.String("user_id", userID), attribute.String("rule_id", ruleID), attribute.Int("revision", revision))
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	revisions, err := s.loadRevisions(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := findRevision(revisions, revision)
+	if err != nil {
+		return nil, err
+	}
+	if s.requireReview && target.Source == "" {
+		return nil, ErrReviewRequired
+	}
+
+	// 되돌리는 내용도 현재 정책과 ID 충돌 기준으로 다시 검증
+	current, exists := s.rules[ruleID]
+	keptIDs := s.ruleIDsOf(target.RuleText)
+	if exists {
+		keptIDs = append(keptIDs, s.ruleIDsOf(current.RuleText)...)
+	}
+	policy, rangeLimit := s.userPolicy, &idRange
+	if target.Source != "" {
+		policy, rangeLimit = s.managedPolicy, nil
+	}
+	ruleText, err := s.prepareRuleTextLocked(s.rules, target.RuleText, policy, rangeLimit, ruleID, keptIDs, target.Enabled)
+	if err != nil {
+		return nil, fmt.Errorf("revision %d no longer validates: %w", revision, err)
+	}
+
+	restored := &models.CustomRule{
+		ID:             ruleID,
+		Name:           target.Name,
+		Description:    target.Description,
+		RuleText:       ruleText,
+		Enabled:        target.Enabled,
+		Severity:       target.Severity,
+		Source:         target.Source,
+		TemplateID:     target.TemplateID,
+		TemplateParams: target.TemplateParams,
+		RuleGroup:      target.RuleGroup,
+		Targets:        target.Targets,
+		UserID:         target.UserID,
+		CreatedAt:      revisions[0].CreatedAt,
+		UpdatedAt:      time.Now(),
+	}
+	if exists {
+		restored.CreatedAt, restored.Priority = current.CreatedAt, current.Priority
+	} else {
+		restored.Priority = nextRulePriority(s.rules)
+	}
+	// 되돌린 대상도 현재 테넌트 호스트 기준으로 다시 확인
+	if restored.Source == "" {
+		targets, err := s.scopeRuleTargets(decodeRuleTargets(restored.Targets), allowedHosts)
+		if err != nil {
+			return nil, fmt.Errorf("revision %d no longer validates: %w", revision, err)
+		}
+		restored.Targets = encodeRuleTargets(targets)
+		if err := checkTargetable(restored, targets); err != nil {
+			return nil, fmt.Errorf("revision %d no longer validates: %w", revision, err)
+		}
+	}
+
+	if reason == "" {
+		reason = fmt.Sprintf("restored revision %d", revision)
+	} else {
+		reason = fmt.Sprintf("restored revision %d: %s", revision, reason)
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		save := tx.Create
+		if exists {
+			save = tx.Save
+		}
+		if err := save(restored).Error; err != nil {
+			return err
+		}
+		return recordRevision(tx, restored, RuleRevisionRestore, userID, reason)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save rule: %w", err)
+	}
+	s.rules[ruleID] = restored
+	s.rebuildModsecIndexLocked()
+
+	// ConfigMap과 Ingress annotation 업데이트 (즉시 적용)
+	if err := s.updateConfigMap(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update ConfigMap")
+	}
+	if err := s.updateIngressAnnotation(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update Ingress annotation")
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"rule_id":  ruleID,
+		"user_id":  userID,
+		"revision": revision,
+	}).Info("Custom rule restored from revision")
+
+	return s.ruleToResponse(restored), nil
+}
+
+// loadRevisions DB에서 룰의 리비전들을 읽고 소유자 확인
+func (s *RuleService) loadRevisions(ctx context.Context, userID, ruleID string) ([]models.CustomRuleRevision, error) {
+	var revisions []models.CustomRuleRevision
+	if err := s.db.WithContext(ctx).Where("rule_id = ?", ruleID).Order("revision").Find(&revisions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load revisions: %w", err)
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("rule not found")
+	}
+	if revisions[0].UserID != userID {
+		return nil, fmt.Errorf("access denied")
+	}
+	return revisions, nil
+}
+
+// recordRevision 룰의 현재 상태를 다음 번호의 리비전으로 저장 (룰 저장과 같은 트랜잭션에서 호출)
+func recordRevision(tx *gorm.DB, rule *models.CustomRule, action, author, reason string) error {
+	last, err := latestRevision(tx, rule.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.CustomRuleRevision{
+		RuleID:         rule.ID,
+		Revision:       last + 1,
+		Action:         action,
+		Name:           rule.Name,
+		Description:    rule.Description,
+		RuleText:       rule.RuleText,
+		Enabled:        rule.Enabled,
+		Severity:       rule.Severity,
+		Source:         rule.Source,
+		TemplateID:     rule.TemplateID,
+		TemplateParams: rule.TemplateParams,
+		RuleGroup:      rule.RuleGroup,
+		Targets:        rule.Targets,
+		UserID:         rule.UserID,
+		Author:         author,
+		Reason:         reason,
+		CreatedAt:      time.Now(),
+	}).Error
+}
+
+// latestRevision 룰의 마지막 리비전 번호 (리비전이 없으면 0)
+func latestRevision(tx *gorm.DB, ruleID string) (int, error) {
+	var last int
+	err := tx.Model(&models.CustomRuleRevision{}).
+		Where("rule_id = ?", ruleID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&last).Error
+	return last, err
+}
+
+// revisionActionFor 활성화 여부만 바뀌었으면 enable/disable, 그 외는 update
+func revisionActionFor(before, after *models.CustomRule) string {
+	toggledOnly := before.Enabled != after.Enabled &&
+		before.Name == after.Name &&
+		before.Description == after.Description &&
+		before.RuleText == after.RuleText &&
+		before.Severity == after.Severity &&
+		before.TemplateParams == after.TemplateParams &&
+		before.RuleGroup == after.RuleGroup &&
+		before.Targets == after.Targets
+	switch {
+	case toggledOnly && after.Enabled:
+		return RuleRevisionEnable
+	case toggledOnly:
+		return RuleRevisionDisable
+	default:
+		return RuleRevisionUpdate
+	}
+}
+
+func findRevision(revisions []models.CustomRuleRevision, revision int) (*models.CustomRuleRevision, error) {
+	for i := range revisions {
+		if revisions[i].Revision == revision {
+			return &revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("revision %d not found", revision)
+}
+
+func revisionToResponse(revision *models.CustomRuleRevision) *dto.RuleRevision {
+	return &dto.RuleRevision{
+		RuleID:         revision.RuleID,
+		Revision:       revision.Revision,
+		Action:         revision.Action,
+		Name:           revision.Name,
+		Description:    revision.Description,
+		RuleText:       revision.RuleText,
+		Enabled:        revision.Enabled,
+		Severity:       revision.Severity,
+		TemplateID:     revision.TemplateID,
+		TemplateParams: decodeTemplateParams(revision.TemplateParams),
+		Group:          revision.RuleGroup,
+		Targets:        decodeRuleTargets(revision.Targets),
+		Author:         revision.Author,
+		Reason:         revision.Reason,
+		CreatedAt:      revision.CreatedAt,
+	}
+}
@@ -3,33 +3,99 @@ package services
 import (
 	"context"
 	"fmt"
-	"regexp"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+	"waf-backend/config"
 	"waf-backend/dto"
+	"waf-backend/metrics"
+	"waf-backend/models"
+	"waf-backend/seclang"
+	"waf-backend/tracing"
 	"waf-backend/utils"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
+// RuleService 커스텀 룰의 원본은 DB에 저장하고, ConfigMap/Ingress 설정은 DB 내용으로 다시 생성
 type RuleService struct {
 	log          *logrus.Logger
-	rules        map[string]*dto.CustomRule
+	db           *gorm.DB
+	rules        map[string]*models.CustomRule // DB 캐시
 	mutex        sync.RWMutex
 	k8sClient    kubernetes.Interface
 	configMapName string
 	namespace    string
+	ingressName   string // 전역 룰을 넣는 WAF Ingress
+	managed      []managedSnippet
+	crsSetup      []managedSnippet       // CRS보다 먼저 로드되는 설정 (crs-tuning.conf)
+	engineMode    func() string          // 전역 SecRuleEngine 모드 (없으면 On)
+	userPolicy    *seclang.Policy // 사용자 작성 룰
+	managedPolicy *seclang.Policy // 오탐 예외 등 시스템이 생성한 룰
+	crsRuleIDs    map[int]string  // 디스크의 CRS 룰 ID → 파일 이름
+	requireReview bool            // 사용자 룰은 승인된 변경 세트로만 변경
+	hitRetention  time.Duration   // 룰 매칭 기록 보관 기간
+	hitSummaries  map[string]*dto.RuleHitSummary // 룰 ID → 매칭 요약
+	hitMutex      sync.Mutex
+	hitQueue      chan *models.RuleHit // 모아서 저장할 매칭 기록
+	hitsDropped   int64                // 큐가 가득 차서 버린 매칭 기록 수 (atomic)
+	modsecIndex   map[int]string       // ModSecurity 룰 ID → 커스텀 룰 ID (s.rules가 바뀔 때마다 다시 만듦)
 }
 
-func NewRuleService(log *logrus.Logger) *RuleService {
+// reservedRuleIDRanges 사용자 룰에 쓸 수 없는 ID 범위
+var reservedRuleIDRanges = []struct {
+	dto.RuleIDRange
+	owner string
+}{
+	{dto.RuleIDRange{Start: banRuleIDBase, End: banRuleIDMax}, "auto-ban rules"},
+	{dto.RuleIDRange{Start: crsTuningRuleIDBase, End: crsTuningRuleIDMax}, "CRS tuning"},
+	{dto.RuleIDRange{Start: exclusionRuleIDMin, End: exclusionRuleIDMax}, "false positive exclusions"},
+	{dto.RuleIDRange{Start: engineRuleIDBase, End: engineRuleIDMax}, "engine mode overrides"},
+	{dto.RuleIDRange{Start: bypassRuleIDBase, End: bypassRuleIDMax}, "path bypasses"},
+	{dto.RuleIDRange{Start: 900000, End: 999999}, "the OWASP CRS"},
+	{dto.RuleIDRange{Start: targetGateRuleIDBase, End: targetGateRuleIDMax}, "rule targeting"},
+}
+
+// ManagedSnippetProvider 커스텀 룰과 함께 배포될 관리형 ModSecurity 설정을 생성
+// (RuleService mutex를 잡은 상태에서 호출되므로 RuleService를 다시 호출하면 안 됨)
+type ManagedSnippetProvider func() string
+
+type managedSnippet struct {
+	name     string
+	provider ManagedSnippetProvider
+}
+
+func NewRuleService(cfg *config.Config, log *logrus.Logger, db *gorm.DB) *RuleService {
 	service := &RuleService{
 		log:           log,
-		rules:         make(map[string]*dto.CustomRule),
+		db:            db,
+		rules:         make(map[string]*models.CustomRule),
 		configMapName: utils.GetEnv("MODSECURITY_CONFIGMAP", "modsecurity-config"),
 		namespace:     utils.GetEnv("KUBERNETES_NAMESPACE", "default"),
+		ingressName:   utils.GetEnv("WAF_INGRESS_NAME", "waf-ingress"),
+		userPolicy: &seclang.Policy{
+			Directives:     []string{"SecRule", "SecAction", "SecMarker"},
+			AllowedActions: cfg.Rules.AllowedActions,
+			DeniedActions:  cfg.Rules.DeniedActions,
+		},
+		managedPolicy: &seclang.Policy{
+			Directives:    []string{"SecRule", "SecRuleUpdateTargetById"},
+			DeniedActions: []string{"exec"},
+		},
+		crsRuleIDs:    loadCRSRuleIDs(log, cfg.Rules.CRSRulesDir),
+		requireReview: cfg.Rules.RequireReview,
+		hitRetention:  time.Duration(cfg.Rules.HitRetentionDays) * 24 * time.Hour,
+		hitSummaries:  make(map[string]*dto.RuleHitSummary),
+		hitQueue:      make(chan *models.RuleHit, hitQueueSize),
+		modsecIndex:   make(map[int]string),
 	}
 	
 	// Kubernetes 클라이언트 초기화
@@ -43,6 +109,11 @@ func NewRuleService(log *logrus.Logger) *RuleService {
 	// 기존 룰들을 로드
 	service.loadExistingRules()
 	
+	// 룰별 매칭 요약 로드, 보관 기간이 지난 기록은 주기적으로 삭제
+	service.loadHitSummaries()
+	go service.writeHits()
+	go service.pruneHits()
+	
 	return service
 }
 
@@ -54,6 +125,9 @@ func (s *RuleService) initK8sClient() (kubernetes.Interface, error) {
 		return nil, err
 	}
 	
+	// client-go 호출마다 span 생성 (요청 context의 trace에 연결)
+	config.Wrap(tracing.Transport)
+	
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
@@ -62,34 +136,88 @@ func (s *RuleService) initK8sClient() (kubernetes.Interface, error) {
 	return clientset, nil
 }
 
-func (s *RuleService) CreateRule(userID string, req *dto.CustomRuleRequest) (*dto.CustomRuleResponse, error) {
-	// 룰 유효성 검증
-	if err := s.validateRule(req.RuleText); err != nil {
-		return nil, fmt.Errorf("invalid rule syntax: %w", err)
+// CreateRule 사용자 룰 저장. id가 없는 룰에는 idRange 안에서 ID를 배정
+func (s *RuleService) CreateRule(ctx context.Context, userID string, idRange dto.RuleIDRange, allowedHosts []string, req *dto.CustomRuleRequest) (*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.CreateRule", attribute.String("user_id", userID))
+	defer span.End()
+	
+	if s.requireReview {
+		return nil, ErrReviewRequired
 	}
 	
+	ruleText, err := ruleTextFrom(req.RuleText, req.Rules)
+	if err != nil {
+		return nil, err
+	}
+	built := *req
+	built.RuleText = ruleText
+	
+	return s.createRule(ctx, userID, &built, "", s.userPolicy, &idRange, allowedHosts, templateLink{})
+}
+
+// CreateManagedRule 시스템이 생성한 관리형 룰 저장 (오탐 예외 룰 등)
+// 사용자 작성 룰과 달리 SecRuleUpdateTargetById를 허용하는 관리형 정책으로 검증
+func (s *RuleService) CreateManagedRule(ctx context.Context, userID, source string, req *dto.CustomRuleRequest) (*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.CreateManagedRule", attribute.String("source", source))
+	defer span.End()
+	
+	return s.createRule(ctx, userID, req, source, s.managedPolicy, nil, nil, templateLink{})
+}
+
+// allowedHosts가 nil이 아니면 (테넌트 사용자) 룰은 그 호스트들로 대상이 제한됨
+func (s *RuleService) createRule(ctx context.Context, userID string, req *dto.CustomRuleRequest, source string, policy *seclang.Policy, idRange *dto.RuleIDRange, allowedHosts []string, link templateLink) (*dto.CustomRuleResponse, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	
-	rule := &dto.CustomRule{
+	// 룰 유효성 검증 (ID 배정과 충돌 검사는 다른 요청과 겹치지 않도록 lock 안에서)
+	ruleText, err := s.prepareRuleTextLocked(s.rules, req.RuleText, policy, idRange, "", nil, req.Enabled)
+	if err != nil {
+		if source != "" {
+			return nil, fmt.Errorf("invalid managed rule: %w", err)
+		}
+		return nil, fmt.Errorf("invalid rule syntax: %w", err)
+	}
+	targets, err := s.scopeRuleTargets(nil, allowedHosts)
+	if err != nil {
+		return nil, err
+	}
+	
+	rule := &models.CustomRule{
 		ID:          generateRuleID(),
 		Name:        req.Name,
 		Description: req.Description,
-		RuleText:    req.RuleText,
+		RuleText:    ruleText,
 		Enabled:     req.Enabled,
 		Severity:    req.Severity,
+		Source:      source,
+		Targets:     encodeRuleTargets(targets),
+		Priority:    nextRulePriority(s.rules),
 		UserID:      userID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	link.applyTo(rule, "")
+	if err := checkTargetable(rule, targets); err != nil {
+		return nil, err
+	}
 	
+	// DB 저장에 실패하면 배포하지 않음 (룰과 리비전은 한 트랜잭션으로 저장)
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rule).Error; err != nil {
+			return err
+		}
+		return recordRevision(tx, rule, RuleRevisionCreate, userID, req.Reason)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save rule: %w", err)
+	}
 	s.rules[rule.ID] = rule
+	s.rebuildModsecIndexLocked()
 	
 	// ConfigMap과 Ingress annotation 업데이트 (즉시 적용)
-	if err := s.updateConfigMap(); err != nil {
+	if err := s.updateConfigMap(ctx); err != nil {
 		s.log.WithError(err).Error("Failed to update ConfigMap")
 	}
-	if err := s.updateIngressAnnotation(); err != nil {
+	if err := s.updateIngressAnnotation(ctx); err != nil {
 		s.log.WithError(err).Error("Failed to update Ingress annotation")
 	}
 	
@@ -97,23 +225,22 @@ func (s *RuleService) CreateRule(userID string, req *dto.CustomRuleRequest) (*dt
 		"rule_id": rule.ID,
 		"user_id": userID,
 		"name":    rule.Name,
+		"source":  source,
 	}).Info("Custom rule created")
 	
 	return s.ruleToResponse(rule), nil
 }
 
-func (s *RuleService) GetRules(userID string) ([]*dto.CustomRuleResponse, error) {
-	// ConfigMap에서 최신 상태 동기화
-	if err := s.syncFromConfigMap(); err != nil {
-		s.log.WithError(err).Warn("Failed to sync from ConfigMap, using cached data")
-	}
+func (s *RuleService) GetRules(ctx context.Context, userID string) ([]*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.GetRules", attribute.String("user_id", userID))
+	defer span.End()
 	
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	
 	var result []*dto.CustomRuleResponse
 	
-	for _, rule := range s.rules {
+	for _, rule := range sortedRules(s.rules) {
 		if rule.UserID == userID {
 			result = append(result, s.ruleToResponse(rule))
 		}
@@ -138,15 +265,32 @@ func (s *RuleService) GetRule(userID, ruleID string) (*dto.CustomRuleResponse, e
 	return s.ruleToResponse(rule), nil
 }
 
-func (s *RuleService) UpdateRule(userID, ruleID string, req *dto.CustomRuleRequest) (*dto.CustomRuleResponse, error) {
-	// 룰 유효성 검증
-	if err := s.validateRule(req.RuleText); err != nil {
-		return nil, fmt.Errorf("invalid rule syntax: %w", err)
-	}
+// UpdateRule 룰 수정. 기존에 쓰던 ID는 현재 범위 밖이어도 그대로 둘 수 있음
+func (s *RuleService) UpdateRule(ctx context.Context, userID, ruleID string, idRange dto.RuleIDRange, allowedHosts []string, req *dto.CustomRuleRequest) (*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.UpdateRule",
+		attribute.String("user_id", userID), attribute.String("rule_id", ruleID))
+	defer span.End()
 	
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	
+	rule, err := s.ownedRuleLocked(userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	
+	ruleText, err := ruleTextFrom(req.RuleText, req.Rules)
+	if err != nil {
+		return nil, err
+	}
+	built := *req
+	built.RuleText = ruleText
+	
+	return s.updateRuleLocked(ctx, userID, rule, idRange, allowedHosts, &built, templateLink{})
+}
+
+// ownedRuleLocked userID가 소유한 룰 (없으면 rule not found, 다른 사용자 룰이면 access denied)
+func (s *RuleService) ownedRuleLocked(userID, ruleID string) (*models.CustomRule, error) {
 	rule, exists := s.rules[ruleID]
 	if !exists {
 		return nil, fmt.Errorf("rule not found")
@@ -156,19 +300,65 @@ func (s *RuleService) UpdateRule(userID, ruleID string, req *dto.CustomRuleReque
 		return nil, fmt.Errorf("access denied")
 	}
 	
-	// 룰 업데이트
-	rule.Name = req.Name
-	rule.Description = req.Description
-	rule.RuleText = req.RuleText
-	rule.Enabled = req.Enabled
-	rule.Severity = req.Severity
-	rule.UpdatedAt = time.Now()
+	return rule, nil
+}
+
+// updateRuleLocked 룰 내용을 바꿔 저장하고 배포. link가 비어 있는데 룰 텍스트가 바뀌면 템플릿 연결을 끊음
+// 테넌트 사용자의 대상 없는 룰(이전에 만든 룰)은 수정할 때 테넌트 호스트로 대상이 채워짐
+func (s *RuleService) updateRuleLocked(ctx context.Context, userID string, rule *models.CustomRule, idRange dto.RuleIDRange, allowedHosts []string, req *dto.CustomRuleRequest, link templateLink) (*dto.CustomRuleResponse, error) {
+	// 관리형 룰은 변경 세트 대상이 아니므로 검토 없이 수정 가능
+	if s.requireReview && rule.Source == "" {
+		return nil, ErrReviewRequired
+	}
+	
+	// 룰 유효성 검증 (관리형 룰은 관리형 룰 기준으로 검증)
+	var ruleText string
+	var err error
+	if rule.Source != "" {
+		if ruleText, err = s.prepareRuleTextLocked(s.rules, req.RuleText, s.managedPolicy, nil, rule.ID, s.ruleIDsOf(rule.RuleText), req.Enabled); err != nil {
+			return nil, fmt.Errorf("invalid managed rule: %w", err)
+		}
+	} else if ruleText, err = s.prepareRuleTextLocked(s.rules, req.RuleText, s.userPolicy, &idRange, rule.ID, s.ruleIDsOf(rule.RuleText), req.Enabled); err != nil {
+		return nil, fmt.Errorf("invalid rule syntax: %w", err)
+	}
+	
+	// 룰 업데이트 (DB 저장이 성공한 뒤에 캐시 반영)
+	updated := *rule
+	updated.Name = req.Name
+	updated.Description = req.Description
+	updated.RuleText = ruleText
+	updated.Enabled = req.Enabled
+	updated.Severity = req.Severity
+	updated.UpdatedAt = time.Now()
+	link.applyTo(&updated, rule.RuleText)
+	if rule.Source == "" {
+		targets, err := s.scopeRuleTargets(decodeRuleTargets(rule.Targets), allowedHosts)
+		if err != nil {
+			return nil, err
+		}
+		updated.Targets = encodeRuleTargets(targets)
+	}
+	if err := checkTargetable(&updated, decodeRuleTargets(updated.Targets)); err != nil {
+		return nil, err
+	}
+	
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&updated).Error; err != nil {
+			return err
+		}
+		return recordRevision(tx, &updated, revisionActionFor(rule, &updated), userID, req.Reason)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save rule: %w", err)
+	}
+	rule = &updated
+	s.rules[rule.ID] = rule
+	s.rebuildModsecIndexLocked()
 	
 	// ConfigMap과 Ingress annotation 업데이트 (즉시 적용)
-	if err := s.updateConfigMap(); err != nil {
+	if err := s.updateConfigMap(ctx); err != nil {
 		s.log.WithError(err).Error("Failed to update ConfigMap")
 	}
-	if err := s.updateIngressAnnotation(); err != nil {
+	if err := s.updateIngressAnnotation(ctx); err != nil {
 		s.log.WithError(err).Error("Failed to update Ingress annotation")
 	}
 	
@@ -181,11 +371,10 @@ func (s *RuleService) UpdateRule(userID, ruleID string, req *dto.CustomRuleReque
 	return s.ruleToResponse(rule), nil
 }
 
-func (s *RuleService) DeleteRule(userID, ruleID string) error {
-	// ConfigMap에서 최신 상태 동기화
-	if err := s.syncFromConfigMap(); err != nil {
-		s.log.WithError(err).Warn("Failed to sync from ConfigMap before delete")
-	}
+func (s *RuleService) DeleteRule(ctx context.Context, userID, ruleID, reason string) error {
+	ctx, span := tracing.Start(ctx, "RuleService.DeleteRule",
+		attribute.String("user_id", userID), attribute.String("rule_id", ruleID))
+	defer span.End()
 	
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -199,13 +388,27 @@ func (s *RuleService) DeleteRule(userID, ruleID string) error {
 		return fmt.Errorf("access denied")
 	}
 	
+	if s.requireReview && rule.Source == "" {
+		return ErrReviewRequired
+	}
+	
+	// 삭제 직전 내용을 리비전으로 남겨서 복원할 수 있게 함
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.CustomRule{}, "id = ?", ruleID).Error; err != nil {
+			return err
+		}
+		return recordRevision(tx, rule, RuleRevisionDelete, userID, reason)
+	}); err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
 	delete(s.rules, ruleID)
+	s.rebuildModsecIndexLocked()
 	
 	// ConfigMap과 Ingress annotation 업데이트 (즉시 적용)
-	if err := s.updateConfigMap(); err != nil {
+	if err := s.updateConfigMap(ctx); err != nil {
 		s.log.WithError(err).Error("Failed to update ConfigMap")
 	}
-	if err := s.updateIngressAnnotation(); err != nil {
+	if err := s.updateIngressAnnotation(ctx); err != nil {
 		s.log.WithError(err).Error("Failed to update Ingress annotation")
 	}
 	
@@ -218,46 +421,416 @@ func (s *RuleService) DeleteRule(userID, ruleID string) error {
 	return nil
 }
 
-func (s *RuleService) validateRule(ruleText string) error {
-	// 기본적인 ModSecurity 룰 문법 검증
-	if len(ruleText) == 0 {
-		return fmt.Errorf("rule text cannot be empty")
+// AllocateRuleID [min, max] 범위에서 기존 룰과 CRS가 사용하지 않는 ModSecurity 룰 ID 반환
+func (s *RuleService) AllocateRuleID(min, max int) (int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	
+	used := s.ruleIDOwnersLocked(s.rules, "", false)
+	for id := min; id <= max; id++ {
+		if _, taken := used[id]; !taken {
+			return id, nil
+		}
+	}
+	
+	return 0, fmt.Errorf("no free rule ID in range %d-%d", min, max)
+}
+
+// prepareRuleTextLocked id가 없는 룰에 ID를 배정한 뒤 문법/정책과 ID 범위/충돌을 검사한 최종 텍스트 반환
+// idRange가 nil이면 (관리형 룰) 자동 배정과 범위 검사를 하지 않음
+// excludeID는 수정 중인 룰 (충돌 검사에서 제외), keptIDs는 그 룰이 이미 쓰던 ID (범위 밖이어도 허용)
+// rules는 충돌 검사 기준이 되는 룰 집합 (보통 s.rules, 변경 세트 검증 시에는 적용 결과)
+func (s *RuleService) prepareRuleTextLocked(rules map[string]*models.CustomRule, ruleText string, policy *seclang.Policy, idRange *dto.RuleIDRange, excludeID string, keptIDs []int, enabled bool) (string, error) {
+	if len(ruleText) > maxRuleTextBytes {
+		return "", fmt.Errorf("rule text is too large: %d bytes (max %d)", len(ruleText), maxRuleTextBytes)
+	}
+	// 배포 파일의 룰 블록 마커와 같은 줄은 다른 룰(다른 소유자)을 위조할 수 있으므로 거부
+	for i, line := range strings.Split(ruleText, "\n") {
+		if isRuleMarkerLine(line) {
+			return "", fmt.Errorf("line %d: rule text must not contain %q or %q lines", i+1, strings.TrimSpace(ruleMarkerPrefix), ruleMarkerEnd)
+		}
+	}
+	kept := make(map[int]bool)
+	for _, id := range keptIDs {
+		kept[id] = true
+	}
+	
+	if idRange != nil {
+		used := s.ruleIDOwnersLocked(rules, excludeID, false)
+		next := idRange.Start
+		assigned, err := seclang.AssignIDs(ruleText, func() (int, error) {
+			for ; next <= idRange.End; next++ {
+				if _, taken := used[next]; !taken {
+					next++
+					return next - 1, nil
+				}
+			}
+			return 0, fmt.Errorf("no free rule ID left in range %d-%d", idRange.Start, idRange.End)
+		})
+		if err != nil {
+			return "", err
+		}
+		ruleText = assigned
+	}
+	
+	directives, err := s.parseRule(ruleText, policy)
+	if err != nil {
+		return "", err
+	}
+	
+	// 중복 ID는 NGINX reload 전체를 실패시키므로 활성화된 룰과 CRS 전체를 기준으로 검사
+	enabledOwners := s.ruleIDOwnersLocked(rules, excludeID, true)
+	var errs seclang.ErrorList
+	for _, directive := range directives {
+		id := directive.ID()
+		if id == 0 {
+			continue
+		}
+		pos := directive.Action("id").Position
+		
+		if idRange != nil && !kept[id] {
+			if owner := reservedRuleIDOwner(id); owner != "" {
+				errs = append(errs, &seclang.Error{Position: pos, Message: fmt.Sprintf("rule id %d is reserved for %s", id, owner)})
+				continue
+			}
+			if id < idRange.Start || id > idRange.End {
+				errs = append(errs, &seclang.Error{Position: pos, Message: fmt.Sprintf("rule id %d is outside your assigned range %d-%d", id, idRange.Start, idRange.End)})
+				continue
+			}
+		}
+		if owner, taken := enabledOwners[id]; taken && enabled {
+			errs = append(errs, &seclang.Error{Position: pos, Message: fmt.Sprintf("rule id %d is already used by %s", id, owner)})
+		}
+	}
+	if len(errs) > 0 {
+		return "", errs
+	}
+	
+	return ruleText, nil
+}
+
+// ruleIDOwnersLocked 사용 중인 룰 ID → 사용처 (excludeRuleID 룰은 제외, enabledOnly면 비활성 룰 제외)
+func (s *RuleService) ruleIDOwnersLocked(rules map[string]*models.CustomRule, excludeRuleID string, enabledOnly bool) map[int]string {
+	owners := make(map[int]string, len(s.crsRuleIDs)+len(rules))
+	for id, file := range s.crsRuleIDs {
+		owners[id] = "the OWASP CRS (" + file + ")"
+	}
+	for _, rule := range rules {
+		if rule.ID == excludeRuleID || (enabledOnly && !rule.Enabled) {
+			continue
+		}
+		for _, id := range s.ruleIDsOf(rule.RuleText) {
+			// 다른 사용자의 룰 이름은 노출하지 않음
+			owners[id] = "another custom rule"
+		}
+	}
+	return owners
+}
+
+// HasRulesInIDRange 저장된 커스텀 룰 중 ID 범위 안의 룰이 있는지 (테넌트 범위 재사용 방지)
+func (s *RuleService) HasRulesInIDRange(idRange dto.RuleIDRange) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	
+	for _, rule := range s.rules {
+		for _, id := range s.ruleIDsOf(rule.RuleText) {
+			if id >= idRange.Start && id <= idRange.End {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ruleIDsOf 룰 텍스트의 시작 룰 ID들 (파싱 오류는 기록하고 읽을 수 있는 만큼 반환)
+func (s *RuleService) ruleIDsOf(ruleText string) []int {
+	ids, err := parseRuleIDs(ruleText)
+	if err != nil {
+		s.log.WithError(err).Warn("Failed to parse rule text, rule IDs may be incomplete")
+	}
+	return ids
+}
+
+// parseRuleIDs 룰 텍스트의 시작 룰 ID들과 파싱 오류 (오류가 있어도 읽을 수 있는 만큼 반환)
+func parseRuleIDs(ruleText string) ([]int, error) {
+	directives, err := seclang.Parse(ruleText)
+	var ids []int
+	for _, directive := range directives {
+		if id := directive.ID(); id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids, err
+}
+
+func reservedRuleIDOwner(id int) string {
+	for _, reserved := range reservedRuleIDRanges {
+		if id >= reserved.Start && id <= reserved.End {
+			return reserved.owner
+		}
+	}
+	return ""
+}
+
+// loadCRSRuleIDs 디스크의 CRS 룰 파일에서 ID 수집 (디렉토리가 없으면 빈 목록)
+func loadCRSRuleIDs(log *logrus.Logger, dir string) map[int]string {
+	ids := make(map[int]string)
+	files, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+	if err != nil || len(files) == 0 {
+		log.WithField("dir", dir).Info("No CRS rule files found, only reserved CRS ID range will be checked")
+		return ids
+	}
+	
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			log.WithError(err).WithField("file", file).Warn("Failed to read CRS rule file")
+			continue
+		}
+		fileIDs, err := parseRuleIDs(string(content))
+		if err != nil {
+			log.WithError(err).WithField("file", file).Warn("Failed to parse CRS rule file, its rule IDs may be incomplete")
+		}
+		for _, id := range fileIDs {
+			ids[id] = filepath.Base(file)
+		}
+	}
+	
+	log.WithFields(logrus.Fields{
+		"dir":   dir,
+		"files": len(files),
+		"ids":   len(ids),
+	}).Info("Loaded CRS rule IDs")
+	return ids
+}
+
+// crsRuleInstalled 설치된 CRS에 있는 룰인지 (CRS 룰 디렉토리를 읽지 못했으면 확인할 수 없으므로 true)
+func (s *RuleService) crsRuleInstalled(id int) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	
+	return len(s.crsRuleIDs) == 0 || s.crsRuleIDs[id] != ""
+}
+
+// RegisterManagedSnippet 배포 설정에 포함될 관리형 snippet 제공자 등록 (등록 순서대로 렌더링)
+func (s *RuleService) RegisterManagedSnippet(name string, provider ManagedSnippetProvider) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	s.managed = append(s.managed, managedSnippet{name: name, provider: provider})
+}
+
+// RegisterCRSSetup CRS 룰보다 먼저 로드되어야 하는 설정(paranoia level 등 tx 변수, 엔진 모드 전환) 제공자 등록
+func (s *RuleService) RegisterCRSSetup(name string, provider ManagedSnippetProvider) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	s.crsSetup = append(s.crsSetup, managedSnippet{name: name, provider: provider})
+}
+
+// renderCRSSetup ConfigMap의 crs-tuning.conf 내용
+func (s *RuleService) renderCRSSetup() string {
+	var content string
+	for _, snippet := range s.crsSetup {
+		if body := snippet.provider(); body != "" {
+			content += fmt.Sprintf("# Managed: %s\n%s\n", snippet.name, body)
+		}
+	}
+	return content
+}
+
+// RegisterEngineMode 배포 설정의 SecRuleEngine 값 제공자 등록
+func (s *RuleService) RegisterEngineMode(provider func() string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	s.engineMode = provider
+}
+
+func (s *RuleService) renderEngineDirective() string {
+	mode := "On"
+	if s.engineMode != nil {
+		mode = s.engineMode()
+	}
+	return "SecRuleEngine " + mode
+}
+
+// Redeploy 현재 룰과 관리형 snippet으로 ConfigMap과 Ingress annotation을 다시 배포
+func (s *RuleService) Redeploy(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "RuleService.Redeploy")
+	defer span.End()
+	
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	if err := s.updateConfigMap(ctx); err != nil {
+		return fmt.Errorf("failed to update ConfigMap: %w", err)
+	}
+	if err := s.updateIngressAnnotation(ctx); err != nil {
+		return fmt.Errorf("failed to update Ingress annotation: %w", err)
+	}
+	
+	return nil
+}
+
+// Reconcile 배포된 ConfigMap이 DB 기준 렌더링 결과와 다르면 다시 배포 (시작 시 호출)
+// 같으면 NGINX 재시작을 피하기 위해 아무것도 하지 않음
+func (s *RuleService) Reconcile(ctx context.Context) (err error) {
+	if s.k8sClient == nil {
+		s.log.Debug("No Kubernetes client available, skipping reconcile")
+		return nil
 	}
 	
-	// SecRule로 시작하는지 확인
-	if !regexp.MustCompile(`^SecRule\s+`).MatchString(ruleText) {
-		return fmt.Errorf("rule must start with 'SecRule'")
+	ctx, span := tracing.Start(ctx, "RuleService.Reconcile")
+	defer func() { tracing.End(span, err) }()
+	
+	configMap, err := s.k8sClient.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.configMapName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get ConfigMap: %w", err)
 	}
 	
-	// 기본적인 문법 구조 검증
-	if !regexp.MustCompile(`SecRule\s+\S+\s+"[^"]*"\s+"[^"]*"`).MatchString(ruleText) {
-		return fmt.Errorf("invalid ModSecurity rule syntax")
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	deployed := configMap.Data["custom-rules.conf"]
+	crsSetupCurrent := configMap.Data[crsTuningConfKey] == s.renderCRSSetup()
+	if deployed == s.renderCustomRulesConf(s.rules) && crsSetupCurrent {
+		s.log.Info("Deployed rules match database, skipping redeploy")
+		return nil
 	}
 	
-	// 위험한 키워드 검증 (보안을 위해)
-	dangerousKeywords := []string{"exec", "system", "eval", "cmd"}
-	for _, keyword := range dangerousKeywords {
-		if regexp.MustCompile(`(?i)`+keyword).MatchString(ruleText) {
-			return fmt.Errorf("dangerous keyword '%s' not allowed", keyword)
+	// ConfigMap에만 있는 룰은 마커의 메타데이터로 DB에 복구 (DB를 잃고 재시작한 경우)
+	recovered, err := s.recoverDeployedRulesLocked(ctx, deployed)
+	if err != nil {
+		s.log.WithError(err).Warn("Failed to recover rules from ConfigMap")
+	}
+	if recovered > 0 {
+		s.log.WithField("recovered", recovered).Warn("Recovered rules missing from the database")
+		if deployed == s.renderCustomRulesConf(s.rules) && crsSetupCurrent {
+			return nil
 		}
 	}
 	
+	// 복구할 수 없는 룰만 있고 DB가 비어 있으면 (마커 이전 형식의 배포) 덮어쓰지 않음
+	if len(s.rules) == 0 && deployed != "" {
+		s.log.WithField("content_length", len(deployed)).Warn("ConfigMap has rules that are not in the database, leaving it untouched")
+		return nil
+	}
+	
+	s.log.WithField("rules_count", len(s.rules)).Info("Deployed rules differ from database, redeploying")
+	if err := s.updateConfigMap(ctx); err != nil {
+		return fmt.Errorf("failed to update ConfigMap: %w", err)
+	}
+	if err := s.updateIngressAnnotation(ctx); err != nil {
+		return fmt.Errorf("failed to update Ingress annotation: %w", err)
+	}
+	
 	return nil
 }
 
+// sortedRules 배포 순서(priority, 생성 순서, ID)대로 정렬된 룰 목록 (렌더링 결과가 항상 같도록)
+func sortedRules(ruleSet map[string]*models.CustomRule) []*models.CustomRule {
+	rules := make([]*models.CustomRule, 0, len(ruleSet))
+	for _, rule := range ruleSet {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		if !rules[i].CreatedAt.Equal(rules[j].CreatedAt) {
+			return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+		}
+		return rules[i].ID < rules[j].ID
+	})
+	return rules
+}
+
+// renderCustomRulesConf ConfigMap의 custom-rules.conf 내용 (관리형 snippet + 룰)
+// 룰마다 메타데이터 마커를 남기고 비활성 룰은 주석 처리 (ConfigMap만으로 룰 전체를 복구할 수 있도록)
+func (s *RuleService) renderCustomRulesConf(rules map[string]*models.CustomRule) string {
+	sorted := sortedRules(rules)
+	content := s.renderManagedSnippets() + renderTargetGates(sorted, "")
+	for _, rule := range sorted {
+		content += renderRuleBlock(rule)
+	}
+	return content
+}
+
+// renderManagedSnippets 등록된 관리형 snippet들을 하나의 설정 블록으로 렌더링
+func (s *RuleService) renderManagedSnippets() string {
+	var content string
+	for _, snippet := range s.managed {
+		if body := snippet.provider(); body != "" {
+			content += fmt.Sprintf("# Managed: %s\n%s\n\n", snippet.name, body)
+		}
+	}
+	return content
+}
+
+// ValidateRule 사용자 룰 정책으로 파싱/검증하고 파싱 결과 반환 (저장하지 않음)
+func (s *RuleService) ValidateRule(ruleText string) ([]*seclang.Directive, error) {
+	return s.parseRule(ruleText, s.userPolicy)
+}
 
+// TestRule 룰을 샘플 요청에 대해 평가 (id가 없어도 되며 저장하거나 클러스터에 반영하지 않음)
+func (s *RuleService) TestRule(req *dto.RuleTestRequest) (*seclang.Result, error) {
+	if strings.TrimSpace(req.RuleText) == "" {
+		return nil, fmt.Errorf("rule text cannot be empty")
+	}
+	
+	directives, err := seclang.ParseDraft(req.RuleText)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userPolicy.Check(directives); err != nil {
+		return nil, err
+	}
+	
+	sample := req.Request
+	return seclang.Evaluate(directives, &seclang.Request{
+		Method:     sample.Method,
+		URI:        sample.URI,
+		Protocol:   sample.Protocol,
+		Headers:    sample.Headers,
+		Body:       sample.Body,
+		RemoteAddr: sample.RemoteAddr,
+	}), nil
+}
 
-func (s *RuleService) updateIngressAnnotation() error {
+// parseRule SecLang 파서로 문법/의미를 검증한 뒤 정책 위반 여부 확인
+// 오류는 위치가 포함된 seclang.ErrorList
+func (s *RuleService) parseRule(ruleText string, policy *seclang.Policy) ([]*seclang.Directive, error) {
+	if strings.TrimSpace(ruleText) == "" {
+		return nil, fmt.Errorf("rule text cannot be empty")
+	}
+	
+	directives, err := seclang.Parse(ruleText)
+	if err != nil {
+		return directives, err
+	}
+	if len(directives) == 0 {
+		return nil, fmt.Errorf("rule text contains no directives")
+	}
+	
+	return directives, policy.Check(directives)
+}
+
+func (s *RuleService) updateIngressAnnotation(ctx context.Context) (err error) {
 	if s.k8sClient == nil {
 		s.log.Debug("No Kubernetes client available, skipping Ingress annotation update")
 		return nil
 	}
+	ctx, span := tracing.Start(ctx, "RuleService.updateIngressAnnotation")
+	defer func(start time.Time) {
+		metrics.RecordRuleDeploy("ingress", time.Since(start), err)
+		tracing.End(span, err)
+	}(time.Now())
 
-	ctx := context.Background()
 	ingressClient := s.k8sClient.NetworkingV1().Ingresses(s.namespace)
 	
 	// Ingress 가져오기
-	ingress, err := ingressClient.Get(ctx, "waf-ingress", metav1.GetOptions{})
+	ingress, err := ingressClient.Get(ctx, s.ingressName, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to get Ingress: %w", err)
 	}
@@ -267,29 +840,44 @@ func (s *RuleService) updateIngressAnnotation() error {
 	}
 	
 	// 기본 ModSecurity 설정
-	baseConfig := `SecRuleEngine On
+	baseConfig := s.renderEngineDirective() + `
 SecAuditEngine On  
 SecAuditLogParts ABIJDEFHZ
 SecAuditLogType Serial
-SecAuditLog /var/log/nginx/modsec_audit.log
-
-# Allow OAuth callback
-SecRule REQUEST_URI "^/auth/callback" "id:9999,phase:1,pass,nolog,ctl:ruleEngine=Off"
-
-# Allow API requests (including DELETE)
-SecRule REQUEST_URI "^/api/" "id:9998,phase:1,pass,nolog,ctl:ruleEngine=Off"`
+SecAuditLog /var/log/nginx/modsec_audit.log`
 	
-	// 활성화된 커스텀 룰들 추가
+	// 호스트별 엔진 모드, 경로 예외 등 커스텀 룰보다 먼저 실행되어야 하는 설정
 	var customRulesSnippet string
-	for _, rule := range s.rules {
-		if rule.Enabled {
+	if setup := s.renderCRSSetup(); setup != "" {
+		customRulesSnippet += "\n\n" + strings.TrimRight(setup, "\n")
+	}
+	
+	// 관리형 snippet (자동 차단 등) 추가
+	if managed := s.renderManagedSnippets(); managed != "" {
+		customRulesSnippet += "\n\n" + managed
+	}
+	
+	// 호스트/경로 대상 게이트 추가
+	rules := sortedRules(s.rules)
+	if gates := renderTargetGates(rules, ""); gates != "" {
+		customRulesSnippet += "\n\n" + strings.TrimRight(gates, "\n")
+	}
+	
+	// 활성화된 커스텀 룰들 추가 (다른 Ingress를 지정한 룰은 그 Ingress에만 배포)
+	for _, rule := range rules {
+		if rule.Enabled && !ingressScoped(decodeRuleTargets(rule.Targets)) {
 			customRulesSnippet += fmt.Sprintf("\n\n# %s\n# %s\n%s", rule.Name, rule.Description, rule.RuleText)
 		}
 	}
 	
+	// 이 Ingress를 지정한 룰
+	if block := renderTargetedBlock(rules, s.namespace+"/"+s.ingressName); block != "" {
+		customRulesSnippet += "\n\n" + block
+	}
+	
 	// ModSecurity snippet 업데이트
 	fullConfig := baseConfig + customRulesSnippet
-	ingress.Annotations["nginx.ingress.kubernetes.io/modsecurity-snippet"] = fullConfig
+	ingress.Annotations[modsecuritySnippetAnnotation] = fullConfig
 	
 	s.log.WithField("config_length", len(fullConfig)).Info("Updating Ingress ModSecurity annotation")
 	
@@ -301,10 +889,15 @@ SecRule REQUEST_URI "^/api/" "id:9998,phase:1,pass,nolog,ctl:ruleEngine=Off"`
 	
 	s.log.Info("Ingress ModSecurity annotation updated successfully")
 	
+	// 룰이 지정한 다른 Ingress 갱신
+	if err := s.updateTargetedIngresses(ctx); err != nil {
+		s.log.WithError(err).Warn("Failed to update targeted Ingresses")
+	}
+	
 	// Force NGINX Ingress Controller reload by restarting the pod
 	// This is required because ModSecurity rules don't always apply immediately
 	s.log.Info("Forcing NGINX Ingress Controller reload...")
-	if err := s.forceNginxReload(); err != nil {
+	if err := s.forceNginxReload(ctx); err != nil {
 		s.log.WithError(err).Warn("Failed to force NGINX reload, rules may not apply immediately")
 	} else {
 		s.log.Info("NGINX Ingress Controller reload initiated successfully")
@@ -313,12 +906,13 @@ SecRule REQUEST_URI "^/api/" "id:9998,phase:1,pass,nolog,ctl:ruleEngine=Off"`
 	return nil
 }
 
-func (s *RuleService) forceNginxReload() error {
+func (s *RuleService) forceNginxReload(ctx context.Context) (err error) {
 	if s.k8sClient == nil {
 		return fmt.Errorf("Kubernetes client not available")
 	}
 	
-	ctx := context.Background()
+	ctx, span := tracing.Start(ctx, "RuleService.forceNginxReload")
+	defer func() { tracing.End(span, err) }()
 	podsClient := s.k8sClient.CoreV1().Pods("ingress-nginx")
 	
 	// Delete NGINX Ingress Controller pods to force reload
@@ -344,24 +938,38 @@ func (s *RuleService) forceNginxReload() error {
 func (s *RuleService) loadExistingRules() {
 	s.log.Info("Loading existing custom rules")
 	
-	// ConfigMap에서 기존 룰들을 로드
-	if err := s.syncFromConfigMap(); err != nil {
-		s.log.WithError(err).Warn("Failed to load rules from ConfigMap, starting with empty rules")
+	// DB에서 기존 룰들을 로드
+	var rules []*models.CustomRule
+	if err := s.db.Find(&rules).Error; err != nil {
+		s.log.WithError(err).Error("Failed to load rules from database, starting with empty rules")
+		return
+	}
+	for _, rule := range rules {
+		s.rules[rule.ID] = rule
 	}
+	s.rebuildModsecIndexLocked()
 	
 	s.log.WithField("count", len(s.rules)).Info("Loaded existing rules")
 }
 
-func (s *RuleService) ruleToResponse(rule *dto.CustomRule) *dto.CustomRuleResponse {
+func (s *RuleService) ruleToResponse(rule *models.CustomRule) *dto.CustomRuleResponse {
 	return &dto.CustomRuleResponse{
-		ID:          rule.ID,
-		Name:        rule.Name,
-		Description: rule.Description,
-		RuleText:    rule.RuleText,
-		Enabled:     rule.Enabled,
-		Severity:    rule.Severity,
-		CreatedAt:   rule.CreatedAt,
-		UpdatedAt:   rule.UpdatedAt,
+		ID:             rule.ID,
+		Name:           rule.Name,
+		Description:    rule.Description,
+		RuleText:       rule.RuleText,
+		Enabled:        rule.Enabled,
+		Severity:       rule.Severity,
+		Source:         rule.Source,
+		TemplateID:     rule.TemplateID,
+		TemplateParams: decodeTemplateParams(rule.TemplateParams),
+		Group:          rule.RuleGroup,
+		Targets:        decodeRuleTargets(rule.Targets),
+		Priority:       rule.Priority,
+		Phase:          rulePhase(rule.RuleText),
+		Hits:           s.hitSummary(rule.ID),
+		CreatedAt:      rule.CreatedAt,
+		UpdatedAt:      rule.UpdatedAt,
 	}
 }
 
@@ -369,13 +977,17 @@ func generateRuleID() string {
 	return fmt.Sprintf("rule_%d", time.Now().UnixNano())
 }
 
-func (s *RuleService) updateConfigMap() error {
+func (s *RuleService) updateConfigMap(ctx context.Context) (err error) {
 	if s.k8sClient == nil {
 		s.log.Debug("No Kubernetes client available, skipping ConfigMap update")
 		return nil
 	}
+	ctx, span := tracing.Start(ctx, "RuleService.updateConfigMap")
+	defer func(start time.Time) {
+		metrics.RecordRuleDeploy("configmap", time.Since(start), err)
+		tracing.End(span, err)
+	}(time.Now())
 
-	ctx := context.Background()
 	configMapClient := s.k8sClient.CoreV1().ConfigMaps(s.namespace)
 	
 	// ConfigMap 가져오기
@@ -388,15 +1000,9 @@ func (s *RuleService) updateConfigMap() error {
 		configMap.Data = make(map[string]string)
 	}
 	
-	// 활성화된 룰들을 custom-rules.conf에 추가
-	var customRulesContent string
-	for _, rule := range s.rules {
-		if rule.Enabled {
-			customRulesContent += fmt.Sprintf("# %s\n# %s\n%s\n\n", rule.Name, rule.Description, rule.RuleText)
-		}
-	}
-	
-	configMap.Data["custom-rules.conf"] = customRulesContent
+	// 관리형 snippet과 활성화된 룰들을 custom-rules.conf에 추가
+	configMap.Data["custom-rules.conf"] = s.renderCustomRulesConf(s.rules)
+	configMap.Data[crsTuningConfKey] = s.renderCRSSetup()
 	
 	s.log.WithField("rules_count", len(s.rules)).Info("Updating ConfigMap with custom rules")
 	
@@ -410,7 +1016,7 @@ func (s *RuleService) updateConfigMap() error {
 	
 	// ConfigMap 업데이트 후 NGINX Ingress Controller ConfigMap의 modsecurity-snippet도 업데이트
 	s.log.Info("Updating NGINX Ingress Controller ConfigMap...")
-	if err := s.updateNginxConfigMapSnippet(); err != nil {
+	if err := s.updateNginxConfigMapSnippet(ctx); err != nil {
 		s.log.WithError(err).Warn("Failed to update NGINX ConfigMap snippet")
 		return err
 	}
@@ -418,12 +1024,13 @@ func (s *RuleService) updateConfigMap() error {
 	return nil
 }
 
-func (s *RuleService) updateNginxConfigMapSnippet() error {
+func (s *RuleService) updateNginxConfigMapSnippet(ctx context.Context) (err error) {
 	if s.k8sClient == nil {
 		return fmt.Errorf("Kubernetes client not available")
 	}
-
-	ctx := context.Background()
+	
+	ctx, span := tracing.Start(ctx, "RuleService.updateNginxConfigMapSnippet")
+	defer func() { tracing.End(span, err) }()
 	configMapClient := s.k8sClient.CoreV1().ConfigMaps("ingress-nginx")
 	
 	// NGINX ConfigMap 가져오기
@@ -437,11 +1044,14 @@ func (s *RuleService) updateNginxConfigMapSnippet() error {
 	}
 	
 	// 기본 ModSecurity 설정
-	baseConfig := `SecRuleEngine On
+	baseConfig := s.renderEngineDirective() + `
 SecAuditEngine On
 SecAuditLogParts ABIJDEFHZ
 SecAuditLogType Serial
 SecAuditLog /var/log/nginx/modsec_audit.log
+
+# CRS tuning (paranoia level, anomaly thresholds) from ConfigMap, must load before CRS
+Include /etc/nginx/modsecurity/custom-rules/`+crsTuningConfKey+`
 Include /etc/nginx/owasp-modsecurity-crs/nginx-modsecurity.conf
 
 # Custom rules from ConfigMap  
@@ -463,7 +1073,7 @@ Include /etc/nginx/modsecurity/custom-rules/custom-rules.conf`
 	
 	// NGINX Ingress Controller 재시작
 	s.log.Info("Restarting NGINX Ingress Controller...")
-	if err := s.restartNginxIngressController(); err != nil {
+	if err := s.restartNginxIngressController(ctx); err != nil {
 		s.log.WithError(err).Warn("Failed to restart NGINX Ingress Controller")
 		return err
 	}
@@ -471,12 +1081,13 @@ Include /etc/nginx/modsecurity/custom-rules/custom-rules.conf`
 	return nil
 }
 
-func (s *RuleService) restartNginxIngressController() error {
+func (s *RuleService) restartNginxIngressController(ctx context.Context) (err error) {
 	if s.k8sClient == nil {
 		return fmt.Errorf("Kubernetes client not available")
 	}
 	
-	ctx := context.Background()
+	ctx, span := tracing.Start(ctx, "RuleService.restartNginxIngressController")
+	defer func() { tracing.End(span, err) }()
 	deploymentClient := s.k8sClient.AppsV1().Deployments("ingress-nginx")
 	
 	// NGINX Ingress Controller 재시작
@@ -499,46 +1110,3 @@ func (s *RuleService) restartNginxIngressController() error {
 	s.log.Info("NGINX Ingress Controller restart initiated")
 	return nil
 }
-
-// syncFromConfigMap ConfigMap에서 룰을 읽어와서 메모리 상태 동기화
-func (s *RuleService) syncFromConfigMap() error {
-	if s.k8sClient == nil {
-		s.log.Debug("No Kubernetes client available, skipping ConfigMap sync")
-		return nil
-	}
-
-	ctx := context.Background()
-	configMapClient := s.k8sClient.CoreV1().ConfigMaps(s.namespace)
-	
-	// ConfigMap 가져오기
-	configMap, err := configMapClient.Get(ctx, s.configMapName, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get ConfigMap: %w", err)
-	}
-
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	// ConfigMap에서 custom-rules.conf 확인
-	customRulesContent, exists := configMap.Data["custom-rules.conf"]
-	if !exists || customRulesContent == "" {
-		// ConfigMap이 비어있으면 메모리도 비움
-		if len(s.rules) > 0 {
-			s.log.Info("ConfigMap is empty, clearing memory rules")
-			s.rules = make(map[string]*dto.CustomRule)
-		}
-		return nil
-	}
-
-	// ConfigMap에 내용이 있지만 메모리가 비어있는 경우
-	// (예: 다른 인스턴스에서 룰을 생성했거나, 직접 ConfigMap을 수정한 경우)
-	if len(s.rules) == 0 {
-		s.log.WithField("content_length", len(customRulesContent)).Warn("ConfigMap has content but memory is empty - this may indicate external changes")
-		// 현재로서는 ConfigMap의 ModSecurity 룰을 파싱해서 객체로 복원하는 것은 복잡하므로
-		// 경고만 남기고 메모리 상태를 유지
-		return nil
-	}
-
-	s.log.WithField("content_length", len(customRulesContent)).Debug("ConfigMap sync completed")
-	return nil
-}
\ No newline at end of file
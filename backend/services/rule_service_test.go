@@ -0,0 +1,464 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/seclang"
+
+	"gorm.io/gorm"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestRuleService DB에서 룰을 로드한 RuleService (k8sClient가 nil이면 배포 생략)
+func newTestRuleService(t *testing.T, db *gorm.DB, k8sClient kubernetes.Interface) *RuleService {
+	t.Helper()
+	if err := db.AutoMigrate(&models.CustomRule{}, &models.CustomRuleRevision{}, &models.RuleChangeset{}, &models.RuleChange{}, &models.RuleHit{}); err != nil {
+		t.Fatalf("failed to migrate custom rules: %v", err)
+	}
+	s := &RuleService{
+		log:           newTestLogger(),
+		db:            db,
+		rules:         make(map[string]*models.CustomRule),
+		k8sClient:     k8sClient,
+		configMapName: "modsecurity-config",
+		ingressName:   "waf-ingress",
+		namespace:     "default",
+		hitRetention:  30 * 24 * time.Hour,
+		hitSummaries:  make(map[string]*dto.RuleHitSummary),
+		hitQueue:      make(chan *models.RuleHit, hitQueueSize),
+		userPolicy: &seclang.Policy{
+			Directives:    []string{"SecRule", "SecAction", "SecMarker"},
+			DeniedActions: []string{"exec"},
+		},
+		managedPolicy: &seclang.Policy{
+			Directives:    []string{"SecRule", "SecRuleUpdateTargetById"},
+			DeniedActions: []string{"exec"},
+		},
+	}
+	s.loadExistingRules()
+	s.loadHitSummaries()
+	return s
+}
+
+// newFakeCluster 배포 대상 ConfigMap/Ingress와 NGINX Ingress Controller가 있는 가짜 클러스터
+func newFakeCluster(deployedRules string) *fake.Clientset {
+	return fake.NewSimpleClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "modsecurity-config", Namespace: "default"}, Data: map[string]string{"custom-rules.conf": deployedRules}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "ingress-nginx-controller", Namespace: "ingress-nginx"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "ingress-nginx-controller", Namespace: "ingress-nginx"}},
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "waf-ingress", Namespace: "default"}},
+	)
+}
+
+func deployedCustomRules(t *testing.T, client kubernetes.Interface) string {
+	t.Helper()
+	configMap, err := client.CoreV1().ConfigMaps("default").Get(context.Background(), "modsecurity-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ConfigMap: %v", err)
+	}
+	return configMap.Data["custom-rules.conf"]
+}
+
+const testRuleText = `SecRule ARGS "@contains attack" "id:1001,phase:2,deny,status:403"`
+
+// testRuleIDRange 테넌트가 없는 사용자의 공용 범위
+var testRuleIDRange = dto.RuleIDRange{Start: defaultRuleIDStart, End: defaultRuleIDEnd}
+
+// 저장한 룰이 새 서비스에서 그대로 로드되고, 잘못된 룰은 저장되지 않음
+func TestRuleServicePersistence(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := newTestRuleService(t, db, nil)
+
+	rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true, Severity: "HIGH"})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	if _, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "bad", RuleText: `SecAction "id:1,exec:/bin/sh"`}); err == nil {
+		t.Fatal("CreateRule(invalid) succeeded")
+	}
+	// Enabled false도 그대로 저장됨
+	updated, err := s.UpdateRule(ctx, "user_a", rule.ID, testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block v2", RuleText: testRuleText, Enabled: false, Severity: "LOW"})
+	if err != nil {
+		t.Fatalf("UpdateRule() error = %v", err)
+	}
+
+	reloaded := newTestRuleService(t, db, nil)
+	rules, _ := reloaded.GetRules(ctx, "user_a")
+	if len(rules) != 1 {
+		t.Fatalf("GetRules() after reload = %d rules, want 1", len(rules))
+	}
+	got := rules[0]
+	if got.Name != "block v2" || got.Enabled || got.Severity != "LOW" || got.RuleText != updated.RuleText {
+		t.Errorf("reloaded rule = %+v", got)
+	}
+
+	if err := reloaded.DeleteRule(ctx, "user_a", rule.ID, ""); err != nil {
+		t.Fatalf("DeleteRule() error = %v", err)
+	}
+	if rules, _ := newTestRuleService(t, db, nil).GetRules(ctx, "user_a"); len(rules) != 0 {
+		t.Errorf("deleted rule is still loaded: %v", rules)
+	}
+}
+
+func TestRuleServiceAccessChecks(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr string
+	}{
+		{"get other user's rule", func() error { _, err := s.GetRule("user_b", rule.ID); return err }, "access denied"},
+		{"update other user's rule", func() error {
+			_, err := s.UpdateRule(ctx, "user_b", rule.ID, testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "x", RuleText: testRuleText})
+			return err
+		}, "access denied"},
+		{"delete other user's rule", func() error { return s.DeleteRule(ctx, "user_b", rule.ID, "") }, "access denied"},
+		{"missing rule", func() error { _, err := s.GetRule("user_a", "rule_missing"); return err }, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if rules, _ := s.GetRules(ctx, "user_b"); len(rules) != 0 {
+		t.Errorf("GetRules(other user) = %d rules, want 0", len(rules))
+	}
+}
+
+// 사용자 룰과 관리형 룰은 각자의 정책으로 검증
+func TestRuleServiceValidatesPolicy(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+
+	tests := []struct {
+		name     string
+		managed  bool
+		ruleText string
+		wantErr  string
+	}{
+		{"user rule", false, testRuleText, ""},
+		{"user secaction", false, `SecAction "id:1002,phase:1,pass,nolog"`, ""},
+		{"user update target", false, `SecRuleUpdateTargetById 942100 "!ARGS:q"`, "directive SecRuleUpdateTargetById is not allowed"},
+		{"user exec", false, `SecRule ARGS "@rx a" "id:1003,deny,exec:/bin/sh"`, "action exec is denied by policy"},
+		{"user syntax error", false, `SecRule ARGS "@rx a`, "line 1"},
+		{"empty", false, "  \n", "cannot be empty"},
+		{"managed update target", true, `SecRuleUpdateTargetById 942100 "!ARGS:q"`, ""},
+		{"managed secaction", true, `SecAction "id:1004,phase:1,pass"`, "directive SecAction is not allowed"},
+		{"managed exec", true, `SecRule ARGS "@rx a" "id:1005,deny,exec:/bin/sh"`, "action exec is denied by policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &dto.CustomRuleRequest{Name: tt.name, RuleText: tt.ruleText}
+			var err error
+			if tt.managed {
+				_, err = s.CreateManagedRule(ctx, "user_a", "exclusion", req)
+			} else {
+				_, err = s.CreateRule(ctx, "user_a", testRuleIDRange, nil, req)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("create error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("create error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// 사용자 룰 ID는 배정된 범위 안에서 자동 배정되고 다른 룰/CRS와 겹치지 않아야 함
+func TestRuleServiceRuleIDs(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	s.crsRuleIDs = map[int]string{1500: "REQUEST-901-INITIALIZATION.conf"}
+	existing, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "existing", RuleText: testRuleText, Enabled: true})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	if _, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "disabled", RuleText: `SecRule ARGS "@rx b" "id:1010,deny"`}); err != nil {
+		t.Fatalf("CreateRule(disabled) error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		ruleText string
+		enabled  bool
+		wantID   string
+		wantErr  string
+	}{
+		{"assigned lowest free id", `SecRule ARGS "@rx a" "phase:2,deny"`, true, "id:1000,", ""},
+		{"explicit id in range", `SecRule ARGS "@rx a" "id:2000,deny"`, true, "id:2000,", ""},
+		{"used by enabled rule", `SecRule ARGS "@rx a" "id:1001,deny"`, true, "", "rule id 1001 is already used by another custom rule"},
+		{"disabled rule id", `SecRule ARGS "@rx a" "id:1010,deny"`, true, "id:1010,", ""},
+		{"disabled duplicate", `SecRule ARGS "@rx a" "id:1001,deny"`, false, "id:1001,", ""},
+		{"crs file id", `SecRule ARGS "@rx a" "id:1500,deny"`, true, "", "already used by the OWASP CRS (REQUEST-901-INITIALIZATION.conf)"},
+		{"outside range", `SecRule ARGS "@rx a" "id:20000,deny"`, true, "", "outside your assigned range 1000-9899"},
+		{"reserved ban range", `SecRule ARGS "@rx a" "id:9900,deny"`, true, "", "reserved for auto-ban rules"},
+		{"reserved crs range", `SecRule ARGS "@rx a" "id:942100,deny"`, true, "", "reserved for the OWASP CRS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: tt.name, RuleText: tt.ruleText, Enabled: tt.enabled})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CreateRule() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateRule() error = %v", err)
+			}
+			if !strings.Contains(rule.RuleText, tt.wantID) {
+				t.Errorf("RuleText = %s, want %s", rule.RuleText, tt.wantID)
+			}
+			if err := s.DeleteRule(ctx, "user_a", rule.ID, ""); err != nil {
+				t.Fatalf("DeleteRule() error = %v", err)
+			}
+		})
+	}
+
+	// 수정 중인 룰이 이미 쓰던 ID는 그대로 허용
+	if _, err := s.UpdateRule(ctx, "user_a", existing.ID, dto.RuleIDRange{Start: 5000, End: 5999}, nil, &dto.CustomRuleRequest{Name: "existing", RuleText: testRuleText, Enabled: true}); err != nil {
+		t.Errorf("UpdateRule(kept id) error = %v", err)
+	}
+	if !s.HasRulesInIDRange(dto.RuleIDRange{Start: 1000, End: 1009}) || s.HasRulesInIDRange(dto.RuleIDRange{Start: 1011, End: 1999}) {
+		t.Error("HasRulesInIDRange() does not match stored rule IDs")
+	}
+}
+
+// 생성/수정/삭제마다 리비전이 남고, 삭제된 룰도 리비전으로 되돌릴 수 있음
+func TestRuleServiceRevisions(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRuleService(t, newTestDB(t), nil)
+	rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true, Severity: "HIGH", Reason: "initial"})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	changedText := `SecRule ARGS "@contains attack2" "id:1001,phase:2,deny,status:403"`
+	steps := []*dto.CustomRuleRequest{
+		{Name: "block", RuleText: changedText, Enabled: true, Severity: "HIGH"},
+		{Name: "block", RuleText: changedText, Enabled: false, Severity: "HIGH"},
+		{Name: "block", RuleText: changedText, Enabled: true, Severity: "HIGH"},
+		{Name: "block", RuleText: changedText, Enabled: true, Severity: "LOW"},
+	}
+	for _, req := range steps {
+		if _, err := s.UpdateRule(ctx, "user_a", rule.ID, testRuleIDRange, nil, req); err != nil {
+			t.Fatalf("UpdateRule() error = %v", err)
+		}
+	}
+	if err := s.DeleteRule(ctx, "user_a", rule.ID, "cleanup"); err != nil {
+		t.Fatalf("DeleteRule() error = %v", err)
+	}
+
+	revisions, err := s.GetRevisions(ctx, "user_a", rule.ID)
+	if err != nil {
+		t.Fatalf("GetRevisions() error = %v", err)
+	}
+	var actions []string
+	for i, revision := range revisions {
+		actions = append(actions, revision.Action)
+		if revision.Revision != i+1 || revision.Author != "user_a" {
+			t.Errorf("revision %d = %+v", i, revision)
+		}
+	}
+	wantActions := []string{RuleRevisionCreate, RuleRevisionUpdate, RuleRevisionDisable, RuleRevisionEnable, RuleRevisionUpdate, RuleRevisionDelete}
+	if !reflect.DeepEqual(actions, wantActions) {
+		t.Errorf("actions = %v, want %v", actions, wantActions)
+	}
+	if revisions[0].Reason != "initial" || revisions[5].Reason != "cleanup" {
+		t.Errorf("reasons = %q, %q", revisions[0].Reason, revisions[5].Reason)
+	}
+
+	tests := []struct {
+		name       string
+		userID     string
+		from, to   int
+		wantFields []string
+		wantDiff   string
+		wantErr    string
+	}{
+		{"rule text", "user_a", 1, 2, []string{"rule_text"}, "+" + changedText, ""},
+		{"disable", "user_a", 2, 3, []string{"enabled"}, "", ""},
+		{"enabled and severity", "user_a", 3, 5, []string{"enabled", "severity"}, "", ""},
+		{"missing revision", "user_a", 1, 9, nil, "", "revision 9 not found"},
+		{"other user", "user_b", 1, 2, nil, "", "access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := s.DiffRevisions(ctx, tt.userID, rule.ID, tt.from, tt.to)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DiffRevisions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DiffRevisions() error = %v", err)
+			}
+			var fields []string
+			for _, change := range diff.Changes {
+				fields = append(fields, change.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("changed fields = %v, want %v", fields, tt.wantFields)
+			}
+			if !strings.Contains(diff.Diff, tt.wantDiff) {
+				t.Errorf("diff =\n%s\nwant %q", diff.Diff, tt.wantDiff)
+			}
+		})
+	}
+
+	if _, err := s.RestoreRevision(ctx, "user_b", rule.ID, 1, testRuleIDRange, nil, ""); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("RestoreRevision(other user) error = %v", err)
+	}
+	restored, err := s.RestoreRevision(ctx, "user_a", rule.ID, 1, testRuleIDRange, nil, "")
+	if err != nil {
+		t.Fatalf("RestoreRevision() error = %v", err)
+	}
+	if restored.ID != rule.ID || restored.RuleText != testRuleText || restored.Severity != "HIGH" {
+		t.Errorf("restored rule = %+v", restored)
+	}
+	if got, _ := s.GetRevisions(ctx, "user_a", rule.ID); len(got) != 7 || got[6].Action != RuleRevisionRestore || got[6].Reason != "restored revision 1" {
+		t.Errorf("last revision after restore = %+v", got[len(got)-1])
+	}
+	if _, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "huge", RuleText: testRuleText + strings.Repeat("\n# padding", maxRuleTextBytes/10)}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("CreateRule(too large) error = %v", err)
+	}
+}
+
+// 룰 테스트는 사용자 룰 정책을 적용하고 id 없는 룰도 평가
+func TestRuleServiceTestRule(t *testing.T) {
+	s := newTestRuleService(t, newTestDB(t), nil)
+	sample := dto.RuleSampleRequest{URI: "/search?q=attack"}
+
+	tests := []struct {
+		name       string
+		ruleText   string
+		wantStatus int
+		wantErr    string
+	}{
+		{"blocks", testRuleText, 403, ""},
+		{"no match", `SecRule ARGS "@contains other" "id:1001,phase:2,deny,status:403"`, 0, ""},
+		{"without id", `SecRule ARGS "@contains attack" "phase:2,deny,status:406"`, 406, ""},
+		{"denied action", `SecRule ARGS "@contains attack" "id:1001,phase:2,deny,exec:/bin/sh"`, 0, "action exec is denied by policy"},
+		{"syntax error", `SecRule ARGS`, 0, "line 1"},
+		{"empty", " ", 0, "cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.TestRule(&dto.RuleTestRequest{RuleText: tt.ruleText, Request: sample})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("TestRule() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestRule() error = %v", err)
+			}
+			status := 0
+			if result.Interruption != nil {
+				status = result.Interruption.Status
+			}
+			if status != tt.wantStatus {
+				t.Errorf("interruption status = %d, want %d (%+v)", status, tt.wantStatus, result)
+			}
+		})
+	}
+	if len(s.rules) != 0 {
+		t.Error("TestRule() stored a rule")
+	}
+}
+
+// 관리형 snippet이 먼저, 룰은 생성 순서대로 마커와 함께 렌더링 (비활성 룰은 주석 처리)
+func TestRenderCustomRulesConf(t *testing.T) {
+	s := newTestRuleService(t, newTestDB(t), nil)
+	now := time.Now()
+	s.rules["b"] = &models.CustomRule{ID: "b", Name: "second", Description: "d2", RuleText: "SecRule B", Enabled: true, CreatedAt: now}
+	s.rules["a"] = &models.CustomRule{ID: "a", Name: "first", Description: "d1", RuleText: "SecRule A", Enabled: true, CreatedAt: now.Add(-time.Minute)}
+	s.rules["c"] = &models.CustomRule{ID: "c", Name: "off", RuleText: "SecRule C", CreatedAt: now.Add(-time.Hour)}
+	s.RegisterManagedSnippet("bans", func() string { return "SecRule BAN" })
+	s.RegisterManagedSnippet("empty", func() string { return "" })
+
+	want := "# Managed: bans\nSecRule BAN\n\n" + renderRuleBlock(s.rules["c"]) + renderRuleBlock(s.rules["a"]) + renderRuleBlock(s.rules["b"])
+	if got := s.renderCustomRulesConf(s.rules); got != want {
+		t.Errorf("renderCustomRulesConf() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRuleServiceReconcile(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	rule := &models.CustomRule{ID: "r1", Name: "block", RuleText: testRuleText, Enabled: true, Severity: "MEDIUM", UserID: "user_a", CreatedAt: created, UpdatedAt: created}
+	rendered := renderRuleBlock(rule)
+
+	tests := []struct {
+		name         string
+		rules        []*models.CustomRule
+		deployed     string
+		wantDeployed string
+		wantRestart  bool
+		wantRules    int
+	}{
+		{"in sync", []*models.CustomRule{rule}, rendered, rendered, false, 1},
+		{"out of date", []*models.CustomRule{rule}, "# old\n", rendered, true, 1},
+		{"empty database keeps legacy rules", nil, "# legacy\n", "# legacy\n", false, 0},
+		{"empty database recovers marked rules", nil, rendered, rendered, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, &models.CustomRule{})
+			for _, r := range tt.rules {
+				copied := *r
+				if err := db.Create(&copied).Error; err != nil {
+					t.Fatalf("failed to seed rule: %v", err)
+				}
+			}
+			client := newFakeCluster(tt.deployed)
+			s := newTestRuleService(t, db, client)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if got := deployedCustomRules(t, client); got != tt.wantDeployed {
+				t.Errorf("deployed custom-rules.conf = %q, want %q", got, tt.wantDeployed)
+			}
+			deployment, _ := client.AppsV1().Deployments("ingress-nginx").Get(context.Background(), "ingress-nginx-controller", metav1.GetOptions{})
+			if restarted := deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] != ""; restarted != tt.wantRestart {
+				t.Errorf("NGINX restarted = %v, want %v", restarted, tt.wantRestart)
+			}
+			var count int64
+			db.Model(&models.CustomRule{}).Count(&count)
+			if int(count) != tt.wantRules || len(s.rules) != tt.wantRules {
+				t.Errorf("rules = %d in DB, %d in memory, want %d", count, len(s.rules), tt.wantRules)
+			}
+			if tt.wantRules > 0 && *s.rules["r1"] != *rule {
+				t.Errorf("rule = %+v, want %+v", s.rules["r1"], rule)
+			}
+		})
+	}
+}
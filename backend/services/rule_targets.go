@@ -0,0 +1,555 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/tracing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// 대상 게이트 룰 ID: base + 룰의 첫 ID * 10 + 0..9 (룰 ID가 전역에서 유일하므로 게이트 ID도 유일)
+	targetGateRuleIDBase = 1000000000
+	targetGateRuleIDMax  = 1999999999
+	maxTargetedRuleID    = (targetGateRuleIDMax - targetGateRuleIDBase) / 10
+
+	maxRuleTargets = 8
+
+	modsecuritySnippetAnnotation = "nginx.ingress.kubernetes.io/modsecurity-snippet"
+	targetedRulesAnnotation      = "waf-backend/targeted-rules" // 대상 룰 블록을 넣은 Ingress 표시
+	targetedBlockBegin           = "# BEGIN waf-backend targeted rules"
+	targetedBlockEnd             = "# END waf-backend targeted rules"
+)
+
+var (
+	targetIngressPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?/[a-z0-9]([-.a-z0-9]*[a-z0-9])?$`)
+	targetPathPattern    = regexp.MustCompile(`^/[^\s"'\\]*$`)
+)
+
+func decodeRuleTargets(encoded string) []dto.RuleTarget {
+	if encoded == "" {
+		return nil
+	}
+	var targets []dto.RuleTarget
+	if err := json.Unmarshal([]byte(encoded), &targets); err != nil {
+		return nil
+	}
+	return targets
+}
+
+func encodeRuleTargets(targets []dto.RuleTarget) string {
+	if len(targets) == 0 {
+		return ""
+	}
+	encoded, _ := json.Marshal(targets)
+	return string(encoded)
+}
+
+// ingressScoped Ingress를 지정한 대상이면 전역 설정이 아니라 해당 Ingress annotation에만 배포
+func ingressScoped(targets []dto.RuleTarget) bool {
+	return len(targets) > 0 && targets[0].Ingress != ""
+}
+
+// normalizeRuleTargets 대상 검증과 정리 (호스트 소문자, Ingress 네임스페이스 채움, 중복 제거)
+// allowedHosts가 nil이 아니면 (관리자가 아닌 사용자) 모든 대상에 그 안의 호스트가 있어야 함
+// (대상 없는 룰이나 Ingress/경로만 지정한 대상은 다른 테넌트 요청에도 적용되므로 거부)
+func (s *RuleService) normalizeRuleTargets(targets []dto.RuleTarget, allowedHosts []string) ([]dto.RuleTarget, error) {
+	if len(targets) > maxRuleTargets {
+		return nil, fmt.Errorf("at most %d targets are allowed per rule", maxRuleTargets)
+	}
+	if allowedHosts != nil && len(targets) == 0 {
+		return nil, fmt.Errorf("rules must be limited to hosts of your tenant")
+	}
+
+	var result []dto.RuleTarget
+	seen := make(map[dto.RuleTarget]bool)
+	for i, target := range targets {
+		target.Ingress = strings.TrimSpace(target.Ingress)
+		target.Host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(target.Host)), ".")
+		target.PathPrefix = strings.TrimSpace(target.PathPrefix)
+		if target.Ingress == "" && target.Host == "" && target.PathPrefix == "" {
+			return nil, fmt.Errorf("target %d: ingress, host or path_prefix is required", i+1)
+		}
+
+		if target.Ingress != "" && !strings.Contains(target.Ingress, "/") {
+			target.Ingress = s.namespace + "/" + target.Ingress
+		}
+		if target.Ingress != "" && !targetIngressPattern.MatchString(target.Ingress) {
+			return nil, fmt.Errorf("target %d: invalid ingress %q", i+1, target.Ingress)
+		}
+		if allowedHosts != nil && target.Host == "" {
+			return nil, fmt.Errorf("target %d: host is required (rules must be limited to hosts of your tenant)", i+1)
+		}
+		if target.Host != "" {
+			if !targetHostPattern.MatchString(target.Host) {
+				return nil, fmt.Errorf("target %d: invalid host %q", i+1, target.Host)
+			}
+			if allowedHosts != nil && !hostInList(target.Host, allowedHosts) {
+				return nil, fmt.Errorf("target %d: host %s is outside your tenant", i+1, target.Host)
+			}
+		}
+		if target.PathPrefix != "" && !targetPathPattern.MatchString(target.PathPrefix) {
+			return nil, fmt.Errorf("target %d: invalid path_prefix %q", i+1, target.PathPrefix)
+		}
+
+		if !seen[target] {
+			seen[target] = true
+			result = append(result, target)
+		}
+	}
+
+	// 한 룰이 전역 설정과 Ingress annotation에 함께 들어가면 같은 ID가 두 번 로드됨
+	for _, target := range result {
+		if (target.Ingress == "") != (result[0].Ingress == "") {
+			return nil, fmt.Errorf("targets must either all name an ingress or none")
+		}
+	}
+	return result, nil
+}
+
+// scopeRuleTargets 룰 저장 전 대상 확인. 관리자가 아닌 사용자의 대상 없는 룰은 자기 테넌트 호스트 전체로 제한
+func (s *RuleService) scopeRuleTargets(targets []dto.RuleTarget, allowedHosts []string) ([]dto.RuleTarget, error) {
+	if allowedHosts != nil && len(targets) == 0 {
+		if len(allowedHosts) == 0 {
+			return nil, fmt.Errorf("you do not belong to a tenant, so your rules cannot be deployed")
+		}
+		if len(allowedHosts) > maxRuleTargets {
+			return nil, fmt.Errorf("your tenants have more than %d hosts, set the rule targets explicitly", maxRuleTargets)
+		}
+		for _, host := range allowedHosts {
+			targets = append(targets, dto.RuleTarget{Host: host})
+		}
+	}
+	return s.normalizeRuleTargets(targets, allowedHosts)
+}
+
+// checkTargetable 호스트/경로 대상은 룰 ID로 게이트를 만들므로 ID가 있어야 함
+func checkTargetable(rule *models.CustomRule, targets []dto.RuleTarget) error {
+	needsGate := false
+	for _, target := range targets {
+		needsGate = needsGate || target.Host != "" || target.PathPrefix != ""
+	}
+	if !needsGate {
+		return nil
+	}
+	ids, err := parseRuleIDs(rule.RuleText)
+	if err != nil {
+		return fmt.Errorf("failed to read rule ids: %w", err)
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("rules without an id cannot be limited to hosts or paths")
+	}
+	if ids[0] > maxTargetedRuleID {
+		return fmt.Errorf("rule id %d is too large to be limited to hosts or paths", ids[0])
+	}
+	return nil
+}
+
+// SetRuleTargets 룰 하나의 그룹과 대상 변경 (대상을 비우면 모든 사이트에 적용)
+func (s *RuleService) SetRuleTargets(ctx context.Context, userID, ruleID string, allowedHosts []string, req *dto.RuleTargetsRequest) (*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.SetRuleTargets",
+		attribute.String("user_id", userID), attribute.String("rule_id", ruleID))
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	rule, err := s.ownedRuleLocked(userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	group := strings.TrimSpace(req.Group)
+	updated, err := s.saveTargetsLocked(ctx, userID, []*models.CustomRule{rule}, &group, req.Targets, allowedHosts, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.ruleToResponse(updated[0]), nil
+}
+
+// SetGroupTargets 그룹에 속한 내 룰 전체의 대상 변경
+func (s *RuleService) SetGroupTargets(ctx context.Context, userID, group string, allowedHosts []string, req *dto.RuleGroupTargetsRequest) ([]*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.SetGroupTargets",
+		attribute.String("user_id", userID), attribute.String("group", group))
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var members []*models.CustomRule
+	for _, rule := range sortedRules(s.rules) {
+		if rule.UserID == userID && rule.RuleGroup == group {
+			members = append(members, rule)
+		}
+	}
+	if group == "" || len(members) == 0 {
+		return nil, fmt.Errorf("rule group not found")
+	}
+
+	updated, err := s.saveTargetsLocked(ctx, userID, members, nil, req.Targets, allowedHosts, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*dto.CustomRuleResponse, 0, len(updated))
+	for _, rule := range updated {
+		result = append(result, s.ruleToResponse(rule))
+	}
+	return result, nil
+}
+
+// saveTargetsLocked 룰들의 대상(과 group이 있으면 그룹)을 바꾸고 리비전을 남긴 뒤 한 번 배포
+func (s *RuleService) saveTargetsLocked(ctx context.Context, userID string, rules []*models.CustomRule, group *string, targets []dto.RuleTarget, allowedHosts []string, reason string) ([]*models.CustomRule, error) {
+	normalized, err := s.normalizeRuleTargets(targets, allowedHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([]*models.CustomRule, 0, len(rules))
+	for _, rule := range rules {
+		// 대상도 배포 내용을 바꾸므로 검토 모드에서는 직접 바꿀 수 없음
+		if s.requireReview && rule.Source == "" {
+			return nil, ErrReviewRequired
+		}
+		if err := checkTargetable(rule, normalized); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		changed := *rule
+		changed.Targets = encodeRuleTargets(normalized)
+		if group != nil {
+			changed.RuleGroup = *group
+		}
+		changed.UpdatedAt = time.Now()
+		updated = append(updated, &changed)
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, rule := range updated {
+			if err := tx.Save(rule).Error; err != nil {
+				return err
+			}
+			if err := recordRevision(tx, rule, revisionActionFor(rules[i], rule), userID, reason); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save rule targets: %w", err)
+	}
+	for _, rule := range updated {
+		s.rules[rule.ID] = rule
+	}
+	s.rebuildModsecIndexLocked()
+
+	if err := s.updateConfigMap(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update ConfigMap")
+	}
+	if err := s.updateIngressAnnotation(ctx); err != nil {
+		s.log.WithError(err).Error("Failed to update Ingress annotation")
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"rules":   len(updated),
+		"targets": len(normalized),
+	}).Info("Custom rule targets updated")
+
+	return updated, nil
+}
+
+// renderTargetGates 호스트/경로 대상이 있는 룰마다 대상이 아닌 요청에서 그 룰을 제거하는 phase 1 게이트
+// ingress가 ""이면 전역 설정용(Ingress를 지정하지 않은 룰), 아니면 그 Ingress를 지정한 룰의 해당 대상만 사용
+func renderTargetGates(rules []*models.CustomRule, ingress string) string {
+	var b strings.Builder
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		var targets []dto.RuleTarget
+		for _, target := range decodeRuleTargets(rule.Targets) {
+			if target.Ingress == ingress {
+				targets = append(targets, target)
+			}
+		}
+		if len(targets) > 0 {
+			b.WriteString(renderTargetGate(rule, targets))
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return b.String() + "\n"
+}
+
+// renderTargetGate 요청이 대상 중 하나와 맞으면 tx 플래그를 세우고, 아니면 ctl:ruleRemoveById로 룰을 제거
+// 조건 없는 대상(Ingress만 지정)이 있으면 항상 적용되므로 게이트가 필요 없음
+func renderTargetGate(rule *models.CustomRule, targets []dto.RuleTarget) string {
+	// 대상이 있는 룰은 checkTargetable에서 파싱 오류 없이 ID를 읽을 수 있는지 확인됨
+	ids, _ := parseRuleIDs(rule.RuleText)
+	if len(ids) == 0 || ids[0] > maxTargetedRuleID {
+		return ""
+	}
+
+	var hosts, paths []string
+	var pairs []dto.RuleTarget
+	for _, target := range targets {
+		switch {
+		case target.Host == "" && target.PathPrefix == "":
+			return ""
+		case target.PathPrefix == "":
+			hosts = append(hosts, target.Host)
+		case target.Host == "":
+			paths = append(paths, target.PathPrefix)
+		default:
+			pairs = append(pairs, target)
+		}
+	}
+
+	gateID := targetGateRuleIDBase + ids[0]*10
+	flag := fmt.Sprintf("waf_target_%d", ids[0])
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Target gate: %s\n", singleLine(rule.Name))
+	fmt.Fprintf(&b, "SecAction \"id:%d,phase:1,pass,nolog,setvar:tx.%s=0\"\n", gateID, flag)
+	next := gateID + 1
+	if len(hosts) > 0 {
+		fmt.Fprintf(&b, "SecRule REQUEST_HEADERS:Host \"@rx %s\" \"id:%d,phase:1,pass,nolog,t:lowercase,setvar:tx.%s=1\"\n", hostRegex(hosts), next, flag)
+		next++
+	}
+	if len(paths) > 0 {
+		fmt.Fprintf(&b, "SecRule REQUEST_FILENAME \"@rx %s\" \"id:%d,phase:1,pass,nolog,setvar:tx.%s=1\"\n", pathRegex(paths), next, flag)
+		next++
+	}
+	for _, pair := range pairs {
+		fmt.Fprintf(&b, "SecRule REQUEST_HEADERS:Host \"@rx %s\" \"id:%d,phase:1,pass,nolog,t:lowercase,chain\"\n", hostRegex([]string{pair.Host}), next)
+		fmt.Fprintf(&b, "    SecRule REQUEST_FILENAME \"@rx %s\" \"setvar:tx.%s=1\"\n", pathRegex([]string{pair.PathPrefix}), flag)
+		next++
+	}
+	removes := make([]string, 0, len(ids))
+	for _, id := range ids {
+		removes = append(removes, fmt.Sprintf("ctl:ruleRemoveById=%d", id))
+	}
+	fmt.Fprintf(&b, "SecRule TX:%s \"@eq 0\" \"id:%d,phase:1,pass,nolog,%s\"\n", flag, gateID+9, strings.Join(removes, ","))
+	return b.String()
+}
+
+func pathRegex(prefixes []string) string {
+	alternatives := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		alternatives = append(alternatives, regexp.QuoteMeta(prefix))
+	}
+	return "^(?:" + strings.Join(alternatives, "|") + ")"
+}
+
+// renderTargetedBlock Ingress 하나에 들어갈 대상 룰 블록 (해당 룰이 없으면 "")
+func renderTargetedBlock(rules []*models.CustomRule, ingress string) string {
+	var body strings.Builder
+	count := 0
+	for _, rule := range rules {
+		if !rule.Enabled || !targetsIngress(rule, ingress) {
+			continue
+		}
+		fmt.Fprintf(&body, "%s%s\n\n", commentLines(rule.Name), strings.TrimRight(rule.RuleText, "\n"))
+		count++
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s\n%s%s%s", targetedBlockBegin, renderTargetGates(rules, ingress), body.String(), targetedBlockEnd)
+}
+
+func targetsIngress(rule *models.CustomRule, ingress string) bool {
+	for _, target := range decodeRuleTargets(rule.Targets) {
+		if target.Ingress == ingress {
+			return true
+		}
+	}
+	return false
+}
+
+// withTargetedBlock 기존 snippet에서 이전 대상 룰 블록을 빼고 새 블록을 붙임 (사용자가 쓴 내용은 유지)
+func withTargetedBlock(snippet, block string) string {
+	if start := strings.Index(snippet, targetedBlockBegin); start >= 0 {
+		if end := strings.Index(snippet[start:], targetedBlockEnd); end >= 0 {
+			before := strings.TrimRight(snippet[:start], "\n")
+			after := strings.TrimLeft(snippet[start+end+len(targetedBlockEnd):], "\n")
+			if before != "" && after != "" {
+				before += "\n\n"
+			}
+			snippet = before + after
+		}
+	}
+	snippet = strings.TrimRight(snippet, "\n")
+	switch {
+	case block == "":
+		return snippet
+	case snippet == "":
+		return block
+	default:
+		return snippet + "\n\n" + block
+	}
+}
+
+// updateTargetedIngresses 대상 룰이 있는 Ingress와 이전에 블록을 넣었던 Ingress의 annotation 갱신
+// (기본 WAF Ingress는 updateIngressAnnotation이 직접 처리)
+func (s *RuleService) updateTargetedIngresses(ctx context.Context) error {
+	rules := sortedRules(s.rules)
+	keys := make(map[string]bool)
+	for _, rule := range rules {
+		for _, target := range decodeRuleTargets(rule.Targets) {
+			if target.Ingress != "" {
+				keys[target.Ingress] = true
+			}
+		}
+	}
+
+	// 이전에 블록을 넣었던 Ingress도 정리 대상 (클러스터 전체 조회 권한이 없으면 현재 대상만)
+	if managed, err := s.k8sClient.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{}); err == nil {
+		for _, ingress := range managed.Items {
+			if _, ok := ingress.Annotations[targetedRulesAnnotation]; ok {
+				keys[ingress.Namespace+"/"+ingress.Name] = true
+			}
+		}
+	} else {
+		s.log.WithError(err).Warn("Failed to list Ingresses, stale targeted rule blocks may remain")
+	}
+	delete(keys, s.namespace+"/"+s.ingressName)
+
+	var failed []string
+	for key := range keys {
+		namespace, name, _ := strings.Cut(key, "/")
+		if err := s.applyTargetedBlock(ctx, namespace, name, renderTargetedBlock(rules, key)); err != nil {
+			s.log.WithError(err).WithField("ingress", key).Warn("Failed to update targeted rules on Ingress")
+			failed = append(failed, key)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("failed to update Ingresses: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (s *RuleService) applyTargetedBlock(ctx context.Context, namespace, name, block string) error {
+	client := s.k8sClient.NetworkingV1().Ingresses(namespace)
+	ingress, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if ingress.Annotations == nil {
+		ingress.Annotations = make(map[string]string)
+	}
+
+	snippet := withTargetedBlock(ingress.Annotations[modsecuritySnippetAnnotation], block)
+	_, marked := ingress.Annotations[targetedRulesAnnotation]
+	if snippet == ingress.Annotations[modsecuritySnippetAnnotation] && (block != "") == marked {
+		return nil
+	}
+	if snippet == "" {
+		delete(ingress.Annotations, modsecuritySnippetAnnotation)
+	} else {
+		ingress.Annotations[modsecuritySnippetAnnotation] = snippet
+	}
+	if block == "" {
+		delete(ingress.Annotations, targetedRulesAnnotation)
+	} else {
+		ingress.Annotations[targetedRulesAnnotation] = "true"
+	}
+
+	_, err = client.Update(ctx, ingress, metav1.UpdateOptions{})
+	return err
+}
+
+// RuleCoverage 호스트별로 적용되는 내 룰 (활성 룰만). 호스트는 클러스터의 Ingress와 룰 대상에서 수집
+// host를 주면 그 호스트만
+func (s *RuleService) RuleCoverage(ctx context.Context, userID, host string) ([]dto.HostCoverage, error) {
+	ingressHosts := make(map[string][]string) // host → Ingress
+	if s.k8sClient != nil {
+		ingresses, err := s.k8sClient.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Ingresses: %w", err)
+		}
+		for _, ingress := range ingresses.Items {
+			for _, rule := range ingress.Spec.Rules {
+				if rule.Host != "" {
+					ingressHosts[rule.Host] = append(ingressHosts[rule.Host], ingress.Namespace+"/"+ingress.Name)
+				}
+			}
+		}
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var rules []*models.CustomRule
+	hosts := make(map[string]bool)
+	for h := range ingressHosts {
+		hosts[h] = true
+	}
+	for _, rule := range sortedRules(s.rules) {
+		if rule.UserID != userID || !rule.Enabled {
+			continue
+		}
+		rules = append(rules, rule)
+		for _, target := range decodeRuleTargets(rule.Targets) {
+			if target.Host != "" {
+				hosts[target.Host] = true
+			}
+		}
+	}
+	if host != "" {
+		host = normalizeHost(host)
+		hosts = map[string]bool{host: true}
+	}
+
+	result := make([]dto.HostCoverage, 0, len(hosts))
+	for h := range hosts {
+		coverage := dto.HostCoverage{Host: h, Ingresses: ingressHosts[h], Rules: []dto.RuleCoverageEntry{}}
+		for _, rule := range rules {
+			if entry, applies := coverageOf(rule, h, ingressHosts[h]); applies {
+				coverage.Rules = append(coverage.Rules, entry)
+			}
+		}
+		result = append(result, coverage)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Host < result[j].Host })
+	return result, nil
+}
+
+// coverageOf 룰이 host에 적용되는지와 적용되는 경로
+func coverageOf(rule *models.CustomRule, host string, ingresses []string) (dto.RuleCoverageEntry, bool) {
+	entry := dto.RuleCoverageEntry{RuleID: rule.ID, Name: rule.Name, Group: rule.RuleGroup, Scope: "global"}
+	targets := decodeRuleTargets(rule.Targets)
+	if len(targets) == 0 {
+		return entry, true
+	}
+
+	entry.Scope = "targeted"
+	applies, allPaths := false, false
+	for _, target := range targets {
+		if target.Host != "" && target.Host != host && !hostInList(host, []string{target.Host}) {
+			continue
+		}
+		if target.Ingress != "" && !containsString(ingresses, target.Ingress) {
+			continue
+		}
+		applies = true
+		if target.PathPrefix == "" {
+			allPaths = true
+		} else {
+			entry.Paths = append(entry.Paths, target.PathPrefix)
+		}
+	}
+	if allPaths {
+		entry.Paths = nil
+	}
+	return entry, applies
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"waf-backend/dto"
+	"waf-backend/models"
+)
+
+func TestNormalizeRuleTargets(t *testing.T) {
+	tenantHosts := []string{"shop.example.com", "*.blog.example.com"}
+	tests := []struct {
+		name         string
+		targets      []dto.RuleTarget
+		allowedHosts []string
+		want         []dto.RuleTarget
+		wantErr      string
+	}{
+		{"admin untargeted", nil, nil, nil, ""},
+		{"admin path only", []dto.RuleTarget{{PathPrefix: "/api"}}, nil, []dto.RuleTarget{{PathPrefix: "/api"}}, ""},
+		{"admin ingress only", []dto.RuleTarget{{Ingress: "web"}}, nil, []dto.RuleTarget{{Ingress: "waf/web"}}, ""},
+		{"tenant host", []dto.RuleTarget{{Host: "Shop.Example.com."}}, tenantHosts, []dto.RuleTarget{{Host: "shop.example.com"}}, ""},
+		{"tenant host and path", []dto.RuleTarget{{Host: "a.blog.example.com", PathPrefix: "/admin"}}, tenantHosts, []dto.RuleTarget{{Host: "a.blog.example.com", PathPrefix: "/admin"}}, ""},
+		{"tenant host with ingress", []dto.RuleTarget{{Host: "shop.example.com", Ingress: "shop/web"}}, tenantHosts, []dto.RuleTarget{{Host: "shop.example.com", Ingress: "shop/web"}}, ""},
+		{"tenant untargeted", nil, tenantHosts, nil, "limited to hosts of your tenant"},
+		{"tenant path only", []dto.RuleTarget{{PathPrefix: "/api"}}, tenantHosts, nil, "host is required"},
+		{"tenant ingress only", []dto.RuleTarget{{Ingress: "shop/web"}}, tenantHosts, nil, "host is required"},
+		{"tenant other host", []dto.RuleTarget{{Host: "other.example.com"}}, tenantHosts, nil, "outside your tenant"},
+		{"tenantless host", []dto.RuleTarget{{Host: "shop.example.com"}}, []string{}, nil, "outside your tenant"},
+		{"mixed ingress", []dto.RuleTarget{{Host: "a.example.com"}, {Ingress: "web"}}, nil, nil, "all name an ingress or none"},
+	}
+
+	s := &RuleService{namespace: "waf"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.normalizeRuleTargets(tt.targets, tt.allowedHosts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("normalizeRuleTargets() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeRuleTargets() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeRuleTargets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeRuleTargets(t *testing.T) {
+	manyHosts := make([]string, maxRuleTargets+1)
+	for i := range manyHosts {
+		manyHosts[i] = string(rune('a'+i)) + ".example.com"
+	}
+	tests := []struct {
+		name         string
+		targets      []dto.RuleTarget
+		allowedHosts []string
+		want         []dto.RuleTarget
+		wantErr      string
+	}{
+		{"admin stays untargeted", nil, nil, nil, ""},
+		{"tenant defaults to tenant hosts", nil, []string{"shop.example.com", "*.blog.example.com"}, []dto.RuleTarget{{Host: "shop.example.com"}, {Host: "*.blog.example.com"}}, ""},
+		{"tenant explicit targets kept", []dto.RuleTarget{{Host: "shop.example.com", PathPrefix: "/cart"}}, []string{"shop.example.com"}, []dto.RuleTarget{{Host: "shop.example.com", PathPrefix: "/cart"}}, ""},
+		{"tenant explicit path only", []dto.RuleTarget{{PathPrefix: "/cart"}}, []string{"shop.example.com"}, nil, "host is required"},
+		{"tenantless user", nil, []string{}, nil, "do not belong to a tenant"},
+		{"too many tenant hosts", nil, manyHosts, nil, "set the rule targets explicitly"},
+	}
+
+	s := &RuleService{namespace: "waf"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.scopeRuleTargets(tt.targets, tt.allowedHosts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("scopeRuleTargets() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("scopeRuleTargets() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scopeRuleTargets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleTargetHostsFor(t *testing.T) {
+	s := &TenantService{
+		authService: &AuthService{adminEmails: map[string]bool{"admin@example.com": true}},
+		tenants: map[string]*dto.Tenant{
+			"t1": {ID: "t1", Hosts: []string{"shop.example.com"}, Members: []string{"alice@example.com"}},
+			"t2": {ID: "t2", Hosts: []string{"*.blog.example.com"}, Members: []string{"alice@example.com", "bob@example.com"}},
+		},
+	}
+	tests := []struct {
+		email string
+		want  []string
+	}{
+		{"admin@example.com", nil},
+		{"bob@example.com", []string{"*.blog.example.com"}},
+		{"nobody@example.com", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			got := s.RuleTargetHostsFor(tt.email)
+			if (got == nil) != (tt.want == nil) || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RuleTargetHostsFor() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTargetGate(t *testing.T) {
+	rule := &models.CustomRule{Name: "block admin", RuleText: "SecRule ARGS \"@rx a\" \"id:1001,phase:2,deny,chain\"\n    SecRule ARGS \"@rx b\" \"t:none\"\nSecRule ARGS \"@rx c\" \"id:1002,phase:2,deny\""}
+	gate := targetGateRuleIDBase + 10010
+	head := fmt.Sprintf("# Target gate: block admin\nSecAction \"id:%d,phase:1,pass,nolog,setvar:tx.waf_target_1001=0\"\n", gate)
+	tail := fmt.Sprintf("SecRule TX:waf_target_1001 \"@eq 0\" \"id:%d,phase:1,pass,nolog,ctl:ruleRemoveById=1001,ctl:ruleRemoveById=1002\"\n", gate+9)
+	tests := []struct {
+		name    string
+		rule    *models.CustomRule
+		targets []dto.RuleTarget
+		want    string
+	}{
+		{"hosts", rule, []dto.RuleTarget{{Host: "shop.example.com"}, {Host: "*.blog.example.com"}},
+			head + fmt.Sprintf("SecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com|.+\\.blog\\.example\\.com)(?::[0-9]+)?$\" \"id:%d,phase:1,pass,nolog,t:lowercase,setvar:tx.waf_target_1001=1\"\n", gate+1) + tail},
+		{"path", rule, []dto.RuleTarget{{PathPrefix: "/api/"}},
+			head + fmt.Sprintf("SecRule REQUEST_FILENAME \"@rx ^(?:/api/)\" \"id:%d,phase:1,pass,nolog,setvar:tx.waf_target_1001=1\"\n", gate+1) + tail},
+		{"host and path", rule, []dto.RuleTarget{{Host: "shop.example.com", PathPrefix: "/cart"}},
+			head + fmt.Sprintf("SecRule REQUEST_HEADERS:Host \"@rx ^(?:shop\\.example\\.com)(?::[0-9]+)?$\" \"id:%d,phase:1,pass,nolog,t:lowercase,chain\"\n    SecRule REQUEST_FILENAME \"@rx ^(?:/cart)\" \"setvar:tx.waf_target_1001=1\"\n", gate+1) + tail},
+		{"unconditional target", rule, []dto.RuleTarget{{Host: "shop.example.com"}, {Ingress: "shop/web"}}, ""},
+		{"rule without id", &models.CustomRule{Name: "marker", RuleText: "SecMarker END"}, []dto.RuleTarget{{Host: "shop.example.com"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderTargetGate(tt.rule, tt.targets); got != tt.want {
+				t.Errorf("renderTargetGate() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleServiceSetRuleTargets(t *testing.T) {
+	ctx := context.Background()
+	tenantHosts := []string{"shop.example.com"}
+	tests := []struct {
+		name         string
+		userID       string
+		allowedHosts []string
+		req          dto.RuleTargetsRequest
+		want         []dto.RuleTarget
+		wantErr      string
+	}{
+		{"tenant host and path", "user_a", tenantHosts, dto.RuleTargetsRequest{Group: "checkout", Targets: []dto.RuleTarget{{Host: "shop.example.com", PathPrefix: "/cart"}}}, []dto.RuleTarget{{Host: "shop.example.com", PathPrefix: "/cart"}}, ""},
+		{"admin clears targets", "user_a", nil, dto.RuleTargetsRequest{}, nil, ""},
+		{"tenant other host", "user_a", tenantHosts, dto.RuleTargetsRequest{Targets: []dto.RuleTarget{{Host: "blog.example.com"}}}, nil, "outside your tenant"},
+		{"tenant clears targets", "user_a", tenantHosts, dto.RuleTargetsRequest{}, nil, "limited to hosts of your tenant"},
+		{"other user's rule", "user_b", nil, dto.RuleTargetsRequest{Targets: []dto.RuleTarget{{Host: "shop.example.com"}}}, nil, "access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestRuleService(t, newTestDB(t), nil)
+			rule, err := s.CreateRule(ctx, "user_a", testRuleIDRange, tenantHosts, &dto.CustomRuleRequest{Name: "block", RuleText: testRuleText, Enabled: true, Severity: "HIGH"})
+			if err != nil {
+				t.Fatalf("CreateRule() error = %v", err)
+			}
+			// 테넌트 사용자의 새 룰은 테넌트 호스트로 제한됨
+			if !reflect.DeepEqual(rule.Targets, []dto.RuleTarget{{Host: "shop.example.com"}}) {
+				t.Fatalf("CreateRule() targets = %+v", rule.Targets)
+			}
+
+			updated, err := s.SetRuleTargets(ctx, tt.userID, rule.ID, tt.allowedHosts, &tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetRuleTargets() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetRuleTargets() error = %v", err)
+			}
+			if !reflect.DeepEqual(updated.Targets, tt.want) || updated.Group != tt.req.Group {
+				t.Errorf("SetRuleTargets() = %+v / %q, want %+v / %q", updated.Targets, updated.Group, tt.want, tt.req.Group)
+			}
+
+			reloaded, err := newTestRuleService(t, s.db, nil).GetRule("user_a", rule.ID)
+			if err != nil {
+				t.Fatalf("GetRule() after reload error = %v", err)
+			}
+			if !reflect.DeepEqual(reloaded.Targets, tt.want) || reloaded.Group != tt.req.Group {
+				t.Errorf("reloaded = %+v / %q, want %+v / %q", reloaded.Targets, reloaded.Group, tt.want, tt.req.Group)
+			}
+			revisions, err := s.GetRevisions(ctx, "user_a", rule.ID)
+			if err != nil || len(revisions) != 2 {
+				t.Errorf("GetRevisions() = %d revisions, %v; want 2", len(revisions), err)
+			}
+		})
+	}
+}
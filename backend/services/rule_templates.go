@@ -0,0 +1,639 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"waf-backend/dto"
+	"waf-backend/models"
+	"waf-backend/seclang"
+	"waf-backend/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 템플릿 파라미터 타입
+const (
+	templateParamString     = "string"      // msg 등에 들어가는 짧은 문구
+	templateParamInt        = "int"         // 임계값, 상태 코드 (min/max 범위)
+	templateParamEnum       = "enum"        // options 중 하나
+	templateParamRegex      = "regex"       // @rx 인자
+	templateParamPath       = "path"        // /로 시작하는 URL 경로 접두어
+	templateParamHeaderName = "header_name" // REQUEST_HEADERS:<name>
+	templateParamCIDRList   = "cidr_list"   // IP 또는 CIDR 목록
+	templateParamStringList = "string_list" // 공백 없는 단어 목록
+)
+
+// ruleTemplate 카탈로그 항목. render는 검증을 마친 파라미터로 id 없는 룰 텍스트를 생성
+type ruleTemplate struct {
+	dto.RuleTemplate
+	render func(p templateParams) string
+}
+
+// templateParams 검증/정규화된 파라미터 (string, int, []string)
+type templateParams map[string]interface{}
+
+func (p templateParams) str(name string) string {
+	value, _ := p[name].(string)
+	return value
+}
+
+func (p templateParams) num(name string) int {
+	value, _ := p[name].(int)
+	return value
+}
+
+func (p templateParams) list(name string) []string {
+	value, _ := p[name].([]string)
+	return value
+}
+
+// templateLink 템플릿으로 만든 룰의 템플릿 ID와 파라미터 (JSON). 빈 값이면 템플릿과 무관한 변경
+type templateLink struct {
+	id     string
+	params string
+}
+
+// applyTo 템플릿으로 렌더링한 변경이면 연결을 갱신하고, 룰 텍스트를 직접 고쳤으면 연결을 끊음
+func (l templateLink) applyTo(rule *models.CustomRule, previousText string) {
+	switch {
+	case l.id != "":
+		rule.TemplateID, rule.TemplateParams = l.id, l.params
+	case rule.RuleText != previousText:
+		rule.TemplateID, rule.TemplateParams = "", ""
+	}
+}
+
+var (
+	headerNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	actionOptions     = []string{"deny", "block"}
+)
+
+func messageParam(defaultMessage string) dto.RuleTemplateParameter {
+	return dto.RuleTemplateParameter{Name: "message", Type: templateParamString, Description: "Log message (msg action)", Default: defaultMessage}
+}
+
+func intBound(v int) *int {
+	return &v
+}
+
+// ruleTemplates 기본 템플릿 카탈로그 (CUSTOM_RULE_GUIDE.md의 예제들)
+var ruleTemplates = []*ruleTemplate{
+	{
+		RuleTemplate: dto.RuleTemplate{
+			ID:          "sqli",
+			Name:        "SQL Injection 차단",
+			Description: "Detects SQL injection in arguments and cookies with @detectSQLi",
+			Severity:    "CRITICAL",
+			Parameters: []dto.RuleTemplateParameter{
+				{Name: "action", Type: templateParamEnum, Description: "deny blocks immediately, block follows SecDefaultAction (anomaly scoring)", Default: "deny", Options: actionOptions},
+				messageParam("SQL Injection Attack Detected"),
+			},
+		},
+		render: func(p templateParams) string {
+			return fmt.Sprintf(`SecRule ARGS|ARGS_NAMES|REQUEST_COOKIES "@detectSQLi" "phase:2,%s,t:none,t:urlDecodeUni,log,msg:'%s',logdata:'Matched Data: %%{MATCHED_VAR} found within %%{MATCHED_VAR_NAME}'"`,
+				p.str("action"), p.str("message"))
+		},
+	},
+	{
+		RuleTemplate: dto.RuleTemplate{
+			ID:          "xss",
+			Name:        "XSS 차단",
+			Description: "Detects cross-site scripting in arguments and cookies with @detectXSS",
+			Severity:    "HIGH",
+			Parameters: []dto.RuleTemplateParameter{
+				{Name: "action", Type: templateParamEnum, Description: "deny blocks immediately, block follows SecDefaultAction (anomaly scoring)", Default: "deny", Options: actionOptions},
+				messageParam("XSS Attack Detected"),
+			},
+		},
+		render: func(p templateParams) string {
+			return fmt.Sprintf(`SecRule ARGS|ARGS_NAMES|REQUEST_COOKIES "@detectXSS" "phase:2,%s,t:none,t:urlDecodeUni,t:htmlEntityDecode,log,msg:'%s',logdata:'Matched Data: %%{MATCHED_VAR} found within %%{MATCHED_VAR_NAME}'"`,
+				p.str("action"), p.str("message"))
+		},
+	},
+	{
+		RuleTemplate: dto.RuleTemplate{
+			ID:          "ip_block",
+			Name:        "IP 차단",
+			Description: "Denies requests from the given IP addresses or CIDR ranges",
+			Severity:    "HIGH",
+			Parameters: []dto.RuleTemplateParameter{
+				{Name: "addresses", Type: templateParamCIDRList, Description: "IP addresses or CIDR ranges (e.g. 192.168.1.100, 10.0.0.0/8)", Required: true},
+				messageParam("Blocked IP Address"),
+			},
+		},
+		render: func(p templateParams) string {
+			return fmt.Sprintf(`SecRule REMOTE_ADDR "@ipMatch %s" "phase:1,deny,status:403,log,msg:'%s'"`,
+				strings.Join(p.list("addresses"), ","), p.str("message"))
+		},
+	},
+	{
+		RuleTemplate: dto.RuleTemplate{
+			ID:          "user_agent_block",
+			Name:        "User-Agent 차단",
+			Description: "Denies requests whose User-Agent contains one of the given words (case-insensitive)",
+			Severity:    "MEDIUM",
+			Parameters: []dto.RuleTemplateParameter{
+				{Name: "agents", Type: templateParamStringList, Description: "Words to look for in the User-Agent header", Default: []string{"sqlmap", "nmap", "nikto", "havij", "masscan"}},
+				messageParam("Malicious bot detected"),
+			},
+		},
+		render: func(p templateParams) string {
+			return fmt.Sprintf(`SecRule REQUEST_HEADERS:User-Agent "@pm %s" "phase:1,deny,status:403,t:none,log,msg:'%s',logdata:'%%{MATCHED_VAR}'"`,
+				strings.Join(p.list("agents"), " "), p.str("message"))
+		},
+	},
+	{
+		RuleTemplate: dto.RuleTemplate{
+			ID:          "path_block",
+			Name:        "경로 차단",
+			Description: "Denies requests whose decoded, normalised path matches a regular expression",
+			Severity:    "MEDIUM",
+			Parameters: []dto.RuleTemplateParameter{
+				{Name: "path_regex", Type: templateParamRegex, Description: "Regular expression matched against the request path (e.g. ^/(admin|internal)/)", Required: true},
+				{Name: "status", Type: templateParamInt, Description: "HTTP status returned when blocked", Default: 403, Min: intBound(400), Max: intBound(599)},
+				messageParam("Blocked path"),
+			},
+		},
+		render: func(p templateParams) string {
+			return fmt.Sprintf(`SecRule REQUEST_FILENAME "@rx %s" "phase:1,deny,status:%d,t:none,t:urlDecodeUni,t:normalisePath,log,msg:'%s',logdata:'%%{MATCHED_VAR}'"`,
+				p.str("path_regex"), p.num("status"), p.str("message"))
+		},
+	},
+	{
+		RuleTemplate: dto.RuleTemplate{
+			ID:          "header_match",
+			Name:        "헤더 값 차단",
+			Description: "Denies requests where a request header matches a regular expression",
+			Severity:    "MEDIUM",
+			Parameters: []dto.RuleTemplateParameter{
+				{Name: "header", Type: templateParamHeaderName, Description: "Request header name (e.g. X-Forwarded-Host)", Required: true},
+				{Name: "pattern", Type: templateParamRegex, Description: "Regular expression matched against the header value", Required: true},
+				{Name: "status", Type: templateParamInt, Description: "HTTP status returned when blocked", Default: 403, Min: intBound(400), Max: intBound(599)},
+				messageParam("Blocked request header"),
+			},
+		},
+		render: func(p templateParams) string {
+			return fmt.Sprintf(`SecRule REQUEST_HEADERS:%s "@rx %s" "phase:1,deny,status:%d,t:none,log,msg:'%s',logdata:'%%{MATCHED_VAR_NAME}: %%{MATCHED_VAR}'"`,
+				p.str("header"), p.str("pattern"), p.num("status"), p.str("message"))
+		},
+	},
+	{
+		RuleTemplate: dto.RuleTemplate{
+			ID:          "upload_extension_block",
+			Name:        "업로드 확장자 차단",
+			Description: "Blocks multipart uploads whose file name ends with one of the given extensions",
+			Severity:    "HIGH",
+			Parameters: []dto.RuleTemplateParameter{
+				{Name: "extensions", Type: templateParamStringList, Description: "File extensions without the dot", Default: []string{"php", "jsp", "asp", "aspx", "exe", "bat", "sh"}},
+				messageParam("Dangerous file upload blocked"),
+			},
+		},
+		render: func(p templateParams) string {
+			extensions := make([]string, 0, len(p.list("extensions")))
+			for _, extension := range p.list("extensions") {
+				extensions = append(extensions, regexp.QuoteMeta(strings.TrimPrefix(extension, ".")))
+			}
+			return fmt.Sprintf(`SecRule FILES_NAMES "@rx (?i)\.(%s)$" "phase:2,deny,status:403,log,msg:'%s',logdata:'%%{MATCHED_VAR}'"`,
+				strings.Join(extensions, "|"), p.str("message"))
+		},
+	},
+	{
+		RuleTemplate: dto.RuleTemplate{
+			ID:          "rate_limit",
+			Name:        "요청 수 제한",
+			Description: "Counts requests per client IP under a path prefix and denies with 429 above the threshold within the window",
+			Severity:    "MEDIUM",
+			Parameters: []dto.RuleTemplateParameter{
+				{Name: "path", Type: templateParamPath, Description: "Path prefix to limit (e.g. /login)", Default: "/"},
+				{Name: "threshold", Type: templateParamInt, Description: "Requests allowed per window", Required: true, Min: intBound(1), Max: intBound(100000)},
+				{Name: "window", Type: templateParamInt, Description: "Window length in seconds", Default: 60, Min: intBound(1), Max: intBound(86400)},
+				messageParam("Rate limit exceeded"),
+			},
+		},
+		render: func(p templateParams) string {
+			// 경로마다 카운터를 따로 두어 같은 템플릿으로 만든 룰끼리 섞이지 않게 함
+			sum := sha1.Sum([]byte(p.str("path")))
+			counter := "rl_" + hex.EncodeToString(sum[:4])
+			return strings.Join([]string{
+				`SecAction "phase:1,nolog,pass,initcol:ip=%{REMOTE_ADDR}"`,
+				fmt.Sprintf(`SecRule REQUEST_FILENAME "@beginsWith %s" "phase:1,nolog,pass,setvar:ip.%s=+1,expirevar:ip.%s=%d"`,
+					p.str("path"), counter, counter, p.num("window")),
+				fmt.Sprintf(`SecRule IP:%s "@gt %d" "phase:1,deny,status:429,log,msg:'%s',logdata:'%%{MATCHED_VAR} requests in %ds'"`,
+					counter, p.num("threshold"), p.str("message"), p.num("window")),
+			}, "\n")
+		},
+	},
+}
+
+func findRuleTemplate(templateID string) (*ruleTemplate, error) {
+	for _, template := range ruleTemplates {
+		if template.ID == templateID {
+			return template, nil
+		}
+	}
+	return nil, fmt.Errorf("template %q not found", templateID)
+}
+
+// ListRuleTemplates 템플릿 카탈로그
+func (s *RuleService) ListRuleTemplates() []dto.RuleTemplate {
+	result := make([]dto.RuleTemplate, 0, len(ruleTemplates))
+	for _, template := range ruleTemplates {
+		result = append(result, template.RuleTemplate)
+	}
+	return result
+}
+
+// RenderRuleTemplate 템플릿을 렌더링해서 사용자 룰 정책으로 검증 (저장하지 않으며 id는 배정 전)
+func (s *RuleService) RenderRuleTemplate(templateID string, raw map[string]interface{}) (*dto.RenderedRuleTemplate, error) {
+	ruleText, params, err := s.renderTemplate(templateID, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RenderedRuleTemplate{TemplateID: templateID, Params: params, RuleText: ruleText}, nil
+}
+
+// CreateRuleFromTemplate 템플릿으로 룰 생성. ID 배정과 검증은 CreateRule과 같음
+func (s *RuleService) CreateRuleFromTemplate(ctx context.Context, userID, templateID string, idRange dto.RuleIDRange, allowedHosts []string, req *dto.RuleFromTemplateRequest) (*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.CreateRuleFromTemplate",
+		attribute.String("user_id", userID), attribute.String("template_id", templateID))
+	defer span.End()
+
+	if s.requireReview {
+		return nil, ErrReviewRequired
+	}
+
+	template, err := findRuleTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	ruleText, params, err := s.renderTemplate(templateID, req.Params)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode template parameters: %w", err)
+	}
+
+	rule := &dto.CustomRuleRequest{
+		Name:        req.Name,
+		Description: req.Description,
+		RuleText:    ruleText,
+		Enabled:     req.Enabled,
+		Severity:    req.Severity,
+		Reason:      req.Reason,
+	}
+	if rule.Name == "" {
+		rule.Name = template.Name
+	}
+	if rule.Description == "" {
+		rule.Description = template.Description
+	}
+	if rule.Severity == "" {
+		rule.Severity = template.Severity
+	}
+
+	return s.createRule(ctx, userID, rule, "", s.userPolicy, &idRange, allowedHosts, templateLink{id: templateID, params: string(encoded)})
+}
+
+// RerenderRule 템플릿으로 만든 룰을 바뀐 파라미터로 다시 렌더링 (기존 룰 ID는 그대로 유지)
+func (s *RuleService) RerenderRule(ctx context.Context, userID, ruleID string, idRange dto.RuleIDRange, allowedHosts []string, req *dto.RuleTemplateRerenderRequest) (*dto.CustomRuleResponse, error) {
+	ctx, span := tracing.Start(ctx, "RuleService.RerenderRule",
+		attribute.String("user_id", userID), attribute.String("rule_id", ruleID))
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	rule, err := s.ownedRuleLocked(userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.TemplateID == "" {
+		return nil, fmt.Errorf("rule was not created from a template")
+	}
+
+	ruleText, link, err := s.rerenderTemplate(rule, rule.TemplateID, req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateRuleLocked(ctx, userID, rule, idRange, allowedHosts, &dto.CustomRuleRequest{
+		Name:        rule.Name,
+		Description: rule.Description,
+		RuleText:    ruleText,
+		Enabled:     rule.Enabled,
+		Severity:    rule.Severity,
+		Reason:      req.Reason,
+	}, link)
+}
+
+// renderTemplate 파라미터를 검증해 룰 텍스트를 만들고 사용자 룰 정책으로 검사 (id 없는 상태)
+func (s *RuleService) renderTemplate(templateID string, raw map[string]interface{}) (string, templateParams, error) {
+	template, err := findRuleTemplate(templateID)
+	if err != nil {
+		return "", nil, err
+	}
+	params, err := template.normalize(raw)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ruleText := template.render(params)
+	directives, err := seclang.ParseDraft(ruleText)
+	if err == nil {
+		err = s.userPolicy.Check(directives)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("template %s rendered an invalid rule: %w", templateID, err)
+	}
+	return ruleText, params, nil
+}
+
+// rerenderTemplate 기존 파라미터에 raw를 덮어써서 다시 렌더링하고, 룰이 쓰던 ID를 순서대로 다시 붙임
+// (템플릿이 바뀌면 기존 파라미터는 쓰지 않음. 지시어가 늘어나면 나머지는 저장할 때 배정)
+func (s *RuleService) rerenderTemplate(rule *models.CustomRule, templateID string, raw map[string]interface{}) (string, templateLink, error) {
+	template, err := findRuleTemplate(templateID)
+	if err != nil {
+		return "", templateLink{}, err
+	}
+
+	merged := make(map[string]interface{})
+	if rule.TemplateID == templateID && rule.TemplateParams != "" {
+		var stored map[string]interface{}
+		if err := json.Unmarshal([]byte(rule.TemplateParams), &stored); err != nil {
+			return "", templateLink{}, fmt.Errorf("failed to decode stored template parameters: %w", err)
+		}
+		// 카탈로그에서 빠진 파라미터는 버림
+		for _, param := range template.Parameters {
+			if value, ok := stored[param.Name]; ok {
+				merged[param.Name] = value
+			}
+		}
+	}
+	for name, value := range raw {
+		merged[name] = value
+	}
+
+	ruleText, params, err := s.renderTemplate(templateID, merged)
+	if err != nil {
+		return "", templateLink{}, err
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", templateLink{}, fmt.Errorf("failed to encode template parameters: %w", err)
+	}
+
+	previousIDs := s.ruleIDsOf(rule.RuleText)
+	ruleText, err = seclang.AssignIDs(ruleText, func() (int, error) {
+		if len(previousIDs) == 0 {
+			return 0, nil
+		}
+		id := previousIDs[0]
+		previousIDs = previousIDs[1:]
+		return id, nil
+	})
+	if err != nil {
+		return "", templateLink{}, err
+	}
+
+	return ruleText, templateLink{id: templateID, params: string(encoded)}, nil
+}
+
+// normalize 기본값을 채우고 타입별로 검증. 알 수 없는 파라미터도 오류
+func (t *ruleTemplate) normalize(raw map[string]interface{}) (templateParams, error) {
+	params := make(templateParams, len(t.Parameters))
+	known := make(map[string]bool, len(t.Parameters))
+	var problems []string
+
+	for i := range t.Parameters {
+		param := &t.Parameters[i]
+		known[param.Name] = true
+
+		value, ok := raw[param.Name]
+		if !ok || value == nil {
+			if param.Required {
+				problems = append(problems, fmt.Sprintf("%s is required", param.Name))
+				continue
+			}
+			value = param.Default
+		}
+		normalized, err := normalizeTemplateParam(param, value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", param.Name, err))
+			continue
+		}
+		params[param.Name] = normalized
+	}
+
+	var unknown []string
+	for name := range raw {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fmt.Sprintf("unknown parameter %s", name))
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid template parameters: %s", strings.Join(problems, "; "))
+	}
+	return params, nil
+}
+
+// normalizeTemplateParam JSON 값(float64, []interface{} 포함)을 파라미터 타입에 맞게 변환하고 검증
+func normalizeTemplateParam(param *dto.RuleTemplateParameter, value interface{}) (interface{}, error) {
+	switch param.Type {
+	case templateParamInt:
+		var n int
+		switch v := value.(type) {
+		case int:
+			n = v
+		case float64:
+			if v != float64(int(v)) {
+				return nil, fmt.Errorf("must be an integer")
+			}
+			n = int(v)
+		default:
+			return nil, fmt.Errorf("must be an integer")
+		}
+		if param.Min != nil && n < *param.Min {
+			return nil, fmt.Errorf("must be at least %d", *param.Min)
+		}
+		if param.Max != nil && n > *param.Max {
+			return nil, fmt.Errorf("must be at most %d", *param.Max)
+		}
+		return n, nil
+
+	case templateParamCIDRList, templateParamStringList:
+		var items []string
+		switch v := value.(type) {
+		case []string:
+			items = v
+		case []interface{}:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("must be a list of strings")
+				}
+				items = append(items, s)
+			}
+		default:
+			return nil, fmt.Errorf("must be a list of strings")
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("must not be empty")
+		}
+		normalized := make([]string, 0, len(items))
+		for _, item := range items {
+			item = strings.TrimSpace(item)
+			if err := validateTemplateListItem(param.Type, item); err != nil {
+				return nil, err
+			}
+			normalized = append(normalized, item)
+		}
+		return normalized, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("must be a string")
+	}
+	if s == "" {
+		return nil, fmt.Errorf("must not be empty")
+	}
+	if strings.ContainsAny(s, "\r\n") {
+		return nil, fmt.Errorf("must not contain line breaks")
+	}
+
+	switch param.Type {
+	case templateParamString:
+		if strings.ContainsAny(s, `'"\`) {
+			return nil, fmt.Errorf("must not contain quotes or backslashes")
+		}
+		if len(s) > 200 {
+			return nil, fmt.Errorf("must be at most 200 characters")
+		}
+	case templateParamEnum:
+		for _, option := range param.Options {
+			if s == option {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(param.Options, ", "))
+	case templateParamRegex:
+		// 룰 텍스트의 큰따옴표 안에 들어가므로 따옴표는 \x22로 쓰게 함
+		if strings.Contains(s, `"`) {
+			return nil, fmt.Errorf(`must not contain double quotes (use \x22)`)
+		}
+		if _, err := regexp.Compile(s); err != nil {
+			return nil, fmt.Errorf("is not a valid regular expression: %v", err)
+		}
+	case templateParamPath:
+		if !strings.HasPrefix(s, "/") || strings.ContainsAny(s, " \t'\"\\") {
+			return nil, fmt.Errorf("must start with / and contain no whitespace, quotes or backslashes")
+		}
+	case templateParamHeaderName:
+		if !headerNamePattern.MatchString(s) {
+			return nil, fmt.Errorf("must be a header name of letters, digits, - and _")
+		}
+	default:
+		return nil, fmt.Errorf("has unknown type %s", param.Type)
+	}
+	return s, nil
+}
+
+func validateTemplateListItem(paramType, item string) error {
+	if item == "" {
+		return fmt.Errorf("must not contain empty entries")
+	}
+	if paramType == templateParamCIDRList {
+		if net.ParseIP(item) != nil {
+			return nil
+		}
+		if _, _, err := net.ParseCIDR(item); err != nil {
+			return fmt.Errorf("entry %q is not an IP address or CIDR range", item)
+		}
+		return nil
+	}
+	if strings.ContainsAny(item, " \t\r\n'\"\\,") {
+		return fmt.Errorf("entry %q must not contain whitespace, quotes, backslashes or commas", item)
+	}
+	return nil
+}
+
+// decodeTemplateParams 저장된 파라미터 JSON (응답용, 잘못된 값이면 nil)
+func decodeTemplateParams(encoded string) map[string]interface{} {
+	if encoded == "" {
+		return nil
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(encoded), &params); err != nil {
+		return nil
+	}
+	return params
+}
+
+// renderChangeTemplate 변경 세트의 템플릿 변경을 렌더링해 change에 채움
+// update는 앞선 변경까지 적용한 룰의 템플릿, 파라미터, ID와 빈 필드 값을 이어받음
+func (s *RuleService) renderChangeTemplate(changeset *models.RuleChangeset, working map[string]*models.CustomRule, change *models.RuleChange, req *dto.RuleChangeRequest) error {
+	if change.Action == RuleChangeDelete {
+		return fmt.Errorf("template_id cannot be used with %s", change.Action)
+	}
+	if change.RuleText != "" {
+		return fmt.Errorf("rule_text (or rules) and template_id cannot be used together")
+	}
+
+	base := &models.CustomRule{}
+	if change.Action == RuleChangeUpdate {
+		current, exists := working[change.RuleID]
+		if !exists {
+			return fmt.Errorf("rule not found")
+		}
+		if current.UserID != changeset.UserID {
+			return fmt.Errorf("access denied")
+		}
+		base = current
+	}
+
+	templateID := req.TemplateID
+	if templateID == "" {
+		templateID = base.TemplateID
+	}
+	if templateID == "" {
+		return fmt.Errorf("template_id is required")
+	}
+	template, err := findRuleTemplate(templateID)
+	if err != nil {
+		return err
+	}
+	ruleText, link, err := s.rerenderTemplate(base, templateID, req.TemplateParams)
+	if err != nil {
+		return err
+	}
+	change.RuleText, change.TemplateID, change.TemplateParams = ruleText, link.id, link.params
+
+	defaults := base
+	if change.Action == RuleChangeCreate {
+		defaults = &models.CustomRule{Name: template.Name, Description: template.Description, Severity: template.Severity}
+	}
+	if change.Name == "" {
+		change.Name = defaults.Name
+	}
+	if change.Description == "" {
+		change.Description = defaults.Description
+	}
+	if change.Severity == "" {
+		change.Severity = defaults.Severity
+	}
+	return nil
+}
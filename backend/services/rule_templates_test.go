@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"waf-backend/dto"
+)
+
+func TestRuleServiceRenderRuleTemplate(t *testing.T) {
+	s := newTestRuleService(t, newTestDB(t), nil)
+
+	tests := []struct {
+		name       string
+		templateID string
+		params     map[string]interface{}
+		want       string
+		wantErr    string
+	}{
+		{"sqli defaults", "sqli", nil, `"@detectSQLi" "phase:2,deny,`, ""},
+		{"ip list", "ip_block", map[string]interface{}{"addresses": []interface{}{"10.0.0.0/8", "192.168.1.100"}}, `"@ipMatch 10.0.0.0/8,192.168.1.100"`, ""},
+		{"int from json", "path_block", map[string]interface{}{"path_regex": "^/admin/", "status": float64(404)}, "status:404,", ""},
+		{"rate limit", "rate_limit", map[string]interface{}{"path": "/login", "threshold": 10}, `"@gt 10"`, ""},
+		{"unknown template", "missing", nil, "", "not found"},
+		{"missing required", "ip_block", nil, "", "addresses is required"},
+		{"bad cidr", "ip_block", map[string]interface{}{"addresses": []interface{}{"10.0.0.300"}}, "", "is not an IP address or CIDR range"},
+		{"status out of range", "path_block", map[string]interface{}{"path_regex": "^/a", "status": 200}, "", "status must be at least 400"},
+		{"invalid regex", "path_block", map[string]interface{}{"path_regex": "(a"}, "", "is not a valid regular expression"},
+		{"quote in message", "sqli", map[string]interface{}{"message": `x' "y`}, "", "must not contain quotes"},
+		{"bad option", "sqli", map[string]interface{}{"action": "pass"}, "", "must be one of"},
+		{"unknown parameter", "sqli", map[string]interface{}{"extra": "x"}, "", "unknown parameter extra"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := s.RenderRuleTemplate(tt.templateID, tt.params)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RenderRuleTemplate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderRuleTemplate() error = %v", err)
+			}
+			if !strings.Contains(rendered.RuleText, tt.want) {
+				t.Errorf("RuleText = %s, want %s", rendered.RuleText, tt.want)
+			}
+		})
+	}
+}
+
+// 다시 렌더링하면 저장된 파라미터에 바뀐 값만 덮어쓰고 룰 ID를 유지
+func TestRuleServiceRerenderRule(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := newTestRuleService(t, db, nil)
+	rule, err := s.CreateRuleFromTemplate(ctx, "user_a", "path_block", testRuleIDRange, nil, &dto.RuleFromTemplateRequest{
+		Params:  map[string]interface{}{"path_regex": "^/admin/", "status": 404},
+		Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateRuleFromTemplate() error = %v", err)
+	}
+	if rule.Name != "경로 차단" || rule.Severity != "MEDIUM" || rule.TemplateID != "path_block" || !strings.Contains(rule.RuleText, "id:1000,") {
+		t.Fatalf("created rule = %+v", rule)
+	}
+	plain, err := s.CreateRule(ctx, "user_a", testRuleIDRange, nil, &dto.CustomRuleRequest{Name: "plain", RuleText: testRuleText, Severity: "LOW"})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		ruleID  string
+		params  map[string]interface{}
+		want    []string
+		wantErr string
+	}{
+		{"keeps stored params and id", "user_a", rule.ID, map[string]interface{}{"status": 410}, []string{`"@rx ^/admin/"`, "status:410,", "id:1000,"}, ""},
+		{"invalid params", "user_a", rule.ID, map[string]interface{}{"status": 100}, nil, "must be at least 400"},
+		{"not from template", "user_a", plain.ID, nil, nil, "not created from a template"},
+		{"other user", "user_b", rule.ID, nil, nil, "access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := s.RerenderRule(ctx, tt.userID, tt.ruleID, testRuleIDRange, nil, &dto.RuleTemplateRerenderRequest{Params: tt.params})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RerenderRule() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RerenderRule() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(updated.RuleText, want) {
+					t.Errorf("RuleText = %s, want %s", updated.RuleText, want)
+				}
+			}
+		})
+	}
+
+	// 템플릿 연결은 DB에 저장되고, rule_text를 직접 고치면 끊어짐
+	reloaded, err := newTestRuleService(t, db, nil).GetRule("user_a", rule.ID)
+	if err != nil || reloaded.TemplateID != "path_block" || reloaded.TemplateParams["status"] != float64(410) {
+		t.Fatalf("reloaded rule = %+v, %v", reloaded, err)
+	}
+	edited, err := s.UpdateRule(ctx, "user_a", rule.ID, testRuleIDRange, nil, &dto.CustomRuleRequest{Name: rule.Name, RuleText: `SecRule REQUEST_FILENAME "@rx ^/x/" "id:1000,phase:1,deny"`, Severity: "MEDIUM"})
+	if err != nil {
+		t.Fatalf("UpdateRule() error = %v", err)
+	}
+	if edited.TemplateID != "" || edited.TemplateParams != nil {
+		t.Errorf("template link after direct edit = %q %v", edited.TemplateID, edited.TemplateParams)
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,8 +11,10 @@ import (
 	"strings"
 	"time"
 	"waf-backend/dto"
+	"waf-backend/tracing"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type SecurityTestService struct {
@@ -35,12 +38,16 @@ func NewSecurityTestService(log *logrus.Logger) *SecurityTestService {
 		log:       log,
 		targetURL: targetURL,
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: tracing.Transport(nil),
 		},
 	}
 }
 
-func (s *SecurityTestService) RunSecurityTest(testType string, customPayloads []string) (*dto.SecurityTest, error) {
+func (s *SecurityTestService) RunSecurityTest(ctx context.Context, testType string, customPayloads []string) (*dto.SecurityTest, error) {
+	ctx, span := tracing.Start(ctx, "SecurityTestService.RunSecurityTest", attribute.String("test_type", testType))
+	defer span.End()
+	
 	s.log.WithFields(logrus.Fields{
 		"test_type": testType,
 		"payloads":  len(customPayloads),
@@ -66,7 +73,7 @@ func (s *SecurityTestService) RunSecurityTest(testType string, customPayloads []
 
 	// 각 페이로드에 대해 테스트 실행
 	for _, payload := range payloads {
-		result := s.testPayload(payload, testType)
+		result := s.testPayload(ctx, payload, testType)
 		test.Results = append(test.Results, result)
 		
 		// 테스트 간 잠깐의 지연
@@ -82,7 +89,7 @@ func (s *SecurityTestService) RunSecurityTest(testType string, customPayloads []
 	return test, nil
 }
 
-func (s *SecurityTestService) testPayload(payload, testType string) dto.SecurityResult {
+func (s *SecurityTestService) testPayload(ctx context.Context, payload, testType string) dto.SecurityResult {
 	result := dto.SecurityResult{
 		Payload:    payload,
 		Blocked:    false,
@@ -111,6 +118,9 @@ func (s *SecurityTestService) testPayload(payload, testType string) dto.Security
 		return result
 	}
 
+	// 요청 context의 trace를 이어서 traceparent 헤더 전파
+	req = req.WithContext(ctx)
+	
 	// WAF 테스트를 위한 추가 헤더 (Host는 각 함수에서 이미 설정됨)
 	req.Header.Set("User-Agent", "WAF-Security-Test/1.0")
 	req.Header.Set("X-Forwarded-For", "192.168.1.100") // 외부 IP로 위장
@@ -140,9 +150,7 @@ func (s *SecurityTestService) testPayload(payload, testType string) dto.Security
 	}
 
 	// 403 Forbidden 또는 406 Not Acceptable이면 WAF에 의해 차단된 것으로 간주
-	result.Blocked = (resp.StatusCode == 403 || resp.StatusCode == 406 || 
-		strings.Contains(result.Response, "ModSecurity") ||
-		strings.Contains(result.Response, "Access denied"))
+	result.Blocked = isBlockedResponse(resp.StatusCode, result.Response)
 
 	return result
 }
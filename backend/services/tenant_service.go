@@ -0,0 +1,359 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// 테넌트마다 1000개씩 배정하는 커스텀 룰 ID 블록 (CRS 900000번대 이전까지)
+	tenantRuleIDBase  = 100000
+	tenantRuleIDBlock = 1000
+	tenantRuleIDLimit = 899999
+
+	// 테넌트가 없는 사용자가 함께 쓰는 범위 (자동 차단 9900번대 이전까지)
+	defaultRuleIDStart = 1000
+	defaultRuleIDEnd   = 9899
+)
+
+type TenantService struct {
+	log         *logrus.Logger
+	db          *gorm.DB
+	authService *AuthService
+	ruleService *RuleService
+	tenants     map[string]*dto.Tenant
+	mutex       sync.RWMutex
+}
+
+func NewTenantService(log *logrus.Logger, db *gorm.DB, authService *AuthService, ruleService *RuleService) *TenantService {
+	service := &TenantService{
+		log:         log,
+		db:          db,
+		authService: authService,
+		ruleService: ruleService,
+		tenants:     make(map[string]*dto.Tenant),
+	}
+
+	var tenants []models.Tenant
+	if err := db.Find(&tenants).Error; err != nil {
+		log.WithError(err).Error("Failed to load tenants")
+	}
+	for i := range tenants {
+		service.tenants[tenants[i].ID] = tenantFromModel(&tenants[i])
+	}
+
+	return service
+}
+
+func (s *TenantService) CreateTenant(req *dto.TenantRequest) (*dto.Tenant, error) {
+	hosts, members, err := normalizeTenantRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkHostConflictsLocked("", hosts); err != nil {
+		return nil, err
+	}
+	idRange, err := s.allocateRuleIDRangeLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	tenant := &dto.Tenant{
+		ID:          generateTenantID(),
+		Name:        req.Name,
+		Hosts:       hosts,
+		Members:     members,
+		RuleIDRange: idRange,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.db.Create(tenantToModel(tenant)).Error; err != nil {
+		return nil, fmt.Errorf("failed to save tenant: %w", err)
+	}
+	s.tenants[tenant.ID] = tenant
+
+	s.log.WithFields(logrus.Fields{
+		"tenant_id": tenant.ID,
+		"name":      tenant.Name,
+		"hosts":     tenant.Hosts,
+		"rule_ids":  fmt.Sprintf("%d-%d", idRange.Start, idRange.End),
+	}).Info("Tenant created")
+
+	copied := *tenant
+	return &copied, nil
+}
+
+func (s *TenantService) UpdateTenant(tenantID string, req *dto.TenantRequest) (*dto.Tenant, error) {
+	hosts, members, err := normalizeTenantRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tenant, exists := s.tenants[tenantID]
+	if !exists {
+		return nil, fmt.Errorf("tenant not found")
+	}
+	if err := s.checkHostConflictsLocked(tenantID, hosts); err != nil {
+		return nil, err
+	}
+
+	updated := *tenant
+	updated.Name = req.Name
+	updated.Hosts = hosts
+	updated.Members = members
+	updated.UpdatedAt = time.Now()
+	if err := s.db.Save(tenantToModel(&updated)).Error; err != nil {
+		return nil, fmt.Errorf("failed to save tenant: %w", err)
+	}
+	*tenant = updated
+
+	s.log.WithFields(logrus.Fields{
+		"tenant_id": tenant.ID,
+		"hosts":     tenant.Hosts,
+	}).Info("Tenant updated")
+
+	copied := *tenant
+	return &copied, nil
+}
+
+func (s *TenantService) DeleteTenant(tenantID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.tenants[tenantID]; !exists {
+		return fmt.Errorf("tenant not found")
+	}
+	// 룰 ID 범위는 그 안에 룰이 남아 있는 동안 다른 테넌트에 배정되지 않음 (allocateRuleIDRangeLocked)
+	if err := s.db.Delete(&models.Tenant{}, "id = ?", tenantID).Error; err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	delete(s.tenants, tenantID)
+
+	s.log.WithField("tenant_id", tenantID).Info("Tenant deleted")
+	return nil
+}
+
+func (s *TenantService) GetTenants() []*dto.Tenant {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.sortedTenantsLocked(func(*dto.Tenant) bool { return true })
+}
+
+// GetUserTenants 사용자가 멤버로 속한 테넌트 목록
+func (s *TenantService) GetUserTenants(email string) []*dto.Tenant {
+	email = strings.ToLower(email)
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.sortedTenantsLocked(func(tenant *dto.Tenant) bool {
+		return containsString(tenant.Members, email)
+	})
+}
+
+// TenantForHost 호스트를 소유한 테넌트 ID (없으면 빈 문자열)
+func (s *TenantService) TenantForHost(host string) string {
+	host = normalizeHost(host)
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, tenant := range s.tenants {
+		if hostInList(host, tenant.Hosts) {
+			return tenant.ID
+		}
+	}
+	return ""
+}
+
+// RuleIDRangeFor 사용자가 커스텀 룰에 쓸 수 있는 ID 범위
+// 여러 테넌트에 속하면 가장 먼저 만들어진 테넌트의 범위, 테넌트가 없으면 공용 범위
+func (s *TenantService) RuleIDRangeFor(email string) dto.RuleIDRange {
+	if tenants := s.GetUserTenants(email); len(tenants) > 0 {
+		return tenants[0].RuleIDRange
+	}
+	return dto.RuleIDRange{Start: defaultRuleIDStart, End: defaultRuleIDEnd}
+}
+
+// RuleTargetHostsFor 사용자가 룰 대상으로 지정할 수 있는 호스트 (관리자는 nil = 제한 없음)
+// 테넌트가 없는 사용자는 빈 목록이라 배포되는 룰을 만들 수 없음 (ScopeFor에서 아무 이벤트도 보지 못하는 것과 같음)
+func (s *TenantService) RuleTargetHostsFor(email string) []string {
+	if s.authService.IsAdmin(email) {
+		return nil
+	}
+
+	hosts := make([]string, 0)
+	for _, tenant := range s.GetUserTenants(email) {
+		hosts = append(hosts, tenant.Hosts...)
+	}
+	return hosts
+}
+
+// ScopeFor 사용자가 조회할 수 있는 이벤트 필터 (관리자는 전체)
+// 호출 시점의 호스트 목록을 복사하므로 반환된 필터는 락 없이 사용 가능
+func (s *TenantService) ScopeFor(email string) LogFilter {
+	if s.authService.IsAdmin(email) {
+		return nil
+	}
+
+	var hosts []string
+	for _, tenant := range s.GetUserTenants(email) {
+		hosts = append(hosts, tenant.Hosts...)
+	}
+
+	return func(wafLog *dto.WAFLog) bool {
+		return len(hosts) > 0 && hostInList(normalizeHost(wafLog.Host), hosts)
+	}
+}
+
+func (s *TenantService) checkHostConflictsLocked(tenantID string, hosts []string) error {
+	for _, tenant := range s.tenants {
+		if tenant.ID == tenantID {
+			continue
+		}
+		for _, host := range hosts {
+			for _, owned := range tenant.Hosts {
+				if hostsOverlap(host, owned) {
+					return fmt.Errorf("host %s overlaps %s of tenant %s", host, owned, tenant.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// allocateRuleIDRangeLocked 다른 테넌트가 쓰지 않고 남아 있는 룰도 없는 가장 낮은 ID 블록
+// (삭제된 테넌트의 룰이 남아 있는 블록은 새 테넌트 멤버가 그 룰을 덮어쓰지 않도록 건너뜀)
+func (s *TenantService) allocateRuleIDRangeLocked() (dto.RuleIDRange, error) {
+	used := make(map[int]bool)
+	for _, tenant := range s.tenants {
+		used[tenant.RuleIDRange.Start] = true
+	}
+
+	for start := tenantRuleIDBase; start+tenantRuleIDBlock-1 <= tenantRuleIDLimit; start += tenantRuleIDBlock {
+		idRange := dto.RuleIDRange{Start: start, End: start + tenantRuleIDBlock - 1}
+		if !used[start] && !s.ruleService.HasRulesInIDRange(idRange) {
+			return idRange, nil
+		}
+	}
+	return dto.RuleIDRange{}, fmt.Errorf("no free rule ID range left for new tenant")
+}
+
+func (s *TenantService) sortedTenantsLocked(include func(*dto.Tenant) bool) []*dto.Tenant {
+	result := make([]*dto.Tenant, 0)
+	for _, tenant := range s.tenants {
+		if include(tenant) {
+			copied := *tenant
+			result = append(result, &copied)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+func normalizeTenantRequest(req *dto.TenantRequest) ([]string, []string, error) {
+	hosts := make([]string, 0, len(req.Hosts))
+	for _, host := range req.Hosts {
+		normalized := normalizeHost(host)
+		if normalized == "" || strings.ContainsAny(normalized, " /") ||
+			strings.Contains(strings.TrimPrefix(normalized, "*."), "*") {
+			return nil, nil, fmt.Errorf("invalid host: %s", host)
+		}
+		if !containsString(hosts, normalized) {
+			hosts = append(hosts, normalized)
+		}
+	}
+
+	members := make([]string, 0, len(req.Members))
+	for _, member := range req.Members {
+		member = strings.ToLower(strings.TrimSpace(member))
+		if member != "" && !containsString(members, member) {
+			members = append(members, member)
+		}
+	}
+
+	return hosts, members, nil
+}
+
+// hostInList 정확히 일치하거나 *.example.com 패턴의 하위 도메인이면 true
+func hostInList(host string, patterns []string) bool {
+	if host == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if pattern == host {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostsOverlap 두 호스트 패턴이 같은 호스트를 포함할 수 있으면 true
+// (*.example.com 과 a.example.com, *.example.com 과 *.a.example.com)
+func hostsOverlap(a, b string) bool {
+	return hostInList(a, []string{b}) || hostInList(b, []string{a})
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func tenantToModel(tenant *dto.Tenant) *models.Tenant {
+	return &models.Tenant{
+		ID:          tenant.ID,
+		Name:        tenant.Name,
+		Hosts:       encodeStrings(tenant.Hosts),
+		Members:     encodeStrings(tenant.Members),
+		RuleIDStart: tenant.RuleIDRange.Start,
+		RuleIDEnd:   tenant.RuleIDRange.End,
+		CreatedAt:   tenant.CreatedAt,
+		UpdatedAt:   tenant.UpdatedAt,
+	}
+}
+
+func tenantFromModel(model *models.Tenant) *dto.Tenant {
+	members := decodeStrings(model.Members)
+	if members == nil {
+		members = []string{}
+	}
+	return &dto.Tenant{
+		ID:          model.ID,
+		Name:        model.Name,
+		Hosts:       decodeStrings(model.Hosts),
+		Members:     members,
+		RuleIDRange: dto.RuleIDRange{Start: model.RuleIDStart, End: model.RuleIDEnd},
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+	}
+}
+
+func generateTenantID() string {
+	return fmt.Sprintf("tenant_%d", time.Now().UnixNano())
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"waf-backend/config"
+	"waf-backend/dto"
+	"waf-backend/models"
+
+	"gorm.io/gorm"
+)
+
+const testAdminEmail = "admin@example.com"
+
+// newTestTenantService testAdminEmail만 관리자인 TenantService (db에 테넌트/커스텀 룰 테이블 생성)
+func newTestTenantService(t *testing.T, db *gorm.DB) *TenantService {
+	t.Helper()
+	if err := db.AutoMigrate(&models.Tenant{}); err != nil {
+		t.Fatalf("failed to migrate tenants: %v", err)
+	}
+	authService := NewAuthService(&config.Config{Security: config.SecurityConfig{AdminEmails: []string{testAdminEmail}}}, newTestLogger())
+	return NewTenantService(newTestLogger(), db, authService, newTestRuleService(t, db, nil))
+}
+
+func TestHostsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"shop.example.com", "shop.example.com", true},
+		{"*.example.com", "a.example.com", true},
+		{"a.example.com", "*.example.com", true},
+		{"*.example.com", "*.a.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"a.example.com", "b.example.com", false},
+		{"*.example.com", "badexample.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := hostsOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("hostsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTenantServiceCreateTenant(t *testing.T) {
+	s := newTestTenantService(t, newTestDB(t))
+	if _, err := s.CreateTenant(&dto.TenantRequest{Name: "shop", Hosts: []string{"*.shop.example.com"}}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		req         dto.TenantRequest
+		wantHosts   []string
+		wantMembers []string
+		wantErr     string
+	}{
+		{
+			name:        "normalized",
+			req:         dto.TenantRequest{Name: "blog", Hosts: []string{" Blog.Example.com:443 ", "blog.example.com."}, Members: []string{"Kim@Example.com", "kim@example.com", ""}},
+			wantHosts:   []string{"blog.example.com"},
+			wantMembers: []string{"kim@example.com"},
+		},
+		{name: "exact overlap", req: dto.TenantRequest{Name: "x", Hosts: []string{"blog.example.com"}}, wantErr: "overlaps"},
+		{name: "covered by wildcard", req: dto.TenantRequest{Name: "x", Hosts: []string{"api.shop.example.com"}}, wantErr: "overlaps"},
+		{name: "wildcard covers other", req: dto.TenantRequest{Name: "x", Hosts: []string{"*.example.com"}}, wantErr: "overlaps"},
+		{name: "inner wildcard", req: dto.TenantRequest{Name: "x", Hosts: []string{"a.*.example.com"}}, wantErr: "invalid host"},
+		{name: "path", req: dto.TenantRequest{Name: "x", Hosts: []string{"example.com/admin"}}, wantErr: "invalid host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant, err := s.CreateTenant(&tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CreateTenant() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateTenant() error = %v", err)
+			}
+			if !reflect.DeepEqual(tenant.Hosts, tt.wantHosts) || !reflect.DeepEqual(tenant.Members, tt.wantMembers) {
+				t.Errorf("tenant hosts %v members %v, want %v %v", tenant.Hosts, tenant.Members, tt.wantHosts, tt.wantMembers)
+			}
+		})
+	}
+}
+
+func TestTenantServiceScopeFor(t *testing.T) {
+	s := newTestTenantService(t, newTestDB(t))
+	if _, err := s.CreateTenant(&dto.TenantRequest{Name: "shop", Hosts: []string{"shop.example.com", "*.shop.example.com"}, Members: []string{"kim@example.com"}}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if _, err := s.CreateTenant(&dto.TenantRequest{Name: "blog", Hosts: []string{"blog.example.com"}, Members: []string{"lee@example.com"}}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	if s.ScopeFor(testAdminEmail) != nil {
+		t.Error("ScopeFor(admin) is not nil")
+	}
+
+	tests := []struct {
+		email string
+		host  string
+		want  bool
+	}{
+		{"kim@example.com", "shop.example.com", true},
+		{"KIM@example.com", "API.shop.example.com:8443", true},
+		{"kim@example.com", "blog.example.com", false},
+		{"kim@example.com", "", false},
+		{"lee@example.com", "blog.example.com", true},
+		{"lee@example.com", "shop.example.com", false},
+		{"nobody@example.com", "shop.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email+" "+tt.host, func(t *testing.T) {
+			if got := s.ScopeFor(tt.email)(&dto.WAFLog{Host: tt.host}); got != tt.want {
+				t.Errorf("ScopeFor(%s)(%s) = %v, want %v", tt.email, tt.host, got, tt.want)
+			}
+		})
+	}
+
+	if got := s.TenantForHost("www.shop.example.com"); got == "" {
+		t.Error("TenantForHost(www.shop.example.com) is empty")
+	}
+}
+
+// 저장한 테넌트가 새 서비스에서 그대로 로드되고, 삭제하면 사라짐
+func TestTenantServicePersistence(t *testing.T) {
+	db := newTestDB(t)
+	s := newTestTenantService(t, db)
+	tenant, err := s.CreateTenant(&dto.TenantRequest{Name: "shop", Hosts: []string{"shop.example.com"}})
+	if err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	updated, err := s.UpdateTenant(tenant.ID, &dto.TenantRequest{Name: "shop", Hosts: []string{"shop.example.com", "*.shop.example.com"}, Members: []string{"kim@example.com"}})
+	if err != nil {
+		t.Fatalf("UpdateTenant() error = %v", err)
+	}
+
+	reloaded := newTestTenantService(t, db)
+	got := reloaded.GetUserTenants("kim@example.com")
+	if len(got) != 1 {
+		t.Fatalf("GetUserTenants() after reload = %v", got)
+	}
+	got[0].CreatedAt, got[0].UpdatedAt = updated.CreatedAt, updated.UpdatedAt
+	if !reflect.DeepEqual(got[0], updated) {
+		t.Errorf("reloaded tenant = %+v, want %+v", got[0], updated)
+	}
+
+	if err := reloaded.DeleteTenant(tenant.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+	if tenants := newTestTenantService(t, db).GetTenants(); len(tenants) != 0 {
+		t.Errorf("deleted tenant is still loaded: %v", tenants)
+	}
+	if err := reloaded.DeleteTenant(tenant.ID); err == nil {
+		t.Error("DeleteTenant(deleted) succeeded")
+	}
+}
+
+// 테넌트마다 겹치지 않는 룰 ID 블록을 배정하고, 룰이 남은 블록은 다시 배정하지 않음
+func TestTenantServiceRuleIDRanges(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := newTestTenantService(t, db)
+
+	shop, err := s.CreateTenant(&dto.TenantRequest{Name: "shop", Hosts: []string{"shop.example.com"}, Members: []string{"kim@example.com"}})
+	if err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	blog, err := s.CreateTenant(&dto.TenantRequest{Name: "blog", Hosts: []string{"blog.example.com"}})
+	if err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if _, err := s.ruleService.CreateRule(ctx, "user_a", shop.RuleIDRange, nil, &dto.CustomRuleRequest{Name: "r", RuleText: `SecRule ARGS "@rx a" "phase:2,deny"`, Enabled: true}); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	if err := s.DeleteTenant(shop.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+	if err := s.DeleteTenant(blog.ID); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+	news, err := s.CreateTenant(&dto.TenantRequest{Name: "news", Hosts: []string{"news.example.com"}, Members: []string{"lee@example.com"}})
+	if err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  dto.RuleIDRange
+		want dto.RuleIDRange
+	}{
+		{"first tenant", shop.RuleIDRange, dto.RuleIDRange{Start: 100000, End: 100999}},
+		{"second tenant", blog.RuleIDRange, dto.RuleIDRange{Start: 101000, End: 101999}},
+		{"block with rules skipped", news.RuleIDRange, dto.RuleIDRange{Start: 101000, End: 101999}},
+		{"member", s.RuleIDRangeFor("LEE@example.com"), news.RuleIDRange},
+		{"no tenant", s.RuleIDRangeFor("kim@example.com"), dto.RuleIDRange{Start: defaultRuleIDStart, End: defaultRuleIDEnd}},
+		{"reloaded", newTestTenantService(t, db).RuleIDRangeFor("lee@example.com"), news.RuleIDRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("range = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
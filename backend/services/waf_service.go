@@ -1,6 +1,8 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os/exec"
 	"regexp"
@@ -10,6 +12,7 @@ import (
 	"sync"
 	"time"
 	"waf-backend/dto"
+	"waf-backend/metrics"
 	"waf-backend/utils"
 
 	"github.com/sirupsen/logrus"
@@ -20,15 +23,26 @@ type WAFService struct {
 	logs       []dto.WAFLog
 	mutex      sync.RWMutex
 	logFile    string
+	listeners  []LogListener
+	redactor   *RedactionService
+	erased     map[string]bool // 삭제/익명화된 원본 로그 해시 (재수집 방지)
 }
 
-func NewWAFService(log *logrus.Logger) *WAFService {
+// LogListener 새로 수집된 WAF 로그를 전달받는 콜백
+type LogListener func(log dto.WAFLog)
+
+// LogFilter 조회 범위를 제한하는 조건 (nil이면 전체, 테넌트 범위 등)
+type LogFilter func(log *dto.WAFLog) bool
+
+func NewWAFService(log *logrus.Logger, redactor *RedactionService) *WAFService {
 	logFile := utils.GetEnv("MODSECURITY_LOG_FILE", "/var/log/nginx/modsec_audit.log")
 	
 	service := &WAFService{
-		log:     log,
-		logs:    make([]dto.WAFLog, 0),
-		logFile: logFile,
+		log:      log,
+		logs:     make([]dto.WAFLog, 0),
+		logFile:  logFile,
+		redactor: redactor,
+		erased:   make(map[string]bool),
 	}
 	
 	// 시작시 기존 로그를 파싱
@@ -40,7 +54,55 @@ func NewWAFService(log *logrus.Logger) *WAFService {
 	return service
 }
 
-func (s *WAFService) GetLogs(limit int) []dto.WAFLog {
+// AddLogListener 새 로그가 저장될 때마다 호출될 리스너를 등록
+func (s *WAFService) AddLogListener(listener LogListener) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	s.listeners = append(s.listeners, listener)
+}
+
+// storeLog 로그를 저장하고 등록된 리스너들에게 전달 (중복이면 false 반환)
+// 민감 정보는 저장/전달 전에 마스킹되므로 리스너와 조회 API는 마스킹된 값만 보게 됨
+func (s *WAFService) storeLog(wafLog dto.WAFLog, checkDuplicate bool) bool {
+	if s.redactor != nil {
+		s.redactor.RedactLog(&wafLog)
+	}
+	
+	s.mutex.Lock()
+	if checkDuplicate {
+		if s.erased[rawLogHash(wafLog.RawLog)] {
+			s.mutex.Unlock()
+			return false
+		}
+		for _, existingLog := range s.logs {
+			if existingLog.RawLog == wafLog.RawLog {
+				s.mutex.Unlock()
+				return false
+			}
+		}
+	}
+	
+	s.logs = append(s.logs, wafLog)
+	
+	// 메모리 관리: 최대 1000개의 로그만 유지
+	if len(s.logs) > 1000 {
+		s.logs = s.logs[len(s.logs)-1000:]
+	}
+	
+	listeners := make([]LogListener, len(s.listeners))
+	copy(listeners, s.listeners)
+	s.mutex.Unlock()
+	
+	// 리스너는 락 밖에서 호출 (리스너가 GetLogs 등을 호출할 수 있음)
+	for _, listener := range listeners {
+		listener(wafLog)
+	}
+	
+	return true
+}
+
+func (s *WAFService) GetLogs(limit int, filter LogFilter) []dto.WAFLog {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	
@@ -49,9 +111,12 @@ func (s *WAFService) GetLogs(limit int) []dto.WAFLog {
 	}
 	
 	// 최신 로그부터 반환
-	result := make([]dto.WAFLog, limit)
-	start := len(s.logs) - limit
-	copy(result, s.logs[start:])
+	result := make([]dto.WAFLog, 0, limit)
+	for i := len(s.logs) - 1; i >= 0 && len(result) < limit; i-- {
+		if filter == nil || filter(&s.logs[i]) {
+			result = append(result, s.logs[i])
+		}
+	}
 	
 	// 시간 순서대로 정렬 (최신 먼저)
 	sort.Slice(result, func(i, j int) bool {
@@ -61,7 +126,89 @@ func (s *WAFService) GetLogs(limit int) []dto.WAFLog {
 	return result
 }
 
-func (s *WAFService) GetStats() *dto.WAFStats {
+// GetLogByID 저장된 로그 하나를 ID로 조회 (조회 범위 밖이면 없는 것으로 처리)
+func (s *WAFService) GetLogByID(id string, filter LogFilter) (*dto.WAFLog, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	
+	for i := range s.logs {
+		if s.logs[i].ID == id && (filter == nil || filter(&s.logs[i])) {
+			found := s.logs[i]
+			return &found, nil
+		}
+	}
+	
+	return nil, fmt.Errorf("log not found")
+}
+
+// FindLogs 조건에 맞는 로그를 최신 순으로 반환
+func (s *WAFService) FindLogs(match func(*dto.WAFLog) bool) []dto.WAFLog {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	
+	result := make([]dto.WAFLog, 0)
+	for i := len(s.logs) - 1; i >= 0; i-- {
+		if match(&s.logs[i]) {
+			result = append(result, s.logs[i])
+		}
+	}
+	return result
+}
+
+// EraseLogs 조건에 맞는 로그를 삭제 (anonymize가 있으면 삭제 대신 해당 함수로 익명화)
+// 처리된 로그의 원본은 다시 수집되지 않도록 해시를 남기고, 처리된 로그 ID 목록 반환
+func (s *WAFService) EraseLogs(match func(*dto.WAFLog) bool, anonymize func(*dto.WAFLog)) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	ids := make([]string, 0)
+	kept := s.logs[:0]
+	for i := range s.logs {
+		wafLog := s.logs[i]
+		if !match(&wafLog) {
+			kept = append(kept, wafLog)
+			continue
+		}
+		
+		ids = append(ids, wafLog.ID)
+		s.erased[rawLogHash(wafLog.RawLog)] = true
+		if anonymize != nil {
+			anonymize(&wafLog)
+			kept = append(kept, wafLog)
+		}
+	}
+	s.logs = kept
+	
+	return ids
+}
+
+// GetLogsInRange [from, to] 구간의 로그를 오래된 순으로 반환 (zero 값이면 해당 방향 제한 없음)
+func (s *WAFService) GetLogsInRange(from, to time.Time, filter LogFilter) []dto.WAFLog {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	
+	result := make([]dto.WAFLog, 0)
+	for _, log := range s.logs {
+		if !from.IsZero() && log.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && log.Timestamp.After(to) {
+			continue
+		}
+		if filter != nil && !filter(&log) {
+			continue
+		}
+		result = append(result, log)
+	}
+	
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	
+	return result
+}
+
+func (s *WAFService) GetStats(filter LogFilter) *dto.WAFStats {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	
@@ -74,6 +221,9 @@ func (s *WAFService) GetStats() *dto.WAFStats {
 	ipCounts := make(map[string]dto.IPStat)
 	
 	for _, log := range s.logs {
+		if filter != nil && !filter(&log) {
+			continue
+		}
 		stats.TotalRequests++
 		
 		if log.Blocked {
@@ -119,7 +269,7 @@ func (s *WAFService) GetStats() *dto.WAFStats {
 	}
 	
 	// 최근 로그 10개
-	recentLogs := s.GetLogs(10)
+	recentLogs := s.GetLogs(10, filter)
 	stats.RecentLogs = recentLogs
 	
 	return stats
@@ -147,6 +297,7 @@ func (s *WAFService) fetchIngressLogs() {
 	output, err := cmd.Output()
 	if err != nil {
 		s.log.WithError(err).Warn("Failed to fetch ingress logs, using sample data")
+		metrics.RecordFetchError("ingress")
 		s.parseSampleModSecurityLogs()
 		return
 	}
@@ -161,27 +312,17 @@ func (s *WAFService) parseIngressLogOutput(logOutput string) {
 	for _, line := range lines {
 		// ModSecurity 로그만 필터링
 		if strings.Contains(line, "ModSecurity") && strings.Contains(line, "Access denied") {
-			if wafLog := s.parseLogLine(line); wafLog != nil {
-				// 중복 확인
-				s.mutex.Lock()
-				exists := false
-				for _, existingLog := range s.logs {
-					if existingLog.RawLog == line {
-						exists = true
-						break
-					}
-				}
-				
-				if !exists {
-					s.logs = append(s.logs, *wafLog)
+			wafLog := s.parseLogLine(line)
+			if wafLog == nil || wafLog.RuleID == "" {
+				// 룰 ID를 찾지 못한 라인은 형식이 바뀐 것으로 보고 파싱 오류로 집계
+				metrics.RecordParseError("ingress")
+			}
+			if wafLog != nil {
+				// 중복이 아닌 경우에만 저장
+				if s.storeLog(*wafLog, true) {
+					metrics.RecordIngest("ingress", wafLog.Timestamp)
 					newLogs++
-					
-					// 메모리 관리: 최대 1000개의 로그만 유지
-					if len(s.logs) > 1000 {
-						s.logs = s.logs[len(s.logs)-1000:]
-					}
 				}
-				s.mutex.Unlock()
 			}
 		}
 	}
@@ -200,26 +341,11 @@ func (s *WAFService) parseSampleModSecurityLogs() {
 	newLogs := 0
 	for _, line := range sampleLogs {
 		if wafLog := s.parseLogLine(line); wafLog != nil {
-			// Check if this log already exists (simple deduplication)
-			s.mutex.Lock()
-			exists := false
-			for _, existingLog := range s.logs {
-				if existingLog.RawLog == line {
-					exists = true
-					break
-				}
-			}
-			
-			if !exists {
-				s.logs = append(s.logs, *wafLog)
+			// Store only if this log doesn't already exist (simple deduplication)
+			if s.storeLog(*wafLog, true) {
+				metrics.RecordIngest("sample", wafLog.Timestamp)
 				newLogs++
-				
-				// Memory management: keep max 1000 logs
-				if len(s.logs) > 1000 {
-					s.logs = s.logs[len(s.logs)-1000:]
-				}
 			}
-			s.mutex.Unlock()
 		}
 	}
 	
@@ -320,6 +446,29 @@ func (s *WAFService) parseLogLine(line string) *dto.WAFLog {
 		}).Debug("URL parsing result")
 	}
 	
+	// 매칭된 변수 파싱 (예: against variable `ARGS:id', 또는 [data "... found within ARGS:id: ..."])
+	matchedVarRegex := regexp.MustCompile("against variable [`']([^'`]+)'")
+	dataVarRegex := regexp.MustCompile(`found within ([A-Z_]+(?::[^:\s"\]]+)?)`)
+	if matches := matchedVarRegex.FindStringSubmatch(line); len(matches) > 1 {
+		wafLog.MatchedVar = matches[1]
+	}
+	// TX 변수(anomaly score 등)는 실제 요청 변수가 아니므로 data 필드에서 다시 확인
+	if wafLog.MatchedVar == "" || strings.HasPrefix(wafLog.MatchedVar, "TX:") {
+		if matches := dataVarRegex.FindStringSubmatch(line); len(matches) > 1 {
+			wafLog.MatchedVar = matches[1]
+		}
+	}
+	
+	// Host 헤더 파싱 (nginx error log의 host: "..." 필드)
+	hostRegex := regexp.MustCompile(`host: "([^"]+)"`)
+	hostnameRegex := regexp.MustCompile(`\[hostname "([^"]+)"\]`)
+	if matches := hostRegex.FindStringSubmatch(line); len(matches) > 1 {
+		wafLog.Host = matches[1]
+	} else if matches := hostnameRegex.FindStringSubmatch(line); len(matches) > 1 {
+		// 요청 라인이 없는 audit 로그는 ModSecurity [hostname] 필드로 대체
+		wafLog.Host = matches[1]
+	}
+	
 	// User-Agent 파싱
 	uaRegex := regexp.MustCompile(`"User-Agent: ([^"]+)"`)
 	if matches := uaRegex.FindStringSubmatch(line); len(matches) > 1 {
@@ -536,6 +685,11 @@ func (s *WAFService) mapSeverityToText(severityStr string) string {
 	}
 }
 
+func rawLogHash(rawLog string) string {
+	sum := sha256.Sum256([]byte(rawLog))
+	return hex.EncodeToString(sum[:])
+}
+
 func generateLogID() string {
 	return fmt.Sprintf("log_%d_%d", time.Now().Unix(), time.Now().Nanosecond()%1000000)
 }
@@ -601,9 +755,6 @@ func (s *WAFService) generateRealisticMessage(attackType string, blocked bool) s
 
 // AddMockLog adds a mock WAF log for testing purposes
 func (s *WAFService) AddMockLog(clientIP, method, uri, userAgent, attackType string, blocked bool) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	
 	// Generate realistic rule ID and severity based on attack type
 	ruleID, severity := s.generateRuleIDAndSeverity(attackType, blocked)
 	
@@ -628,7 +779,8 @@ func (s *WAFService) AddMockLog(clientIP, method, uri, userAgent, attackType str
 			userAgent),
 	}
 	
-	s.logs = append(s.logs, mockLog)
+	s.storeLog(mockLog, false)
+	metrics.RecordIngest("mock", mockLog.Timestamp)
 	s.log.WithFields(logrus.Fields{
 		"client_ip": clientIP,
 		"blocked": blocked,
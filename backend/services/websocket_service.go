@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 	"waf-backend/dto"
+	"waf-backend/metrics"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -22,13 +23,20 @@ var upgrader = websocket.Upgrader{
 }
 
 type WebSocketService struct {
-	log        *logrus.Logger
-	wafService *WAFService
-	clients    map[*websocket.Conn]*Client
-	clientsMux sync.RWMutex
-	broadcast  chan []byte
-	register   chan *Client
-	unregister chan *Client
+	log           *logrus.Logger
+	wafService    *WAFService
+	tenantService *TenantService
+	clients       map[*websocket.Conn]*Client
+	clientsMux    sync.RWMutex
+	register      chan *Client
+	unregister    chan *Client
+	direct        chan clientMessage
+}
+
+// clientMessage 특정 클라이언트 전용 메시지 (테넌트별 통계 등)
+type clientMessage struct {
+	client *Client
+	data   []byte
 }
 
 type Client struct {
@@ -44,14 +52,15 @@ type WebSocketMessage struct {
 	Timestamp time.Time   `json:"timestamp"`
 }
 
-func NewWebSocketService(log *logrus.Logger, wafService *WAFService) *WebSocketService {
+func NewWebSocketService(log *logrus.Logger, wafService *WAFService, tenantService *TenantService) *WebSocketService {
 	service := &WebSocketService{
-		log:        log,
-		wafService: wafService,
-		clients:    make(map[*websocket.Conn]*Client),
-		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		log:           log,
+		wafService:    wafService,
+		tenantService: tenantService,
+		clients:       make(map[*websocket.Conn]*Client),
+		register:      make(chan *Client),
+		unregister:    make(chan *Client),
+		direct:        make(chan clientMessage, 256),
 	}
 	
 	// WebSocket 허브 실행
@@ -124,8 +133,8 @@ func (s *WebSocketService) run() {
 				}
 			}
 			
-			// 초기 통계 전송
-			stats := s.wafService.GetStats()
+			// 초기 통계 전송 (사용자 테넌트 범위)
+			stats := s.wafService.GetStats(s.tenantService.ScopeFor(client.email))
 			statsMessage := WebSocketMessage{
 				Type:      "stats",
 				Data:      stats,
@@ -152,14 +161,13 @@ func (s *WebSocketService) run() {
 			}
 			s.clientsMux.Unlock()
 			
-		case message := <-s.broadcast:
+		case message := <-s.direct:
 			s.clientsMux.RLock()
-			for conn, client := range s.clients {
+			if _, ok := s.clients[message.client.conn]; ok {
 				select {
-				case client.send <- message:
+				case message.client.send <- message.data:
 				default:
-					close(client.send)
-					delete(s.clients, conn)
+					metrics.RecordWebSocketDrop("client_buffer_full")
 				}
 			}
 			s.clientsMux.RUnlock()
@@ -242,7 +250,7 @@ func (s *WebSocketService) handleClientMessage(client *Client, msg map[string]in
 			limit = int(limitVal)
 		}
 		
-		logs := s.wafService.GetLogs(limit)
+		logs := s.wafService.GetLogs(limit, s.tenantService.ScopeFor(client.email))
 		response := WebSocketMessage{
 			Type:      "logs",
 			Data:      logs,
@@ -254,12 +262,13 @@ func (s *WebSocketService) handleClientMessage(client *Client, msg map[string]in
 			case client.send <- data:
 			default:
 				// 클라이언트 전송 버퍼가 가득 참
+				metrics.RecordWebSocketDrop("client_buffer_full")
 			}
 		}
 		
 	case "get_stats":
 		// 통계 요청
-		stats := s.wafService.GetStats()
+		stats := s.wafService.GetStats(s.tenantService.ScopeFor(client.email))
 		response := WebSocketMessage{
 			Type:      "stats",
 			Data:      stats,
@@ -271,6 +280,7 @@ func (s *WebSocketService) handleClientMessage(client *Client, msg map[string]in
 			case client.send <- data:
 			default:
 				// 클라이언트 전송 버퍼가 가득 참
+				metrics.RecordWebSocketDrop("client_buffer_full")
 			}
 		}
 	}
@@ -281,23 +291,22 @@ func (s *WebSocketService) broadcastStats() {
 	defer ticker.Stop()
 	
 	for range ticker.C {
-		stats := s.wafService.GetStats()
-		message := WebSocketMessage{
-			Type:      "stats_update",
-			Data:      stats,
-			Timestamp: time.Now(),
-		}
-		
-		if data, err := json.Marshal(message); err == nil {
-			select {
-			case s.broadcast <- data:
-			default:
-				// 브로드캐스트 채널이 가득 참
+		// 클라이언트마다 테넌트 범위가 다르므로 각자 통계를 계산해서 전송
+		for _, client := range s.snapshotClients() {
+			message := WebSocketMessage{
+				Type:      "stats_update",
+				Data:      s.wafService.GetStats(s.tenantService.ScopeFor(client.email)),
+				Timestamp: time.Now(),
+			}
+			
+			if data, err := json.Marshal(message); err == nil {
+				s.sendToClient(client, data)
 			}
 		}
 	}
 }
 
+// BroadcastNewLog 이벤트가 속한 테넌트의 클라이언트에게만 전송
 func (s *WebSocketService) BroadcastNewLog(log *dto.WAFLog) {
 	message := WebSocketMessage{
 		Type:      "new_log",
@@ -305,15 +314,38 @@ func (s *WebSocketService) BroadcastNewLog(log *dto.WAFLog) {
 		Timestamp: time.Now(),
 	}
 	
-	if data, err := json.Marshal(message); err == nil {
-		select {
-		case s.broadcast <- data:
-		default:
-			// 브로드캐스트 채널이 가득 참
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	
+	for _, client := range s.snapshotClients() {
+		if scope := s.tenantService.ScopeFor(client.email); scope == nil || scope(log) {
+			s.sendToClient(client, data)
 		}
 	}
 }
 
+func (s *WebSocketService) snapshotClients() []*Client {
+	s.clientsMux.RLock()
+	defer s.clientsMux.RUnlock()
+	
+	clients := make([]*Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// sendToClient 특정 클라이언트에게 전송 (send 채널은 허브만 다루므로 허브를 거쳐 전달)
+func (s *WebSocketService) sendToClient(client *Client, data []byte) {
+	select {
+	case s.direct <- clientMessage{client: client, data: data}:
+	default:
+		metrics.RecordWebSocketDrop("direct_full")
+	}
+}
+
 func (s *WebSocketService) GetConnectedClients() int {
 	s.clientsMux.RLock()
 	defer s.clientsMux.RUnlock()
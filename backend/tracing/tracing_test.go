@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"waf-backend/config"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestRecorder 전역 TracerProvider를 span recorder로 교체 (테스트 종료 시 복원)
+func newTestRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	if _, err := Init(&config.Config{}, log); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestInitDisabled(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	shutdown, err := Init(&config.Config{Tracing: config.TracingConfig{Enabled: false}}, log)
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+}
+
+func TestEnd(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+		wantEvents int
+	}{
+		{"success", nil, codes.Unset, 0},
+		{"failure", errors.New("deploy failed"), codes.Error, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newTestRecorder(t)
+			_, span := Start(context.Background(), "RuleService.Deploy")
+			End(span, tt.err)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("ended spans = %d, want 1", len(spans))
+			}
+			if spans[0].Name() != "RuleService.Deploy" || spans[0].Status().Code != tt.wantStatus || len(spans[0].Events()) != tt.wantEvents {
+				t.Errorf("span %s status %v events %d", spans[0].Name(), spans[0].Status(), len(spans[0].Events()))
+			}
+		})
+	}
+}
+
+// 나가는 요청에 현재 trace의 traceparent가 전파됨
+func TestTransportPropagatesTraceContext(t *testing.T) {
+	recorder := newTestRecorder(t)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, span := Start(context.Background(), "SecurityTestService.RunTest")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	span.End()
+
+	traceID := span.SpanContext().TraceID().String()
+	if len(traceparent) != 55 || traceparent[3:35] != traceID {
+		t.Errorf("traceparent = %q, want trace ID %s", traceparent, traceID)
+	}
+	if spans := recorder.Ended(); len(spans) != 2 {
+		t.Errorf("ended spans = %d, want 2 (parent + client)", len(spans))
+	}
+}
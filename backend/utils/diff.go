@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineDiff 두 텍스트의 줄 단위 unified diff (전체 문맥 포함, 같으면 빈 문자열)
+// Myers의 선형 공간 알고리즘이라 메모리는 줄 수에 비례 (O(n·m) 표를 만들지 않음)
+func LineDiff(from, to, fromLabel, toLabel string) string {
+	if from == to {
+		return ""
+	}
+
+	a := splitLines(from)
+	b := splitLines(to)
+
+	var ops []diffOp
+	ops = diffLines(a, b, ops)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromLabel, toLabel)
+	// 연속된 변경은 삭제를 먼저, 추가를 나중에 출력
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			out.WriteString(" " + ops[start].line + "\n")
+			start++
+			continue
+		}
+		end := start
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+		for _, kind := range []byte{'-', '+'} {
+			for _, op := range ops[start:end] {
+				if op.kind == kind {
+					out.WriteString(string(kind) + op.line + "\n")
+				}
+			}
+		}
+		start = end
+	}
+	return out.String()
+}
+
+// maxDiffEditCost middle snake 탐색 한도. 넘으면 그 구간은 통째로 삭제/추가로 표시
+// (아주 다른 큰 텍스트에서 O((n+m)·d) 시간이 커지지 않도록, 결과는 여전히 올바른 diff)
+const maxDiffEditCost = 1000
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+}
+
+// diffLines 공통 앞/뒤를 떼어낸 뒤 middle snake 기준으로 나눠서 재귀
+func diffLines(a, b []string, ops []diffOp) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+	default:
+		if x, y, ok := middleSnake(a, b); ok {
+			ops = diffLines(a[:x], b[:y], ops)
+			ops = diffLines(a[x:], b[y:], ops)
+		} else {
+			for _, line := range a {
+				ops = append(ops, diffOp{'-', line})
+			}
+			for _, line := range b {
+				ops = append(ops, diffOp{'+', line})
+			}
+		}
+	}
+
+	for _, line := range common {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// middleSnake 앞/뒤에서 동시에 최단 편집 경로를 찾아 만나는 지점 (a[:x], b[:y]로 나눔)
+// 공통 줄이 전혀 없거나 maxDiffEditCost 안에 찾지 못하면 ok=false
+func middleSnake(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	limit := maxD
+	if limit > maxDiffEditCost {
+		limit = maxDiffEditCost
+	}
+	offset := limit
+	size := 2*limit + 2
+	forward := make([]int, size)
+	backward := make([]int, size)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	delta := n - m
+	// 편집 거리 홀수/짝수에 따라 겹침은 앞 또는 뒤 방향에서만 확인
+	checkForward := delta%2 != 0
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for d := 0; d < limit; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case checkForward:
+				j := offset + delta - k
+				if j >= 0 && j < size && backward[j] != -1 && x >= n-backward[j] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !checkForward:
+				j := offset + delta - k
+				if j >= 0 && j < size && forward[j] != -1 {
+					fx := forward[j]
+					fy := offset + fx - j
+					if fx >= n-x {
+						return fx, fy, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// splitLines 빈 텍스트는 줄이 없는 것으로 취급 (생성/삭제 diff에 빈 줄이 생기지 않도록)
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
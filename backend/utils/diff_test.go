@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{"same", "a\nb", "a\nb", ""},
+		{"create", "", "a\nb", "--- old\n+++ new\n+a\n+b\n"},
+		{"delete", "a\nb", "", "--- old\n+++ new\n-a\n-b\n"},
+		{"change middle", "a\nb\nc", "a\nx\nc", "--- old\n+++ new\n a\n-b\n+x\n c\n"},
+		{"insert", "a\nc", "a\nb\nc", "--- old\n+++ new\n a\n+b\n c\n"},
+		{"remove", "a\nb\nc", "a\nc", "--- old\n+++ new\n a\n-b\n c\n"},
+		{"replace all", "a\nb", "x\ny", "--- old\n+++ new\n-a\n-b\n+x\n+y\n"},
+		{"move", "a\nb\nc\nd", "b\nc\nd\na", "--- old\n+++ new\n-a\n b\n c\n d\n+a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LineDiff(tt.from, tt.to, "old", "new"); got != tt.want {
+				t.Errorf("LineDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// 무작위 입력에서 diff가 양쪽 텍스트를 그대로 복원하고 변경 줄 수가 최소(LCS 기준)인지 확인
+func TestLineDiffIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "c", "d"}
+	randomText := func() string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	for i := 0; i < 500; i++ {
+		from, to := randomText(), randomText()
+		diff := LineDiff(from, to, "old", "new")
+		if from == to {
+			continue
+		}
+
+		var gotFrom, gotTo []string
+		changed := 0
+		for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n")[2:] {
+			switch line[0] {
+			case ' ':
+				gotFrom = append(gotFrom, line[1:])
+				gotTo = append(gotTo, line[1:])
+			case '-':
+				gotFrom = append(gotFrom, line[1:])
+				changed++
+			case '+':
+				gotTo = append(gotTo, line[1:])
+				changed++
+			}
+		}
+		if strings.Join(gotFrom, "\n") != from || strings.Join(gotTo, "\n") != to {
+			t.Fatalf("LineDiff(%q, %q) does not reproduce inputs:\n%s", from, to, diff)
+		}
+
+		a, b := splitLines(from), splitLines(to)
+		if want := len(a) + len(b) - 2*lcsLength(a, b); changed != want {
+			t.Fatalf("LineDiff(%q, %q) changed %d lines, want %d:\n%s", from, to, changed, want, diff)
+		}
+	}
+}
+
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else if prev[j+1] > cur[j] {
+				cur[j+1] = prev[j+1]
+			} else {
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -408,8 +408,8 @@ const RulesManagement: React.FC = () => {
               onChange={(e) => setFormData({ ...formData, rule_text: e.target.value })}
               multiline
               rows={6}
-              placeholder="SecRule ARGS '@detectSQLi' 'id:1001,phase:2,block,msg:&quot;SQL Injection Attack&quot;,severity:HIGH'"
-              helperText="Enter a valid ModSecurity rule. Must start with 'SecRule'"
+              placeholder={`SecRule ARGS "@detectSQLi" "id:1001,phase:2,block,msg:'SQL Injection Attack',severity:CRITICAL"`}
+              helperText="Enter valid SecLang (SecRule, SecAction, SecMarker). Every rule needs a unique id."
               required
               sx={{ fontFamily: 'monospace' }}
             />
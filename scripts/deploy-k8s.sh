@@ -35,6 +35,7 @@ kubectl apply -f k8s/modsecurity/modsecurity-configmap.yaml
 
 # Deploy backend services
 echo "📦 Deploying backend services..."
+kubectl apply -f k8s/backend/pvc.yaml
 kubectl apply -f k8s/backend/deployment.yaml
 kubectl apply -f k8s/backend/service.yaml
 